@@ -160,6 +160,39 @@ type HealthCheckRegistry interface {
 	RunHealthCheck(name string) (HealthCheckResult, errors.Error)
 }
 
+// Adapter to use an ordinary function as a health check
+type HealthCheckFunc func() (HealthCheckResult, errors.Error)
+
+func (f HealthCheckFunc) Check() (HealthCheckResult, errors.Error) {
+	return f()
+}
+
+type healthCheckResult struct {
+	healthy bool
+	message string
+	err     errors.Error
+}
+
+func (r *healthCheckResult) IsHealthy() bool {
+	return r.healthy
+}
+
+func (r *healthCheckResult) Message() string {
+	return r.message
+}
+
+func (r *healthCheckResult) Error() errors.Error {
+	return r.err
+}
+
+func NewHealthyResult(message string) HealthCheckResult {
+	return &healthCheckResult{healthy: true, message: message}
+}
+
+func NewUnhealthyResult(message string, err errors.Error) HealthCheckResult {
+	return &healthCheckResult{healthy: false, message: message, err: err}
+}
+
 // Periodically report all registered metrics to a source (console, log, service)
 type MetricReporter interface {
 	MetricRegistry() MetricRegistry // The Metrics Registry being reported on
@@ -21,7 +21,6 @@ import (
 	"time"
 
 	"github.com/couchbase/query/accounting"
-	"github.com/couchbase/query/accounting/stub"
 	"github.com/couchbase/query/errors"
 	"github.com/couchbase/query/server"
 	"github.com/couchbase/query/util"
@@ -30,16 +29,18 @@ import (
 
 type gometricsAccountingStore struct {
 	sync.Mutex
-	registry accounting.MetricRegistry
-	reporter accounting.MetricReporter
-	vitals   map[string]interface{}
+	registry     accounting.MetricRegistry
+	reporter     accounting.MetricReporter
+	healthChecks accounting.HealthCheckRegistry
+	vitals       map[string]interface{}
 }
 
 func NewAccountingStore() accounting.AccountingStore {
 	rv := &gometricsAccountingStore{
-		registry: &goMetricRegistry{},
-		reporter: &goMetricReporter{},
-		vitals:   map[string]interface{}{},
+		registry:     &goMetricRegistry{},
+		reporter:     &goMetricReporter{},
+		healthChecks: &goHealthCheckRegistry{checks: map[string]accounting.HealthCheck{}},
+		vitals:       map[string]interface{}{},
 	}
 
 	var lastUtime, lastStime int64
@@ -73,7 +74,7 @@ func (g *gometricsAccountingStore) MetricReporter() accounting.MetricReporter {
 }
 
 func (g *gometricsAccountingStore) HealthCheckRegistry() accounting.HealthCheckRegistry {
-	return accounting_stub.HealthCheckRegistryStub{}
+	return g.healthChecks
 }
 
 func (g *gometricsAccountingStore) Vitals() (interface{}, errors.Error) {
@@ -267,6 +268,71 @@ func (g *goMetricRegistry) Histograms() map[string]accounting.Histogram {
 	return histograms
 }
 
+type goHealthCheckRegistry struct {
+	sync.RWMutex
+	checks map[string]accounting.HealthCheck
+}
+
+func (g *goHealthCheckRegistry) Register(name string, hc accounting.HealthCheck) errors.Error {
+	g.Lock()
+	defer g.Unlock()
+	_, ok := g.checks[name]
+	if ok {
+		return errors.NewAdminHealthCheckExists(name)
+	}
+	g.checks[name] = hc
+	return nil
+}
+
+func (g *goHealthCheckRegistry) Unregister(name string) errors.Error {
+	g.Lock()
+	defer g.Unlock()
+	_, ok := g.checks[name]
+	if !ok {
+		return errors.NewAdminHealthCheckNotFound(name)
+	}
+	delete(g.checks, name)
+	return nil
+}
+
+// checks may take a while, so they run outside of the lock
+func (g *goHealthCheckRegistry) RunHealthChecks() (map[string]accounting.HealthCheckResult, errors.Error) {
+	g.RLock()
+	checks := make(map[string]accounting.HealthCheck, len(g.checks))
+	for name, hc := range g.checks {
+		checks[name] = hc
+	}
+	g.RUnlock()
+
+	results := make(map[string]accounting.HealthCheckResult, len(checks))
+	for name, hc := range checks {
+		results[name] = runHealthCheck(hc)
+	}
+	return results, nil
+}
+
+func (g *goHealthCheckRegistry) RunHealthCheck(name string) (accounting.HealthCheckResult, errors.Error) {
+	g.RLock()
+	hc, ok := g.checks[name]
+	g.RUnlock()
+	if !ok {
+		return nil, errors.NewAdminHealthCheckNotFound(name)
+	}
+	return runHealthCheck(hc), nil
+}
+
+// a check that fails to complete is as unhealthy as one that fails
+func runHealthCheck(hc accounting.HealthCheck) accounting.HealthCheckResult {
+	res, err := hc.Check()
+	if err != nil && (res == nil || res.IsHealthy()) {
+		return accounting.NewUnhealthyResult(err.Error(), err)
+	}
+	if res == nil {
+		return accounting.NewHealthyResult("")
+	}
+	return res
+}
+
 type goMetricReporter struct {
 }
 
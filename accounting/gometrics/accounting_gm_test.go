@@ -11,6 +11,9 @@ package accounting_gm
 
 import (
 	"testing"
+
+	"github.com/couchbase/query/accounting"
+	"github.com/couchbase/query/errors"
 )
 
 func TestGoMetrics(t *testing.T) {
@@ -51,3 +54,40 @@ func TestGoMetrics(t *testing.T) {
 	acctstore.MetricRegistry().Histogram("response_count")
 	acctstore.MetricRegistry().Timer("request_time")
 }
+
+func TestHealthChecks(t *testing.T) {
+	hr := NewAccountingStore().HealthCheckRegistry()
+
+	healthy := accounting.HealthCheckFunc(func() (accounting.HealthCheckResult, errors.Error) {
+		return accounting.NewHealthyResult("fine"), nil
+	})
+	failing := accounting.HealthCheckFunc(func() (accounting.HealthCheckResult, errors.Error) {
+		return nil, errors.NewAdminHealthCheckNotFound("other")
+	})
+
+	if hr.Register("healthy", healthy) != nil || hr.Register("failing", failing) != nil {
+		t.Fatalf("Expected to register health checks")
+	}
+	if hr.Register("healthy", healthy) == nil {
+		t.Fatalf("Expected registering a health check twice to fail")
+	}
+
+	results, err := hr.RunHealthChecks()
+	if err != nil || len(results) != 2 {
+		t.Fatalf("Expected 2 health check results, got %v %v", results, err)
+	}
+	if !results["healthy"].IsHealthy() || results["healthy"].Message() != "fine" {
+		t.Fatalf("Expected healthy result")
+	}
+	if results["failing"].IsHealthy() || results["failing"].Error() == nil {
+		t.Fatalf("Expected a check that fails to run to be unhealthy")
+	}
+
+	if hr.Unregister("failing") != nil {
+		t.Fatalf("Expected to unregister health check")
+	}
+	_, err = hr.RunHealthCheck("failing")
+	if err == nil {
+		t.Fatalf("Expected unregistered health check to be gone")
+	}
+}
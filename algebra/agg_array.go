@@ -79,21 +79,29 @@ func (this *ArrayAgg) Default() value.Value { return value.NULL_VALUE }
 
 /*
 Aggregates input data by evaluating operands. For missing
-item values, return the input value itself. Call
+item values, return the input value itself. With an ORDER BY
+clause, values are paired with their sort keys. Call
 cumulatePart to compute the intermediate aggregate value
 and return it.
 */
 func (this *ArrayAgg) CumulateInitial(item, cumulative value.Value, context Context) (value.Value, error) {
-	item, e := this.Operand().Evaluate(item, context)
+	val, e := this.Operand().Evaluate(item, context)
 	if e != nil {
 		return nil, e
 	}
 
-	if item.Type() <= value.MISSING || item.Type() == value.BINARY {
+	if val.Type() <= value.MISSING || val.Type() == value.BINARY {
 		return cumulative, nil
 	}
 
-	return this.cumulatePart(value.NewValue([]interface{}{item}), cumulative, context)
+	if this.Order() != nil {
+		val, e = orderedEntry(item, val, this.Order(), context)
+		if e != nil {
+			return nil, e
+		}
+	}
+
+	return this.cumulatePart(value.NewValue([]interface{}{val}), cumulative, context)
 }
 
 /*
@@ -104,13 +112,22 @@ func (this *ArrayAgg) CumulateIntermediate(part, cumulative value.Value, context
 }
 
 /*
-Compute the Final result after sorting(post processing).
+Compute the Final result after sorting(post processing), by the
+ORDER BY clause if any, else by value.
 */
 func (this *ArrayAgg) ComputeFinal(cumulative value.Value, context Context) (value.Value, error) {
 	if cumulative == value.NULL_VALUE {
 		return cumulative, nil
 	}
 
+	if this.Order() != nil {
+		values, e := orderedValues("ARRAY_AGG", cumulative, this.Order())
+		if e != nil {
+			return nil, e
+		}
+		return value.NewValue(values), nil
+	}
+
 	sort.Sort(value.NewSorter(cumulative))
 	return cumulative, nil
 }
@@ -122,6 +122,10 @@ func (this *Avg) ComputeFinal(cumulative value.Value, context Context) (value.Va
 	}
 
 	if count.Actual().(float64) > 0.0 {
+		if value.IsDecimal(sum) {
+			return value.DecimalDiv(sum, count, -1, value.ROUND_HALF_UP), nil
+		}
+
 		return value.NewValue(sum.Actual().(float64) / count.Actual().(float64)), nil
 	} else {
 		return value.NULL_VALUE, nil
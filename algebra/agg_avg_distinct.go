@@ -130,5 +130,9 @@ func (this *AvgDistinct) ComputeFinal(cumulative value.Value, context Context) (
 		}
 	}
 
+	if value.IsDecimal(sum) {
+		return value.DecimalDiv(sum, value.NewValue(set.Len()), -1, value.ROUND_HALF_UP), nil
+	}
+
 	return value.NewValue(sum.Actual().(float64) / float64(set.Len())), nil
 }
@@ -0,0 +1,177 @@
+//  Copyright (c) 2018 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package algebra
+
+import (
+	"fmt"
+
+	"github.com/couchbase/query/expression"
+	"github.com/couchbase/query/value"
+)
+
+/*
+This represents the Aggregate function MODE(expr). It returns the
+most frequent non-NULL, non-MISSING value in the group, and the
+least of them in collation order on ties. Type Mode is a struct
+that inherits from AggregateBase.
+*/
+type Mode struct {
+	AggregateBase
+}
+
+/*
+The function NewMode calls NewAggregateBase to
+create an aggregate function named MODE with
+one expression as input.
+*/
+func NewMode(operand expression.Expression) Aggregate {
+	rv := &Mode{
+		*NewAggregateBase("mode", operand),
+	}
+
+	rv.SetExpr(rv)
+	return rv
+}
+
+/*
+It calls the VisitFunction method by passing in the receiver to
+and returns the interface. It is a visitor pattern.
+*/
+func (this *Mode) Accept(visitor expression.Visitor) (interface{}, error) {
+	return visitor.VisitFunction(this)
+}
+
+/*
+It returns a value of type JSON.
+*/
+func (this *Mode) Type() value.Type { return value.JSON }
+
+/*
+Calls the evaluate method for aggregate functions and passes in the
+receiver, current item and current context.
+*/
+func (this *Mode) Evaluate(item value.Value, context expression.Context) (result value.Value, e error) {
+	return this.evaluate(this, item, context)
+}
+
+/*
+The constructor returns a NewMode with the input operand
+cast to a Function as the FunctionConstructor.
+*/
+func (this *Mode) Constructor() expression.FunctionConstructor {
+	return func(operands ...expression.Expression) expression.Function {
+		return NewMode(operands[0])
+	}
+}
+
+/*
+If no input to the MODE function, then the default value
+returned is a null.
+*/
+func (this *Mode) Default() value.Value { return value.NULL_VALUE }
+
+/*
+Aggregates input data by evaluating operands. The state maps
+the JSON text of each value to the value and its count.
+*/
+func (this *Mode) CumulateInitial(item, cumulative value.Value, context Context) (value.Value, error) {
+	item, e := this.Operand().Evaluate(item, context)
+	if e != nil {
+		return nil, e
+	}
+
+	if item.Type() <= value.NULL || item.Type() == value.BINARY {
+		return cumulative, nil
+	}
+
+	part := map[string]interface{}{
+		item.String(): map[string]interface{}{"value": item, "count": value.ONE_VALUE},
+	}
+	return this.cumulatePart(value.NewValue(part), cumulative, context)
+}
+
+/*
+Aggregates intermediate results and return them.
+*/
+func (this *Mode) CumulateIntermediate(part, cumulative value.Value, context Context) (value.Value, error) {
+	return this.cumulatePart(part, cumulative, context)
+}
+
+/*
+Compute the Final, the value with the highest count.
+*/
+func (this *Mode) ComputeFinal(cumulative value.Value, context Context) (value.Value, error) {
+	if cumulative == value.NULL_VALUE {
+		return cumulative, nil
+	}
+
+	var mode value.Value
+	var most float64
+	for _, entry := range cumulative.Fields() {
+		v, count, e := modeEntry(value.NewValue(entry))
+		if e != nil {
+			return nil, e
+		}
+
+		if count > most || (count == most && v.Collate(mode) < 0) {
+			mode, most = v, count
+		}
+	}
+
+	if mode == nil {
+		return value.NULL_VALUE, nil
+	}
+	return mode, nil
+}
+
+/*
+Add the counts of the partial state to the cumulative state.
+*/
+func (this *Mode) cumulatePart(part, cumulative value.Value, context Context) (value.Value, error) {
+	if part == value.NULL_VALUE {
+		return cumulative, nil
+	} else if cumulative == value.NULL_VALUE {
+		return part, nil
+	}
+
+	if part.Type() != value.OBJECT || cumulative.Type() != value.OBJECT {
+		return nil, fmt.Errorf("Invalid partial MODE %v or MODE %v.", part.Actual(), cumulative.Actual())
+	}
+
+	for key, pentry := range part.Fields() {
+		pv, pcount, e := modeEntry(value.NewValue(pentry))
+		if e != nil {
+			return nil, e
+		}
+
+		if centry, ok := cumulative.Field(key); ok {
+			_, ccount, e := modeEntry(centry)
+			if e != nil {
+				return nil, e
+			}
+			pcount += ccount
+		}
+
+		cumulative.SetField(key, map[string]interface{}{"value": pv, "count": pcount})
+	}
+
+	return cumulative, nil
+}
+
+func modeEntry(entry value.Value) (value.Value, float64, error) {
+	v, _ := entry.Field("value")
+	count, _ := entry.Field("count")
+	if v.Type() <= value.NULL || count.Type() != value.NUMBER {
+		return nil, 0.0, fmt.Errorf("Missing or invalid value or count in MODE: %v, %v.",
+			v.Actual(), count.Actual())
+	}
+
+	return v, count.Actual().(float64), nil
+}
@@ -0,0 +1,391 @@
+//  Copyright (c) 2018 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package algebra
+
+import (
+	"math"
+
+	"github.com/couchbase/query/expression"
+	"github.com/couchbase/query/value"
+)
+
+/*
+This represents the Aggregate function PERCENTILE_CONT(expr,
+fraction). It returns the value at the given fraction, between 0
+and 1, of the ordered number values in the group, interpolating
+between adjacent values. Type PercentileCont is a struct that
+inherits from AggregateBase.
+*/
+type PercentileCont struct {
+	AggregateBase
+}
+
+/*
+The function NewPercentileCont calls NewAggregateBaseArgs to
+create an aggregate function named PERCENTILE_CONT with the
+expression and the fraction as input.
+*/
+func NewPercentileCont(operand, fraction expression.Expression) Aggregate {
+	rv := &PercentileCont{
+		*NewAggregateBaseArgs("percentile_cont", operand, fraction),
+	}
+
+	rv.SetExpr(rv)
+	return rv
+}
+
+/*
+It calls the VisitFunction method by passing in the receiver to
+and returns the interface. It is a visitor pattern.
+*/
+func (this *PercentileCont) Accept(visitor expression.Visitor) (interface{}, error) {
+	return visitor.VisitFunction(this)
+}
+
+/*
+It returns a value of type NUMBER.
+*/
+func (this *PercentileCont) Type() value.Type { return value.NUMBER }
+
+/*
+Calls the evaluate method for aggregate functions and passes in the
+receiver, current item and current context.
+*/
+func (this *PercentileCont) Evaluate(item value.Value, context expression.Context) (result value.Value, e error) {
+	return this.evaluate(this, item, context)
+}
+
+/*
+Minimum input arguments required is 2, the expression and the
+fraction.
+*/
+func (this *PercentileCont) MinArgs() int { return 2 }
+
+/*
+Maximum number of input arguments allowed is 2.
+*/
+func (this *PercentileCont) MaxArgs() int { return 2 }
+
+/*
+Returns the fraction of the percentile.
+*/
+func (this *PercentileCont) Fraction() expression.Expression {
+	return this.Operands()[1]
+}
+
+/*
+The constructor returns a NewPercentileCont with the input operands
+cast to a Function as the FunctionConstructor.
+*/
+func (this *PercentileCont) Constructor() expression.FunctionConstructor {
+	return func(operands ...expression.Expression) expression.Function {
+		return NewPercentileCont(operands[0], operands[1])
+	}
+}
+
+/*
+If no input to the PERCENTILE_CONT function, then the default value
+returned is a null.
+*/
+func (this *PercentileCont) Default() value.Value { return value.NULL_VALUE }
+
+/*
+Aggregates input data by evaluating operands. Numbers are
+appended to the cumulative buffer; other values are ignored.
+*/
+func (this *PercentileCont) CumulateInitial(item, cumulative value.Value, context Context) (value.Value, error) {
+	item, e := this.Operand().Evaluate(item, context)
+	if e != nil {
+		return nil, e
+	}
+
+	if item.Type() != value.NUMBER {
+		return cumulative, nil
+	}
+
+	return cumulateNumbers("PERCENTILE_CONT", value.NewValue([]interface{}{item}), cumulative)
+}
+
+/*
+Aggregates intermediate results and return them.
+*/
+func (this *PercentileCont) CumulateIntermediate(part, cumulative value.Value, context Context) (value.Value, error) {
+	return cumulateNumbers("PERCENTILE_CONT", part, cumulative)
+}
+
+/*
+Compute the interpolated percentile of the sorted buffer.
+*/
+func (this *PercentileCont) ComputeFinal(cumulative value.Value, context Context) (value.Value, error) {
+	if cumulative == value.NULL_VALUE {
+		return cumulative, nil
+	}
+
+	numbers, e := sortedNumbers("PERCENTILE_CONT", cumulative)
+	if e != nil || len(numbers) == 0 {
+		return value.NULL_VALUE, e
+	}
+
+	p, e := percentileFraction("PERCENTILE_CONT", this.Fraction(), context)
+	if e != nil {
+		return nil, e
+	}
+
+	return value.NewValue(percentileCont(numbers, p)), nil
+}
+
+/*
+This represents the Aggregate function PERCENTILE_DISC(expr,
+fraction). It returns the first of the ordered number values in the
+group whose cumulative distribution reaches the given fraction,
+between 0 and 1. Type PercentileDisc is a struct that inherits from
+AggregateBase.
+*/
+type PercentileDisc struct {
+	AggregateBase
+}
+
+/*
+The function NewPercentileDisc calls NewAggregateBaseArgs to
+create an aggregate function named PERCENTILE_DISC with the
+expression and the fraction as input.
+*/
+func NewPercentileDisc(operand, fraction expression.Expression) Aggregate {
+	rv := &PercentileDisc{
+		*NewAggregateBaseArgs("percentile_disc", operand, fraction),
+	}
+
+	rv.SetExpr(rv)
+	return rv
+}
+
+/*
+It calls the VisitFunction method by passing in the receiver to
+and returns the interface. It is a visitor pattern.
+*/
+func (this *PercentileDisc) Accept(visitor expression.Visitor) (interface{}, error) {
+	return visitor.VisitFunction(this)
+}
+
+/*
+It returns a value of type NUMBER.
+*/
+func (this *PercentileDisc) Type() value.Type { return value.NUMBER }
+
+/*
+Calls the evaluate method for aggregate functions and passes in the
+receiver, current item and current context.
+*/
+func (this *PercentileDisc) Evaluate(item value.Value, context expression.Context) (result value.Value, e error) {
+	return this.evaluate(this, item, context)
+}
+
+/*
+Minimum input arguments required is 2, the expression and the
+fraction.
+*/
+func (this *PercentileDisc) MinArgs() int { return 2 }
+
+/*
+Maximum number of input arguments allowed is 2.
+*/
+func (this *PercentileDisc) MaxArgs() int { return 2 }
+
+/*
+Returns the fraction of the percentile.
+*/
+func (this *PercentileDisc) Fraction() expression.Expression {
+	return this.Operands()[1]
+}
+
+/*
+The constructor returns a NewPercentileDisc with the input operands
+cast to a Function as the FunctionConstructor.
+*/
+func (this *PercentileDisc) Constructor() expression.FunctionConstructor {
+	return func(operands ...expression.Expression) expression.Function {
+		return NewPercentileDisc(operands[0], operands[1])
+	}
+}
+
+/*
+If no input to the PERCENTILE_DISC function, then the default value
+returned is a null.
+*/
+func (this *PercentileDisc) Default() value.Value { return value.NULL_VALUE }
+
+/*
+Aggregates input data by evaluating operands. Numbers are
+appended to the cumulative buffer; other values are ignored.
+*/
+func (this *PercentileDisc) CumulateInitial(item, cumulative value.Value, context Context) (value.Value, error) {
+	item, e := this.Operand().Evaluate(item, context)
+	if e != nil {
+		return nil, e
+	}
+
+	if item.Type() != value.NUMBER {
+		return cumulative, nil
+	}
+
+	return cumulateNumbers("PERCENTILE_DISC", value.NewValue([]interface{}{item}), cumulative)
+}
+
+/*
+Aggregates intermediate results and return them.
+*/
+func (this *PercentileDisc) CumulateIntermediate(part, cumulative value.Value, context Context) (value.Value, error) {
+	return cumulateNumbers("PERCENTILE_DISC", part, cumulative)
+}
+
+/*
+Compute the discrete percentile of the sorted buffer.
+*/
+func (this *PercentileDisc) ComputeFinal(cumulative value.Value, context Context) (value.Value, error) {
+	if cumulative == value.NULL_VALUE {
+		return cumulative, nil
+	}
+
+	numbers, e := sortedNumbers("PERCENTILE_DISC", cumulative)
+	if e != nil || len(numbers) == 0 {
+		return value.NULL_VALUE, e
+	}
+
+	p, e := percentileFraction("PERCENTILE_DISC", this.Fraction(), context)
+	if e != nil {
+		return nil, e
+	}
+
+	return value.NewValue(percentileDisc(numbers, p)), nil
+}
+
+/*
+This represents the Aggregate function MEDIAN(expr). It returns
+the middle of the ordered number values in the group, or the mean
+of the two middle values, as PERCENTILE_CONT(expr, 0.5). Type
+Median is a struct that inherits from AggregateBase.
+*/
+type Median struct {
+	AggregateBase
+}
+
+/*
+The function NewMedian calls NewAggregateBase to
+create an aggregate function named MEDIAN with
+one expression as input.
+*/
+func NewMedian(operand expression.Expression) Aggregate {
+	rv := &Median{
+		*NewAggregateBase("median", operand),
+	}
+
+	rv.SetExpr(rv)
+	return rv
+}
+
+/*
+It calls the VisitFunction method by passing in the receiver to
+and returns the interface. It is a visitor pattern.
+*/
+func (this *Median) Accept(visitor expression.Visitor) (interface{}, error) {
+	return visitor.VisitFunction(this)
+}
+
+/*
+It returns a value of type NUMBER.
+*/
+func (this *Median) Type() value.Type { return value.NUMBER }
+
+/*
+Calls the evaluate method for aggregate functions and passes in the
+receiver, current item and current context.
+*/
+func (this *Median) Evaluate(item value.Value, context expression.Context) (result value.Value, e error) {
+	return this.evaluate(this, item, context)
+}
+
+/*
+The constructor returns a NewMedian with the input operand
+cast to a Function as the FunctionConstructor.
+*/
+func (this *Median) Constructor() expression.FunctionConstructor {
+	return func(operands ...expression.Expression) expression.Function {
+		return NewMedian(operands[0])
+	}
+}
+
+/*
+If no input to the MEDIAN function, then the default value
+returned is a null.
+*/
+func (this *Median) Default() value.Value { return value.NULL_VALUE }
+
+/*
+Aggregates input data by evaluating operands. Numbers are
+appended to the cumulative buffer; other values are ignored.
+*/
+func (this *Median) CumulateInitial(item, cumulative value.Value, context Context) (value.Value, error) {
+	item, e := this.Operand().Evaluate(item, context)
+	if e != nil {
+		return nil, e
+	}
+
+	if item.Type() != value.NUMBER {
+		return cumulative, nil
+	}
+
+	return cumulateNumbers("MEDIAN", value.NewValue([]interface{}{item}), cumulative)
+}
+
+/*
+Aggregates intermediate results and return them.
+*/
+func (this *Median) CumulateIntermediate(part, cumulative value.Value, context Context) (value.Value, error) {
+	return cumulateNumbers("MEDIAN", part, cumulative)
+}
+
+/*
+Compute the median of the sorted buffer.
+*/
+func (this *Median) ComputeFinal(cumulative value.Value, context Context) (value.Value, error) {
+	if cumulative == value.NULL_VALUE {
+		return cumulative, nil
+	}
+
+	numbers, e := sortedNumbers("MEDIAN", cumulative)
+	if e != nil || len(numbers) == 0 {
+		return value.NULL_VALUE, e
+	}
+
+	return value.NewValue(percentileCont(numbers, 0.5)), nil
+}
+
+/*
+Interpolate the value at fraction p of sorted numbers.
+*/
+func percentileCont(numbers []float64, p float64) float64 {
+	rn := p * float64(len(numbers)-1)
+	lo := math.Floor(rn)
+	hi := math.Ceil(rn)
+	low := numbers[int(lo)]
+	return low + (rn-lo)*(numbers[int(hi)]-low)
+}
+
+/*
+Return the first of sorted numbers whose cumulative distribution
+is at least p.
+*/
+func percentileDisc(numbers []float64, p float64) float64 {
+	i := int(math.Ceil(p*float64(len(numbers)))) - 1
+	if i < 0 {
+		i = 0
+	}
+	return numbers[i]
+}
@@ -31,6 +31,21 @@ func GetAggregate(name string, distinct bool) (Aggregate, bool) {
 	}
 }
 
+/*
+This method is used to retrieve an aggregate function with an
+ORDER BY clause by the parser. Only aggregate functions whose
+result depends on the order of their input are returned.
+*/
+func GetOrderedAggregate(name string) (Aggregate, bool) {
+	name = strings.ToLower(name)
+	if !_ORDERED_AGGREGATES[name] {
+		return nil, false
+	}
+
+	rv, ok := _OTHER_AGGREGATES[name]
+	return rv, ok
+}
+
 /*
 Aggregate functions with a DISTINCT specified. The variable
 represents a map from string to Aggregate Function. The
@@ -49,7 +64,8 @@ var _DISTINCT_AGGREGATES = map[string]Aggregate{
 /*
 Non Distinct Aggregate functions. The variable represents a
 map from string to Aggregate Function. Contains aggregate
-functions ARRAY_AGG, AVG, COUNT, MAX, MIN and SUM.
+functions ARRAY_AGG, AVG, COUNT, MAX, MIN and SUM, the
+statistical aggregate functions, and STRING_AGG and LISTAGG.
 */
 var _OTHER_AGGREGATES = map[string]Aggregate{
 	"array_agg": &ArrayAgg{},
@@ -59,4 +75,30 @@ var _OTHER_AGGREGATES = map[string]Aggregate{
 	"max":       &Max{},
 	"min":       &Min{},
 	"sum":       &Sum{},
+
+	// Statistics
+	"median":          &Median{},
+	"mode":            &Mode{},
+	"percentile_cont": &PercentileCont{},
+	"percentile_disc": &PercentileDisc{},
+	"stddev":          &Stddev{},
+	"stddev_pop":      &StddevPop{},
+	"stddev_samp":     &StddevSamp{},
+	"var_pop":         &VarPop{},
+	"var_samp":        &VarSamp{},
+	"variance":        &Variance{},
+
+	// Strings
+	"listagg":    &ListAgg{},
+	"string_agg": &StringAgg{},
+}
+
+/*
+Aggregate functions that accept an ORDER BY clause, because
+their result depends on the order of their input.
+*/
+var _ORDERED_AGGREGATES = map[string]bool{
+	"array_agg":  true,
+	"listagg":    true,
+	"string_agg": true,
 }
@@ -0,0 +1,314 @@
+//  Copyright (c) 2018 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package algebra
+
+import (
+	"math"
+
+	"github.com/couchbase/query/expression"
+	"github.com/couchbase/query/value"
+)
+
+/*
+This represents the Aggregate function STDDEV(expr). It returns
+the sample standard deviation of all the number values in the
+group, and 0 for a single value. Type Stddev is a struct that
+inherits from AggregateBase.
+*/
+type Stddev struct {
+	AggregateBase
+}
+
+/*
+The function NewStddev calls NewAggregateBase to
+create an aggregate function named STDDEV with
+one expression as input.
+*/
+func NewStddev(operand expression.Expression) Aggregate {
+	rv := &Stddev{
+		*NewAggregateBase("stddev", operand),
+	}
+
+	rv.SetExpr(rv)
+	return rv
+}
+
+/*
+It calls the VisitFunction method by passing in the receiver to
+and returns the interface. It is a visitor pattern.
+*/
+func (this *Stddev) Accept(visitor expression.Visitor) (interface{}, error) {
+	return visitor.VisitFunction(this)
+}
+
+/*
+It returns a value of type NUMBER.
+*/
+func (this *Stddev) Type() value.Type { return value.NUMBER }
+
+/*
+Calls the evaluate method for aggregate functions and passes in the
+receiver, current item and current context.
+*/
+func (this *Stddev) Evaluate(item value.Value, context expression.Context) (result value.Value, e error) {
+	return this.evaluate(this, item, context)
+}
+
+/*
+The constructor returns a NewStddev with the input operand
+cast to a Function as the FunctionConstructor.
+*/
+func (this *Stddev) Constructor() expression.FunctionConstructor {
+	return func(operands ...expression.Expression) expression.Function {
+		return NewStddev(operands[0])
+	}
+}
+
+/*
+If no input to the STDDEV function, then the default value
+returned is a null.
+*/
+func (this *Stddev) Default() value.Value { return value.NULL_VALUE }
+
+/*
+Aggregates input data by evaluating operands. Values other
+than numbers are ignored. Each number starts a variance state
+of its own, which is merged into the cumulative state.
+*/
+func (this *Stddev) CumulateInitial(item, cumulative value.Value, context Context) (value.Value, error) {
+	item, e := this.Operand().Evaluate(item, context)
+	if e != nil {
+		return nil, e
+	}
+
+	if item.Type() != value.NUMBER {
+		return cumulative, nil
+	}
+
+	return cumulateVariance("STDDEV", newVarianceState(1.0, item.Actual().(float64), 0.0), cumulative)
+}
+
+/*
+Aggregates intermediate results and return them.
+*/
+func (this *Stddev) CumulateIntermediate(part, cumulative value.Value, context Context) (value.Value, error) {
+	return cumulateVariance("STDDEV", part, cumulative)
+}
+
+/*
+Compute the sample standard deviation, the square root of the
+sample variance.
+*/
+func (this *Stddev) ComputeFinal(cumulative value.Value, context Context) (value.Value, error) {
+	v, e := computeVariance("STDDEV", cumulative, false, false)
+	if e != nil || v.Type() != value.NUMBER {
+		return v, e
+	}
+
+	return value.NewValue(math.Sqrt(v.Actual().(float64))), nil
+}
+
+/*
+This represents the Aggregate function STDDEV_POP(expr). It
+returns the population standard deviation of all the number values
+in the group. Type StddevPop is a struct that inherits from
+AggregateBase.
+*/
+type StddevPop struct {
+	AggregateBase
+}
+
+/*
+The function NewStddevPop calls NewAggregateBase to
+create an aggregate function named STDDEV_POP with
+one expression as input.
+*/
+func NewStddevPop(operand expression.Expression) Aggregate {
+	rv := &StddevPop{
+		*NewAggregateBase("stddev_pop", operand),
+	}
+
+	rv.SetExpr(rv)
+	return rv
+}
+
+/*
+It calls the VisitFunction method by passing in the receiver to
+and returns the interface. It is a visitor pattern.
+*/
+func (this *StddevPop) Accept(visitor expression.Visitor) (interface{}, error) {
+	return visitor.VisitFunction(this)
+}
+
+/*
+It returns a value of type NUMBER.
+*/
+func (this *StddevPop) Type() value.Type { return value.NUMBER }
+
+/*
+Calls the evaluate method for aggregate functions and passes in the
+receiver, current item and current context.
+*/
+func (this *StddevPop) Evaluate(item value.Value, context expression.Context) (result value.Value, e error) {
+	return this.evaluate(this, item, context)
+}
+
+/*
+The constructor returns a NewStddevPop with the input operand
+cast to a Function as the FunctionConstructor.
+*/
+func (this *StddevPop) Constructor() expression.FunctionConstructor {
+	return func(operands ...expression.Expression) expression.Function {
+		return NewStddevPop(operands[0])
+	}
+}
+
+/*
+If no input to the STDDEV_POP function, then the default value
+returned is a null.
+*/
+func (this *StddevPop) Default() value.Value { return value.NULL_VALUE }
+
+/*
+Aggregates input data by evaluating operands. Values other
+than numbers are ignored. Each number starts a variance state
+of its own, which is merged into the cumulative state.
+*/
+func (this *StddevPop) CumulateInitial(item, cumulative value.Value, context Context) (value.Value, error) {
+	item, e := this.Operand().Evaluate(item, context)
+	if e != nil {
+		return nil, e
+	}
+
+	if item.Type() != value.NUMBER {
+		return cumulative, nil
+	}
+
+	return cumulateVariance("STDDEV_POP", newVarianceState(1.0, item.Actual().(float64), 0.0), cumulative)
+}
+
+/*
+Aggregates intermediate results and return them.
+*/
+func (this *StddevPop) CumulateIntermediate(part, cumulative value.Value, context Context) (value.Value, error) {
+	return cumulateVariance("STDDEV_POP", part, cumulative)
+}
+
+/*
+Compute the population standard deviation, the square root of
+the population variance.
+*/
+func (this *StddevPop) ComputeFinal(cumulative value.Value, context Context) (value.Value, error) {
+	v, e := computeVariance("STDDEV_POP", cumulative, true, false)
+	if e != nil || v.Type() != value.NUMBER {
+		return v, e
+	}
+
+	return value.NewValue(math.Sqrt(v.Actual().(float64))), nil
+}
+
+/*
+This represents the Aggregate function STDDEV_SAMP(expr). It
+returns the sample standard deviation of all the number values in
+the group, and NULL for a single value. Type StddevSamp is a
+struct that inherits from AggregateBase.
+*/
+type StddevSamp struct {
+	AggregateBase
+}
+
+/*
+The function NewStddevSamp calls NewAggregateBase to
+create an aggregate function named STDDEV_SAMP with
+one expression as input.
+*/
+func NewStddevSamp(operand expression.Expression) Aggregate {
+	rv := &StddevSamp{
+		*NewAggregateBase("stddev_samp", operand),
+	}
+
+	rv.SetExpr(rv)
+	return rv
+}
+
+/*
+It calls the VisitFunction method by passing in the receiver to
+and returns the interface. It is a visitor pattern.
+*/
+func (this *StddevSamp) Accept(visitor expression.Visitor) (interface{}, error) {
+	return visitor.VisitFunction(this)
+}
+
+/*
+It returns a value of type NUMBER.
+*/
+func (this *StddevSamp) Type() value.Type { return value.NUMBER }
+
+/*
+Calls the evaluate method for aggregate functions and passes in the
+receiver, current item and current context.
+*/
+func (this *StddevSamp) Evaluate(item value.Value, context expression.Context) (result value.Value, e error) {
+	return this.evaluate(this, item, context)
+}
+
+/*
+The constructor returns a NewStddevSamp with the input operand
+cast to a Function as the FunctionConstructor.
+*/
+func (this *StddevSamp) Constructor() expression.FunctionConstructor {
+	return func(operands ...expression.Expression) expression.Function {
+		return NewStddevSamp(operands[0])
+	}
+}
+
+/*
+If no input to the STDDEV_SAMP function, then the default value
+returned is a null.
+*/
+func (this *StddevSamp) Default() value.Value { return value.NULL_VALUE }
+
+/*
+Aggregates input data by evaluating operands. Values other
+than numbers are ignored. Each number starts a variance state
+of its own, which is merged into the cumulative state.
+*/
+func (this *StddevSamp) CumulateInitial(item, cumulative value.Value, context Context) (value.Value, error) {
+	item, e := this.Operand().Evaluate(item, context)
+	if e != nil {
+		return nil, e
+	}
+
+	if item.Type() != value.NUMBER {
+		return cumulative, nil
+	}
+
+	return cumulateVariance("STDDEV_SAMP", newVarianceState(1.0, item.Actual().(float64), 0.0), cumulative)
+}
+
+/*
+Aggregates intermediate results and return them.
+*/
+func (this *StddevSamp) CumulateIntermediate(part, cumulative value.Value, context Context) (value.Value, error) {
+	return cumulateVariance("STDDEV_SAMP", part, cumulative)
+}
+
+/*
+Compute the sample standard deviation, the square root of the
+sample variance.
+*/
+func (this *StddevSamp) ComputeFinal(cumulative value.Value, context Context) (value.Value, error) {
+	v, e := computeVariance("STDDEV_SAMP", cumulative, false, true)
+	if e != nil || v.Type() != value.NUMBER {
+		return v, e
+	}
+
+	return value.NewValue(math.Sqrt(v.Actual().(float64))), nil
+}
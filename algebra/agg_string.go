@@ -0,0 +1,238 @@
+//  Copyright (c) 2018 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package algebra
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/couchbase/query/expression"
+	"github.com/couchbase/query/value"
+)
+
+/*
+This represents the Aggregate function STRING_AGG(expr, separator).
+It returns the string values in the group, concatenated with the
+separator, in the order of the ORDER BY clause if any, else in
+string order. Type StringAgg is a struct that inherits from
+AggregateBase.
+*/
+type StringAgg struct {
+	AggregateBase
+}
+
+/*
+The function NewStringAgg calls NewAggregateBaseArgs to
+create an aggregate function named STRING_AGG with the
+expression and the separator as input.
+*/
+func NewStringAgg(operand, separator expression.Expression) Aggregate {
+	rv := &StringAgg{
+		*NewAggregateBaseArgs("string_agg", operand, separator),
+	}
+
+	rv.SetExpr(rv)
+	return rv
+}
+
+/*
+It calls the VisitFunction method by passing in the receiver to
+and returns the interface. It is a visitor pattern.
+*/
+func (this *StringAgg) Accept(visitor expression.Visitor) (interface{}, error) {
+	return visitor.VisitFunction(this)
+}
+
+/*
+It returns a value of type STRING.
+*/
+func (this *StringAgg) Type() value.Type { return value.STRING }
+
+/*
+Calls the evaluate method for aggregate functions and passes in the
+receiver, current item and current context.
+*/
+func (this *StringAgg) Evaluate(item value.Value, context expression.Context) (result value.Value, e error) {
+	return this.evaluate(this, item, context)
+}
+
+/*
+Minimum input arguments required is 2, the expression and the
+separator.
+*/
+func (this *StringAgg) MinArgs() int { return 2 }
+
+/*
+Maximum number of input arguments allowed is 2.
+*/
+func (this *StringAgg) MaxArgs() int { return 2 }
+
+/*
+The constructor returns a NewStringAgg with the input operands
+cast to a Function as the FunctionConstructor.
+*/
+func (this *StringAgg) Constructor() expression.FunctionConstructor {
+	return func(operands ...expression.Expression) expression.Function {
+		return NewStringAgg(operands[0], operands[1])
+	}
+}
+
+/*
+If no input to the STRING_AGG function, then the default value
+returned is a null.
+*/
+func (this *StringAgg) Default() value.Value { return value.NULL_VALUE }
+
+/*
+Aggregates input data by evaluating operands. Values other than
+strings are ignored. With an ORDER BY clause, strings are paired
+with their sort keys.
+*/
+func (this *StringAgg) CumulateInitial(item, cumulative value.Value, context Context) (value.Value, error) {
+	val, e := this.Operand().Evaluate(item, context)
+	if e != nil {
+		return nil, e
+	}
+
+	if val.Type() != value.STRING {
+		return cumulative, nil
+	}
+
+	if this.Order() != nil {
+		val, e = orderedEntry(item, val, this.Order(), context)
+		if e != nil {
+			return nil, e
+		}
+	}
+
+	return this.cumulatePart(value.NewValue([]interface{}{val}), cumulative, context)
+}
+
+/*
+Aggregates intermediate results and return them.
+*/
+func (this *StringAgg) CumulateIntermediate(part, cumulative value.Value, context Context) (value.Value, error) {
+	return this.cumulatePart(part, cumulative, context)
+}
+
+/*
+Compute the Final. Order the strings and join them with the
+separator, which must not depend on the input documents.
+*/
+func (this *StringAgg) ComputeFinal(cumulative value.Value, context Context) (value.Value, error) {
+	if cumulative == value.NULL_VALUE {
+		return cumulative, nil
+	}
+
+	separator := ""
+	if len(this.Operands()) > 1 {
+		sep, e := this.Operands()[1].Evaluate(value.NULL_VALUE, context)
+		if e != nil {
+			return nil, e
+		}
+
+		s, ok := sep.Actual().(string)
+		if !ok {
+			return nil, fmt.Errorf("The separator of %s must be a string: %v.", strings.ToUpper(this.Name()), sep)
+		}
+		separator = s
+	}
+
+	var values []interface{}
+	if this.Order() != nil {
+		var e error
+		values, e = orderedValues(strings.ToUpper(this.Name()), cumulative, this.Order())
+		if e != nil {
+			return nil, e
+		}
+	} else {
+		values, _ = cumulative.Actual().([]interface{})
+	}
+
+	strs := make([]string, len(values))
+	for i, v := range values {
+		s, ok := value.NewValue(v).Actual().(string)
+		if !ok {
+			return nil, fmt.Errorf("Invalid %s string %v of type %T.", strings.ToUpper(this.Name()), v, v)
+		}
+		strs[i] = s
+	}
+
+	if this.Order() == nil {
+		sort.Strings(strs)
+	}
+
+	return value.NewValue(strings.Join(strs, separator)), nil
+}
+
+/*
+Aggregate input partial values into the cumulative slice, as
+ARRAY_AGG does.
+*/
+func (this *StringAgg) cumulatePart(part, cumulative value.Value, context Context) (value.Value, error) {
+	if part == value.NULL_VALUE {
+		return cumulative, nil
+	} else if cumulative == value.NULL_VALUE {
+		return part, nil
+	}
+
+	actual, ok := part.Actual().([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("Invalid partial %s %v of type %T.", strings.ToUpper(this.Name()), part.Actual(), part.Actual())
+	}
+
+	array, ok := cumulative.Actual().([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("Invalid %s %v of type %T.", strings.ToUpper(this.Name()), cumulative.Actual(), cumulative.Actual())
+	}
+
+	return value.NewValue(append(array, actual...)), nil
+}
+
+/*
+This represents the Aggregate function LISTAGG(expr [, separator]).
+It is STRING_AGG with an optional separator, which defaults to the
+empty string. Type ListAgg is a struct that inherits from StringAgg.
+*/
+type ListAgg struct {
+	StringAgg
+}
+
+/*
+The function NewListAgg creates an aggregate function named
+LISTAGG with the expression and optionally the separator as
+input.
+*/
+func NewListAgg(operands ...expression.Expression) Aggregate {
+	rv := &ListAgg{
+		StringAgg{
+			*NewAggregateBaseArgs("listagg", operands[0], operands[1:]...),
+		},
+	}
+
+	rv.SetExpr(rv)
+	return rv
+}
+
+/*
+Minimum input arguments required is 1.
+*/
+func (this *ListAgg) MinArgs() int { return 1 }
+
+/*
+The constructor returns a NewListAgg with the input operands
+cast to a Function as the FunctionConstructor.
+*/
+func (this *ListAgg) Constructor() expression.FunctionConstructor {
+	return func(operands ...expression.Expression) expression.Function {
+		return NewListAgg(operands...)
+	}
+}
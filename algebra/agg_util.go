@@ -11,7 +11,9 @@ package algebra
 
 import (
 	"fmt"
+	"sort"
 
+	"github.com/couchbase/query/expression"
 	"github.com/couchbase/query/value"
 )
 
@@ -99,3 +101,210 @@ func getSet(item value.Value) (*value.Set, error) {
 		return nil, fmt.Errorf("Invalid DISTINCT %v of type %T.", item, item)
 	}
 }
+
+/*
+Aggregate partial variance states, using the parallel algorithm
+of Chan et al. Each state holds the count, mean and sum of squared
+differences from the mean (m2) of its values.
+*/
+func cumulateVariance(name string, part, cumulative value.Value) (value.Value, error) {
+	if part == value.NULL_VALUE {
+		return cumulative, nil
+	} else if cumulative == value.NULL_VALUE {
+		return part, nil
+	}
+
+	pcount, pmean, pm2, e := varianceState(name, part)
+	if e != nil {
+		return nil, e
+	}
+
+	ccount, cmean, cm2, e := varianceState(name, cumulative)
+	if e != nil {
+		return nil, e
+	}
+
+	count := pcount + ccount
+	delta := pmean - cmean
+	return newVarianceState(count, cmean+delta*pcount/count,
+		cm2+pm2+delta*delta*pcount*ccount/count), nil
+}
+
+/*
+Compute the variance of a state. Sample variances are NULL for a
+single value if strict, and 0 otherwise.
+*/
+func computeVariance(name string, cumulative value.Value, population, strict bool) (value.Value, error) {
+	if cumulative == value.NULL_VALUE {
+		return cumulative, nil
+	}
+
+	count, _, m2, e := varianceState(name, cumulative)
+	if e != nil {
+		return nil, e
+	}
+
+	switch {
+	case population:
+		return value.NewValue(m2 / count), nil
+	case count > 1.0:
+		return value.NewValue(m2 / (count - 1.0)), nil
+	case strict:
+		return value.NULL_VALUE, nil
+	default:
+		return value.ZERO_VALUE, nil
+	}
+}
+
+func newVarianceState(count, mean, m2 float64) value.Value {
+	return value.NewValue(map[string]interface{}{"count": count, "mean": mean, "m2": m2})
+}
+
+func varianceState(name string, state value.Value) (count, mean, m2 float64, e error) {
+	c, _ := state.Field("count")
+	m, _ := state.Field("mean")
+	s, _ := state.Field("m2")
+
+	if c.Type() != value.NUMBER || m.Type() != value.NUMBER || s.Type() != value.NUMBER {
+		e = fmt.Errorf("Missing or invalid count, mean or m2 in %s: %v, %v, %v.",
+			name, c.Actual(), m.Actual(), s.Actual())
+		return
+	}
+
+	return c.Actual().(float64), m.Actual().(float64), s.Actual().(float64), nil
+}
+
+/*
+Append the numbers of a partial buffer to the cumulative buffer.
+*/
+func cumulateNumbers(name string, part, cumulative value.Value) (value.Value, error) {
+	if part == value.NULL_VALUE {
+		return cumulative, nil
+	} else if cumulative == value.NULL_VALUE {
+		return part, nil
+	}
+
+	actual, ok := part.Actual().([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("Invalid partial %s %v of type %T.", name, part.Actual(), part.Actual())
+	}
+
+	array, ok := cumulative.Actual().([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("Invalid %s %v of type %T.", name, cumulative.Actual(), cumulative.Actual())
+	}
+
+	return value.NewValue(append(array, actual...)), nil
+}
+
+/*
+Return the sorted numbers of a buffer.
+*/
+func sortedNumbers(name string, cumulative value.Value) ([]float64, error) {
+	array, ok := cumulative.Actual().([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("Invalid %s %v of type %T.", name, cumulative.Actual(), cumulative.Actual())
+	}
+
+	rv := make([]float64, len(array))
+	for i, a := range array {
+		n, ok := value.NewValue(a).Actual().(float64)
+		if !ok {
+			return nil, fmt.Errorf("Invalid %s number %v of type %T.", name, a, a)
+		}
+		rv[i] = n
+	}
+
+	sort.Float64s(rv)
+	return rv, nil
+}
+
+/*
+Evaluate the fraction of percentile aggregates, which must not depend
+on the input documents.
+*/
+func percentileFraction(name string, fraction expression.Expression, context Context) (float64, error) {
+	f, e := fraction.Evaluate(value.NULL_VALUE, context)
+	if e != nil {
+		return 0.0, e
+	}
+
+	if f.Type() == value.NUMBER {
+		p := f.Actual().(float64)
+		if p >= 0.0 && p <= 1.0 {
+			return p, nil
+		}
+	}
+
+	return 0.0, fmt.Errorf("The fraction of %s must be a number between 0 and 1: %v.", name, f)
+}
+
+/*
+Pair a value with the keys of the ORDER BY clause of an ordered
+aggregate, evaluated against the input item.
+*/
+func orderedEntry(item, val value.Value, order *Order, context Context) (value.Value, error) {
+	terms := order.Terms()
+	keys := make([]interface{}, len(terms))
+	for i, term := range terms {
+		key, e := term.Expression().Evaluate(item, context)
+		if e != nil {
+			return nil, e
+		}
+		keys[i] = key
+	}
+
+	return value.NewValue(map[string]interface{}{"keys": keys, "value": val}), nil
+}
+
+/*
+Sort the entries of an ordered aggregate by their keys, and return
+their values.
+*/
+func orderedValues(name string, cumulative value.Value, order *Order) ([]interface{}, error) {
+	entries, ok := cumulative.Actual().([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("Invalid %s %v of type %T.", name, cumulative.Actual(), cumulative.Actual())
+	}
+
+	terms := order.Terms()
+	keys := make([][]value.Value, len(entries))
+	values := make([]interface{}, len(entries))
+	for i, entry := range entries {
+		ev := value.NewValue(entry)
+		k, _ := ev.Field("keys")
+		v, ok := ev.Field("value")
+		ka, _ := k.Actual().([]interface{})
+		if !ok || len(ka) != len(terms) {
+			return nil, fmt.Errorf("Invalid %s entry %v.", name, entry)
+		}
+
+		keys[i] = make([]value.Value, len(ka))
+		for j, key := range ka {
+			keys[i][j] = value.NewValue(key)
+		}
+		values[i] = v
+	}
+
+	index := make([]int, len(entries))
+	for i, _ := range index {
+		index[i] = i
+	}
+
+	sort.SliceStable(index, func(a, b int) bool {
+		ka, kb := keys[index[a]], keys[index[b]]
+		for t, term := range terms {
+			c := ka[t].Collate(kb[t])
+			if c != 0 {
+				return (c < 0) != term.Descending()
+			}
+		}
+		return false
+	})
+
+	rv := make([]interface{}, len(index))
+	for i, n := range index {
+		rv[i] = values[n]
+	}
+	return rv, nil
+}
@@ -0,0 +1,293 @@
+//  Copyright (c) 2018 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package algebra
+
+import (
+	"github.com/couchbase/query/expression"
+	"github.com/couchbase/query/value"
+)
+
+/*
+This represents the Aggregate function VARIANCE(expr). It returns
+the sample variance of all the number values in the group, and 0
+for a single value. Type Variance is a struct that inherits from
+AggregateBase.
+*/
+type Variance struct {
+	AggregateBase
+}
+
+/*
+The function NewVariance calls NewAggregateBase to
+create an aggregate function named VARIANCE with
+one expression as input.
+*/
+func NewVariance(operand expression.Expression) Aggregate {
+	rv := &Variance{
+		*NewAggregateBase("variance", operand),
+	}
+
+	rv.SetExpr(rv)
+	return rv
+}
+
+/*
+It calls the VisitFunction method by passing in the receiver to
+and returns the interface. It is a visitor pattern.
+*/
+func (this *Variance) Accept(visitor expression.Visitor) (interface{}, error) {
+	return visitor.VisitFunction(this)
+}
+
+/*
+It returns a value of type NUMBER.
+*/
+func (this *Variance) Type() value.Type { return value.NUMBER }
+
+/*
+Calls the evaluate method for aggregate functions and passes in the
+receiver, current item and current context.
+*/
+func (this *Variance) Evaluate(item value.Value, context expression.Context) (result value.Value, e error) {
+	return this.evaluate(this, item, context)
+}
+
+/*
+The constructor returns a NewVariance with the input operand
+cast to a Function as the FunctionConstructor.
+*/
+func (this *Variance) Constructor() expression.FunctionConstructor {
+	return func(operands ...expression.Expression) expression.Function {
+		return NewVariance(operands[0])
+	}
+}
+
+/*
+If no input to the VARIANCE function, then the default value
+returned is a null.
+*/
+func (this *Variance) Default() value.Value { return value.NULL_VALUE }
+
+/*
+Aggregates input data by evaluating operands. Values other
+than numbers are ignored. Each number starts a variance state
+of its own, which is merged into the cumulative state.
+*/
+func (this *Variance) CumulateInitial(item, cumulative value.Value, context Context) (value.Value, error) {
+	item, e := this.Operand().Evaluate(item, context)
+	if e != nil {
+		return nil, e
+	}
+
+	if item.Type() != value.NUMBER {
+		return cumulative, nil
+	}
+
+	return cumulateVariance("VARIANCE", newVarianceState(1.0, item.Actual().(float64), 0.0), cumulative)
+}
+
+/*
+Aggregates intermediate results and return them.
+*/
+func (this *Variance) CumulateIntermediate(part, cumulative value.Value, context Context) (value.Value, error) {
+	return cumulateVariance("VARIANCE", part, cumulative)
+}
+
+/*
+Compute the sample variance from the state.
+*/
+func (this *Variance) ComputeFinal(cumulative value.Value, context Context) (value.Value, error) {
+	return computeVariance("VARIANCE", cumulative, false, false)
+}
+
+/*
+This represents the Aggregate function VAR_POP(expr). It returns
+the population variance of all the number values in the group.
+Type VarPop is a struct that inherits from AggregateBase.
+*/
+type VarPop struct {
+	AggregateBase
+}
+
+/*
+The function NewVarPop calls NewAggregateBase to
+create an aggregate function named VAR_POP with
+one expression as input.
+*/
+func NewVarPop(operand expression.Expression) Aggregate {
+	rv := &VarPop{
+		*NewAggregateBase("var_pop", operand),
+	}
+
+	rv.SetExpr(rv)
+	return rv
+}
+
+/*
+It calls the VisitFunction method by passing in the receiver to
+and returns the interface. It is a visitor pattern.
+*/
+func (this *VarPop) Accept(visitor expression.Visitor) (interface{}, error) {
+	return visitor.VisitFunction(this)
+}
+
+/*
+It returns a value of type NUMBER.
+*/
+func (this *VarPop) Type() value.Type { return value.NUMBER }
+
+/*
+Calls the evaluate method for aggregate functions and passes in the
+receiver, current item and current context.
+*/
+func (this *VarPop) Evaluate(item value.Value, context expression.Context) (result value.Value, e error) {
+	return this.evaluate(this, item, context)
+}
+
+/*
+The constructor returns a NewVarPop with the input operand
+cast to a Function as the FunctionConstructor.
+*/
+func (this *VarPop) Constructor() expression.FunctionConstructor {
+	return func(operands ...expression.Expression) expression.Function {
+		return NewVarPop(operands[0])
+	}
+}
+
+/*
+If no input to the VAR_POP function, then the default value
+returned is a null.
+*/
+func (this *VarPop) Default() value.Value { return value.NULL_VALUE }
+
+/*
+Aggregates input data by evaluating operands. Values other
+than numbers are ignored. Each number starts a variance state
+of its own, which is merged into the cumulative state.
+*/
+func (this *VarPop) CumulateInitial(item, cumulative value.Value, context Context) (value.Value, error) {
+	item, e := this.Operand().Evaluate(item, context)
+	if e != nil {
+		return nil, e
+	}
+
+	if item.Type() != value.NUMBER {
+		return cumulative, nil
+	}
+
+	return cumulateVariance("VAR_POP", newVarianceState(1.0, item.Actual().(float64), 0.0), cumulative)
+}
+
+/*
+Aggregates intermediate results and return them.
+*/
+func (this *VarPop) CumulateIntermediate(part, cumulative value.Value, context Context) (value.Value, error) {
+	return cumulateVariance("VAR_POP", part, cumulative)
+}
+
+/*
+Compute the population variance from the state.
+*/
+func (this *VarPop) ComputeFinal(cumulative value.Value, context Context) (value.Value, error) {
+	return computeVariance("VAR_POP", cumulative, true, false)
+}
+
+/*
+This represents the Aggregate function VAR_SAMP(expr). It returns
+the sample variance of all the number values in the group, and
+NULL for a single value. Type VarSamp is a struct that inherits
+from AggregateBase.
+*/
+type VarSamp struct {
+	AggregateBase
+}
+
+/*
+The function NewVarSamp calls NewAggregateBase to
+create an aggregate function named VAR_SAMP with
+one expression as input.
+*/
+func NewVarSamp(operand expression.Expression) Aggregate {
+	rv := &VarSamp{
+		*NewAggregateBase("var_samp", operand),
+	}
+
+	rv.SetExpr(rv)
+	return rv
+}
+
+/*
+It calls the VisitFunction method by passing in the receiver to
+and returns the interface. It is a visitor pattern.
+*/
+func (this *VarSamp) Accept(visitor expression.Visitor) (interface{}, error) {
+	return visitor.VisitFunction(this)
+}
+
+/*
+It returns a value of type NUMBER.
+*/
+func (this *VarSamp) Type() value.Type { return value.NUMBER }
+
+/*
+Calls the evaluate method for aggregate functions and passes in the
+receiver, current item and current context.
+*/
+func (this *VarSamp) Evaluate(item value.Value, context expression.Context) (result value.Value, e error) {
+	return this.evaluate(this, item, context)
+}
+
+/*
+The constructor returns a NewVarSamp with the input operand
+cast to a Function as the FunctionConstructor.
+*/
+func (this *VarSamp) Constructor() expression.FunctionConstructor {
+	return func(operands ...expression.Expression) expression.Function {
+		return NewVarSamp(operands[0])
+	}
+}
+
+/*
+If no input to the VAR_SAMP function, then the default value
+returned is a null.
+*/
+func (this *VarSamp) Default() value.Value { return value.NULL_VALUE }
+
+/*
+Aggregates input data by evaluating operands. Values other
+than numbers are ignored. Each number starts a variance state
+of its own, which is merged into the cumulative state.
+*/
+func (this *VarSamp) CumulateInitial(item, cumulative value.Value, context Context) (value.Value, error) {
+	item, e := this.Operand().Evaluate(item, context)
+	if e != nil {
+		return nil, e
+	}
+
+	if item.Type() != value.NUMBER {
+		return cumulative, nil
+	}
+
+	return cumulateVariance("VAR_SAMP", newVarianceState(1.0, item.Actual().(float64), 0.0), cumulative)
+}
+
+/*
+Aggregates intermediate results and return them.
+*/
+func (this *VarSamp) CumulateIntermediate(part, cumulative value.Value, context Context) (value.Value, error) {
+	return cumulateVariance("VAR_SAMP", part, cumulative)
+}
+
+/*
+Compute the sample variance from the state.
+*/
+func (this *VarSamp) ComputeFinal(cumulative value.Value, context Context) (value.Value, error) {
+	return computeVariance("VAR_SAMP", cumulative, false, true)
+}
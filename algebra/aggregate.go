@@ -63,16 +63,32 @@ type Aggregate interface {
 	   Performs final post-processing, if any.
 	*/
 	ComputeFinal(cumulative value.Value, context Context) (value.Value, error)
+
+	/*
+	   The condition of the FILTER clause, if any. Only input
+	   data satisfying it is aggregated.
+	*/
+	Filter() expression.Expression
+	SetFilter(filter expression.Expression)
+
+	/*
+	   The ORDER BY clause, if any, of ordered aggregates.
+	*/
+	Order() *Order
+	SetOrder(order *Order)
 }
 
 /*
 Base class for Aggregate functions. It inherits from
 expressions UnaryFunctionBase, and has field text
-which represents the function name.
+which represents the function name, and the FILTER
+and ORDER BY clauses.
 */
 type AggregateBase struct {
 	expression.UnaryFunctionBase
-	text string
+	text   string
+	filter expression.Expression
+	order  *Order
 }
 
 /*
@@ -84,6 +100,25 @@ func NewAggregateBase(name string, operand expression.Expression) *AggregateBase
 	return &AggregateBase{
 		*expression.NewUnaryFunctionBase(name, operand),
 		"",
+		nil,
+		nil,
+	}
+}
+
+/*
+This method creates an AggregateBase with further constant
+operands, such as the fraction of PERCENTILE_CONT(). The first
+operand remains the aggregated expression.
+*/
+func NewAggregateBaseArgs(name string, operand expression.Expression,
+	args ...expression.Expression) *AggregateBase {
+	return &AggregateBase{
+		expression.UnaryFunctionBase{
+			*expression.NewFunctionBase(name, append(expression.Expressions{operand}, args...)...),
+		},
+		"",
+		nil,
+		nil,
 	}
 }
 
@@ -145,7 +180,17 @@ Aggregates are same Return true otherwise Return false.
 func (this *AggregateBase) EquivalentTo(other expression.Expression) bool {
 	otherAggregate, ok := other.(Aggregate)
 	return ok && !otherAggregate.Distinct() && this.Name() == otherAggregate.Name() &&
-		expression.Equivalents(this.Children(), otherAggregate.Children())
+		expression.Equivalents(this.Children(), otherAggregate.Children()) &&
+		this.clausesEquivalentTo(otherAggregate)
+}
+
+/*
+The FILTER and ORDER BY clauses are equivalent.
+*/
+func (this *AggregateBase) clausesEquivalentTo(other Aggregate) bool {
+	return (this.filter == nil) == (other.Filter() == nil) &&
+		(this.order == nil) == (other.Order() == nil) &&
+		(this.order == nil || this.order.String() == other.Order().String())
 }
 
 /*
@@ -156,14 +201,28 @@ func (this *AggregateBase) SubsetOf(other expression.Expression) bool {
 }
 
 /*
-Return the operands of the Aggregate function.
+Return the operands of the Aggregate function, followed by the
+expressions of the ORDER BY and FILTER clauses.
 */
 func (this *AggregateBase) Children() expression.Expressions {
-	if this.Operands()[0] == nil {
-		return nil
-	} else {
-		return this.Operands()
+	var children expression.Expressions
+	if this.Operands()[0] != nil {
+		children = this.Operands()
+	}
+
+	if this.order == nil && this.filter == nil {
+		return children
+	}
+
+	rv := make(expression.Expressions, 0, len(children)+4)
+	rv = append(rv, children...)
+	if this.order != nil {
+		rv = append(rv, this.order.Expressions()...)
+	}
+	if this.filter != nil {
+		rv = append(rv, this.filter)
 	}
+	return rv
 }
 
 /*
@@ -171,19 +230,84 @@ It is a utility function that takes in as input parameter
 a mapper and maps the involved expressions to an expression.
 If there is an error during the mapping, an error is returned.
 */
-func (this *AggregateBase) MapChildren(mapper expression.Mapper) error {
-	children := this.Children()
+func (this *AggregateBase) MapChildren(mapper expression.Mapper) (err error) {
+	operands := this.Operands()
 
-	for i, c := range children {
-		expr, err := mapper.Map(c)
+	for i, c := range operands {
+		if c == nil {
+			continue
+		}
+
+		operands[i], err = mapper.Map(c)
 		if err != nil {
-			return err
+			return
 		}
+	}
 
-		children[i] = expr
+	if this.order != nil {
+		err = this.order.MapExpressions(mapper)
+		if err != nil {
+			return
+		}
 	}
 
-	return nil
+	if this.filter != nil {
+		this.filter, err = mapper.Map(this.filter)
+	}
+
+	return
+}
+
+/*
+Copy the aggregate, including its FILTER and ORDER BY clauses.
+*/
+func (this *AggregateBase) Copy() expression.Expression {
+	rv := this.UnaryFunctionBase.Copy().(Aggregate)
+	if this.filter != nil {
+		rv.SetFilter(this.filter.Copy())
+	}
+	if this.order != nil {
+		rv.SetOrder(this.order.Copy())
+	}
+	return rv
+}
+
+/*
+Return the condition of the FILTER clause.
+*/
+func (this *AggregateBase) Filter() expression.Expression {
+	return this.filter
+}
+
+/*
+Set the condition of the FILTER clause.
+*/
+func (this *AggregateBase) SetFilter(filter expression.Expression) {
+	this.filter = filter
+}
+
+/*
+Return the ORDER BY clause.
+*/
+func (this *AggregateBase) Order() *Order {
+	return this.order
+}
+
+/*
+Set the ORDER BY clause.
+*/
+func (this *AggregateBase) SetOrder(order *Order) {
+	this.order = order
+}
+
+/*
+Representation of the ORDER BY clause within the operands.
+*/
+func (this *AggregateBase) OrderString() string {
+	if this.order == nil {
+		return ""
+	}
+	return this.order.String()
 }
 
 func (this *AggregateBase) SurvivesGrouping(groupKeys expression.Expressions,
@@ -220,5 +344,6 @@ func (this *DistinctAggregateBase) Distinct() bool { return true }
 func (this *DistinctAggregateBase) EquivalentTo(other expression.Expression) bool {
 	otherAggregate, ok := other.(Aggregate)
 	return ok && otherAggregate.Distinct() && this.Name() == otherAggregate.Name() &&
-		expression.Equivalents(this.Children(), otherAggregate.Children())
+		expression.Equivalents(this.Children(), otherAggregate.Children()) &&
+		this.clausesEquivalentTo(otherAggregate)
 }
@@ -0,0 +1,171 @@
+//  Copyright (c) 2018 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package algebra
+
+import (
+	"encoding/json"
+
+	"github.com/couchbase/query/auth"
+	"github.com/couchbase/query/errors"
+	"github.com/couchbase/query/expression"
+	"github.com/couchbase/query/value"
+)
+
+/*
+Represents the COPY ... FROM statement, which loads the documents of
+a file into a keyspace. Type CopyFrom is a struct that contains
+fields mapping to each clause in the statement: the keyspace, the
+file, the key expression, evaluated against each document under the
+keyspace alias, and the options of the WITH clause. Documents are
+upserted, and keys default to UUID().
+*/
+type CopyFrom struct {
+	statementBase
+
+	keyspace *KeyspaceRef          `json:"keyspace"`
+	file     string                `json:"file"`
+	key      expression.Expression `json:"key"`
+	with     value.Value           `json:"with"`
+}
+
+/*
+The function NewCopyFrom returns a pointer to the CopyFrom
+struct with the input argument values as fields.
+*/
+func NewCopyFrom(keyspace *KeyspaceRef, file string, key expression.Expression, with value.Value) *CopyFrom {
+	if key == nil {
+		key = expression.NewUuid()
+	}
+
+	rv := &CopyFrom{
+		keyspace: keyspace,
+		file:     file,
+		key:      key,
+		with:     with,
+	}
+
+	rv.stmt = rv
+	return rv
+}
+
+/*
+It calls the VisitCopyFrom method by passing in the receiver
+and returns the interface. It is a visitor pattern.
+*/
+func (this *CopyFrom) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitCopyFrom(this)
+}
+
+/*
+Returns nil.
+*/
+func (this *CopyFrom) Signature() value.Value {
+	return nil
+}
+
+/*
+Applies mapper to the key expression.
+*/
+func (this *CopyFrom) MapExpressions(mapper expression.Mapper) (err error) {
+	this.key, err = mapper.Map(this.key)
+	return
+}
+
+/*
+Returns all contained Expressions.
+*/
+func (this *CopyFrom) Expressions() expression.Expressions {
+	return expression.Expressions{this.key}
+}
+
+/*
+Returns all required privileges. Reading files on the query node
+is, like CURL(), external access.
+*/
+func (this *CopyFrom) Privileges() (*auth.Privileges, errors.Error) {
+	privs := auth.NewPrivileges()
+	fullKeyspace := this.keyspace.FullName()
+	privs.Add(fullKeyspace, auth.PRIV_QUERY_INSERT)
+	privs.Add(fullKeyspace, auth.PRIV_QUERY_UPDATE)
+	privs.Add("", auth.PRIV_QUERY_EXTERNAL_ACCESS)
+
+	exprs := this.Expressions()
+	subprivs, err := subqueryPrivileges(exprs)
+	if err != nil {
+		return nil, err
+	}
+	privs.AddAll(subprivs)
+
+	for _, expr := range exprs {
+		privs.AddAll(expr.Privileges())
+	}
+
+	return privs, nil
+}
+
+/*
+Fully qualify identifiers of the key expression with the
+keyspace alias.
+*/
+func (this *CopyFrom) Formalize() (err error) {
+	f, err := this.keyspace.Formalize()
+	if err != nil {
+		return err
+	}
+
+	this.key, err = f.Map(this.key)
+	return
+}
+
+/*
+Returns the keyspace-ref of the statement.
+*/
+func (this *CopyFrom) KeyspaceRef() *KeyspaceRef {
+	return this.keyspace
+}
+
+/*
+Returns the file to be loaded.
+*/
+func (this *CopyFrom) File() string {
+	return this.file
+}
+
+/*
+Returns the key expression.
+*/
+func (this *CopyFrom) Key() expression.Expression {
+	return this.key
+}
+
+/*
+Returns the options of the with clause.
+*/
+func (this *CopyFrom) With() value.Value {
+	return this.with
+}
+
+/*
+Marshals input receiver into byte array.
+*/
+func (this *CopyFrom) MarshalJSON() ([]byte, error) {
+	r := map[string]interface{}{"type": "copyFrom"}
+	r["keyspaceRef"] = this.keyspace
+	r["file"] = this.file
+	r["key"] = expression.NewStringer().Visit(this.key)
+	if this.with != nil {
+		r["with"] = this.with
+	}
+	return json.Marshal(r)
+}
+
+func (this *CopyFrom) Type() string {
+	return "COPY_FROM"
+}
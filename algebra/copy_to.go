@@ -0,0 +1,138 @@
+//  Copyright (c) 2018 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package algebra
+
+import (
+	"encoding/json"
+
+	"github.com/couchbase/query/auth"
+	"github.com/couchbase/query/errors"
+	"github.com/couchbase/query/expression"
+	"github.com/couchbase/query/value"
+)
+
+/*
+Represents the COPY ... TO statement, which writes the results of a
+query to a file. Type CopyTo is a struct that contains fields mapping
+to each clause in the statement: the query, the file and the options
+of the WITH clause.
+*/
+type CopyTo struct {
+	statementBase
+
+	query *Select     `json:"select"`
+	file  string      `json:"file"`
+	with  value.Value `json:"with"`
+}
+
+/*
+The function NewCopyTo returns a pointer to the CopyTo
+struct with the input argument values as fields.
+*/
+func NewCopyTo(query *Select, file string, with value.Value) *CopyTo {
+	rv := &CopyTo{
+		query: query,
+		file:  file,
+		with:  with,
+	}
+
+	rv.stmt = rv
+	return rv
+}
+
+/*
+It calls the VisitCopyTo method by passing in the receiver
+and returns the interface. It is a visitor pattern.
+*/
+func (this *CopyTo) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitCopyTo(this)
+}
+
+/*
+Returns nil, as results are written to the file.
+*/
+func (this *CopyTo) Signature() value.Value {
+	return nil
+}
+
+/*
+Applies mapper to all the expressions of the query.
+*/
+func (this *CopyTo) MapExpressions(mapper expression.Mapper) error {
+	return this.query.MapExpressions(mapper)
+}
+
+/*
+Returns all contained Expressions.
+*/
+func (this *CopyTo) Expressions() expression.Expressions {
+	return this.query.Expressions()
+}
+
+/*
+Returns the privileges required by the query, and that of writing
+files on the query node, which like CURL() is external access.
+*/
+func (this *CopyTo) Privileges() (*auth.Privileges, errors.Error) {
+	privs, err := this.query.Privileges()
+	if err != nil {
+		return nil, err
+	}
+
+	rv := auth.NewPrivileges()
+	rv.AddAll(privs)
+	rv.Add("", auth.PRIV_QUERY_EXTERNAL_ACCESS)
+	return rv, nil
+}
+
+/*
+Fully qualify identifiers of the query.
+*/
+func (this *CopyTo) Formalize() error {
+	return this.query.Formalize()
+}
+
+/*
+Returns the query whose results are written.
+*/
+func (this *CopyTo) Select() *Select {
+	return this.query
+}
+
+/*
+Returns the file to be written.
+*/
+func (this *CopyTo) File() string {
+	return this.file
+}
+
+/*
+Returns the options of the with clause.
+*/
+func (this *CopyTo) With() value.Value {
+	return this.with
+}
+
+/*
+Marshals input receiver into byte array.
+*/
+func (this *CopyTo) MarshalJSON() ([]byte, error) {
+	r := map[string]interface{}{"type": "copyTo"}
+	r["select"] = this.query
+	r["file"] = this.file
+	if this.with != nil {
+		r["with"] = this.with
+	}
+	return json.Marshal(r)
+}
+
+func (this *CopyTo) Type() string {
+	return "COPY_TO"
+}
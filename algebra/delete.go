@@ -147,8 +147,8 @@ func (this *Delete) Privileges() (*auth.Privileges, errors.Error) {
 	fullKeyspace := this.keyspace.FullName()
 	name := this.keyspace.Keyspace()
 	if this.keyspace.Namespace() == "#system" &&
-		(name == "prepareds" || name == "active_requests" || name == "completed_requests") {
-		// Temp fix. For now, deleting from these three tables should require
+		(name == "prepareds" || name == "plan_baselines" || name == "active_requests" || name == "completed_requests") {
+		// Temp fix. For now, deleting from these tables should require
 		// the same permissions as reading from them.
 		privs.Add("", auth.PRIV_SYSTEM_READ)
 	} else {
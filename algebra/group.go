@@ -24,6 +24,7 @@ BY clauses. Having specifies a condition.
 */
 type Group struct {
 	by      expression.Expressions `json:by`
+	sets    [][]int                `json:"sets"`
 	letting expression.Bindings    `json:"letting"`
 	having  expression.Expression  `json:"having"`
 }
@@ -41,6 +42,114 @@ func NewGroup(by expression.Expressions, letting expression.Bindings, having exp
 	}
 }
 
+/*
+The function NewGroupingSets returns a pointer to the Group
+struct for GROUP BY ROLLUP, CUBE and GROUPING SETS. The by
+expressions are the distinct keys of all the grouping sets,
+and each grouping set is kept as the positions of its keys
+within them. A single grouping set is a plain GROUP BY.
+*/
+func NewGroupingSets(sets []expression.Expressions, letting expression.Bindings,
+	having expression.Expression) *Group {
+	if len(sets) == 1 {
+		var by expression.Expressions
+		if len(sets[0]) > 0 {
+			by = sets[0]
+		}
+
+		return NewGroup(by, letting, having)
+	}
+
+	var by expression.Expressions
+	positions := make([][]int, len(sets))
+	for i, set := range sets {
+		positions[i] = make([]int, 0, len(set))
+
+	keys:
+		for _, expr := range set {
+			pos := len(by)
+			for j, b := range by {
+				if b.EquivalentTo(expr) {
+					pos = j
+					break
+				}
+			}
+
+			for _, p := range positions[i] {
+				if p == pos {
+					continue keys
+				}
+			}
+
+			if pos == len(by) {
+				by = append(by, expr)
+			}
+
+			positions[i] = append(positions[i], pos)
+		}
+	}
+
+	return &Group{
+		by:      by,
+		sets:    positions,
+		letting: letting,
+		having:  having,
+	}
+}
+
+/*
+The grouping sets of ROLLUP(a, b, ...), which are (a, b, ...)
+and each of its prefixes down to the empty grouping set.
+*/
+func RollupSets(exprs expression.Expressions) []expression.Expressions {
+	sets := make([]expression.Expressions, 0, len(exprs)+1)
+	for i := len(exprs); i >= 0; i-- {
+		sets = append(sets, exprs[:i])
+	}
+
+	return sets
+}
+
+/*
+The grouping sets of CUBE(a, b, ...), which are all the subsets
+of (a, b, ...), from the largest to the empty grouping set.
+*/
+func CubeSets(exprs expression.Expressions) []expression.Expressions {
+	n := uint(len(exprs))
+	sets := make([]expression.Expressions, 0, 1<<n)
+	for mask := (1 << n) - 1; mask >= 0; mask-- {
+		set := make(expression.Expressions, 0, n)
+		for i, expr := range exprs {
+			if mask&(1<<(n-1-uint(i))) != 0 {
+				set = append(set, expr)
+			}
+		}
+
+		sets = append(sets, set)
+	}
+
+	return sets
+}
+
+/*
+Combine the grouping sets of two GROUP BY elements, which is
+their cross product: every set of the left is concatenated
+with every set of the right.
+*/
+func CrossGroupingSets(left, right []expression.Expressions) []expression.Expressions {
+	sets := make([]expression.Expressions, 0, len(left)*len(right))
+	for _, l := range left {
+		for _, r := range right {
+			set := make(expression.Expressions, 0, len(l)+len(r))
+			set = append(set, l...)
+			set = append(set, r...)
+			sets = append(sets, set)
+		}
+	}
+
+	return sets
+}
+
 /*
 This method qualifies identifiers for all the constituent clauses,
 namely the by, letting and having expressions by mapping them.
@@ -100,6 +209,26 @@ func (this *Group) MapExpressions(mapper expression.Mapper) (err error) {
 	return
 }
 
+/*
+This method maps the letting and having expressions, which
+are evaluated over the groups, leaving the by expressions
+unchanged.
+*/
+func (this *Group) MapGroupedExpressions(mapper expression.Mapper) (err error) {
+	if this.letting != nil {
+		err = this.letting.MapExpressions(mapper)
+		if err != nil {
+			return
+		}
+	}
+
+	if this.having != nil {
+		this.having, err = mapper.Map(this.having)
+	}
+
+	return
+}
+
 /*
    Returns all contained Expressions.
 */
@@ -127,7 +256,27 @@ func (this *Group) Expressions() expression.Expressions {
 func (this *Group) String() string {
 	s := ""
 
-	if this.by != nil {
+	if this.sets != nil {
+		s += " group by grouping sets ("
+
+		for i, set := range this.sets {
+			if i > 0 {
+				s += ", "
+			}
+
+			s += "("
+			for j, k := range set {
+				if j > 0 {
+					s += ", "
+				}
+
+				s += this.by[k].String()
+			}
+			s += ")"
+		}
+
+		s += ")"
+	} else if this.by != nil {
 		s += " group by "
 
 		for i, b := range this.by {
@@ -157,6 +306,15 @@ func (this *Group) By() expression.Expressions {
 	return this.by
 }
 
+/*
+Returns the grouping sets of ROLLUP, CUBE and GROUPING SETS,
+as the positions of their keys within the Group by expressions,
+or nil for a plain GROUP BY.
+*/
+func (this *Group) Sets() [][]int {
+	return this.sets
+}
+
 /*
 Returns the letting expression bindings.
 */
@@ -0,0 +1,122 @@
+//  Copyright (c) 2018 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package algebra
+
+import (
+	"math"
+
+	"github.com/couchbase/query/expression"
+	"github.com/couchbase/query/value"
+)
+
+/*
+This represents the function GROUPING(expr, ...) of GROUP BY
+ROLLUP, CUBE and GROUPING SETS. For each of its operands, which
+must be group keys, it has a bit that is 1 if the key is not part
+of the grouping set of the current group, i.e. the group is a
+subtotal over that key, and 0 otherwise. The bit of the first
+operand is the most significant one.
+*/
+type Grouping struct {
+	expression.FunctionBase
+}
+
+func NewGrouping(operands ...expression.Expression) expression.Function {
+	rv := &Grouping{
+		*expression.NewFunctionBase("grouping", operands...),
+	}
+
+	rv.SetExpr(rv)
+	return rv
+}
+
+/*
+Visitor pattern.
+*/
+func (this *Grouping) Accept(visitor expression.Visitor) (interface{}, error) {
+	return visitor.VisitFunction(this)
+}
+
+func (this *Grouping) Type() value.Type { return value.NUMBER }
+
+/*
+The grouping set of the current group is retrieved from the
+grouping attachment, which maps the keys of the grouping set
+to true. Without it, the group comes from a plain GROUP BY and
+every key is part of it.
+*/
+func (this *Grouping) Evaluate(item value.Value, context expression.Context) (value.Value, error) {
+	var keys map[string]bool
+	if av, ok := item.(value.AnnotatedValue); ok {
+		keys, _ = av.GetAttachment("grouping").(map[string]bool)
+	}
+
+	rv := 0
+	for _, op := range this.Operands() {
+		rv <<= 1
+		if keys != nil && !keys[op.String()] {
+			rv |= 1
+		}
+	}
+
+	return value.NewValue(rv), nil
+}
+
+/*
+Each operand must itself be a group key; an expression over
+group keys is not enough, as it has no bit of its own.
+*/
+func (this *Grouping) SurvivesGrouping(groupKeys expression.Expressions,
+	allowed *value.ScopeValue) (bool, expression.Expression) {
+operands:
+	for _, op := range this.Operands() {
+		for _, key := range groupKeys {
+			if op.EquivalentTo(key) {
+				continue operands
+			}
+		}
+
+		return false, op
+	}
+
+	return true, nil
+}
+
+/*
+The result depends on the current group, so it is never constant.
+*/
+func (this *Grouping) Value() value.Value {
+	return nil
+}
+
+func (this *Grouping) Static() expression.Expression {
+	return nil
+}
+
+func (this *Grouping) Indexable() bool {
+	return false
+}
+
+/*
+Minimum input arguments required is 1.
+*/
+func (this *Grouping) MinArgs() int { return 1 }
+
+/*
+Maximum number of input arguments defined is MaxInt16 = 1<<15 - 1.
+*/
+func (this *Grouping) MaxArgs() int { return math.MaxInt16 }
+
+/*
+Factory method pattern.
+*/
+func (this *Grouping) Constructor() expression.FunctionConstructor {
+	return NewGrouping
+}
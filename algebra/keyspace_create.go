@@ -0,0 +1,133 @@
+//  Copyright (c) 2018 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package algebra
+
+import (
+	"encoding/json"
+
+	"github.com/couchbase/query/auth"
+	"github.com/couchbase/query/errors"
+	"github.com/couchbase/query/expression"
+	"github.com/couchbase/query/value"
+)
+
+/*
+Represents the Create keyspace ddl statement, which registers a
+keyspace of the given type, such as an external file, with a
+namespace. Type CreateKeyspace is a struct that contains fields
+mapping to each clause in the statement, namely the keyspace, the
+keyspace type and the type specific options.
+*/
+type CreateKeyspace struct {
+	statementBase
+
+	keyspace *KeyspaceRef `json:"keyspace"`
+	using    string       `json:"using"`
+	with     value.Value  `json:"with"`
+}
+
+/*
+The function NewCreateKeyspace returns a pointer to the
+CreateKeyspace struct with the input argument values as fields.
+*/
+func NewCreateKeyspace(keyspace *KeyspaceRef, using string, with value.Value) *CreateKeyspace {
+	rv := &CreateKeyspace{
+		keyspace: keyspace,
+		using:    using,
+		with:     with,
+	}
+
+	rv.stmt = rv
+	return rv
+}
+
+/*
+It calls the VisitCreateKeyspace method by passing in the
+receiver and returns the interface. It is a visitor
+pattern.
+*/
+func (this *CreateKeyspace) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitCreateKeyspace(this)
+}
+
+/*
+Returns nil.
+*/
+func (this *CreateKeyspace) Signature() value.Value {
+	return nil
+}
+
+/*
+Returns nil.
+*/
+func (this *CreateKeyspace) Formalize() error {
+	return nil
+}
+
+/*
+Returns nil.
+*/
+func (this *CreateKeyspace) MapExpressions(mapper expression.Mapper) error {
+	return nil
+}
+
+/*
+Returns all contained Expressions.
+*/
+func (this *CreateKeyspace) Expressions() expression.Expressions {
+	return nil
+}
+
+/*
+Returns all required privileges.
+*/
+func (this *CreateKeyspace) Privileges() (*auth.Privileges, errors.Error) {
+	privs := auth.NewPrivileges()
+	privs.Add(this.keyspace.FullName(), auth.PRIV_QUERY_MANAGE_KEYSPACE)
+	return privs, nil
+}
+
+/*
+Returns the keyspace to be created.
+*/
+func (this *CreateKeyspace) Keyspace() *KeyspaceRef {
+	return this.keyspace
+}
+
+/*
+Returns the keyspace type of the using clause.
+*/
+func (this *CreateKeyspace) Using() string {
+	return this.using
+}
+
+/*
+Returns the options of the with clause.
+*/
+func (this *CreateKeyspace) With() value.Value {
+	return this.with
+}
+
+/*
+Marshals input receiver into byte array.
+*/
+func (this *CreateKeyspace) MarshalJSON() ([]byte, error) {
+	r := map[string]interface{}{"type": "createKeyspace"}
+	r["keyspaceRef"] = this.keyspace
+	r["using"] = this.using
+	if this.with != nil {
+		r["with"] = this.with
+	}
+	return json.Marshal(r)
+}
+
+func (this *CreateKeyspace) Type() string {
+	return "CREATE_KEYSPACE"
+}
@@ -0,0 +1,108 @@
+//  Copyright (c) 2018 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package algebra
+
+import (
+	"encoding/json"
+
+	"github.com/couchbase/query/auth"
+	"github.com/couchbase/query/errors"
+	"github.com/couchbase/query/expression"
+	"github.com/couchbase/query/value"
+)
+
+/*
+Represents the Drop keyspace ddl statement, which removes a keyspace
+registered by CREATE KEYSPACE.
+*/
+type DropKeyspace struct {
+	statementBase
+
+	keyspace *KeyspaceRef `json:"keyspace"`
+}
+
+/*
+The function NewDropKeyspace returns a pointer to the
+DropKeyspace struct with the input argument values as fields.
+*/
+func NewDropKeyspace(keyspace *KeyspaceRef) *DropKeyspace {
+	rv := &DropKeyspace{
+		keyspace: keyspace,
+	}
+
+	rv.stmt = rv
+	return rv
+}
+
+/*
+It calls the VisitDropKeyspace method by passing in the
+receiver and returns the interface. It is a visitor
+pattern.
+*/
+func (this *DropKeyspace) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitDropKeyspace(this)
+}
+
+/*
+Returns nil.
+*/
+func (this *DropKeyspace) Signature() value.Value {
+	return nil
+}
+
+/*
+Returns nil.
+*/
+func (this *DropKeyspace) Formalize() error {
+	return nil
+}
+
+/*
+Returns nil.
+*/
+func (this *DropKeyspace) MapExpressions(mapper expression.Mapper) error {
+	return nil
+}
+
+/*
+Returns all contained Expressions.
+*/
+func (this *DropKeyspace) Expressions() expression.Expressions {
+	return nil
+}
+
+/*
+Returns all required privileges.
+*/
+func (this *DropKeyspace) Privileges() (*auth.Privileges, errors.Error) {
+	privs := auth.NewPrivileges()
+	privs.Add(this.keyspace.FullName(), auth.PRIV_QUERY_MANAGE_KEYSPACE)
+	return privs, nil
+}
+
+/*
+Returns the keyspace to be dropped.
+*/
+func (this *DropKeyspace) Keyspace() *KeyspaceRef {
+	return this.keyspace
+}
+
+/*
+Marshals input receiver into byte array.
+*/
+func (this *DropKeyspace) MarshalJSON() ([]byte, error) {
+	r := map[string]interface{}{"type": "dropKeyspace"}
+	r["keyspaceRef"] = this.keyspace
+	return json.Marshal(r)
+}
+
+func (this *DropKeyspace) Type() string {
+	return "DROP_KEYSPACE"
+}
@@ -47,6 +47,18 @@ func (this *Order) Expressions() expression.Expressions {
 	return this.terms.Expressions()
 }
 
+/*
+   Returns a deep copy of the order by clause.
+*/
+func (this *Order) Copy() *Order {
+	terms := make(SortTerms, len(this.terms))
+	for i, term := range this.terms {
+		terms[i] = NewSortTerm(term.expr.Copy(), term.descending)
+	}
+
+	return NewOrder(terms)
+}
+
 /*
    Representation as a N1QL string.
 */
@@ -105,6 +105,27 @@ func (this *Projection) MapExpressions(mapper expression.Mapper) (err error) {
 	return
 }
 
+/*
+This method maps the result expressions over the groups of
+ROLLUP, CUBE and GROUPING SETS. An alias derived from a result
+expression is kept as an explicit alias, since the mapped
+expression need no longer be a path.
+*/
+func (this *Projection) MapGroupedExpressions(mapper expression.Mapper) (err error) {
+	for _, term := range this.terms {
+		if !term.star && term.as == "" && term.expr != nil && term.expr.Alias() != "" {
+			term.as = term.alias
+		}
+
+		err = term.MapExpression(mapper)
+		if err != nil {
+			return
+		}
+	}
+
+	return
+}
+
 /*
    Returns all contained Expressions.
 */
@@ -26,6 +26,13 @@ type Visitor interface {
 	VisitUpdate(stmt *Update) (interface{}, error)
 	VisitMerge(stmt *Merge) (interface{}, error)
 
+	/*
+	   Visitor for COPY statements, which load a file into a
+	   keyspace, or write the results of a query to a file.
+	*/
+	VisitCopyFrom(stmt *CopyFrom) (interface{}, error)
+	VisitCopyTo(stmt *CopyTo) (interface{}, error)
+
 	/*
 	   Visitor for DDL statements. N1QL provides index
 	   statements CREATE PRIMARY INDEX, CREATE INDEX, DROP
@@ -36,6 +43,14 @@ type Visitor interface {
 	VisitDropIndex(stmt *DropIndex) (interface{}, error)
 	VisitAlterIndex(stmt *AlterIndex) (interface{}, error)
 	VisitBuildIndexes(stmt *BuildIndexes) (interface{}, error)
+
+	/*
+	   Visitor for keyspace DDL statements, CREATE KEYSPACE and
+	   DROP KEYSPACE.
+	*/
+	VisitCreateKeyspace(stmt *CreateKeyspace) (interface{}, error)
+	VisitDropKeyspace(stmt *DropKeyspace) (interface{}, error)
+
 	/*
 	   Visitor for ROLES statements.
 	*/
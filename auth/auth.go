@@ -26,7 +26,8 @@ const (
 	PRIV_QUERY_ALTER_INDEX     Privilege = 13 // Ability to run ALTER INDEX statements.
 	PRIV_QUERY_DROP_INDEX      Privilege = 14 // Ability to run DROP INDEX statements.
 	PRIV_QUERY_LIST_INDEX      Privilege = 15 // Ability to list indexes of a keyspace.
-	PRIV_QUERY_EXTERNAL_ACCESS Privilege = 16 // Ability to access the web from a N1QL query.
+	PRIV_QUERY_EXTERNAL_ACCESS Privilege = 16 // Ability to access the web, or files on the query node, from a N1QL query.
+	PRIV_QUERY_MANAGE_KEYSPACE Privilege = 17 // Ability to run CREATE KEYSPACE and DROP KEYSPACE statements.
 )
 
 func IsStatementTypePrivilege(priv Privilege) bool {
@@ -24,7 +24,7 @@ import (
 func opIsUnimplemented(namespace, bucket string, requested auth.Privilege) bool {
 	if namespace == "#system" {
 		// For system monitoring tables INSERT and UPDATE are not supported.
-		if bucket == "prepareds" || bucket == "completed_requests" || bucket == "active_requests" {
+		if bucket == "prepareds" || bucket == "plan_baselines" || bucket == "completed_requests" || bucket == "active_requests" {
 			if requested == auth.PRIV_QUERY_UPDATE || requested == auth.PRIV_QUERY_INSERT {
 				return true
 			}
@@ -72,6 +72,8 @@ func privilegeString(namespace, bucket string, requested auth.Privilege) (string
 		permission = fmt.Sprintf("cluster.bucket[%s].n1ql.index!list", bucket)
 	case auth.PRIV_QUERY_EXTERNAL_ACCESS:
 		permission = "cluster.n1ql.curl!execute"
+	case auth.PRIV_QUERY_MANAGE_KEYSPACE:
+		permission = fmt.Sprintf("cluster.bucket[%s].settings!write", bucket)
 	default:
 		return "", fmt.Errorf("Invalid Privileges")
 	}
@@ -348,8 +350,11 @@ func messageForDeniedPrivilege(pair auth.PrivilegePair) string {
 		privilege = "index operations"
 		role = fmt.Sprintf("query_manage_index on %s", keyspace)
 	case auth.PRIV_QUERY_EXTERNAL_ACCESS:
-		privilege = "queries using the CURL() function"
+		privilege = "queries using the CURL() function or COPY"
 		role = "query_external_access"
+	case auth.PRIV_QUERY_MANAGE_KEYSPACE:
+		privilege = fmt.Sprintf("CREATE and DROP KEYSPACE on the %s bucket (cluster.bucket[%s].settings!write)", keyspace, keyspace)
+		role = fmt.Sprintf("bucket_admin on %s", keyspace)
 	default:
 		privilege = "this type of query"
 		role = "admin"
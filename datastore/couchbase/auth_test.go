@@ -213,6 +213,8 @@ func TestMessageForDeniedPrivilege(t *testing.T) {
 			expected: "User does not have credentials to run index operations. Add role query_manage_index on testbucket to allow the query to run."},
 		deniedCase{data: auth.PrivilegePair{Target: "", Priv: auth.PRIV_SYSTEM_READ},
 			expected: "User does not have credentials to run queries accessing the system tables. Add role query_system_catalog to allow the query to run."},
+		deniedCase{data: auth.PrivilegePair{Target: "testbucket", Priv: auth.PRIV_QUERY_MANAGE_KEYSPACE},
+			expected: "User does not have credentials to run CREATE and DROP KEYSPACE on the testbucket bucket (cluster.bucket[testbucket].settings!write). Add role bucket_admin on testbucket to allow the query to run."},
 	}
 
 	for i, c := range cases {
@@ -92,6 +92,13 @@ type Namespace interface {
 	MetadataVersion() uint64                             // Current version of the metadata
 }
 
+// KeyspaceManager is implemented by namespaces whose keyspaces are
+// registered with CREATE KEYSPACE, such as keyspaces over external files.
+type KeyspaceManager interface {
+	CreateKeyspace(name, using string, with value.Value) errors.Error // Register a keyspace of the given type
+	DropKeyspace(name string) errors.Error                            // Remove a registered keyspace
+}
+
 // Keyspace is a map of key-value entries (typically key-document, but
 // also key-counter, key-blob, etc.). Keys are unique within a
 // keyspace.
@@ -138,6 +145,15 @@ func GetSystemstore() Datastore {
 }
 
 func GetKeyspace(namespace, keyspace string) (Keyspace, errors.Error) {
+	ns, err := GetNamespace(namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	return ns.KeyspaceByName(keyspace)
+}
+
+func GetNamespace(namespace string) (Namespace, errors.Error) {
 	var datastore Datastore
 
 	if namespace == "#system" {
@@ -154,12 +170,7 @@ func GetKeyspace(namespace, keyspace string) (Keyspace, errors.Error) {
 		namespace = "default"
 	}
 
-	ns, err := datastore.NamespaceByName(namespace)
-	if err != nil {
-		return nil, err
-	}
-
-	return ns.KeyspaceByName(keyspace)
+	return datastore.NamespaceByName(namespace)
 }
 
 // These structures are generic representations of users and their roles.
@@ -0,0 +1,136 @@
+//  Copyright (c) 2018 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package external
+
+import (
+	"os"
+	"sync"
+	"time"
+
+	"github.com/couchbase/query/errors"
+)
+
+// stats describe a file as it was last parsed. They outlive the
+// documents, so that files can be skipped without being read again.
+type stats struct {
+	modTime time.Time
+	size    int64
+	rows    int
+	min     string
+	max     string
+}
+
+type entry struct {
+	stats *stats
+	data  *data
+	used  uint64
+}
+
+// cache keeps parsed files, up to a number of rows. The documents of
+// the least recently used files are evicted first.
+type cache struct {
+	sync.Mutex
+	entries map[*keyspace]map[string]*entry
+	rows    int
+	limit   int
+	clock   uint64
+}
+
+func newCache(limit int) *cache {
+	return &cache{
+		entries: make(map[*keyspace]map[string]*entry, 8),
+		limit:   limit,
+	}
+}
+
+// The stats of a file of a keyspace, and its documents if asked for.
+// The file is parsed again if it has changed since it was cached.
+func (this *cache) get(ks *keyspace, path string, docs bool) (*stats, *data, errors.Error) {
+	fi, er := os.Stat(path)
+	if er != nil {
+		return nil, nil, errors.NewExternalFileError(er, path)
+	}
+
+	this.Lock()
+	files := this.entries[ks]
+	e := files[path]
+	if e != nil && e.stats.modTime.Equal(fi.ModTime()) && e.stats.size == fi.Size() &&
+		(e.data != nil || !docs) {
+		this.clock++
+		e.used = this.clock
+		this.Unlock()
+		return e.stats, e.data, nil
+	}
+	this.Unlock()
+
+	// parse outside of the lock, so that other files can be served
+	d, st, err := readFile(path, ks.options)
+	if err != nil {
+		return nil, nil, err
+	}
+	st.modTime = fi.ModTime()
+	st.size = fi.Size()
+
+	this.Lock()
+	defer this.Unlock()
+
+	if files == nil {
+		files = this.entries[ks]
+		if files == nil {
+			files = make(map[string]*entry, 1)
+			this.entries[ks] = files
+		}
+	}
+
+	if old := files[path]; old != nil && old.data != nil {
+		this.rows -= old.stats.rows
+	}
+
+	this.clock++
+	e = &entry{stats: st, data: d, used: this.clock}
+	files[path] = e
+	this.rows += st.rows
+	this.evict(e)
+	return st, d, nil
+}
+
+// Drop documents until the cache is within its limit, sparing the
+// entry just added. The caller holds the lock.
+func (this *cache) evict(keep *entry) {
+	for this.rows > this.limit {
+		var oldest *entry
+		for _, files := range this.entries {
+			for _, e := range files {
+				if e != keep && e.data != nil && (oldest == nil || e.used < oldest.used) {
+					oldest = e
+				}
+			}
+		}
+
+		if oldest == nil {
+			return
+		}
+		oldest.data = nil
+		this.rows -= oldest.stats.rows
+	}
+}
+
+// Forget the files of a dropped keyspace
+func (this *cache) purge(ks *keyspace) {
+	this.Lock()
+	defer this.Unlock()
+
+	for _, e := range this.entries[ks] {
+		if e.data != nil {
+			this.rows -= e.stats.rows
+		}
+	}
+	delete(this.entries, ks)
+}
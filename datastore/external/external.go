@@ -0,0 +1,553 @@
+//  Copyright (c) 2018 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+/*
+Package external provides a read-only implementation of the datastore
+package over CSV and newline-delimited JSON files.
+
+The datastore is a directory, holding a single namespace, default.
+Keyspaces are registered with CREATE KEYSPACE, which maps a keyspace
+to one or more files of the directory:
+
+	CREATE KEYSPACE ext:rates USING csv
+	    WITH {"path": "rates/*.csv", "header": true, "key": "code"}
+
+Registered keyspaces are kept in keyspaces.json, in the directory.
+Files are parsed when they are first read, and kept in a cache that is
+refreshed when the files change.
+*/
+package external
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/couchbase/query/auth"
+	"github.com/couchbase/query/datastore"
+	"github.com/couchbase/query/errors"
+	"github.com/couchbase/query/logging"
+	"github.com/couchbase/query/util"
+	"github.com/couchbase/query/value"
+)
+
+const _NAMESPACE = "default"
+
+// The file recording the registered keyspaces
+const _CATALOG = "keyspaces.json"
+
+// Maximum number of rows kept in the cache
+const _CACHE_ROWS = 1 << 20
+
+// store is the root for the external file Datastore.
+type store struct {
+	path      string
+	namespace *namespace
+	cache     *cache
+
+	users map[string]*datastore.User
+}
+
+func (s *store) Id() string {
+	return s.path
+}
+
+func (s *store) URL() string {
+	return "ext:" + s.path
+}
+
+func (s *store) Info() datastore.Info {
+	return &infoImpl{}
+}
+
+type infoImpl struct {
+}
+
+func (i *infoImpl) Version() string {
+	return util.VERSION
+}
+
+func (info *infoImpl) Topology() ([]string, []errors.Error) {
+	return []string{}, nil
+}
+
+func (info *infoImpl) Services(node string) (map[string]interface{}, []errors.Error) {
+	return map[string]interface{}{}, nil
+}
+
+func (s *store) NamespaceIds() ([]string, errors.Error) {
+	return s.NamespaceNames()
+}
+
+func (s *store) NamespaceNames() ([]string, errors.Error) {
+	return []string{s.namespace.name}, nil
+}
+
+func (s *store) NamespaceById(id string) (datastore.Namespace, errors.Error) {
+	return s.NamespaceByName(id)
+}
+
+func (s *store) NamespaceByName(name string) (datastore.Namespace, errors.Error) {
+	if name != s.namespace.name {
+		return nil, errors.NewExternalNamespaceNotFoundError(nil, name)
+	}
+
+	return s.namespace, nil
+}
+
+func (s *store) Authorize(*auth.Privileges, auth.Credentials, *http.Request) (auth.AuthenticatedUsers, errors.Error) {
+	return nil, nil
+}
+
+func (s *store) CredsString(req *http.Request) string {
+	return ""
+}
+
+func (s *store) SetLogLevel(level logging.Level) {
+	// No-op. Uses query engine logger.
+}
+
+func (s *store) Inferencer(name datastore.InferenceType) (datastore.Inferencer, errors.Error) {
+	return nil, errors.NewOtherNotImplementedError(nil, "INFER")
+}
+
+func (s *store) Inferencers() ([]datastore.Inferencer, errors.Error) {
+	return nil, errors.NewOtherNotImplementedError(nil, "INFER")
+}
+
+func (s *store) AuditInfo() (*datastore.AuditInfo, errors.Error) {
+	return nil, errors.NewOtherNotImplementedError(nil, "AuditInfo")
+}
+
+func (s *store) ProcessAuditUpdateStream(callb func(uid string) error) errors.Error {
+	return errors.NewOtherNotImplementedError(nil, "ProcessAuditUpdateStream")
+}
+
+func (s *store) UserInfo() (value.Value, errors.Error) {
+	// Return an array of no users.
+	jsonData := make([]interface{}, 0)
+	v := value.NewValue(jsonData)
+	return v, nil
+}
+
+func (s *store) GetUserInfoAll() ([]datastore.User, errors.Error) {
+	ret := make([]datastore.User, 0, len(s.users))
+	for _, v := range s.users {
+		ret = append(ret, *v)
+	}
+	return ret, nil
+}
+
+func (s *store) PutUserInfo(u *datastore.User) errors.Error {
+	s.users[u.Id] = u
+	return nil
+}
+
+func (s *store) GetRolesAll() ([]datastore.Role, errors.Error) {
+	return []datastore.Role{
+		datastore.Role{Name: "cluster_admin"},
+		datastore.Role{Name: "replication_admin"},
+		datastore.Role{Name: "bucket_admin", Bucket: "*"},
+	}, nil
+}
+
+// NewDatastore opens the directory at the given path, and the keyspaces
+// registered in it.
+func NewDatastore(path string) (s datastore.Datastore, e errors.Error) {
+	path, er := filepath.Abs(path)
+	if er != nil {
+		return nil, errors.NewExternalDatastoreError(er, "")
+	}
+
+	fi, er := os.Stat(path)
+	if er != nil {
+		return nil, errors.NewExternalDatastoreError(er, "")
+	}
+	if !fi.IsDir() {
+		return nil, errors.NewExternalDatastoreError(nil, path+" is not a directory")
+	}
+
+	ss := &store{
+		path:  path,
+		cache: newCache(_CACHE_ROWS),
+		users: make(map[string]*datastore.User, 4),
+	}
+
+	ss.namespace, e = loadNamespace(ss, _NAMESPACE)
+	if e != nil {
+		return
+	}
+
+	s = ss
+	return
+}
+
+// definition is the catalog entry of a keyspace.
+type definition struct {
+	Using string      `json:"using"`
+	With  interface{} `json:"with"`
+}
+
+// namespace represents the external file Namespace.
+type namespace struct {
+	sync.RWMutex
+	store     *store
+	name      string
+	keyspaces map[string]*keyspace
+}
+
+func loadNamespace(s *store, name string) (*namespace, errors.Error) {
+	p := &namespace{
+		store:     s,
+		name:      name,
+		keyspaces: make(map[string]*keyspace, 8),
+	}
+
+	bytes, er := ioutil.ReadFile(filepath.Join(s.path, _CATALOG))
+	if os.IsNotExist(er) {
+		return p, nil
+	} else if er != nil {
+		return nil, errors.NewExternalDatastoreError(er, "Cannot read "+_CATALOG)
+	}
+
+	var catalog map[string]*definition
+	if er = json.Unmarshal(bytes, &catalog); er != nil {
+		return nil, errors.NewExternalDatastoreError(er, "Cannot read "+_CATALOG)
+	}
+
+	for name, def := range catalog {
+		b, err := newKeyspace(p, name, def)
+		if err != nil {
+			return nil, err
+		}
+		p.keyspaces[name] = b
+	}
+
+	return p, nil
+}
+
+func (p *namespace) DatastoreId() string {
+	return p.store.Id()
+}
+
+func (p *namespace) Id() string {
+	return p.Name()
+}
+
+func (p *namespace) Name() string {
+	return p.name
+}
+
+func (p *namespace) KeyspaceIds() ([]string, errors.Error) {
+	return p.KeyspaceNames()
+}
+
+func (p *namespace) KeyspaceNames() ([]string, errors.Error) {
+	p.RLock()
+	defer p.RUnlock()
+
+	rv := make([]string, 0, len(p.keyspaces))
+	for name, _ := range p.keyspaces {
+		rv = append(rv, name)
+	}
+
+	sort.Strings(rv)
+	return rv, nil
+}
+
+func (p *namespace) KeyspaceById(id string) (datastore.Keyspace, errors.Error) {
+	return p.KeyspaceByName(id)
+}
+
+func (p *namespace) KeyspaceByName(name string) (datastore.Keyspace, errors.Error) {
+	p.RLock()
+	defer p.RUnlock()
+
+	b, ok := p.keyspaces[name]
+	if !ok {
+		return nil, errors.NewExternalKeyspaceNotFoundError(nil, name)
+	}
+	return b, nil
+}
+
+func (p *namespace) MetadataVersion() uint64 {
+	return 0
+}
+
+// CreateKeyspace registers a keyspace over the files given by the
+// options. The files must exist and be readable.
+func (p *namespace) CreateKeyspace(name, using string, with value.Value) errors.Error {
+	var w interface{}
+	if with != nil {
+		w = with.Actual()
+	}
+
+	b, err := newKeyspace(p, name, &definition{Using: using, With: w})
+	if err != nil {
+		return err
+	}
+
+	// reading the files validates them; this is done before taking the
+	// lock, so that lookups of the other keyspaces are not held up
+	_, err = b.Count(datastore.NULL_QUERY_CONTEXT)
+	if err != nil {
+		p.store.cache.purge(b)
+		return err
+	}
+
+	p.Lock()
+	defer p.Unlock()
+
+	if _, ok := p.keyspaces[name]; ok {
+		p.store.cache.purge(b)
+		return errors.NewExternalKeyspaceExistsError(nil, name)
+	}
+
+	p.keyspaces[name] = b
+	err = p.save()
+	if err != nil {
+		delete(p.keyspaces, name)
+		p.store.cache.purge(b)
+	}
+	return err
+}
+
+// DropKeyspace unregisters a keyspace. The files are left untouched.
+func (p *namespace) DropKeyspace(name string) errors.Error {
+	p.Lock()
+	defer p.Unlock()
+
+	b, ok := p.keyspaces[name]
+	if !ok {
+		return errors.NewExternalKeyspaceNotFoundError(nil, name)
+	}
+
+	delete(p.keyspaces, name)
+	err := p.save()
+	if err != nil {
+		p.keyspaces[name] = b
+		return err
+	}
+
+	p.store.cache.purge(b)
+	return nil
+}
+
+// Write the catalog. The caller holds the lock.
+func (p *namespace) save() errors.Error {
+	catalog := make(map[string]*definition, len(p.keyspaces))
+	for name, b := range p.keyspaces {
+		catalog[name] = b.definition
+	}
+
+	bytes, er := json.MarshalIndent(catalog, "", "    ")
+	if er != nil {
+		return errors.NewExternalDatastoreError(er, "Cannot write "+_CATALOG)
+	}
+
+	// the catalog is replaced, not rewritten, so that it is never partial
+	path := filepath.Join(p.store.path, _CATALOG)
+	er = ioutil.WriteFile(path+".tmp", bytes, 0644)
+	if er == nil {
+		er = os.Rename(path+".tmp", path)
+	}
+	if er != nil {
+		os.Remove(path + ".tmp")
+		return errors.NewExternalDatastoreError(er, "Cannot write "+_CATALOG)
+	}
+	return nil
+}
+
+// keyspace is a keyspace over one or more external files.
+type keyspace struct {
+	namespace  *namespace
+	name       string
+	definition *definition
+	options    *options
+	path       string
+	indexer    *externalIndexer
+}
+
+func newKeyspace(p *namespace, name string, def *definition) (*keyspace, errors.Error) {
+	opts, err := newOptions(def.Using, value.NewValue(def.With))
+	if err != nil {
+		return nil, err
+	}
+
+	path, err := opts.resolve(p.store.path)
+	if err != nil {
+		return nil, err
+	}
+
+	b := &keyspace{
+		namespace:  p,
+		name:       name,
+		definition: def,
+		options:    opts,
+		path:       path,
+	}
+
+	b.indexer = newExternalIndexer(b)
+	return b, nil
+}
+
+func (b *keyspace) NamespaceId() string {
+	return b.namespace.Id()
+}
+
+func (b *keyspace) Namespace() datastore.Namespace {
+	return b.namespace
+}
+
+func (b *keyspace) Id() string {
+	return b.Name()
+}
+
+func (b *keyspace) Name() string {
+	return b.name
+}
+
+func (b *keyspace) Count(context datastore.QueryContext) (int64, errors.Error) {
+	files, err := b.files()
+	if err != nil {
+		return 0, err
+	}
+
+	var count int64
+	for _, path := range files {
+		stats, _, err := b.namespace.store.cache.get(b, path, false)
+		if err != nil {
+			return 0, err
+		}
+		count += int64(stats.rows)
+	}
+	return count, nil
+}
+
+func (b *keyspace) Indexer(name datastore.IndexType) (datastore.Indexer, errors.Error) {
+	return b.indexer, nil
+}
+
+func (b *keyspace) Indexers() ([]datastore.Indexer, errors.Error) {
+	return []datastore.Indexer{b.indexer}, nil
+}
+
+// Files whose key range cannot hold a key are not read
+func (b *keyspace) Fetch(keys []string, context datastore.QueryContext, subPaths []string) ([]value.AnnotatedPair, []errors.Error) {
+	files, err := b.files()
+	if err != nil {
+		return nil, []errors.Error{err}
+	}
+
+	cache := b.namespace.store.cache
+	rv := make([]value.AnnotatedPair, 0, len(keys))
+	for _, k := range keys {
+		for _, path := range files {
+			stats, _, err := cache.get(b, path, false)
+			if err != nil {
+				return nil, []errors.Error{err}
+			}
+
+			if stats.rows == 0 || k < stats.min || k > stats.max {
+				continue
+			}
+
+			_, data, err := cache.get(b, path, true)
+			if err != nil {
+				return nil, []errors.Error{err}
+			}
+
+			doc, ok := data.docs[k]
+			if !ok {
+				continue
+			}
+
+			rv = append(rv, value.AnnotatedPair{
+				Name:  k,
+				Value: newDocument(k, doc),
+			})
+			break
+		}
+	}
+
+	return rv, nil
+}
+
+func newDocument(key string, doc value.Value) value.AnnotatedValue {
+	rv := value.NewAnnotatedValue(doc.Copy())
+	rv.SetAttachment("meta", map[string]interface{}{"id": key})
+	return rv
+}
+
+func (b *keyspace) Insert(inserts []value.Pair) ([]value.Pair, errors.Error) {
+	return nil, errors.NewExternalReadOnlyError(nil, "keyspace "+b.Name())
+}
+
+func (b *keyspace) Update(updates []value.Pair) ([]value.Pair, errors.Error) {
+	return nil, errors.NewExternalReadOnlyError(nil, "keyspace "+b.Name())
+}
+
+func (b *keyspace) Upsert(upserts []value.Pair) ([]value.Pair, errors.Error) {
+	return nil, errors.NewExternalReadOnlyError(nil, "keyspace "+b.Name())
+}
+
+func (b *keyspace) Delete(deletes []string, context datastore.QueryContext) ([]string, errors.Error) {
+	return nil, errors.NewExternalReadOnlyError(nil, "keyspace "+b.Name())
+}
+
+func (b *keyspace) Release() {
+}
+
+// The files of the keyspace, in name order. Patterns are expanded
+// every time, so that new files are picked up.
+func (b *keyspace) files() ([]string, errors.Error) {
+	if !b.options.pattern {
+		return []string{b.path}, b.check(b.path)
+	}
+
+	matches, er := filepath.Glob(b.path)
+	if er != nil {
+		return nil, errors.NewExternalOptionError(er, "path "+b.options.path)
+	}
+
+	rv := matches[:0]
+	for _, match := range matches {
+		fi, er := os.Stat(match)
+		if er != nil || fi.IsDir() {
+			continue
+		}
+
+		err := b.check(match)
+		if err != nil {
+			return nil, err
+		}
+		rv = append(rv, match)
+	}
+
+	sort.Strings(rv)
+	return rv, nil
+}
+
+// Symbolic links may lead out of the directory
+func (b *keyspace) check(path string) errors.Error {
+	real, er := filepath.EvalSymlinks(path)
+	if os.IsNotExist(er) {
+		return nil
+	}
+
+	dir, er1 := filepath.EvalSymlinks(b.namespace.store.path)
+	if er != nil || er1 != nil || !within(dir, real) {
+		return errors.NewExternalOptionError(er, "path "+path+" is outside of "+b.namespace.store.path)
+	}
+	return nil
+}
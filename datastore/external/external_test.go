@@ -0,0 +1,232 @@
+//  Copyright (c) 2018 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package external
+
+import (
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/couchbase/query/datastore"
+	"github.com/couchbase/query/errors"
+	"github.com/couchbase/query/value"
+)
+
+const _RATES = `code;rate;active
+EUR;1.1;true
+GBP;1.3;
+JPY;0.009;false
+`
+
+const _EVENTS_1 = `{"id": "e1", "kind": "click"}
+{"id": "e2", "kind": "view"}
+`
+
+const _EVENTS_2 = `{"id": "e3", "kind": "click"}
+
+{"id": "e4", "kind": "buy"}
+`
+
+func TestExternal(t *testing.T) {
+	dir, er := ioutil.TempDir("", "external")
+	if er != nil {
+		t.Fatalf("failed to create directory: %v", er)
+	}
+	defer os.RemoveAll(dir)
+
+	writeFile(t, dir, "rates.csv", _RATES)
+	writeFile(t, dir, "events/1.json", _EVENTS_1)
+	writeFile(t, dir, "events/2.json", _EVENTS_2)
+
+	ds, err := NewDatastore(dir)
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+
+	namespace, _ := ds.NamespaceByName("default")
+	manager := namespace.(datastore.KeyspaceManager)
+
+	err = manager.CreateKeyspace("rates", "csv", value.NewValue(map[string]interface{}{
+		"path":      "rates.csv",
+		"header":    true,
+		"delimiter": ";",
+		"key":       "code",
+		"types":     map[string]interface{}{"rate": "number", "active": "boolean"},
+	}))
+	if err != nil {
+		t.Fatalf("failed to create keyspace rates: %v", err)
+	}
+
+	err = manager.CreateKeyspace("events", "ndjson", value.NewValue(map[string]interface{}{
+		"path": "events/*.json",
+	}))
+	if err != nil {
+		t.Fatalf("failed to create keyspace events: %v", err)
+	}
+
+	// invalid definitions
+	invalid := []map[string]interface{}{
+		{"path": "../rates.csv"},
+		{"path": "missing.csv"},
+		{"path": "rates.csv", "delimiter": ";;"},
+		{"path": "rates.csv", "header": true, "delimiter": ";", "types": map[string]interface{}{"code": "number"}},
+		{"path": "rates.csv", "key": "c2"},
+	}
+	for _, with := range invalid {
+		if err = manager.CreateKeyspace("bad", "csv", value.NewValue(with)); err == nil {
+			t.Errorf("CreateKeyspace should have failed for %v", with)
+		}
+	}
+
+	if err = manager.CreateKeyspace("rates", "ndjson", value.NewValue(map[string]interface{}{
+		"path": "events/1.json"})); err == nil {
+		t.Errorf("CreateKeyspace should have failed for existing keyspace")
+	}
+
+	// the catalog survives a restart
+	ds, err = NewDatastore(dir)
+	if err != nil {
+		t.Fatalf("failed to reopen store: %v", err)
+	}
+	namespace, _ = ds.NamespaceByName("default")
+	names, _ := namespace.KeyspaceNames()
+	if len(names) != 2 || names[0] != "events" || names[1] != "rates" {
+		t.Fatalf("unexpected keyspaces %v", names)
+	}
+
+	rates, _ := namespace.KeyspaceByName("rates")
+	count, _ := rates.Count(datastore.NULL_QUERY_CONTEXT)
+	if count != 3 {
+		t.Errorf("expected 3 rates, got %v", count)
+	}
+
+	docs, errs := rates.Fetch([]string{"GBP", "USD", "EUR"}, datastore.NULL_QUERY_CONTEXT, nil)
+	if errs != nil || len(docs) != 2 || docs[0].Name != "GBP" || docs[1].Name != "EUR" {
+		t.Errorf("failed to fetch GBP and EUR: %v", errs)
+	} else {
+		rate, _ := docs[0].Value.Field("rate")
+		active, _ := docs[0].Value.Field("active")
+		if rate.Actual() != 1.3 || active.Type() != value.NULL {
+			t.Errorf("unexpected GBP document %v", docs[0].Value)
+		}
+	}
+
+	if _, err = rates.Insert([]value.Pair{{Name: "USD", Value: value.NewValue(1)}}); err == nil {
+		t.Errorf("Insert should not have succeeded")
+	}
+
+	// keys of patterns are prefixed with the file name
+	events, _ := namespace.KeyspaceByName("events")
+	primary, _ := events.(*keyspace).indexer.PrimaryIndexes()
+	keys := scanKeys(t, func(conn *datastore.IndexConnection) {
+		primary[0].ScanEntries("", math.MaxInt64, datastore.UNBOUNDED, nil, conn)
+	})
+	if len(keys) != 4 || keys[0] != "1.json:1" || keys[3] != "2.json:3" {
+		t.Errorf("unexpected events %v", keys)
+	}
+
+	keys = scanKeys(t, func(conn *datastore.IndexConnection) {
+		primary[0].Scan("", &datastore.Span{Range: datastore.Range{
+			Low:       value.Values{value.NewValue("1.json:2")},
+			Inclusion: datastore.LOW,
+		}}, false, 2, datastore.UNBOUNDED, nil, conn)
+	})
+	if len(keys) != 2 || keys[0] != "1.json:2" || keys[1] != "2.json:1" {
+		t.Errorf("unexpected events range %v", keys)
+	}
+
+	// changed files are read again
+	writeFile(t, dir, "events/2.json", _EVENTS_2+`{"id": "e5", "kind": "view"}`+"\n")
+	count, _ = events.Count(datastore.NULL_QUERY_CONTEXT)
+	if count != 5 {
+		t.Errorf("expected 5 events after change, got %v", count)
+	}
+
+	err = namespace.(datastore.KeyspaceManager).DropKeyspace("events")
+	if err != nil {
+		t.Errorf("failed to drop keyspace events: %v", err)
+	}
+
+	if _, err = namespace.KeyspaceByName("events"); err == nil {
+		t.Errorf("keyspace events should not exist")
+	}
+}
+
+func TestCacheEviction(t *testing.T) {
+	dir, er := ioutil.TempDir("", "external")
+	if er != nil {
+		t.Fatalf("failed to create directory: %v", er)
+	}
+	defer os.RemoveAll(dir)
+
+	writeFile(t, dir, "1.json", _EVENTS_1)
+	writeFile(t, dir, "2.json", _EVENTS_2)
+
+	ds, _ := NewDatastore(dir)
+	s := ds.(*store)
+	s.cache.limit = 2
+
+	err := s.namespace.CreateKeyspace("events", "ndjson", value.NewValue(map[string]interface{}{
+		"path": "*.json", "key": "id"}))
+	if err != nil {
+		t.Fatalf("failed to create keyspace: %v", err)
+	}
+
+	events, _ := s.namespace.KeyspaceByName("events")
+	docs, errs := events.Fetch([]string{"e4", "e1"}, datastore.NULL_QUERY_CONTEXT, nil)
+	if errs != nil || len(docs) != 2 || docs[0].Name != "e4" || docs[1].Name != "e1" {
+		t.Errorf("failed to fetch e4 and e1: %v", errs)
+	}
+
+	if s.cache.rows > 2 {
+		t.Errorf("expected at most 2 cached rows, got %v", s.cache.rows)
+	}
+}
+
+func writeFile(t *testing.T, dir, name, content string) {
+	path := filepath.Join(dir, name)
+	os.MkdirAll(filepath.Dir(path), 0755)
+	if er := ioutil.WriteFile(path, []byte(content), 0644); er != nil {
+		t.Fatalf("failed to write %s: %v", name, er)
+	}
+}
+
+func scanKeys(t *testing.T, scan func(conn *datastore.IndexConnection)) []string {
+	conn := datastore.NewIndexConnection(&testingContext{t})
+	go scan(conn)
+
+	var rv []string
+	for entry := range conn.EntryChannel() {
+		rv = append(rv, entry.PrimaryKey)
+	}
+	return rv
+}
+
+type testingContext struct {
+	t *testing.T
+}
+
+func (this *testingContext) GetScanCap() int64 {
+	return 16
+}
+
+func (this *testingContext) Error(err errors.Error) {
+	this.t.Errorf("Scan error: %v", err)
+}
+
+func (this *testingContext) Warning(wrn errors.Error) {
+	this.t.Logf("scan warning: %v", wrn)
+}
+
+func (this *testingContext) Fatal(fatal errors.Error) {
+	this.t.Errorf("scan fatal: %v", fatal)
+}
@@ -0,0 +1,295 @@
+//  Copyright (c) 2018 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package external
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/couchbase/query/datastore"
+	"github.com/couchbase/query/errors"
+	"github.com/couchbase/query/expression"
+	"github.com/couchbase/query/logging"
+	"github.com/couchbase/query/timestamp"
+	"github.com/couchbase/query/value"
+)
+
+const _PRIMARY_NAME = "#primary"
+
+type externalIndexer struct {
+	keyspace *keyspace
+	primary  *primaryIndex
+}
+
+func newExternalIndexer(keyspace *keyspace) *externalIndexer {
+	indexer := &externalIndexer{
+		keyspace: keyspace,
+	}
+
+	indexer.primary = &primaryIndex{
+		name:     _PRIMARY_NAME,
+		keyspace: keyspace,
+		indexer:  indexer,
+	}
+
+	return indexer
+}
+
+func (si *externalIndexer) KeyspaceId() string {
+	return si.keyspace.Id()
+}
+
+func (si *externalIndexer) Name() datastore.IndexType {
+	return datastore.DEFAULT
+}
+
+func (si *externalIndexer) IndexIds() ([]string, errors.Error) {
+	return si.IndexNames()
+}
+
+func (si *externalIndexer) IndexNames() ([]string, errors.Error) {
+	return []string{si.primary.name}, nil
+}
+
+func (si *externalIndexer) IndexById(id string) (datastore.Index, errors.Error) {
+	return si.IndexByName(id)
+}
+
+func (si *externalIndexer) IndexByName(name string) (datastore.Index, errors.Error) {
+	if name != si.primary.name {
+		return nil, errors.NewExternalIdxNotFound(nil, name)
+	}
+	return si.primary, nil
+}
+
+func (si *externalIndexer) PrimaryIndexes() ([]datastore.PrimaryIndex, errors.Error) {
+	return []datastore.PrimaryIndex{si.primary}, nil
+}
+
+func (si *externalIndexer) Indexes() ([]datastore.Index, errors.Error) {
+	return []datastore.Index{si.primary}, nil
+}
+
+// The primary index always exists, as documents are looked up by key
+func (si *externalIndexer) CreatePrimaryIndex(requestId, name string, with value.Value) (
+	datastore.PrimaryIndex, errors.Error) {
+	return si.primary, nil
+}
+
+func (si *externalIndexer) CreateIndex(requestId, name string, seekKey, rangeKey expression.Expressions,
+	where expression.Expression, with value.Value) (datastore.Index, errors.Error) {
+	return nil, errors.NewExternalNotSupported(nil, "CREATE INDEX is not supported for external keyspaces.")
+}
+
+func (si *externalIndexer) BuildIndexes(requestId string, names ...string) errors.Error {
+	return errors.NewExternalNotSupported(nil, "BUILD INDEXES is not supported for external keyspaces.")
+}
+
+func (si *externalIndexer) Refresh() errors.Error {
+	return nil
+}
+
+func (si *externalIndexer) MetadataVersion() uint64 {
+	return 0
+}
+
+func (si *externalIndexer) SetLogLevel(level logging.Level) {
+	// No-op, uses query engine logger
+}
+
+// primaryIndex performs range scans over the keys of the files.
+type primaryIndex struct {
+	name     string
+	keyspace *keyspace
+	indexer  *externalIndexer
+}
+
+func (pi *primaryIndex) KeyspaceId() string {
+	return pi.keyspace.Id()
+}
+
+func (pi *primaryIndex) Id() string {
+	return pi.Name()
+}
+
+func (pi *primaryIndex) Name() string {
+	return pi.name
+}
+
+func (pi *primaryIndex) Type() datastore.IndexType {
+	return datastore.DEFAULT
+}
+
+func (pi *primaryIndex) Indexer() datastore.Indexer {
+	return pi.indexer
+}
+
+func (pi *primaryIndex) SeekKey() expression.Expressions {
+	return nil
+}
+
+func (pi *primaryIndex) RangeKey() expression.Expressions {
+	return nil
+}
+
+func (pi *primaryIndex) Condition() expression.Expression {
+	return nil
+}
+
+func (pi *primaryIndex) IsPrimary() bool {
+	return true
+}
+
+func (pi *primaryIndex) State() (state datastore.IndexState, msg string, err errors.Error) {
+	return datastore.ONLINE, "", nil
+}
+
+func (pi *primaryIndex) Statistics(requestId string, span *datastore.Span) (
+	datastore.Statistics, errors.Error) {
+	return nil, nil
+}
+
+func (pi *primaryIndex) Drop(requestId string) errors.Error {
+	return errors.NewExternalPrimaryIdxNoDropError(nil, pi.Name())
+}
+
+func (pi *primaryIndex) Scan(requestId string, span *datastore.Span, distinct bool, limit int64,
+	cons datastore.ScanConsistency, vector timestamp.Vector, conn *datastore.IndexConnection) {
+	defer close(conn.EntryChannel())
+
+	var low, high *string
+	inclusion := span.Range.Inclusion
+
+	// For primary indexes, bounds must always be strings
+	if len(span.Seek) > 0 {
+		s, ok := span.Seek[0].Actual().(string)
+		if !ok {
+			conn.Error(errors.NewExternalIndexScanError(nil, fmt.Sprintf("Invalid seek value %v.", span.Seek[0])))
+			return
+		}
+		low, high = &s, &s
+		inclusion = datastore.BOTH
+	} else {
+		if len(span.Range.Low) > 0 {
+			s, ok := span.Range.Low[0].Actual().(string)
+			if !ok {
+				conn.Error(errors.NewExternalIndexScanError(nil, fmt.Sprintf("Invalid lower bound %v.", span.Range.Low[0])))
+				return
+			}
+			low = &s
+		}
+
+		if len(span.Range.High) > 0 {
+			s, ok := span.Range.High[0].Actual().(string)
+			if !ok {
+				conn.Error(errors.NewExternalIndexScanError(nil, fmt.Sprintf("Invalid upper bound %v.", span.Range.High[0])))
+				return
+			}
+			high = &s
+		}
+	}
+
+	pi.scan(low, high, inclusion, limit, conn)
+}
+
+func (pi *primaryIndex) ScanEntries(requestId string, limit int64, cons datastore.ScanConsistency,
+	vector timestamp.Vector, conn *datastore.IndexConnection) {
+	defer close(conn.EntryChannel())
+
+	pi.scan(nil, nil, datastore.BOTH, limit, conn)
+}
+
+// Files whose key range is outside of the span are not read. Keys
+// are sent in order, so that limits apply as in other indexes.
+func (pi *primaryIndex) scan(low, high *string, inclusion datastore.Inclusion, limit int64,
+	conn *datastore.IndexConnection) {
+	files, err := pi.keyspace.files()
+	if err != nil {
+		conn.Error(err)
+		return
+	}
+
+	cache := pi.keyspace.namespace.store.cache
+	keys := make([]string, 0, 1024)
+	seen := make(map[string]string, 1024)
+	for _, path := range files {
+		stats, _, err := cache.get(pi.keyspace, path, false)
+		if err != nil {
+			conn.Error(err)
+			return
+		}
+
+		if stats.rows == 0 || !overlaps(stats, low, high) {
+			continue
+		}
+
+		_, data, err := cache.get(pi.keyspace, path, true)
+		if err != nil {
+			conn.Error(err)
+			return
+		}
+
+		for key, _ := range data.docs {
+			if !inRange(key, low, high, inclusion) {
+				continue
+			}
+
+			if other, ok := seen[key]; ok {
+				conn.Error(errors.NewExternalIndexScanError(nil,
+					fmt.Sprintf("Duplicate key %s in %s and %s.", key, other, path)))
+				return
+			}
+			seen[key] = path
+			keys = append(keys, key)
+		}
+	}
+
+	sort.Strings(keys)
+	if limit > 0 && int64(len(keys)) > limit {
+		keys = keys[:limit]
+	}
+
+	for _, key := range keys {
+		entry := &datastore.IndexEntry{PrimaryKey: key}
+		if !sendEntry(entry, conn) {
+			return
+		}
+	}
+}
+
+func overlaps(stats *stats, low, high *string) bool {
+	return (low == nil || stats.max >= *low) && (high == nil || stats.min <= *high)
+}
+
+func inRange(key string, low, high *string, inclusion datastore.Inclusion) bool {
+	if low != nil {
+		if key < *low || (key == *low && inclusion&datastore.LOW == 0) {
+			return false
+		}
+	}
+
+	if high != nil {
+		if key > *high || (key == *high && inclusion&datastore.HIGH == 0) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Returns false if the scan has been stopped
+func sendEntry(entry *datastore.IndexEntry, conn *datastore.IndexConnection) bool {
+	select {
+	case conn.EntryChannel() <- entry:
+		return true
+	case <-conn.StopChannel():
+		return false
+	}
+}
@@ -0,0 +1,185 @@
+//  Copyright (c) 2018 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package external
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/couchbase/query/errors"
+	"github.com/couchbase/query/value"
+)
+
+const (
+	_CSV    = "csv"
+	_NDJSON = "ndjson"
+)
+
+// Column types of CSV files
+const (
+	_STRING  = "string"
+	_NUMBER  = "number"
+	_BOOLEAN = "boolean"
+	_JSON    = "json"
+)
+
+/*
+options are the WITH clause of CREATE KEYSPACE:
+
+	path       file, or glob pattern, relative to the datastore directory
+	key        field whose value is the document key; by default, the
+	           key is the row number, prefixed with the file name when
+	           path is a pattern
+	header     csv only: the first row holds the column names
+	columns    csv only: the column names
+	delimiter  csv only: the field delimiter, a comma by default
+	types      csv only: column types, string, number, boolean or json;
+	           columns are strings by default
+*/
+type options struct {
+	format    string
+	path      string
+	pattern   bool
+	key       string
+	header    bool
+	columns   []string
+	delimiter rune
+	types     map[string]string
+}
+
+func newOptions(format string, with value.Value) (*options, errors.Error) {
+	format = strings.ToLower(format)
+	if format != _CSV && format != _NDJSON {
+		return nil, errors.NewExternalOptionError(nil, "USING "+format+": expected csv or ndjson")
+	}
+
+	if with == nil || with.Type() != value.OBJECT {
+		return nil, errors.NewExternalOptionError(nil, "WITH must be an object with a path")
+	}
+
+	rv := &options{
+		format:    format,
+		delimiter: ',',
+	}
+
+	for name, val := range with.Fields() {
+		v := value.NewValue(val)
+		var err errors.Error
+
+		switch name {
+		case "path":
+			rv.path, err = stringOption(name, v)
+			rv.pattern = strings.ContainsAny(rv.path, "*?[")
+		case "key":
+			rv.key, err = stringOption(name, v)
+		case "header":
+			if v.Type() != value.BOOLEAN {
+				err = errors.NewExternalOptionError(nil, "header must be a boolean")
+			}
+			rv.header = v.Truth()
+		case "columns":
+			rv.columns, err = columnsOption(v)
+		case "delimiter":
+			var d string
+			d, err = stringOption(name, v)
+			if err == nil {
+				if utf8.RuneCountInString(d) != 1 {
+					err = errors.NewExternalOptionError(nil, "delimiter must be a single character")
+				}
+				rv.delimiter, _ = utf8.DecodeRuneInString(d)
+			}
+		case "types":
+			rv.types, err = typesOption(v)
+		default:
+			err = errors.NewExternalOptionError(nil, "unknown option "+name)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if rv.path == "" {
+		return nil, errors.NewExternalOptionError(nil, "path is required")
+	}
+
+	if format == _NDJSON && (rv.header || rv.columns != nil || rv.types != nil) {
+		return nil, errors.NewExternalOptionError(nil, "header, columns and types only apply to csv")
+	}
+
+	return rv, nil
+}
+
+func stringOption(name string, v value.Value) (string, errors.Error) {
+	s, ok := v.Actual().(string)
+	if !ok || s == "" {
+		return "", errors.NewExternalOptionError(nil, name+" must be a non empty string")
+	}
+	return s, nil
+}
+
+func columnsOption(v value.Value) ([]string, errors.Error) {
+	cols, ok := v.Actual().([]interface{})
+	if !ok {
+		return nil, errors.NewExternalOptionError(nil, "columns must be an array of names")
+	}
+
+	rv := make([]string, len(cols))
+	for i, col := range cols {
+		name, ok := value.NewValue(col).Actual().(string)
+		if !ok {
+			return nil, errors.NewExternalOptionError(nil, fmt.Sprintf("invalid column name %v", col))
+		}
+		rv[i] = name
+	}
+	return rv, nil
+}
+
+func typesOption(v value.Value) (map[string]string, errors.Error) {
+	if v.Type() != value.OBJECT {
+		return nil, errors.NewExternalOptionError(nil, "types must be an object")
+	}
+
+	rv := make(map[string]string, len(v.Fields()))
+	for col, t := range v.Fields() {
+		s, _ := value.NewValue(t).Actual().(string)
+		s = strings.ToLower(s)
+		switch s {
+		case _STRING, _NUMBER, _BOOLEAN, _JSON:
+			rv[col] = s
+		default:
+			return nil, errors.NewExternalOptionError(nil, fmt.Sprintf("invalid type %v for column %s", t, col))
+		}
+	}
+	return rv, nil
+}
+
+// Resolve the path against the datastore directory, which it may not
+// leave
+func (this *options) resolve(dir string) (string, errors.Error) {
+	path := this.path
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+	path = filepath.Clean(path)
+
+	if !within(dir, path) {
+		return "", errors.NewExternalOptionError(nil, "path "+this.path+" is outside of "+dir)
+	}
+
+	return path, nil
+}
+
+func within(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
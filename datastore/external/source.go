@@ -0,0 +1,211 @@
+//  Copyright (c) 2018 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package external
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/couchbase/query/errors"
+	"github.com/couchbase/query/value"
+)
+
+// data holds the documents of a file, by key.
+type data struct {
+	docs map[string]value.Value
+}
+
+// Parse a file into its documents, and the range of their keys
+func readFile(path string, opts *options) (*data, *stats, errors.Error) {
+	f, er := os.Open(path)
+	if er != nil {
+		return nil, nil, errors.NewExternalFileError(er, path)
+	}
+	defer f.Close()
+
+	prefix := ""
+	if opts.pattern {
+		prefix = filepath.Base(path) + ":"
+	}
+
+	d := &data{docs: make(map[string]value.Value, 1024)}
+	st := &stats{}
+
+	// rows are numbered from 1, as are lines of NDJSON files
+	add := func(row int, doc value.Value) errors.Error {
+		key := prefix + strconv.Itoa(row)
+		if opts.key != "" {
+			var err errors.Error
+			key, err = documentKey(doc, opts.key)
+			if err != nil {
+				return errors.NewExternalFileError(err, fmt.Sprintf("%s row %d", path, row))
+			}
+		}
+
+		if _, ok := d.docs[key]; ok {
+			return errors.NewExternalFileError(nil, fmt.Sprintf("%s row %d: duplicate key %s", path, row, key))
+		}
+
+		d.docs[key] = doc
+		if st.rows == 0 || key < st.min {
+			st.min = key
+		}
+		if st.rows == 0 || key > st.max {
+			st.max = key
+		}
+		st.rows++
+		return nil
+	}
+
+	var err errors.Error
+	if opts.format == _CSV {
+		err = readCSV(f, path, opts, add)
+	} else {
+		err = readNDJSON(f, path, add)
+	}
+
+	if err != nil {
+		return nil, nil, err
+	}
+	return d, st, nil
+}
+
+// Keys are strings; other scalar values are used in their JSON form
+func documentKey(doc value.Value, field string) (string, errors.Error) {
+	v, ok := doc.Field(field)
+	if !ok || v.Type() <= value.NULL {
+		return "", errors.NewExternalOptionError(nil, "missing key "+field)
+	}
+
+	switch v.Type() {
+	case value.STRING:
+		return v.Actual().(string), nil
+	case value.NUMBER, value.BOOLEAN:
+		return v.String(), nil
+	}
+	return "", errors.NewExternalOptionError(nil, "invalid key "+field+" "+v.String())
+}
+
+func readCSV(r io.Reader, path string, opts *options, add func(int, value.Value) errors.Error) errors.Error {
+	reader := csv.NewReader(r)
+	reader.Comma = opts.delimiter
+	reader.FieldsPerRecord = -1
+	reader.ReuseRecord = true
+
+	columns := opts.columns
+	header := opts.header
+	row := 0
+	for {
+		record, er := reader.Read()
+		if er == io.EOF {
+			return nil
+		} else if er != nil {
+			return errors.NewExternalFileError(er, path)
+		}
+
+		if header {
+			header = false
+			if columns == nil {
+				columns = make([]string, len(record))
+				copy(columns, record)
+			}
+			continue
+		}
+
+		row++
+		doc := make(map[string]interface{}, len(record))
+		for i, field := range record {
+			col := column(columns, i)
+			val, er := convert(field, opts.types[col])
+			if er != nil {
+				return errors.NewExternalFileError(er, fmt.Sprintf("%s row %d column %s", path, row, col))
+			}
+			doc[col] = val
+		}
+
+		err := add(row, value.NewValue(doc))
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// Columns without a name are c1, c2...
+func column(columns []string, i int) string {
+	if i < len(columns) && columns[i] != "" {
+		return columns[i]
+	}
+	return "c" + strconv.Itoa(i+1)
+}
+
+// Convert a CSV field to its column type. Empty fields of typed
+// columns are null.
+func convert(field, typ string) (interface{}, error) {
+	if typ == "" || typ == _STRING {
+		return field, nil
+	}
+
+	field = strings.TrimSpace(field)
+	if field == "" {
+		return nil, nil
+	}
+
+	switch typ {
+	case _NUMBER:
+		if i, er := strconv.ParseInt(field, 10, 64); er == nil {
+			return i, nil
+		}
+		return strconv.ParseFloat(field, 64)
+	case _BOOLEAN:
+		return strconv.ParseBool(field)
+	default:
+		var rv interface{}
+		er := json.Unmarshal([]byte(field), &rv)
+		return rv, er
+	}
+}
+
+func readNDJSON(r io.Reader, path string, add func(int, value.Value) errors.Error) errors.Error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+
+	line := 0
+	for scanner.Scan() {
+		line++
+		b := bytes.TrimSpace(scanner.Bytes())
+		if len(b) == 0 {
+			continue
+		}
+
+		if !json.Valid(b) {
+			return errors.NewExternalFileError(nil, fmt.Sprintf("%s line %d: invalid JSON", path, line))
+		}
+
+		doc := make([]byte, len(b))
+		copy(doc, b)
+		err := add(line, value.NewValue(doc))
+		if err != nil {
+			return err
+		}
+	}
+
+	if er := scanner.Err(); er != nil {
+		return errors.NewExternalFileError(er, path)
+	}
+	return nil
+}
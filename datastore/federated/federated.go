@@ -0,0 +1,365 @@
+//  Copyright (c) 2018 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+/*
+Package federated provides a datastore that combines several named
+datastores, so that a single statement can span them.
+
+Each member datastore is known by a name, and that name is a namespace
+of the federated datastore, which routes to the default namespace of
+the member: with -datastore=prod=http://...,ref=dir:/data, ref:countries
+is the countries keyspace of the file store. The namespaces of the
+members are also reachable by their own names, looked up in the order
+the members were given, so that default:orders is still the orders
+keyspace of the first member.
+
+Authorization is delegated to the members, each checking the
+privileges whose targets it owns. System-wide privileges are checked
+by the first member, which also provides users, roles and auditing.
+*/
+package federated
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/couchbase/query/auth"
+	"github.com/couchbase/query/datastore"
+	"github.com/couchbase/query/errors"
+	"github.com/couchbase/query/logging"
+	"github.com/couchbase/query/value"
+)
+
+type member struct {
+	name             string
+	store            datastore.Datastore
+	defaultNamespace string
+}
+
+// store is the root for the federated Datastore.
+type store struct {
+	id         string
+	members    []*member
+	namespaces map[string]*namespace
+}
+
+// NewDatastore federates the given datastores, each under its name.
+// The first datastore is the primary one.
+func NewDatastore(id string, names []string, stores []datastore.Datastore) (datastore.Datastore, errors.Error) {
+	if len(names) == 0 || len(names) != len(stores) {
+		return nil, errors.NewFederatedDatastoreError(nil, "Invalid datastores "+id)
+	}
+
+	s := &store{
+		id:         id,
+		members:    make([]*member, len(names)),
+		namespaces: make(map[string]*namespace, len(names)),
+	}
+
+	for i, name := range names {
+		if _, ok := s.namespaces[name]; ok {
+			return nil, errors.NewFederatedDuplicateNameError(nil, name)
+		}
+
+		defaultNamespace, err := memberNamespace(stores[i])
+		if err != nil {
+			return nil, err
+		}
+
+		actual, err := stores[i].NamespaceByName(defaultNamespace)
+		if err != nil {
+			return nil, err
+		}
+
+		s.members[i] = &member{
+			name:             name,
+			store:            stores[i],
+			defaultNamespace: defaultNamespace,
+		}
+		s.namespaces[name] = newNamespace(s, name, actual)
+	}
+
+	return s, nil
+}
+
+// The namespace a member's name stands for: default, if the member
+// has it, or else its first namespace
+func memberNamespace(ds datastore.Datastore) (string, errors.Error) {
+	names, err := ds.NamespaceNames()
+	if err != nil {
+		return "", err
+	}
+
+	if len(names) == 0 {
+		return "", errors.NewFederatedNamespaceNotFoundError(nil, "in "+ds.URL())
+	}
+
+	for _, name := range names {
+		if name == "default" {
+			return name, nil
+		}
+	}
+	return names[0], nil
+}
+
+func (s *store) primary() datastore.Datastore {
+	return s.members[0].store
+}
+
+func (s *store) Id() string {
+	return s.id
+}
+
+// The URL of the first member, which provides auditing and users
+func (s *store) URL() string {
+	return s.primary().URL()
+}
+
+func (s *store) Info() datastore.Info {
+	return s.primary().Info()
+}
+
+func (s *store) NamespaceIds() ([]string, errors.Error) {
+	return s.NamespaceNames()
+}
+
+// The names of the members, followed by the namespaces that are only
+// reachable by their own name
+func (s *store) NamespaceNames() ([]string, errors.Error) {
+	rv := make([]string, 0, len(s.members))
+	seen := make(map[string]bool, len(s.members))
+	for _, m := range s.members {
+		rv = append(rv, m.name)
+		seen[m.name] = true
+	}
+
+	for _, m := range s.members {
+		names, err := m.store.NamespaceNames()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, name := range names {
+			if name != m.defaultNamespace && !seen[name] {
+				rv = append(rv, name)
+				seen[name] = true
+			}
+		}
+	}
+
+	return rv, nil
+}
+
+func (s *store) NamespaceById(id string) (datastore.Namespace, errors.Error) {
+	return s.NamespaceByName(id)
+}
+
+func (s *store) NamespaceByName(name string) (datastore.Namespace, errors.Error) {
+	if ns, ok := s.namespaces[name]; ok {
+		return ns, nil
+	}
+
+	for _, m := range s.members {
+		ns, err := m.store.NamespaceByName(name)
+		if err == nil {
+			return ns, nil
+		}
+	}
+
+	return nil, errors.NewFederatedNamespaceNotFoundError(nil, name)
+}
+
+// Find the member owning a privilege target, and the target as the
+// member knows it
+func (s *store) route(target string) (int, string) {
+	colon := strings.IndexByte(target, ':')
+	if colon < 0 {
+		return 0, target
+	}
+
+	name, keyspace := target[:colon], target[colon+1:]
+	for i, m := range s.members {
+		if m.name == name {
+			return i, m.defaultNamespace + ":" + keyspace
+		}
+	}
+
+	for i, m := range s.members {
+		if _, err := m.store.NamespaceByName(name); err == nil {
+			return i, target
+		}
+	}
+
+	return 0, target
+}
+
+func (s *store) Authorize(privileges *auth.Privileges, credentials auth.Credentials, req *http.Request) (
+	auth.AuthenticatedUsers, errors.Error) {
+	privs := make([]*auth.Privileges, len(s.members))
+	if privileges != nil {
+		privileges.ForEach(func(pair auth.PrivilegePair) {
+			i, target := s.route(pair.Target)
+			if privs[i] == nil {
+				privs[i] = auth.NewPrivileges()
+			}
+			privs[i].Add(target, pair.Priv)
+		})
+	}
+
+	// credentials are always checked, even if nothing else is
+	if privs[0] == nil {
+		privs[0] = auth.NewPrivileges()
+	}
+
+	var rv auth.AuthenticatedUsers
+	for i, m := range s.members {
+		if privs[i] == nil {
+			continue
+		}
+
+		users, err := m.store.Authorize(privs[i], credentials, req)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, user := range users {
+			found := false
+			for _, u := range rv {
+				if u == user {
+					found = true
+					break
+				}
+			}
+			if !found {
+				rv = append(rv, user)
+			}
+		}
+	}
+
+	return rv, nil
+}
+
+func (s *store) CredsString(req *http.Request) string {
+	return s.primary().CredsString(req)
+}
+
+func (s *store) SetLogLevel(level logging.Level) {
+	for _, m := range s.members {
+		m.store.SetLogLevel(level)
+	}
+}
+
+func (s *store) Inferencer(name datastore.InferenceType) (datastore.Inferencer, errors.Error) {
+	return s.primary().Inferencer(name)
+}
+
+func (s *store) Inferencers() ([]datastore.Inferencer, errors.Error) {
+	return s.primary().Inferencers()
+}
+
+func (s *store) AuditInfo() (*datastore.AuditInfo, errors.Error) {
+	return s.primary().AuditInfo()
+}
+
+func (s *store) ProcessAuditUpdateStream(callb func(uid string) error) errors.Error {
+	return s.primary().ProcessAuditUpdateStream(callb)
+}
+
+func (s *store) UserInfo() (value.Value, errors.Error) {
+	return s.primary().UserInfo()
+}
+
+func (s *store) GetUserInfoAll() ([]datastore.User, errors.Error) {
+	return s.primary().GetUserInfoAll()
+}
+
+func (s *store) PutUserInfo(u *datastore.User) errors.Error {
+	return s.primary().PutUserInfo(u)
+}
+
+func (s *store) GetRolesAll() ([]datastore.Role, errors.Error) {
+	return s.primary().GetRolesAll()
+}
+
+// namespace presents the default namespace of a member under the
+// member's name, so that its keyspaces, and the plans that refer to
+// them, route back to the member.
+type namespace struct {
+	datastore.Namespace
+	store *store
+	name  string
+}
+
+func newNamespace(s *store, name string, actual datastore.Namespace) *namespace {
+	return &namespace{
+		Namespace: actual,
+		store:     s,
+		name:      name,
+	}
+}
+
+func (p *namespace) DatastoreId() string {
+	return p.store.Id()
+}
+
+func (p *namespace) Id() string {
+	return p.Name()
+}
+
+func (p *namespace) Name() string {
+	return p.name
+}
+
+func (p *namespace) KeyspaceById(id string) (datastore.Keyspace, errors.Error) {
+	return p.KeyspaceByName(id)
+}
+
+// Keyspaces are not cached, as the member may replace them
+func (p *namespace) KeyspaceByName(name string) (datastore.Keyspace, errors.Error) {
+	actual, err := p.Namespace.KeyspaceByName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return &keyspace{
+		Keyspace:  actual,
+		namespace: p,
+	}, nil
+}
+
+// keyspace is a member keyspace, seen from the federated namespace.
+type keyspace struct {
+	datastore.Keyspace
+	namespace *namespace
+}
+
+func (b *keyspace) NamespaceId() string {
+	return b.namespace.Id()
+}
+
+func (b *keyspace) Namespace() datastore.Namespace {
+	return b.namespace
+}
+
+// Keyspaces are managed by the member, if it supports it
+func (p *namespace) CreateKeyspace(name, using string, with value.Value) errors.Error {
+	manager, ok := p.Namespace.(datastore.KeyspaceManager)
+	if !ok {
+		return errors.NewOtherNotSupportedError(nil, "CREATE KEYSPACE on namespace "+p.name)
+	}
+	return manager.CreateKeyspace(name, using, with)
+}
+
+func (p *namespace) DropKeyspace(name string) errors.Error {
+	manager, ok := p.Namespace.(datastore.KeyspaceManager)
+	if !ok {
+		return errors.NewOtherNotSupportedError(nil, "DROP KEYSPACE on namespace "+p.name)
+	}
+	return manager.DropKeyspace(name)
+}
@@ -0,0 +1,141 @@
+//  Copyright (c) 2018 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package federated
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/couchbase/query/auth"
+	"github.com/couchbase/query/datastore"
+	"github.com/couchbase/query/datastore/file"
+	"github.com/couchbase/query/datastore/mock"
+	"github.com/couchbase/query/errors"
+)
+
+// authorizer records the privileges it is asked to check
+type authorizer struct {
+	datastore.Datastore
+	privs *auth.Privileges
+	user  string
+}
+
+func (this *authorizer) Authorize(privs *auth.Privileges, creds auth.Credentials, req *http.Request) (
+	auth.AuthenticatedUsers, errors.Error) {
+	this.privs = privs
+	return auth.AuthenticatedUsers{this.user}, nil
+}
+
+func TestFederated(t *testing.T) {
+	main, err := mock.NewDatastore("mock:")
+	if err != nil {
+		t.Fatalf("failed to create mock store: %v", err)
+	}
+
+	ref, err := file.NewDatastore("../../test/filestore/json")
+	if err != nil {
+		t.Fatalf("failed to create file store: %v", err)
+	}
+
+	mainAuth := &authorizer{Datastore: main, user: "main"}
+	refAuth := &authorizer{Datastore: ref, user: "ref"}
+	ds, err := NewDatastore("main=mock:,ref=dir:json", []string{"main", "ref"},
+		[]datastore.Datastore{mainAuth, refAuth})
+	if err != nil {
+		t.Fatalf("failed to federate: %v", err)
+	}
+
+	names, _ := ds.NamespaceNames()
+	if len(names) != 2 || names[0] != "main" || names[1] != "ref" {
+		t.Errorf("unexpected namespaces %v", names)
+	}
+
+	// member names route to the member's default namespace
+	namespace, err := ds.NamespaceByName("ref")
+	if err != nil {
+		t.Fatalf("failed to get namespace ref: %v", err)
+	}
+
+	ks, err := namespace.KeyspaceByName("orders")
+	if err != nil {
+		t.Fatalf("failed to get keyspace ref:orders: %v", err)
+	}
+
+	if ks.NamespaceId() != "ref" || ks.Namespace().Name() != "ref" {
+		t.Errorf("expected keyspace in namespace ref, got %v", ks.NamespaceId())
+	}
+
+	count, _ := ks.Count(datastore.NULL_QUERY_CONTEXT)
+	if count == 0 {
+		t.Errorf("expected documents in ref:orders")
+	}
+
+	namespace, err = ds.NamespaceByName("main")
+	if err != nil {
+		t.Fatalf("failed to get namespace main: %v", err)
+	}
+
+	_, err = namespace.KeyspaceByName("b0")
+	if err != nil {
+		t.Errorf("failed to get keyspace main:b0: %v", err)
+	}
+
+	// member namespaces are reachable by their own names
+	namespace, err = ds.NamespaceByName("default")
+	if err != nil || namespace.Name() != "default" {
+		t.Errorf("failed to get namespace default: %v", err)
+	}
+
+	_, err = ds.NamespaceByName("nowhere")
+	if err == nil {
+		t.Errorf("namespace nowhere should not exist")
+	}
+
+	// authorization
+	privs := auth.NewPrivileges()
+	privs.Add("main:b0", auth.PRIV_QUERY_SELECT)
+	privs.Add("ref:orders", auth.PRIV_QUERY_SELECT)
+	privs.Add("default:contacts", auth.PRIV_QUERY_UPDATE)
+	privs.Add("", auth.PRIV_SYSTEM_READ)
+
+	users, err := ds.Authorize(privs, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to authorize: %v", err)
+	}
+
+	if len(users) != 2 || users[0] != "main" || users[1] != "ref" {
+		t.Errorf("unexpected authenticated users %v", users)
+	}
+
+	expected := []auth.PrivilegePair{
+		{Target: "p0:b0", Priv: auth.PRIV_QUERY_SELECT},
+		{Target: "", Priv: auth.PRIV_SYSTEM_READ},
+	}
+	checkPrivileges(t, "main", mainAuth.privs, expected)
+
+	expected = []auth.PrivilegePair{
+		{Target: "default:orders", Priv: auth.PRIV_QUERY_SELECT},
+		{Target: "default:contacts", Priv: auth.PRIV_QUERY_UPDATE},
+	}
+	checkPrivileges(t, "ref", refAuth.privs, expected)
+}
+
+func checkPrivileges(t *testing.T, name string, privs *auth.Privileges, expected []auth.PrivilegePair) {
+	if privs == nil || len(privs.List) != len(expected) {
+		t.Errorf("unexpected privileges for %s: %v", name, privs)
+		return
+	}
+
+	for i, pair := range expected {
+		if privs.List[i] != pair {
+			t.Errorf("unexpected privilege for %s: %v, expected %v", name, privs.List[i], pair)
+		}
+	}
+}
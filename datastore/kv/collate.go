@@ -0,0 +1,307 @@
+//  Copyright (c) 2018 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package kv
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/couchbase/query/value"
+)
+
+/*
+Index keys are stored in an order preserving binary encoding, so that
+the byte order of two encoded keys matches the N1QL collation of the
+values they represent. Every encoded value starts with a type marker,
+and the markers are ordered like value.Type. Strings and binaries
+escape 0x00 so that the terminator sorts before any content byte.
+Numbers are the nearest float64 followed by the difference of the
+actual value from it, as integers beyond 2^53 are not exact floats.
+Arrays and objects are terminated by a 0x00 byte, which is lower than
+any type marker.
+*/
+
+const (
+	_TERMINATOR = byte(0x00)
+	_ESCAPE     = byte(0xff)
+
+	_MISSING = byte(0x01)
+	_NULL    = byte(0x02)
+	_FALSE   = byte(0x03)
+	_TRUE    = byte(0x04)
+	_NUMBER  = byte(0x05)
+	_STRING  = byte(0x06)
+	_ARRAY   = byte(0x07)
+	_OBJECT  = byte(0x08)
+	_BINARY  = byte(0x09)
+)
+
+// Encode a composite index key
+func encodeKeys(buf []byte, keys value.Values) []byte {
+	for _, key := range keys {
+		buf = encodeValue(buf, key)
+	}
+	return buf
+}
+
+func encodeValue(buf []byte, val value.Value) []byte {
+	if val == nil {
+		return append(buf, _MISSING)
+	}
+
+	switch val.Type() {
+	case value.MISSING:
+		return append(buf, _MISSING)
+	case value.NULL:
+		return append(buf, _NULL)
+	case value.BOOLEAN:
+		if val.Truth() {
+			return append(buf, _TRUE)
+		}
+		return append(buf, _FALSE)
+	case value.NUMBER:
+		var f float64
+		var offset int16
+		switch a := val.ActualForIndex().(type) {
+		case float64:
+			f = a
+		case int64:
+			f, offset = intOffset(a)
+		}
+		buf = encodeNumber(append(buf, _NUMBER), f)
+		return append(buf, byte(uint16(offset)>>8)^0x80, byte(uint16(offset)))
+	case value.STRING:
+		return encodeBytes(append(buf, _STRING), []byte(val.Actual().(string)))
+	case value.ARRAY:
+		buf = append(buf, _ARRAY)
+		for _, elem := range val.Actual().([]interface{}) {
+			buf = encodeValue(buf, value.NewValue(elem))
+		}
+		return append(buf, _TERMINATOR)
+	case value.OBJECT:
+		fields := val.Fields()
+		names := make([]string, 0, len(fields))
+		for name, _ := range fields {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		// Longer objects collate after shorter ones
+		buf = append(buf, _OBJECT)
+		buf = encodeNumber(buf, float64(len(names)))
+		for _, name := range names {
+			buf = encodeBytes(buf, []byte(name))
+			buf = encodeValue(buf, value.NewValue(fields[name]))
+		}
+		return append(buf, _TERMINATOR)
+	default:
+		bytes, _ := val.MarshalJSON()
+		return encodeBytes(append(buf, _BINARY), bytes)
+	}
+}
+
+func encodeNumber(buf []byte, f float64) []byte {
+	if f == 0 {
+		f = 0 // normalize -0
+	}
+
+	bits := math.Float64bits(f)
+	if f < 0 {
+		bits = ^bits
+	} else {
+		bits |= 1 << 63
+	}
+
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], bits)
+	return append(buf, b[:]...)
+}
+
+// The nearest float64 to an integer, and how far the integer is from it.
+// Rounding is monotonic, so integers with the same float64 sort by
+// the difference, which is well within an int16
+func intOffset(i int64) (float64, int16) {
+	f := float64(i)
+	if f >= 1<<63 {
+		return f, int16(i - math.MaxInt64 - 1)
+	}
+	return f, int16(i - int64(f))
+}
+
+func encodeBytes(buf []byte, bytes []byte) []byte {
+	for _, b := range bytes {
+		buf = append(buf, b)
+		if b == _TERMINATOR {
+			buf = append(buf, _ESCAPE)
+		}
+	}
+	return append(buf, _TERMINATOR, _TERMINATOR)
+}
+
+// Decode n composite key values, returning the remaining bytes
+func decodeKeys(buf []byte, n int) (value.Values, []byte, error) {
+	keys := make(value.Values, n)
+	for i := 0; i < n; i++ {
+		var val interface{}
+		var missing bool
+		var err error
+
+		val, missing, buf, err = decodeValue(buf)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if missing {
+			keys[i] = value.MISSING_VALUE
+		} else {
+			keys[i] = value.NewValue(val)
+		}
+	}
+
+	return keys, buf, nil
+}
+
+func decodeValue(buf []byte) (interface{}, bool, []byte, error) {
+	if len(buf) == 0 {
+		return nil, false, nil, fmt.Errorf("Unexpected end of encoded index key")
+	}
+
+	marker := buf[0]
+	buf = buf[1:]
+
+	switch marker {
+	case _MISSING:
+		return nil, true, buf, nil
+	case _NULL:
+		return nil, false, buf, nil
+	case _FALSE:
+		return false, false, buf, nil
+	case _TRUE:
+		return true, false, buf, nil
+	case _NUMBER:
+		f, rest, err := decodeNumber(buf)
+		if err != nil {
+			return nil, false, nil, err
+		}
+		if len(rest) < 2 {
+			return nil, false, nil, fmt.Errorf("Truncated number in encoded index key")
+		}
+		offset := int64(int16(uint16(rest[0]^0x80)<<8 | uint16(rest[1])))
+		rest = rest[2:]
+		switch {
+		case offset != 0 && f >= 1<<63:
+			return math.MaxInt64 + (offset + 1), false, rest, nil
+		case offset != 0:
+			return int64(f) + offset, false, rest, nil
+		case f == math.Trunc(f) && f >= -(1<<63) && f < (1<<63):
+			return int64(f), false, rest, nil
+		}
+		return f, false, rest, nil
+	case _STRING:
+		bytes, rest, err := decodeBytes(buf)
+		if err != nil {
+			return nil, false, nil, err
+		}
+		return string(bytes), false, rest, nil
+	case _ARRAY:
+		arr := make([]interface{}, 0, 4)
+		for len(buf) > 0 && buf[0] != _TERMINATOR {
+			elem, missing, rest, err := decodeValue(buf)
+			if err != nil {
+				return nil, false, nil, err
+			}
+			if !missing {
+				arr = append(arr, elem)
+			}
+			buf = rest
+		}
+		if len(buf) == 0 {
+			return nil, false, nil, fmt.Errorf("Unterminated array in encoded index key")
+		}
+		return arr, false, buf[1:], nil
+	case _OBJECT:
+		n, rest, err := decodeNumber(buf)
+		if err != nil {
+			return nil, false, nil, err
+		}
+		buf = rest
+
+		obj := make(map[string]interface{}, int(n))
+		for len(buf) > 0 && buf[0] != _TERMINATOR {
+			name, rest, err := decodeBytes(buf)
+			if err != nil {
+				return nil, false, nil, err
+			}
+			elem, missing, rest, err := decodeValue(rest)
+			if err != nil {
+				return nil, false, nil, err
+			}
+			if !missing {
+				obj[string(name)] = elem
+			}
+			buf = rest
+		}
+		if len(buf) == 0 {
+			return nil, false, nil, fmt.Errorf("Unterminated object in encoded index key")
+		}
+		return obj, false, buf[1:], nil
+	case _BINARY:
+		bytes, rest, err := decodeBytes(buf)
+		if err != nil {
+			return nil, false, nil, err
+		}
+		return bytes, false, rest, nil
+	}
+
+	return nil, false, nil, fmt.Errorf("Invalid marker %x in encoded index key", marker)
+}
+
+func decodeNumber(buf []byte) (float64, []byte, error) {
+	if len(buf) < 8 {
+		return 0, nil, fmt.Errorf("Truncated number in encoded index key")
+	}
+
+	bits := binary.BigEndian.Uint64(buf[:8])
+	if bits&(1<<63) != 0 {
+		bits &^= 1 << 63
+	} else {
+		bits = ^bits
+	}
+
+	return math.Float64frombits(bits), buf[8:], nil
+}
+
+func decodeBytes(buf []byte) ([]byte, []byte, error) {
+	rv := make([]byte, 0, len(buf))
+	for i := 0; i < len(buf); i++ {
+		if buf[i] != _TERMINATOR {
+			rv = append(rv, buf[i])
+			continue
+		}
+
+		if i+1 >= len(buf) {
+			break
+		}
+
+		switch buf[i+1] {
+		case _TERMINATOR:
+			return rv, buf[i+2:], nil
+		case _ESCAPE:
+			rv = append(rv, _TERMINATOR)
+			i++
+		default:
+			return nil, nil, fmt.Errorf("Invalid escape in encoded index key")
+		}
+	}
+
+	return nil, nil, fmt.Errorf("Unterminated string in encoded index key")
+}
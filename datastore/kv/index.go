@@ -0,0 +1,724 @@
+//  Copyright (c) 2018 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package kv
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/boltdb/bolt"
+	"github.com/couchbase/query/datastore"
+	"github.com/couchbase/query/errors"
+	"github.com/couchbase/query/expression"
+	"github.com/couchbase/query/expression/parser"
+	"github.com/couchbase/query/logging"
+	"github.com/couchbase/query/timestamp"
+	"github.com/couchbase/query/value"
+)
+
+const _PRIMARY_NAME = "#primary"
+
+type kvIndexer struct {
+	sync.RWMutex
+	keyspace    *keyspace
+	primary     *primaryIndex
+	secondaries map[string]*secondaryIndex
+}
+
+func newKVIndexer(keyspace *keyspace) *kvIndexer {
+	indexer := &kvIndexer{
+		keyspace:    keyspace,
+		secondaries: make(map[string]*secondaryIndex),
+	}
+
+	indexer.primary = &primaryIndex{
+		name:     _PRIMARY_NAME,
+		keyspace: keyspace,
+		indexer:  indexer,
+	}
+
+	return indexer
+}
+
+func (fi *kvIndexer) KeyspaceId() string {
+	return fi.keyspace.Id()
+}
+
+func (fi *kvIndexer) Name() datastore.IndexType {
+	return datastore.DEFAULT
+}
+
+func (fi *kvIndexer) IndexIds() ([]string, errors.Error) {
+	return fi.IndexNames()
+}
+
+func (fi *kvIndexer) IndexNames() ([]string, errors.Error) {
+	fi.RLock()
+	defer fi.RUnlock()
+
+	rv := make([]string, 0, len(fi.secondaries)+1)
+	rv = append(rv, fi.primary.name)
+	for name, _ := range fi.secondaries {
+		rv = append(rv, name)
+	}
+	return rv, nil
+}
+
+func (fi *kvIndexer) IndexById(id string) (datastore.Index, errors.Error) {
+	return fi.IndexByName(id)
+}
+
+func (fi *kvIndexer) IndexByName(name string) (datastore.Index, errors.Error) {
+	if name == fi.primary.name {
+		return fi.primary, nil
+	}
+
+	fi.RLock()
+	defer fi.RUnlock()
+
+	index, ok := fi.secondaries[name]
+	if !ok {
+		return nil, errors.NewKVIdxNotFound(nil, name)
+	}
+	return index, nil
+}
+
+func (fi *kvIndexer) PrimaryIndexes() ([]datastore.PrimaryIndex, errors.Error) {
+	return []datastore.PrimaryIndex{fi.primary}, nil
+}
+
+func (fi *kvIndexer) Indexes() ([]datastore.Index, errors.Error) {
+	fi.RLock()
+	defer fi.RUnlock()
+
+	rv := make([]datastore.Index, 0, len(fi.secondaries)+1)
+	rv = append(rv, fi.primary)
+	for _, index := range fi.secondaries {
+		rv = append(rv, index)
+	}
+	return rv, nil
+}
+
+// The primary index always exists, as it is the document key space itself
+func (fi *kvIndexer) CreatePrimaryIndex(requestId, name string, with value.Value) (
+	datastore.PrimaryIndex, errors.Error) {
+	return fi.primary, nil
+}
+
+// indexDef is the catalog entry of a secondary index
+type indexDef struct {
+	Name      string   `json:"name"`
+	Keys      []string `json:"keys"`
+	Condition string   `json:"condition,omitempty"`
+}
+
+func (fi *kvIndexer) CreateIndex(requestId, name string, seekKey, rangeKey expression.Expressions,
+	where expression.Expression, with value.Value) (datastore.Index, errors.Error) {
+
+	if name == fi.primary.name {
+		return nil, errors.NewKVIdxExists(nil, name)
+	}
+
+	def := &indexDef{
+		Name: name,
+		Keys: make([]string, len(rangeKey)),
+	}
+	for i, key := range rangeKey {
+		def.Keys[i] = key.String()
+	}
+	if where != nil {
+		def.Condition = where.String()
+	}
+
+	data, er := json.Marshal(def)
+	if er != nil {
+		return nil, errors.NewKVDatastoreError(er, "Cannot create index "+name)
+	}
+
+	index := newSecondaryIndex(fi, name, rangeKey, where)
+
+	fi.Lock()
+	_, ok := fi.secondaries[name]
+	if !ok {
+		fi.secondaries[name] = index
+	}
+	fi.Unlock()
+
+	if ok {
+		return nil, errors.NewKVIdxExists(nil, name)
+	}
+
+	// Index registration happens before the build, so that writes
+	// that follow the build transaction maintain the new index
+	keyspace := fi.keyspace
+	er = keyspace.namespace.store.db.Update(func(tx *bolt.Tx) error {
+		err := tx.Bucket(_CATALOG).Put(index.catalogKey(), data)
+		if err != nil {
+			return err
+		}
+
+		c := tx.Bucket(_DOCS).Cursor()
+		for k, v := c.Seek(keyspace.prefix); k != nil && bytes.HasPrefix(k, keyspace.prefix); k, v = c.Next() {
+			key := string(k[len(keyspace.prefix):])
+			err = index.putEntries(tx, key, newDocument(key, v))
+			if err != nil {
+				return err
+			}
+		}
+
+		tx.OnCommit(index.setOnline)
+		return keyspace.updateCount(tx, 0)
+	})
+
+	if er != nil {
+		fi.Lock()
+		delete(fi.secondaries, name)
+		fi.Unlock()
+		return nil, errors.NewKVDatastoreError(er, "Cannot create index "+name)
+	}
+
+	return index, nil
+}
+
+func (fi *kvIndexer) loadIndex(def *indexDef) error {
+	rangeKey := make(expression.Expressions, len(def.Keys))
+	for i, key := range def.Keys {
+		expr, err := parser.Parse(key)
+		if err != nil {
+			return err
+		}
+		rangeKey[i] = expr
+	}
+
+	var where expression.Expression
+	if def.Condition != "" {
+		expr, err := parser.Parse(def.Condition)
+		if err != nil {
+			return err
+		}
+		where = expr
+	}
+
+	index := newSecondaryIndex(fi, def.Name, rangeKey, where)
+	index.setOnline()
+
+	fi.Lock()
+	fi.secondaries[def.Name] = index
+	fi.Unlock()
+	return nil
+}
+
+// Indexes are built at creation
+func (fi *kvIndexer) BuildIndexes(requestId string, names ...string) errors.Error {
+	return nil
+}
+
+func (fi *kvIndexer) Refresh() errors.Error {
+	return nil
+}
+
+func (fi *kvIndexer) MetadataVersion() uint64 {
+	return 0
+}
+
+func (fi *kvIndexer) SetLogLevel(level logging.Level) {
+	// No-op, uses query engine logger
+}
+
+// Replace the entries of a document in all the secondary indexes.
+// Either document can be nil.
+func (fi *kvIndexer) updateEntries(tx *bolt.Tx, key string, oldDoc, newDoc value.Value) error {
+	fi.RLock()
+	defer fi.RUnlock()
+
+	for _, index := range fi.secondaries {
+		if oldDoc != nil {
+			if err := index.deleteEntries(tx, key, oldDoc); err != nil {
+				return err
+			}
+		}
+
+		if newDoc != nil {
+			if err := index.putEntries(tx, key, newDoc); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// primaryIndex performs ordered scans of the document keys.
+type primaryIndex struct {
+	name     string
+	keyspace *keyspace
+	indexer  *kvIndexer
+}
+
+func (pi *primaryIndex) KeyspaceId() string {
+	return pi.keyspace.Id()
+}
+
+func (pi *primaryIndex) Id() string {
+	return pi.Name()
+}
+
+func (pi *primaryIndex) Name() string {
+	return pi.name
+}
+
+func (pi *primaryIndex) Type() datastore.IndexType {
+	return datastore.DEFAULT
+}
+
+func (pi *primaryIndex) Indexer() datastore.Indexer {
+	return pi.indexer
+}
+
+func (pi *primaryIndex) SeekKey() expression.Expressions {
+	return nil
+}
+
+func (pi *primaryIndex) RangeKey() expression.Expressions {
+	return nil
+}
+
+func (pi *primaryIndex) Condition() expression.Expression {
+	return nil
+}
+
+func (pi *primaryIndex) IsPrimary() bool {
+	return true
+}
+
+func (pi *primaryIndex) State() (state datastore.IndexState, msg string, err errors.Error) {
+	return datastore.ONLINE, "", nil
+}
+
+func (pi *primaryIndex) Statistics(requestId string, span *datastore.Span) (
+	datastore.Statistics, errors.Error) {
+	return nil, nil
+}
+
+func (pi *primaryIndex) Drop(requestId string) errors.Error {
+	return errors.NewKVPrimaryIdxNoDropError(nil, pi.Name())
+}
+
+func (pi *primaryIndex) Scan(requestId string, span *datastore.Span, distinct bool, limit int64,
+	cons datastore.ScanConsistency, vector timestamp.Vector, conn *datastore.IndexConnection) {
+	defer close(conn.EntryChannel())
+
+	var low, high []byte
+	inclusion := span.Range.Inclusion
+
+	// For primary indexes, bounds must always be strings
+	if len(span.Seek) > 0 {
+		s, ok := span.Seek[0].Actual().(string)
+		if !ok {
+			conn.Error(errors.NewKVIndexScanError(nil, fmt.Sprintf("Invalid seek value %v.", span.Seek[0])))
+			return
+		}
+		low = pi.keyspace.docKey(s)
+		high = low
+		inclusion = datastore.BOTH
+	} else {
+		if len(span.Range.Low) > 0 {
+			s, ok := span.Range.Low[0].Actual().(string)
+			if !ok {
+				conn.Error(errors.NewKVIndexScanError(nil, fmt.Sprintf("Invalid lower bound %v.", span.Range.Low[0])))
+				return
+			}
+			low = pi.keyspace.docKey(s)
+		}
+
+		if len(span.Range.High) > 0 {
+			s, ok := span.Range.High[0].Actual().(string)
+			if !ok {
+				conn.Error(errors.NewKVIndexScanError(nil, fmt.Sprintf("Invalid upper bound %v.", span.Range.High[0])))
+				return
+			}
+			high = pi.keyspace.docKey(s)
+		}
+	}
+
+	pi.scan(low, high, inclusion, limit, conn)
+}
+
+func (pi *primaryIndex) ScanEntries(requestId string, limit int64, cons datastore.ScanConsistency,
+	vector timestamp.Vector, conn *datastore.IndexConnection) {
+	defer close(conn.EntryChannel())
+
+	pi.scan(nil, nil, datastore.BOTH, limit, conn)
+}
+
+func (pi *primaryIndex) scan(low, high []byte, inclusion datastore.Inclusion, limit int64,
+	conn *datastore.IndexConnection) {
+	prefix := pi.keyspace.prefix
+	if low == nil {
+		low = prefix
+	}
+
+	er := scanBatches(pi.keyspace.namespace.store.db, _DOCS, low, limit, conn,
+		func(k []byte) (*datastore.IndexEntry, bool, error) {
+			if !bytes.HasPrefix(k, prefix) {
+				return nil, false, nil
+			}
+
+			if inclusion&datastore.LOW == 0 && bytes.Equal(k, low) {
+				return nil, true, nil
+			}
+
+			if high != nil {
+				cmp := bytes.Compare(k, high)
+				if cmp > 0 || (cmp == 0 && inclusion&datastore.HIGH == 0) {
+					return nil, false, nil
+				}
+			}
+
+			return &datastore.IndexEntry{PrimaryKey: string(k[len(prefix):])}, true, nil
+		})
+
+	if er != nil {
+		conn.Error(errors.NewKVIndexScanError(er, ""))
+	}
+}
+
+// secondaryIndex keeps its entries in the index key space, ordered by
+// the encoded index keys followed by the document key.
+type secondaryIndex struct {
+	sync.RWMutex
+	name      string
+	indexer   *kvIndexer
+	rangeKey  expression.Expressions
+	condition expression.Expression
+	prefix    []byte
+	state     datastore.IndexState
+}
+
+func newSecondaryIndex(indexer *kvIndexer, name string, rangeKey expression.Expressions,
+	where expression.Expression) *secondaryIndex {
+	return &secondaryIndex{
+		name:      name,
+		indexer:   indexer,
+		rangeKey:  rangeKey,
+		condition: where,
+		prefix:    append(append([]byte(nil), indexer.keyspace.prefix...), joinKey([]byte(name))...),
+		state:     datastore.BUILDING,
+	}
+}
+
+func (si *secondaryIndex) KeyspaceId() string {
+	return si.indexer.keyspace.Id()
+}
+
+func (si *secondaryIndex) Id() string {
+	return si.Name()
+}
+
+func (si *secondaryIndex) Name() string {
+	return si.name
+}
+
+func (si *secondaryIndex) Type() datastore.IndexType {
+	return datastore.DEFAULT
+}
+
+func (si *secondaryIndex) Indexer() datastore.Indexer {
+	return si.indexer
+}
+
+func (si *secondaryIndex) SeekKey() expression.Expressions {
+	return nil
+}
+
+func (si *secondaryIndex) RangeKey() expression.Expressions {
+	return si.rangeKey
+}
+
+func (si *secondaryIndex) Condition() expression.Expression {
+	return si.condition
+}
+
+func (si *secondaryIndex) IsPrimary() bool {
+	return false
+}
+
+func (si *secondaryIndex) State() (state datastore.IndexState, msg string, err errors.Error) {
+	si.RLock()
+	defer si.RUnlock()
+	return si.state, "", nil
+}
+
+func (si *secondaryIndex) setOnline() {
+	si.Lock()
+	si.state = datastore.ONLINE
+	si.Unlock()
+}
+
+func (si *secondaryIndex) Statistics(requestId string, span *datastore.Span) (
+	datastore.Statistics, errors.Error) {
+	return nil, nil
+}
+
+// The index leaves the indexer in the same transaction that deletes its
+// entries, so that no writer maintains it after they are gone
+func (si *secondaryIndex) Drop(requestId string) errors.Error {
+	fi := si.indexer
+	removed := false
+
+	er := fi.keyspace.namespace.store.db.Update(func(tx *bolt.Tx) error {
+		err := tx.Bucket(_CATALOG).Delete(si.catalogKey())
+		if err != nil {
+			return err
+		}
+
+		err = deletePrefix(tx.Bucket(_INDEX), si.prefix)
+		if err != nil {
+			return err
+		}
+
+		fi.Lock()
+		delete(fi.secondaries, si.name)
+		fi.Unlock()
+		removed = true
+		return nil
+	})
+
+	if er != nil {
+		if removed {
+			fi.Lock()
+			fi.secondaries[si.name] = si
+			fi.Unlock()
+		}
+		return errors.NewKVDatastoreError(er, "Cannot drop index "+si.name)
+	}
+
+	return nil
+}
+
+func (si *secondaryIndex) Scan(requestId string, span *datastore.Span, distinct bool, limit int64,
+	cons datastore.ScanConsistency, vector timestamp.Vector, conn *datastore.IndexConnection) {
+	defer close(conn.EntryChannel())
+
+	low, high := span.Range.Low, span.Range.High
+	inclusion := span.Range.Inclusion
+	if len(span.Seek) > 0 {
+		low, high = span.Seek, span.Seek
+		inclusion = datastore.BOTH
+	}
+
+	lowKey := encodeKeys(append([]byte(nil), si.prefix...), low)
+	var highKey []byte
+	if len(high) > 0 {
+		highKey = encodeKeys(append([]byte(nil), si.prefix...), high)
+	}
+
+	er := scanBatches(si.indexer.keyspace.namespace.store.db, _INDEX, lowKey, limit, conn,
+		func(k []byte) (*datastore.IndexEntry, bool, error) {
+			if !bytes.HasPrefix(k, si.prefix) {
+				return nil, false, nil
+			}
+
+			// entries whose leading keys equal a bound carry it as a prefix
+			if len(low) > 0 && inclusion&datastore.LOW == 0 && bytes.HasPrefix(k, lowKey) {
+				return nil, true, nil
+			}
+
+			if highKey != nil {
+				cmp := bytes.Compare(k, highKey)
+				if inclusion&datastore.HIGH == 0 {
+					if cmp >= 0 {
+						return nil, false, nil
+					}
+				} else if cmp > 0 && !bytes.HasPrefix(k, highKey) {
+					return nil, false, nil
+				}
+			}
+
+			keys, rest, err := decodeKeys(k[len(si.prefix):], len(si.rangeKey))
+			if err != nil {
+				return nil, false, err
+			}
+
+			return &datastore.IndexEntry{EntryKey: keys, PrimaryKey: string(rest)}, true, nil
+		})
+
+	if er != nil {
+		conn.Error(errors.NewKVIndexScanError(er, si.name))
+	}
+}
+
+func (si *secondaryIndex) catalogKey() []byte {
+	return si.prefix[:len(si.prefix)-1]
+}
+
+func (si *secondaryIndex) putEntries(tx *bolt.Tx, key string, doc value.Value) error {
+	index := tx.Bucket(_INDEX)
+	for _, entry := range si.entries(key, doc) {
+		if err := index.Put(entry, []byte{}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (si *secondaryIndex) deleteEntries(tx *bolt.Tx, key string, doc value.Value) error {
+	index := tx.Bucket(_INDEX)
+	for _, entry := range si.entries(key, doc) {
+		if err := index.Delete(entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Compute the encoded index entries of a document. Array index keys
+// produce one entry per element. Documents whose leading key is
+// MISSING are not indexed.
+func (si *secondaryIndex) entries(key string, doc value.Value) [][]byte {
+	context := expression.NewIndexContext()
+
+	if si.condition != nil {
+		cond, err := si.condition.Evaluate(doc, context)
+		if err != nil || !cond.Truth() {
+			return nil
+		}
+	}
+
+	keys := []value.Values{make(value.Values, 0, len(si.rangeKey))}
+	for i, expr := range si.rangeKey {
+		val, vals, err := expr.EvaluateForIndex(doc, context)
+		if err != nil {
+			logging.Debugf("Index %s: cannot evaluate key %v for <ud>%s</ud>: %v", si.name, expr, key, err)
+			return nil
+		}
+
+		if vals == nil {
+			vals = value.Values{val}
+		} else if _, distinct := expr.IsArrayIndexKey(); distinct {
+			vals = distinctValues(vals)
+		}
+
+		next := make([]value.Values, 0, len(keys)*len(vals))
+		for _, val := range vals {
+			if i == 0 && val.Type() == value.MISSING {
+				continue
+			}
+
+			for _, prefix := range keys {
+				composite := append(append(make(value.Values, 0, len(si.rangeKey)), prefix...), val)
+				next = append(next, composite)
+			}
+		}
+		keys = next
+	}
+
+	rv := make([][]byte, len(keys))
+	for i, composite := range keys {
+		entry := encodeKeys(append([]byte(nil), si.prefix...), composite)
+		rv[i] = append(entry, key...)
+	}
+	return rv
+}
+
+func distinctValues(vals value.Values) value.Values {
+	rv := make(value.Values, 0, len(vals))
+	for _, val := range vals {
+		found := false
+		for _, v := range rv {
+			if val.Equals(v).Truth() {
+				found = true
+				break
+			}
+		}
+		if !found {
+			rv = append(rv, val)
+		}
+	}
+	return rv
+}
+
+const _SCAN_BATCH = 256
+
+// Scans read the entries of a bucket in batches, each in its own read
+// transaction, and only send them once the transaction is closed: a
+// writer in the statement consuming the scan could otherwise wait for
+// the read transaction, which waits for the scan to be consumed.
+// Each batch sees the changes committed before it was read.
+// visit returns the entry of a key, nil to skip it, and false past the range.
+func scanBatches(db *bolt.DB, bucket, low []byte, limit int64, conn *datastore.IndexConnection,
+	visit func(k []byte) (*datastore.IndexEntry, bool, error)) error {
+	var n int64
+
+	seek, resume := low, false
+	for {
+		batch := make([]*datastore.IndexEntry, 0, _SCAN_BATCH)
+		more := false
+
+		er := db.View(func(tx *bolt.Tx) error {
+			c := tx.Bucket(bucket).Cursor()
+			k, _ := c.Seek(seek)
+
+			// a batch resumes after the last key of the previous one
+			if resume && k != nil && bytes.Equal(k, seek) {
+				k, _ = c.Next()
+			}
+
+			for ; k != nil; k, _ = c.Next() {
+				entry, ok, err := visit(k)
+				if err != nil || !ok {
+					return err
+				}
+
+				if entry == nil {
+					continue
+				}
+
+				batch = append(batch, entry)
+				if len(batch) == _SCAN_BATCH {
+					seek, resume, more = append([]byte(nil), k...), true, true
+					break
+				}
+			}
+			return nil
+		})
+
+		if er != nil {
+			return er
+		}
+
+		for _, entry := range batch {
+			if !sendEntry(entry, conn) {
+				return nil
+			}
+
+			n++
+			if limit > 0 && n >= limit {
+				return nil
+			}
+		}
+
+		if !more {
+			return nil
+		}
+	}
+}
+
+// Returns false if the scan has been stopped
+func sendEntry(entry *datastore.IndexEntry, conn *datastore.IndexConnection) bool {
+	select {
+	case conn.EntryChannel() <- entry:
+		return true
+	case <-conn.StopChannel():
+		return false
+	}
+}
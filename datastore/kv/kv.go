@@ -0,0 +1,753 @@
+//  Copyright (c) 2018 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+/*
+Package kv provides an implementation of the datastore package on top
+of an embedded, ordered key-value engine, for single-node deployments.
+
+All documents live in a single database file. Namespaces and keyspaces
+are key prefixes. Keyspaces are created with CREATE KEYSPACE ... USING kv
+and recorded in a catalog, with their indexes. The primary index is the
+ordered key space itself, and secondary indexes are kept in a separate
+ordered key space, maintained in the same transaction as the documents
+they index.
+*/
+package kv
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/boltdb/bolt"
+	atomic "github.com/couchbase/go-couchbase/platform"
+	"github.com/couchbase/query/auth"
+	"github.com/couchbase/query/datastore"
+	"github.com/couchbase/query/errors"
+	"github.com/couchbase/query/logging"
+	"github.com/couchbase/query/util"
+	"github.com/couchbase/query/value"
+)
+
+var (
+	_DOCS    = []byte("docs")    // documents, by keyspace prefix and key
+	_CATALOG = []byte("catalog") // keyspace and index definitions
+	_INDEX   = []byte("index")   // secondary index entries
+)
+
+const _SEPARATOR = byte(0x00)
+
+const _OPEN_TIMEOUT = 5 * time.Second
+
+const _USING = "kv"
+
+// store is the root for the kv-based Datastore.
+type store struct {
+	sync.RWMutex
+	path       string
+	db         *bolt.DB
+	namespaces map[string]*namespace
+
+	users map[string]*datastore.User
+}
+
+func (s *store) Id() string {
+	return s.path
+}
+
+func (s *store) URL() string {
+	return "kv:" + s.path
+}
+
+func (s *store) Info() datastore.Info {
+	return &infoImpl{}
+}
+
+type infoImpl struct {
+}
+
+func (i *infoImpl) Version() string {
+	return util.VERSION
+}
+
+func (info *infoImpl) Topology() ([]string, []errors.Error) {
+	return []string{}, nil
+}
+
+func (info *infoImpl) Services(node string) (map[string]interface{}, []errors.Error) {
+	return map[string]interface{}{}, nil
+}
+
+func (s *store) NamespaceIds() ([]string, errors.Error) {
+	return s.NamespaceNames()
+}
+
+func (s *store) NamespaceNames() ([]string, errors.Error) {
+	s.RLock()
+	defer s.RUnlock()
+
+	rv := make([]string, 0, len(s.namespaces))
+	for name, _ := range s.namespaces {
+		rv = append(rv, name)
+	}
+	sort.Strings(rv)
+	return rv, nil
+}
+
+func (s *store) NamespaceById(id string) (datastore.Namespace, errors.Error) {
+	return s.NamespaceByName(id)
+}
+
+// Namespaces are key prefixes, so any valid name resolves to a namespace.
+func (s *store) NamespaceByName(name string) (datastore.Namespace, errors.Error) {
+	if name == "" {
+		return nil, errors.NewKVNamespaceNotFoundError(nil, name)
+	}
+
+	return s.namespace(name), nil
+}
+
+func (s *store) namespace(name string) *namespace {
+	s.RLock()
+	p, ok := s.namespaces[name]
+	s.RUnlock()
+	if ok {
+		return p
+	}
+
+	s.Lock()
+	defer s.Unlock()
+	p, ok = s.namespaces[name]
+	if !ok {
+		p = newNamespace(s, name)
+		s.namespaces[name] = p
+	}
+	return p
+}
+
+func (s *store) Authorize(*auth.Privileges, auth.Credentials, *http.Request) (auth.AuthenticatedUsers, errors.Error) {
+	return nil, nil
+}
+
+func (s *store) CredsString(req *http.Request) string {
+	return ""
+}
+
+func (s *store) SetLogLevel(level logging.Level) {
+	// No-op. Uses query engine logger.
+}
+
+func (s *store) Inferencer(name datastore.InferenceType) (datastore.Inferencer, errors.Error) {
+	return nil, errors.NewOtherNotImplementedError(nil, "INFER")
+}
+
+func (s *store) Inferencers() ([]datastore.Inferencer, errors.Error) {
+	return nil, errors.NewOtherNotImplementedError(nil, "INFER")
+}
+
+func (s *store) AuditInfo() (*datastore.AuditInfo, errors.Error) {
+	return nil, errors.NewOtherNotImplementedError(nil, "AuditInfo")
+}
+
+func (s *store) ProcessAuditUpdateStream(callb func(uid string) error) errors.Error {
+	return errors.NewOtherNotImplementedError(nil, "ProcessAuditUpdateStream")
+}
+
+func (s *store) UserInfo() (value.Value, errors.Error) {
+	// Return an array of no users.
+	jsonData := make([]interface{}, 0)
+	v := value.NewValue(jsonData)
+	return v, nil
+}
+
+func (s *store) GetUserInfoAll() ([]datastore.User, errors.Error) {
+	s.RLock()
+	defer s.RUnlock()
+
+	ret := make([]datastore.User, 0, len(s.users))
+	for _, v := range s.users {
+		ret = append(ret, *v)
+	}
+	return ret, nil
+}
+
+func (s *store) PutUserInfo(u *datastore.User) errors.Error {
+	s.Lock()
+	defer s.Unlock()
+
+	s.users[u.Id] = u
+	return nil
+}
+
+func (s *store) GetRolesAll() ([]datastore.Role, errors.Error) {
+	return []datastore.Role{
+		datastore.Role{Name: "cluster_admin"},
+		datastore.Role{Name: "replication_admin"},
+		datastore.Role{Name: "bucket_admin", Bucket: "*"},
+	}, nil
+}
+
+// NewDatastore opens, or creates, a kv-based store in the given file.
+func NewDatastore(path string) (s datastore.Datastore, e errors.Error) {
+	path, er := filepath.Abs(path)
+	if er != nil {
+		return nil, errors.NewKVDatastoreError(er, "")
+	}
+
+	db, er := bolt.Open(path, 0600, &bolt.Options{Timeout: _OPEN_TIMEOUT})
+	if er != nil {
+		return nil, errors.NewKVDatastoreError(er, "Cannot open "+path)
+	}
+
+	ks := &store{
+		path:       path,
+		db:         db,
+		namespaces: make(map[string]*namespace, 4),
+		users:      make(map[string]*datastore.User, 4),
+	}
+
+	e = ks.loadCatalog()
+	if e != nil {
+		db.Close()
+		return
+	}
+
+	s = ks
+	return
+}
+
+// keyspaceEntry is the catalog entry of a keyspace
+type keyspaceEntry struct {
+	Count int64 `json:"count"`
+}
+
+func (s *store) loadCatalog() errors.Error {
+	er := s.db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{_DOCS, _CATALOG, _INDEX} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+
+		c := tx.Bucket(_CATALOG).Cursor()
+		for k, v := c.First(); k != nil; k, v = c.Next() {
+			parts := splitKey(k)
+			switch len(parts) {
+			case 2:
+				var entry keyspaceEntry
+				if err := json.Unmarshal(v, &entry); err != nil {
+					return err
+				}
+				b := s.namespace(parts[0]).keyspace(parts[1])
+				atomic.StoreInt64(&b.count, entry.Count)
+			case 3:
+				var def indexDef
+				if err := json.Unmarshal(v, &def); err != nil {
+					return err
+				}
+				b := s.namespace(parts[0]).keyspace(parts[1])
+				if err := b.indexer.loadIndex(&def); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+
+	if er != nil {
+		return errors.NewKVDatastoreError(er, "Cannot load catalog of "+s.path)
+	}
+
+	// the default namespace always exists
+	s.namespace("default")
+	return nil
+}
+
+// namespace represents a kv-based Namespace.
+type namespace struct {
+	sync.RWMutex
+	store     *store
+	name      string
+	keyspaces map[string]*keyspace
+}
+
+func newNamespace(s *store, name string) *namespace {
+	return &namespace{
+		store:     s,
+		name:      name,
+		keyspaces: make(map[string]*keyspace, 8),
+	}
+}
+
+func (p *namespace) DatastoreId() string {
+	return p.store.Id()
+}
+
+func (p *namespace) Id() string {
+	return p.Name()
+}
+
+func (p *namespace) Name() string {
+	return p.name
+}
+
+func (p *namespace) KeyspaceIds() ([]string, errors.Error) {
+	return p.KeyspaceNames()
+}
+
+func (p *namespace) KeyspaceNames() ([]string, errors.Error) {
+	p.RLock()
+	defer p.RUnlock()
+
+	rv := make([]string, 0, len(p.keyspaces))
+	for name, _ := range p.keyspaces {
+		rv = append(rv, name)
+	}
+	sort.Strings(rv)
+	return rv, nil
+}
+
+func (p *namespace) KeyspaceById(id string) (datastore.Keyspace, errors.Error) {
+	return p.KeyspaceByName(id)
+}
+
+func (p *namespace) KeyspaceByName(name string) (datastore.Keyspace, errors.Error) {
+	p.RLock()
+	defer p.RUnlock()
+
+	b, ok := p.keyspaces[name]
+	if !ok {
+		return nil, errors.NewKVKeyspaceNotFoundError(nil, name)
+	}
+	return b, nil
+}
+
+// Find or add the keyspace of a catalog entry
+func (p *namespace) keyspace(name string) *keyspace {
+	p.RLock()
+	b, ok := p.keyspaces[name]
+	p.RUnlock()
+	if ok {
+		return b
+	}
+
+	p.Lock()
+	defer p.Unlock()
+	b, ok = p.keyspaces[name]
+	if !ok {
+		b = newKeyspace(p, name)
+		p.keyspaces[name] = b
+	}
+	return b
+}
+
+func (p *namespace) MetadataVersion() uint64 {
+	return 0
+}
+
+// CreateKeyspace records a new, empty keyspace in the catalog.
+// kv keyspaces take no options.
+func (p *namespace) CreateKeyspace(name, using string, with value.Value) errors.Error {
+	if strings.ToLower(using) != _USING {
+		return errors.NewKVKeyspaceOptionError(nil, "USING "+using+": expected "+_USING)
+	}
+
+	if with != nil {
+		return errors.NewKVKeyspaceOptionError(nil, "WITH is not supported")
+	}
+
+	b := newKeyspace(p, name)
+	exists := false
+
+	er := p.store.db.Update(func(tx *bolt.Tx) error {
+		catalog := tx.Bucket(_CATALOG)
+		if catalog.Get(b.catalogKey()) != nil {
+			exists = true
+			return nil
+		}
+
+		bytes, err := json.Marshal(&keyspaceEntry{})
+		if err != nil {
+			return err
+		}
+
+		err = catalog.Put(b.catalogKey(), bytes)
+		if err != nil {
+			return err
+		}
+
+		tx.OnCommit(func() {
+			p.Lock()
+			p.keyspaces[name] = b
+			p.Unlock()
+		})
+		return nil
+	})
+
+	if er != nil {
+		return errors.NewKVDatastoreError(er, "Cannot create keyspace "+name)
+	}
+
+	if exists {
+		return errors.NewKVKeyspaceExistsError(nil, name)
+	}
+
+	return nil
+}
+
+// DropKeyspace removes a keyspace, with its documents and indexes
+func (p *namespace) DropKeyspace(name string) errors.Error {
+	p.RLock()
+	b, ok := p.keyspaces[name]
+	p.RUnlock()
+
+	if !ok {
+		return errors.NewKVKeyspaceNotFoundError(nil, name)
+	}
+
+	er := p.store.db.Update(func(tx *bolt.Tx) error {
+
+		// the catalog holds the keyspace entry and its index definitions
+		catalog := tx.Bucket(_CATALOG)
+		err := catalog.Delete(b.catalogKey())
+		if err != nil {
+			return err
+		}
+
+		for _, bucket := range []*bolt.Bucket{catalog, tx.Bucket(_DOCS), tx.Bucket(_INDEX)} {
+			err = deletePrefix(bucket, b.prefix)
+			if err != nil {
+				return err
+			}
+		}
+
+		tx.OnCommit(func() {
+			p.Lock()
+			delete(p.keyspaces, name)
+			p.Unlock()
+		})
+		return nil
+	})
+
+	if er != nil {
+		return errors.NewKVDatastoreError(er, "Cannot drop keyspace "+name)
+	}
+
+	return nil
+}
+
+// keyspace is a kv-based keyspace.
+type keyspace struct {
+	namespace *namespace
+	name      string
+	prefix    []byte
+	indexer   *kvIndexer
+	count     atomic.AlignedInt64
+}
+
+func newKeyspace(p *namespace, name string) *keyspace {
+	b := &keyspace{
+		namespace: p,
+		name:      name,
+		prefix:    joinKey([]byte(p.name), []byte(name)),
+	}
+
+	b.indexer = newKVIndexer(b)
+	return b
+}
+
+func (b *keyspace) NamespaceId() string {
+	return b.namespace.Id()
+}
+
+func (b *keyspace) Namespace() datastore.Namespace {
+	return b.namespace
+}
+
+func (b *keyspace) Id() string {
+	return b.Name()
+}
+
+func (b *keyspace) Name() string {
+	return b.name
+}
+
+func (b *keyspace) Count(context datastore.QueryContext) (int64, errors.Error) {
+	return atomic.LoadInt64(&b.count), nil
+}
+
+func (b *keyspace) Indexer(name datastore.IndexType) (datastore.Indexer, errors.Error) {
+	return b.indexer, nil
+}
+
+func (b *keyspace) Indexers() ([]datastore.Indexer, errors.Error) {
+	return []datastore.Indexer{b.indexer}, nil
+}
+
+func (b *keyspace) Fetch(keys []string, context datastore.QueryContext, subPaths []string) ([]value.AnnotatedPair, []errors.Error) {
+	rv := make([]value.AnnotatedPair, 0, len(keys))
+
+	er := b.namespace.store.db.View(func(tx *bolt.Tx) error {
+		docs := tx.Bucket(_DOCS)
+		for _, k := range keys {
+			bytes := docs.Get(b.docKey(k))
+			if bytes == nil {
+				// key denotes non-existent doc => ignore it
+				continue
+			}
+
+			rv = append(rv, value.AnnotatedPair{
+				Name:  k,
+				Value: newDocument(k, bytes),
+			})
+		}
+		return nil
+	})
+
+	if er != nil {
+		return nil, []errors.Error{errors.NewKVDatastoreError(er, "")}
+	}
+
+	return rv, nil
+}
+
+// Documents must be copied out of the transaction they were read in
+func newDocument(key string, bytes []byte) value.AnnotatedValue {
+	doc := value.NewAnnotatedValue(value.NewValue(append([]byte(nil), bytes...)))
+	doc.SetAttachment("meta", map[string]interface{}{"id": key})
+	return doc
+}
+
+const (
+	INSERT = 0x01
+	UPDATE = 0x02
+	UPSERT = 0x04
+)
+
+func opToString(op int) string {
+
+	switch op {
+	case INSERT:
+		return "insert"
+	case UPDATE:
+		return "update"
+	case UPSERT:
+		return "upsert"
+	}
+
+	return "unknown operation"
+}
+
+// All the pairs are written, and indexed, in a single transaction.
+// Pairs that cannot be written are reported and skipped.
+func (b *keyspace) performOp(op int, kvPairs []value.Pair) ([]value.Pair, errors.Error) {
+
+	if len(kvPairs) == 0 {
+		return nil, errors.NewKVNoKeysInsertError(nil, "keyspace "+b.Name())
+	}
+
+	var insertedKeys []value.Pair
+	var returnErr errors.Error
+	var added int64
+
+	er := b.namespace.store.db.Update(func(tx *bolt.Tx) error {
+		insertedKeys = make([]value.Pair, 0, len(kvPairs))
+		returnErr = nil
+		added = 0
+
+		docs := tx.Bucket(_DOCS)
+		for _, kv := range kvPairs {
+			key := kv.Name
+			docKey := b.docKey(key)
+			old := docs.Get(docKey)
+
+			switch op {
+			case INSERT:
+				if old != nil {
+					returnErr = errors.NewKVKeyExists(returnErr, "Key "+key)
+					continue
+				}
+			case UPDATE:
+				if old == nil {
+					returnErr = errors.NewKVKeyNotFound(returnErr, "Key "+key)
+					continue
+				}
+			}
+
+			bytes, err := json.Marshal(kv.Value.Actual())
+			if err != nil {
+				returnErr = errors.NewKVDMLError(returnErr, opToString(op)+" Failed "+err.Error())
+				continue
+			}
+
+			var oldDoc value.Value
+			if old != nil {
+				oldDoc = newDocument(key, old)
+			} else {
+				added++
+			}
+
+			err = b.indexer.updateEntries(tx, key, oldDoc, newDocument(key, bytes))
+			if err != nil {
+				return err
+			}
+
+			err = docs.Put(docKey, bytes)
+			if err != nil {
+				return err
+			}
+
+			insertedKeys = append(insertedKeys, kv)
+		}
+
+		return b.updateCount(tx, added)
+	})
+
+	if er != nil {
+		return nil, errors.NewKVDMLError(er, opToString(op)+" Failed")
+	}
+
+	atomic.AddInt64(&b.count, added)
+	return insertedKeys, returnErr
+}
+
+func (b *keyspace) Insert(inserts []value.Pair) ([]value.Pair, errors.Error) {
+	return b.performOp(INSERT, inserts)
+}
+
+func (b *keyspace) Update(updates []value.Pair) ([]value.Pair, errors.Error) {
+	return b.performOp(UPDATE, updates)
+}
+
+func (b *keyspace) Upsert(upserts []value.Pair) ([]value.Pair, errors.Error) {
+	return b.performOp(UPSERT, upserts)
+}
+
+func (b *keyspace) Delete(deletes []string, context datastore.QueryContext) ([]string, errors.Error) {
+	var deleted []string
+
+	er := b.namespace.store.db.Update(func(tx *bolt.Tx) error {
+		deleted = make([]string, 0, len(deletes))
+
+		docs := tx.Bucket(_DOCS)
+		for _, key := range deletes {
+			docKey := b.docKey(key)
+			old := docs.Get(docKey)
+			if old == nil {
+				continue
+			}
+
+			err := b.indexer.updateEntries(tx, key, newDocument(key, old), nil)
+			if err != nil {
+				return err
+			}
+
+			err = docs.Delete(docKey)
+			if err != nil {
+				return err
+			}
+
+			deleted = append(deleted, key)
+		}
+
+		return b.updateCount(tx, -int64(len(deleted)))
+	})
+
+	if er != nil {
+		return nil, errors.NewKVDMLError(er, "delete Failed")
+	}
+
+	atomic.AddInt64(&b.count, -int64(len(deleted)))
+	return deleted, nil
+}
+
+func (b *keyspace) Release() {
+}
+
+// Update the document count in the catalog. The catalog entry also
+// tells writers holding on to a keyspace that has been dropped.
+func (b *keyspace) updateCount(tx *bolt.Tx, delta int64) error {
+
+	// the persisted count is authoritative, as writers are serialized
+	var entry keyspaceEntry
+	catalog := tx.Bucket(_CATALOG)
+	bytes := catalog.Get(b.catalogKey())
+	if bytes == nil {
+		return errors.NewKVKeyspaceNotFoundError(nil, b.name)
+	}
+
+	if delta == 0 {
+		return nil
+	}
+
+	if err := json.Unmarshal(bytes, &entry); err != nil {
+		return err
+	}
+
+	entry.Count += delta
+	bytes, err := json.Marshal(&entry)
+	if err != nil {
+		return err
+	}
+
+	return catalog.Put(b.catalogKey(), bytes)
+}
+
+func (b *keyspace) catalogKey() []byte {
+	return b.prefix[:len(b.prefix)-1]
+}
+
+func (b *keyspace) docKey(key string) []byte {
+	rv := make([]byte, 0, len(b.prefix)+len(key))
+	rv = append(rv, b.prefix...)
+	return append(rv, key...)
+}
+
+// Concatenate key parts, terminating each one with a separator
+func joinKey(parts ...[]byte) []byte {
+	n := 0
+	for _, part := range parts {
+		n += len(part) + 1
+	}
+
+	rv := make([]byte, 0, n)
+	for _, part := range parts {
+		rv = append(rv, part...)
+		rv = append(rv, _SEPARATOR)
+	}
+	return rv
+}
+
+// Delete all the keys of a bucket that start with a prefix
+func deletePrefix(bucket *bolt.Bucket, prefix []byte) error {
+	c := bucket.Cursor()
+	for k, _ := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Seek(prefix) {
+		if err := c.Delete(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func splitKey(key []byte) []string {
+	rv := make([]string, 0, 3)
+	start := 0
+	for i, b := range key {
+		if b == _SEPARATOR {
+			rv = append(rv, string(key[start:i]))
+			start = i + 1
+		}
+	}
+	return append(rv, string(key[start:]))
+}
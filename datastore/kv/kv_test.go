@@ -0,0 +1,334 @@
+//  Copyright (c) 2018 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package kv
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/couchbase/query/datastore"
+	"github.com/couchbase/query/errors"
+	"github.com/couchbase/query/expression"
+	"github.com/couchbase/query/expression/parser"
+	"github.com/couchbase/query/value"
+)
+
+func TestKV(t *testing.T) {
+	dir, er := ioutil.TempDir("", "kvstore")
+	if er != nil {
+		t.Fatalf("failed to create directory: %v", er)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "test.db")
+	ds, err := NewDatastore(path)
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+
+	namespace, err := ds.NamespaceByName("default")
+	if err != nil {
+		t.Fatalf("failed to get namespace: %v", err)
+	}
+
+	_, err = namespace.KeyspaceByName("contacts")
+	if err == nil {
+		t.Errorf("keyspace contacts should not exist before it is created")
+	}
+
+	names, _ := namespace.KeyspaceNames()
+	if len(names) != 0 {
+		t.Errorf("expected no keyspaces before CREATE KEYSPACE, got %v", names)
+	}
+
+	manager := namespace.(datastore.KeyspaceManager)
+	err = manager.CreateKeyspace("contacts", "kv", nil)
+	if err != nil {
+		t.Fatalf("failed to create keyspace: %v", err)
+	}
+
+	err = manager.CreateKeyspace("contacts", "kv", nil)
+	if err == nil {
+		t.Errorf("keyspace contacts should not be created twice")
+	}
+
+	ks, err := namespace.KeyspaceByName("contacts")
+	if err != nil {
+		t.Fatalf("failed to get keyspace: %v", err)
+	}
+
+	pairs := []value.Pair{
+		{Name: "dave", Value: value.NewValue(map[string]interface{}{"name": "dave", "age": 46})},
+		{Name: "earl", Value: value.NewValue(map[string]interface{}{"name": "earl", "age": 29})},
+		{Name: "fred", Value: value.NewValue(map[string]interface{}{"name": "fred", "age": 35})},
+		{Name: "ian", Value: value.NewValue(map[string]interface{}{"name": "ian"})},
+	}
+
+	_, err = ks.Insert(pairs)
+	if err != nil {
+		t.Fatalf("failed to insert: %v", err)
+	}
+
+	_, err = ks.Insert(pairs[:1])
+	if err == nil {
+		t.Errorf("Insert should not have succeeded for dave")
+	}
+
+	count, _ := ks.Count(datastore.NULL_QUERY_CONTEXT)
+	if count != 4 {
+		t.Errorf("expected 4 documents, got %v", count)
+	}
+
+	freds, errs := ks.Fetch([]string{"fred", "george"}, datastore.NULL_QUERY_CONTEXT, nil)
+	if errs != nil || len(freds) != 1 || freds[0].Name != "fred" {
+		t.Errorf("failed to fetch fred: %v", errs)
+	}
+
+	// primary range scan
+	primary, _ := ks.(*keyspace).indexer.PrimaryIndexes()
+	span := &datastore.Span{Range: datastore.Range{
+		Low:       value.Values{value.NewValue("dave")},
+		High:      value.Values{value.NewValue("fred")},
+		Inclusion: datastore.HIGH,
+	}}
+	keys := scanKeys(t, func(conn *datastore.IndexConnection) {
+		primary[0].Scan("", span, false, math.MaxInt64, datastore.UNBOUNDED, nil, conn)
+	})
+	if len(keys) != 2 || keys[0] != "earl" || keys[1] != "fred" {
+		t.Errorf("unexpected primary scan result %v", keys)
+	}
+
+	// secondary index, built over the existing documents
+	age, _ := parser.Parse("age")
+	indexer, _ := ks.Indexer(datastore.DEFAULT)
+	index, err := indexer.CreateIndex("", "ix_age", nil, expression.Expressions{age}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create index: %v", err)
+	}
+
+	span = &datastore.Span{Range: datastore.Range{
+		Low:       value.Values{value.NewValue(30)},
+		Inclusion: datastore.LOW,
+	}}
+	scanAge := func() []string {
+		return scanKeys(t, func(conn *datastore.IndexConnection) {
+			index.Scan("", span, false, math.MaxInt64, datastore.UNBOUNDED, nil, conn)
+		})
+	}
+
+	keys = scanAge()
+	if len(keys) != 2 || keys[0] != "fred" || keys[1] != "dave" {
+		t.Errorf("unexpected index scan result %v", keys)
+	}
+
+	// index maintenance
+	_, err = ks.Upsert([]value.Pair{
+		{Name: "dave", Value: value.NewValue(map[string]interface{}{"name": "dave", "age": 12})},
+		{Name: "gina", Value: value.NewValue(map[string]interface{}{"name": "gina", "age": 31})},
+	})
+	if err != nil {
+		t.Errorf("failed to upsert: %v", err)
+	}
+
+	_, err = ks.Delete([]string{"fred"}, datastore.NULL_QUERY_CONTEXT)
+	if err != nil {
+		t.Errorf("failed to delete: %v", err)
+	}
+
+	keys = scanAge()
+	if len(keys) != 1 || keys[0] != "gina" {
+		t.Errorf("unexpected index scan result after DML %v", keys)
+	}
+
+	// the catalog survives a restart
+	ds.(*store).db.Close()
+	ds, err = NewDatastore(path)
+	if err != nil {
+		t.Fatalf("failed to reopen store: %v", err)
+	}
+	defer ds.(*store).db.Close()
+
+	namespace, _ = ds.NamespaceByName("default")
+	names, _ = namespace.KeyspaceNames()
+	if len(names) != 1 || names[0] != "contacts" {
+		t.Errorf("expected keyspace contacts, got %v", names)
+	}
+
+	ks, _ = namespace.KeyspaceByName("contacts")
+	count, _ = ks.Count(datastore.NULL_QUERY_CONTEXT)
+	if count != 4 {
+		t.Errorf("expected 4 documents after restart, got %v", count)
+	}
+
+	indexer, _ = ks.Indexer(datastore.DEFAULT)
+	index, err = indexer.IndexByName("ix_age")
+	if err != nil {
+		t.Fatalf("failed to find index after restart: %v", err)
+	}
+
+	keys = scanAge()
+	if len(keys) != 1 || keys[0] != "gina" {
+		t.Errorf("unexpected index scan result after restart %v", keys)
+	}
+
+	err = index.Drop("")
+	if err != nil {
+		t.Errorf("failed to drop index: %v", err)
+	}
+
+	_, err = indexer.IndexByName("ix_age")
+	if err == nil {
+		t.Errorf("index ix_age should have been dropped")
+	}
+
+	err = namespace.(datastore.KeyspaceManager).DropKeyspace("contacts")
+	if err != nil {
+		t.Errorf("failed to drop keyspace: %v", err)
+	}
+
+	names, _ = namespace.KeyspaceNames()
+	if len(names) != 0 {
+		t.Errorf("expected no keyspaces after DROP KEYSPACE, got %v", names)
+	}
+
+	_, err = ks.Insert(pairs[:1])
+	if err == nil {
+		t.Errorf("Insert should not have succeeded in a dropped keyspace")
+	}
+}
+
+// A statement may write to the keyspace it is scanning
+func TestScanWhileWriting(t *testing.T) {
+	dir, er := ioutil.TempDir("", "kvstore")
+	if er != nil {
+		t.Fatalf("failed to create directory: %v", er)
+	}
+	defer os.RemoveAll(dir)
+
+	ds, err := NewDatastore(filepath.Join(dir, "test.db"))
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+	defer ds.(*store).db.Close()
+
+	namespace, _ := ds.NamespaceByName("default")
+	err = namespace.(datastore.KeyspaceManager).CreateKeyspace("items", "kv", nil)
+	if err != nil {
+		t.Fatalf("failed to create keyspace: %v", err)
+	}
+	ks, _ := namespace.KeyspaceByName("items")
+
+	n := 3*_SCAN_BATCH + 1
+	pairs := make([]value.Pair, n)
+	for i := range pairs {
+		pairs[i] = value.Pair{Name: fmt.Sprintf("k%04d", i), Value: value.NewValue(map[string]interface{}{"n": i})}
+	}
+	_, err = ks.Insert(pairs)
+	if err != nil {
+		t.Fatalf("failed to insert: %v", err)
+	}
+
+	primary, _ := ks.(*keyspace).indexer.PrimaryIndexes()
+	conn := datastore.NewIndexConnection(&testingContext{t})
+	go primary[0].ScanEntries("", math.MaxInt64, datastore.UNBOUNDED, nil, conn)
+
+	var keys []string
+	for entry := range conn.EntryChannel() {
+		keys = append(keys, entry.PrimaryKey)
+		_, err = ks.Upsert([]value.Pair{{Name: entry.PrimaryKey, Value: value.NewValue(map[string]interface{}{"n": -1})}})
+		if err != nil {
+			t.Fatalf("failed to upsert while scanning: %v", err)
+		}
+	}
+
+	if len(keys) != n || !sort.StringsAreSorted(keys) || keys[n-1] != pairs[n-1].Name {
+		t.Errorf("unexpected scan result: %d keys", len(keys))
+	}
+}
+
+func TestCollate(t *testing.T) {
+	vals := value.Values{
+		value.NULL_VALUE,
+		value.FALSE_VALUE,
+		value.TRUE_VALUE,
+		value.NewValue(int64(math.MinInt64)),
+		value.NewValue(int64(-1<<60 - 1)),
+		value.NewValue(-10.5),
+		value.NewValue(-1),
+		value.NewValue(0),
+		value.NewValue(2),
+		value.NewValue(2.5),
+		value.NewValue(int64(1 << 53)),
+		value.NewValue(int64(1<<53 + 1)),
+		value.NewValue(float64(1 << 60)),
+		value.NewValue(int64(1<<60 + 1)),
+		value.NewValue(int64(math.MaxInt64 - 1)),
+		value.NewValue(int64(math.MaxInt64)),
+		value.NewValue(""),
+		value.NewValue("a"),
+		value.NewValue("a\x00b"),
+		value.NewValue("ab"),
+		value.NewValue([]interface{}{1}),
+		value.NewValue([]interface{}{1, 2}),
+		value.NewValue([]interface{}{2}),
+		value.NewValue(map[string]interface{}{"a": 1}),
+	}
+
+	for i := 1; i < len(vals); i++ {
+		prev := encodeValue(nil, vals[i-1])
+		next := encodeValue(nil, vals[i])
+		if bytes.Compare(prev, next) >= 0 {
+			t.Errorf("expected %v to collate before %v", vals[i-1], vals[i])
+		}
+
+		decoded, rest, err := decodeKeys(next, 1)
+		if err != nil || len(rest) != 0 || !decoded[0].Equals(vals[i]).Truth() ||
+			decoded[0].Collate(vals[i]) != 0 {
+			t.Errorf("failed to decode %v: %v %v", vals[i], decoded, err)
+		}
+	}
+}
+
+func scanKeys(t *testing.T, scan func(conn *datastore.IndexConnection)) []string {
+	conn := datastore.NewIndexConnection(&testingContext{t})
+	go scan(conn)
+
+	var rv []string
+	for entry := range conn.EntryChannel() {
+		rv = append(rv, entry.PrimaryKey)
+	}
+	return rv
+}
+
+type testingContext struct {
+	t *testing.T
+}
+
+func (this *testingContext) GetScanCap() int64 {
+	return 16
+}
+
+func (this *testingContext) Error(err errors.Error) {
+	this.t.Errorf("Scan error: %v", err)
+}
+
+func (this *testingContext) Warning(wrn errors.Error) {
+	this.t.Logf("scan warning: %v", wrn)
+}
+
+func (this *testingContext) Fatal(fatal errors.Error) {
+	this.t.Errorf("scan fatal: %v", fatal)
+}
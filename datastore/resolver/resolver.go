@@ -11,16 +11,25 @@ package resolver
 
 import (
 	"fmt"
+	"regexp"
 	"strings"
 
 	"github.com/couchbase/query/datastore"
 	"github.com/couchbase/query/datastore/couchbase"
+	"github.com/couchbase/query/datastore/external"
+	"github.com/couchbase/query/datastore/federated"
 	"github.com/couchbase/query/datastore/file"
+	"github.com/couchbase/query/datastore/kv"
 	"github.com/couchbase/query/datastore/mock"
+	"github.com/couchbase/query/datastore/sql"
 	"github.com/couchbase/query/errors"
 )
 
 func NewDatastore(uri string) (datastore.Datastore, errors.Error) {
+	if names, uris := splitFederated(uri); names != nil {
+		return newFederatedDatastore(names, uris)
+	}
+
 	if strings.HasPrefix(uri, ".") || strings.HasPrefix(uri, "/") {
 		return file.NewDatastore(uri)
 	}
@@ -37,9 +46,75 @@ func NewDatastore(uri string) (datastore.Datastore, errors.Error) {
 		return file.NewDatastore(uri[5:])
 	}
 
+	if strings.HasPrefix(uri, "kv:") {
+		return kv.NewDatastore(uri[3:])
+	}
+
+	if strings.HasPrefix(uri, "ext:") {
+		return external.NewDatastore(uri[4:])
+	}
+
+	if strings.HasPrefix(uri, "sqlite:") || strings.HasPrefix(uri, "postgres:") ||
+		strings.HasPrefix(uri, "postgresql:") {
+		return sql.NewDatastore(uri)
+	}
+
 	if strings.HasPrefix(uri, "mock:") {
 		return mock.NewDatastore(uri)
 	}
 
 	return nil, errors.NewError(nil, fmt.Sprintf("Invalid datastore uri: %s", uri))
 }
+
+// A named datastore, as in prod=http://... or ref=dir:/data
+var _NAMED_DATASTORE = regexp.MustCompile(`^([A-Za-z_][A-Za-z0-9_]*)=([A-Za-z]+:|[./])`)
+
+// Split a list of named datastores, such as prod=http://...,ref=dir:/data.
+// Commas that are not followed by a named datastore belong to the
+// preceding uri. Returns nil names if uri is not such a list.
+func splitFederated(uri string) ([]string, []string) {
+	if !_NAMED_DATASTORE.MatchString(uri) {
+		return nil, nil
+	}
+
+	var names, uris []string
+	for _, part := range strings.Split(uri, ",") {
+		match := _NAMED_DATASTORE.FindStringSubmatch(part)
+		if match == nil {
+			uris[len(uris)-1] += "," + part
+			continue
+		}
+
+		names = append(names, match[1])
+		uris = append(uris, part[len(match[1])+1:])
+	}
+
+	return names, uris
+}
+
+// The address to audit through: that of the first of a list of
+// named datastores, or else the address itself
+func AuditAddress(uri string) string {
+	if names, uris := splitFederated(uri); names != nil {
+		return uris[0]
+	}
+	return uri
+}
+
+func newFederatedDatastore(names, uris []string) (datastore.Datastore, errors.Error) {
+	stores := make([]datastore.Datastore, len(uris))
+	for i, u := range uris {
+		ds, err := NewDatastore(u)
+		if err != nil {
+			return nil, err
+		}
+		stores[i] = ds
+	}
+
+	// the members' URLs, unlike the addresses given, carry no passwords
+	ids := make([]string, len(stores))
+	for i, ds := range stores {
+		ids[i] = names[i] + "=" + ds.URL()
+	}
+	return federated.NewDatastore(strings.Join(ids, ","), names, stores)
+}
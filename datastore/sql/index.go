@@ -0,0 +1,248 @@
+//  Copyright (c) 2018 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package sql
+
+import (
+	"fmt"
+
+	"github.com/couchbase/query/datastore"
+	"github.com/couchbase/query/errors"
+	"github.com/couchbase/query/expression"
+	"github.com/couchbase/query/logging"
+	"github.com/couchbase/query/timestamp"
+	"github.com/couchbase/query/value"
+)
+
+const _PRIMARY_NAME = "#primary"
+
+type sqlIndexer struct {
+	keyspace *keyspace
+	primary  *primaryIndex
+}
+
+func newSQLIndexer(keyspace *keyspace) *sqlIndexer {
+	indexer := &sqlIndexer{
+		keyspace: keyspace,
+	}
+
+	indexer.primary = &primaryIndex{
+		name:     _PRIMARY_NAME,
+		keyspace: keyspace,
+		indexer:  indexer,
+	}
+
+	return indexer
+}
+
+func (si *sqlIndexer) KeyspaceId() string {
+	return si.keyspace.Id()
+}
+
+func (si *sqlIndexer) Name() datastore.IndexType {
+	return datastore.DEFAULT
+}
+
+func (si *sqlIndexer) IndexIds() ([]string, errors.Error) {
+	return si.IndexNames()
+}
+
+func (si *sqlIndexer) IndexNames() ([]string, errors.Error) {
+	return []string{si.primary.name}, nil
+}
+
+func (si *sqlIndexer) IndexById(id string) (datastore.Index, errors.Error) {
+	return si.IndexByName(id)
+}
+
+func (si *sqlIndexer) IndexByName(name string) (datastore.Index, errors.Error) {
+	if name != si.primary.name {
+		return nil, errors.NewSQLIdxNotFound(nil, name)
+	}
+	return si.primary, nil
+}
+
+func (si *sqlIndexer) PrimaryIndexes() ([]datastore.PrimaryIndex, errors.Error) {
+	return []datastore.PrimaryIndex{si.primary}, nil
+}
+
+func (si *sqlIndexer) Indexes() ([]datastore.Index, errors.Error) {
+	return []datastore.Index{si.primary}, nil
+}
+
+// The primary index always exists, as it is the primary key of the table
+func (si *sqlIndexer) CreatePrimaryIndex(requestId, name string, with value.Value) (
+	datastore.PrimaryIndex, errors.Error) {
+	return si.primary, nil
+}
+
+func (si *sqlIndexer) CreateIndex(requestId, name string, seekKey, rangeKey expression.Expressions,
+	where expression.Expression, with value.Value) (datastore.Index, errors.Error) {
+	return nil, errors.NewSQLNotSupported(nil, "CREATE INDEX is not supported for sql-based datastore.")
+}
+
+func (si *sqlIndexer) BuildIndexes(requestId string, names ...string) errors.Error {
+	return errors.NewSQLNotSupported(nil, "BUILD INDEXES is not supported for sql-based datastore.")
+}
+
+func (si *sqlIndexer) Refresh() errors.Error {
+	return nil
+}
+
+func (si *sqlIndexer) MetadataVersion() uint64 {
+	return 0
+}
+
+func (si *sqlIndexer) SetLogLevel(level logging.Level) {
+	// No-op, uses query engine logger
+}
+
+// primaryIndex performs range scans over the primary key of the table.
+type primaryIndex struct {
+	name     string
+	keyspace *keyspace
+	indexer  *sqlIndexer
+}
+
+func (pi *primaryIndex) KeyspaceId() string {
+	return pi.keyspace.Id()
+}
+
+func (pi *primaryIndex) Id() string {
+	return pi.Name()
+}
+
+func (pi *primaryIndex) Name() string {
+	return pi.name
+}
+
+func (pi *primaryIndex) Type() datastore.IndexType {
+	return datastore.DEFAULT
+}
+
+func (pi *primaryIndex) Indexer() datastore.Indexer {
+	return pi.indexer
+}
+
+func (pi *primaryIndex) SeekKey() expression.Expressions {
+	return nil
+}
+
+func (pi *primaryIndex) RangeKey() expression.Expressions {
+	return nil
+}
+
+func (pi *primaryIndex) Condition() expression.Expression {
+	return nil
+}
+
+func (pi *primaryIndex) IsPrimary() bool {
+	return true
+}
+
+func (pi *primaryIndex) State() (state datastore.IndexState, msg string, err errors.Error) {
+	return datastore.ONLINE, "", nil
+}
+
+func (pi *primaryIndex) Statistics(requestId string, span *datastore.Span) (
+	datastore.Statistics, errors.Error) {
+	return nil, nil
+}
+
+func (pi *primaryIndex) Drop(requestId string) errors.Error {
+	return errors.NewSQLPrimaryIdxNoDropError(nil, pi.Name())
+}
+
+func (pi *primaryIndex) Scan(requestId string, span *datastore.Span, distinct bool, limit int64,
+	cons datastore.ScanConsistency, vector timestamp.Vector, conn *datastore.IndexConnection) {
+	defer close(conn.EntryChannel())
+
+	var low, high *string
+	inclusion := span.Range.Inclusion
+
+	// For primary indexes, bounds must always be strings
+	if len(span.Seek) > 0 {
+		s, ok := span.Seek[0].Actual().(string)
+		if !ok {
+			conn.Error(errors.NewSQLIndexScanError(nil, fmt.Sprintf("Invalid seek value %v.", span.Seek[0])))
+			return
+		}
+		low, high = &s, &s
+		inclusion = datastore.BOTH
+	} else {
+		if len(span.Range.Low) > 0 {
+			s, ok := span.Range.Low[0].Actual().(string)
+			if !ok {
+				conn.Error(errors.NewSQLIndexScanError(nil, fmt.Sprintf("Invalid lower bound %v.", span.Range.Low[0])))
+				return
+			}
+			low = &s
+		}
+
+		if len(span.Range.High) > 0 {
+			s, ok := span.Range.High[0].Actual().(string)
+			if !ok {
+				conn.Error(errors.NewSQLIndexScanError(nil, fmt.Sprintf("Invalid upper bound %v.", span.Range.High[0])))
+				return
+			}
+			high = &s
+		}
+	}
+
+	pi.scan(low, high, inclusion, limit, conn)
+}
+
+func (pi *primaryIndex) ScanEntries(requestId string, limit int64, cons datastore.ScanConsistency,
+	vector timestamp.Vector, conn *datastore.IndexConnection) {
+	defer close(conn.EntryChannel())
+
+	pi.scan(nil, nil, datastore.BOTH, limit, conn)
+}
+
+func (pi *primaryIndex) scan(low, high *string, inclusion datastore.Inclusion, limit int64,
+	conn *datastore.IndexConnection) {
+	if !pi.keyspace.isCreated() {
+		return
+	}
+
+	query, args := pi.keyspace.dialect().scan(pi.keyspace.table, low, high, inclusion, limit)
+	rows, er := pi.keyspace.db().Query(query, args...)
+	if er != nil {
+		conn.Error(errors.NewSQLIndexScanError(er, ""))
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var key string
+		if er = rows.Scan(&key); er != nil {
+			conn.Error(errors.NewSQLIndexScanError(er, ""))
+			return
+		}
+
+		entry := &datastore.IndexEntry{PrimaryKey: key}
+		if !sendEntry(entry, conn) {
+			return
+		}
+	}
+
+	if er = rows.Err(); er != nil {
+		conn.Error(errors.NewSQLIndexScanError(er, ""))
+	}
+}
+
+// Returns false if the scan has been stopped
+func sendEntry(entry *datastore.IndexEntry, conn *datastore.IndexConnection) bool {
+	select {
+	case conn.EntryChannel() <- entry:
+		return true
+	case <-conn.StopChannel():
+		return false
+	}
+}
@@ -0,0 +1,597 @@
+//  Copyright (c) 2018 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+/*
+Package sql provides an implementation of the datastore package on top
+of a SQL database, currently SQLite or PostgreSQL.
+
+The database holds a single namespace, default. Each keyspace is a
+table with two columns, id TEXT PRIMARY KEY and doc JSON, which is
+created the first time a document is written to the keyspace. The
+primary index is the table's primary key, and index spans are
+translated into range predicates on it.
+*/
+package sql
+
+import (
+	dbsql "database/sql"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+
+	"github.com/couchbase/query/auth"
+	"github.com/couchbase/query/datastore"
+	"github.com/couchbase/query/errors"
+	"github.com/couchbase/query/logging"
+	"github.com/couchbase/query/util"
+	"github.com/couchbase/query/value"
+)
+
+const _NAMESPACE = "default"
+
+// Maximum number of keys bound in a single fetch statement
+const _FETCH_BATCH = 256
+
+// store is the root for the SQL-based Datastore.
+type store struct {
+	uri       string // without the password
+	db        *dbsql.DB
+	dialect   *dialect
+	namespace *namespace
+
+	users map[string]*datastore.User
+}
+
+func (s *store) Id() string {
+	return s.uri
+}
+
+func (s *store) URL() string {
+	return s.uri
+}
+
+func (s *store) Info() datastore.Info {
+	return &infoImpl{}
+}
+
+type infoImpl struct {
+}
+
+func (i *infoImpl) Version() string {
+	return util.VERSION
+}
+
+func (info *infoImpl) Topology() ([]string, []errors.Error) {
+	return []string{}, nil
+}
+
+func (info *infoImpl) Services(node string) (map[string]interface{}, []errors.Error) {
+	return map[string]interface{}{}, nil
+}
+
+func (s *store) NamespaceIds() ([]string, errors.Error) {
+	return s.NamespaceNames()
+}
+
+func (s *store) NamespaceNames() ([]string, errors.Error) {
+	return []string{s.namespace.name}, nil
+}
+
+func (s *store) NamespaceById(id string) (datastore.Namespace, errors.Error) {
+	return s.NamespaceByName(id)
+}
+
+func (s *store) NamespaceByName(name string) (datastore.Namespace, errors.Error) {
+	if name != s.namespace.name {
+		return nil, errors.NewSQLNamespaceNotFoundError(nil, name)
+	}
+
+	return s.namespace, nil
+}
+
+func (s *store) Authorize(*auth.Privileges, auth.Credentials, *http.Request) (auth.AuthenticatedUsers, errors.Error) {
+	return nil, nil
+}
+
+func (s *store) CredsString(req *http.Request) string {
+	return ""
+}
+
+func (s *store) SetLogLevel(level logging.Level) {
+	// No-op. Uses query engine logger.
+}
+
+func (s *store) Inferencer(name datastore.InferenceType) (datastore.Inferencer, errors.Error) {
+	return nil, errors.NewOtherNotImplementedError(nil, "INFER")
+}
+
+func (s *store) Inferencers() ([]datastore.Inferencer, errors.Error) {
+	return nil, errors.NewOtherNotImplementedError(nil, "INFER")
+}
+
+func (s *store) AuditInfo() (*datastore.AuditInfo, errors.Error) {
+	return nil, errors.NewOtherNotImplementedError(nil, "AuditInfo")
+}
+
+func (s *store) ProcessAuditUpdateStream(callb func(uid string) error) errors.Error {
+	return errors.NewOtherNotImplementedError(nil, "ProcessAuditUpdateStream")
+}
+
+func (s *store) UserInfo() (value.Value, errors.Error) {
+	// Return an array of no users.
+	jsonData := make([]interface{}, 0)
+	v := value.NewValue(jsonData)
+	return v, nil
+}
+
+func (s *store) GetUserInfoAll() ([]datastore.User, errors.Error) {
+	ret := make([]datastore.User, 0, len(s.users))
+	for _, v := range s.users {
+		ret = append(ret, *v)
+	}
+	return ret, nil
+}
+
+func (s *store) PutUserInfo(u *datastore.User) errors.Error {
+	s.users[u.Id] = u
+	return nil
+}
+
+func (s *store) GetRolesAll() ([]datastore.Role, errors.Error) {
+	return []datastore.Role{
+		datastore.Role{Name: "cluster_admin"},
+		datastore.Role{Name: "replication_admin"},
+		datastore.Role{Name: "bucket_admin", Bucket: "*"},
+	}, nil
+}
+
+// NewDatastore connects to the database at the given URI, which is
+// either sqlite:PATH or a postgres:// connection URL.
+func NewDatastore(uri string) (s datastore.Datastore, e errors.Error) {
+	dialect, source, er := parseURI(uri)
+	if er != nil {
+		return nil, errors.NewSQLDatastoreError(er, "")
+	}
+
+	db, er := dbsql.Open(dialect.driver, source)
+	if er == nil {
+		er = db.Ping()
+	}
+	if er != nil {
+		return nil, errors.NewSQLDatastoreError(er, "Cannot connect to "+redactURI(uri))
+	}
+
+	ss := &store{
+		uri:     redactURI(uri),
+		db:      db,
+		dialect: dialect,
+		users:   make(map[string]*datastore.User, 4),
+	}
+	ss.namespace = newNamespace(ss, _NAMESPACE)
+
+	s = ss
+	return
+}
+
+// namespace represents the SQL-based Namespace.
+type namespace struct {
+	sync.RWMutex
+	store     *store
+	name      string
+	keyspaces map[string]*keyspace
+}
+
+func newNamespace(s *store, name string) *namespace {
+	return &namespace{
+		store:     s,
+		name:      name,
+		keyspaces: make(map[string]*keyspace, 8),
+	}
+}
+
+func (p *namespace) DatastoreId() string {
+	return p.store.Id()
+}
+
+func (p *namespace) Id() string {
+	return p.Name()
+}
+
+func (p *namespace) Name() string {
+	return p.name
+}
+
+func (p *namespace) KeyspaceIds() ([]string, errors.Error) {
+	return p.KeyspaceNames()
+}
+
+// Tables are listed from the database catalog, so that tables created
+// outside of the query engine are visible
+func (p *namespace) KeyspaceNames() ([]string, errors.Error) {
+	rows, er := p.store.db.Query(p.store.dialect.tablesQuery)
+	if er != nil {
+		return nil, errors.NewSQLDatastoreError(er, "Cannot list tables")
+	}
+	defer rows.Close()
+
+	rv := make([]string, 0, 16)
+	for rows.Next() {
+		var name string
+		if er = rows.Scan(&name); er != nil {
+			return nil, errors.NewSQLDatastoreError(er, "Cannot list tables")
+		}
+		rv = append(rv, name)
+	}
+
+	if er = rows.Err(); er != nil {
+		return nil, errors.NewSQLDatastoreError(er, "Cannot list tables")
+	}
+
+	sort.Strings(rv)
+	return rv, nil
+}
+
+func (p *namespace) KeyspaceById(id string) (datastore.Keyspace, errors.Error) {
+	return p.KeyspaceByName(id)
+}
+
+// Any name resolves to a keyspace, whose table is created on first write
+func (p *namespace) KeyspaceByName(name string) (datastore.Keyspace, errors.Error) {
+	p.RLock()
+	b, ok := p.keyspaces[name]
+	p.RUnlock()
+	if ok {
+		return b, nil
+	}
+
+	var found string
+	er := p.store.db.QueryRow(p.store.dialect.tableQuery, name).Scan(&found)
+	if er != nil && er != dbsql.ErrNoRows {
+		return nil, errors.NewSQLDatastoreError(er, "Cannot look up table "+name)
+	}
+
+	p.Lock()
+	defer p.Unlock()
+	b, ok = p.keyspaces[name]
+	if !ok {
+		b = newKeyspace(p, name)
+		b.created = er == nil
+		p.keyspaces[name] = b
+	}
+	return b, nil
+}
+
+func (p *namespace) MetadataVersion() uint64 {
+	return 0
+}
+
+// keyspace is a SQL-based keyspace.
+type keyspace struct {
+	namespace *namespace
+	name      string
+	table     string
+	indexer   *sqlIndexer
+	created   bool
+}
+
+func newKeyspace(p *namespace, name string) *keyspace {
+	b := &keyspace{
+		namespace: p,
+		name:      name,
+		table:     quoteIdentifier(name),
+	}
+
+	b.indexer = newSQLIndexer(b)
+	return b
+}
+
+func (b *keyspace) NamespaceId() string {
+	return b.namespace.Id()
+}
+
+func (b *keyspace) Namespace() datastore.Namespace {
+	return b.namespace
+}
+
+func (b *keyspace) Id() string {
+	return b.Name()
+}
+
+func (b *keyspace) Name() string {
+	return b.name
+}
+
+func (b *keyspace) Count(context datastore.QueryContext) (int64, errors.Error) {
+	if !b.isCreated() {
+		return 0, nil
+	}
+
+	var count int64
+	er := b.db().QueryRow(b.dialect().count(b.table)).Scan(&count)
+	if er != nil {
+		return 0, errors.NewSQLDatastoreError(er, "Cannot count "+b.name)
+	}
+	return count, nil
+}
+
+func (b *keyspace) Indexer(name datastore.IndexType) (datastore.Indexer, errors.Error) {
+	return b.indexer, nil
+}
+
+func (b *keyspace) Indexers() ([]datastore.Indexer, errors.Error) {
+	return []datastore.Indexer{b.indexer}, nil
+}
+
+func (b *keyspace) Fetch(keys []string, context datastore.QueryContext, subPaths []string) ([]value.AnnotatedPair, []errors.Error) {
+	if !b.isCreated() {
+		return nil, nil
+	}
+
+	docs := make(map[string]value.AnnotatedValue, len(keys))
+	for start := 0; start < len(keys); start += _FETCH_BATCH {
+		batch := keys[start:]
+		if len(batch) > _FETCH_BATCH {
+			batch = batch[:_FETCH_BATCH]
+		}
+
+		er := b.fetch(batch, docs)
+		if er != nil {
+			return nil, []errors.Error{errors.NewSQLDatastoreError(er, "")}
+		}
+	}
+
+	// results follow the order of the keys
+	rv := make([]value.AnnotatedPair, 0, len(docs))
+	for _, k := range keys {
+		doc, ok := docs[k]
+		if !ok {
+			// key denotes non-existent doc => ignore it
+			continue
+		}
+
+		delete(docs, k)
+		rv = append(rv, value.AnnotatedPair{
+			Name:  k,
+			Value: doc,
+		})
+	}
+
+	return rv, nil
+}
+
+func (b *keyspace) fetch(keys []string, docs map[string]value.AnnotatedValue) error {
+	args := make([]interface{}, len(keys))
+	for i, k := range keys {
+		args[i] = k
+	}
+
+	rows, er := b.db().Query(b.dialect().fetch(b.table, len(keys)), args...)
+	if er != nil {
+		return er
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var key string
+		var bytes []byte
+		if er = rows.Scan(&key, &bytes); er != nil {
+			return er
+		}
+		docs[key] = newDocument(key, bytes)
+	}
+
+	return rows.Err()
+}
+
+func newDocument(key string, bytes []byte) value.AnnotatedValue {
+	doc := value.NewAnnotatedValue(value.NewValue(bytes))
+	doc.SetAttachment("meta", map[string]interface{}{"id": key})
+	return doc
+}
+
+const (
+	INSERT = 0x01
+	UPDATE = 0x02
+	UPSERT = 0x04
+)
+
+func opToString(op int) string {
+
+	switch op {
+	case INSERT:
+		return "insert"
+	case UPDATE:
+		return "update"
+	case UPSERT:
+		return "upsert"
+	}
+
+	return "unknown operation"
+}
+
+// All the pairs are written in a single transaction. Pairs that cannot
+// be written are reported and skipped.
+func (b *keyspace) performOp(op int, kvPairs []value.Pair) ([]value.Pair, errors.Error) {
+
+	if len(kvPairs) == 0 {
+		return nil, errors.NewSQLNoKeysInsertError(nil, "keyspace "+b.Name())
+	}
+
+	er := b.create()
+	if er != nil {
+		return nil, errors.NewSQLDMLError(er, opToString(op)+" Failed")
+	}
+
+	var stmt string
+	switch op {
+	case INSERT:
+		stmt = b.dialect().insert(b.table)
+	case UPDATE:
+		stmt = b.dialect().update(b.table)
+	default:
+		stmt = b.dialect().upsert(b.table)
+	}
+
+	var insertedKeys []value.Pair
+	var returnErr errors.Error
+
+	er = b.transaction(func(tx *dbsql.Tx) error {
+		insertedKeys = make([]value.Pair, 0, len(kvPairs))
+		returnErr = nil
+
+		for _, kv := range kvPairs {
+			key := kv.Name
+			doc, err := marshalDocument(kv.Value)
+			if err != nil {
+				returnErr = errors.NewSQLDMLError(returnErr, opToString(op)+" Failed "+err.Error())
+				continue
+			}
+
+			res, err := tx.Exec(stmt, key, doc)
+			if err != nil {
+				return err
+			}
+
+			n, err := res.RowsAffected()
+			if err != nil {
+				return err
+			}
+
+			if n == 0 {
+				switch op {
+				case INSERT:
+					returnErr = errors.NewSQLKeyExists(returnErr, "Key "+key)
+				case UPDATE:
+					returnErr = errors.NewSQLKeyNotFound(returnErr, "Key "+key)
+				}
+				continue
+			}
+
+			insertedKeys = append(insertedKeys, kv)
+		}
+		return nil
+	})
+
+	if er != nil {
+		return nil, errors.NewSQLDMLError(er, opToString(op)+" Failed")
+	}
+
+	return insertedKeys, returnErr
+}
+
+// Binary documents are stored as they are, everything else as JSON
+func marshalDocument(val value.Value) (string, error) {
+	if val.Type() == value.BINARY {
+		return string(val.Actual().([]byte)), nil
+	}
+
+	bytes, err := json.Marshal(val.Actual())
+	if err != nil {
+		return "", err
+	}
+	return string(bytes), nil
+}
+
+func (b *keyspace) Insert(inserts []value.Pair) ([]value.Pair, errors.Error) {
+	return b.performOp(INSERT, inserts)
+}
+
+func (b *keyspace) Update(updates []value.Pair) ([]value.Pair, errors.Error) {
+	return b.performOp(UPDATE, updates)
+}
+
+func (b *keyspace) Upsert(upserts []value.Pair) ([]value.Pair, errors.Error) {
+	return b.performOp(UPSERT, upserts)
+}
+
+func (b *keyspace) Delete(deletes []string, context datastore.QueryContext) ([]string, errors.Error) {
+	if !b.isCreated() {
+		return []string{}, nil
+	}
+
+	var deleted []string
+	stmt := b.dialect().delete(b.table)
+
+	er := b.transaction(func(tx *dbsql.Tx) error {
+		deleted = make([]string, 0, len(deletes))
+
+		for _, key := range deletes {
+			res, err := tx.Exec(stmt, key)
+			if err != nil {
+				return err
+			}
+
+			n, err := res.RowsAffected()
+			if err != nil {
+				return err
+			}
+
+			if n > 0 {
+				deleted = append(deleted, key)
+			}
+		}
+		return nil
+	})
+
+	if er != nil {
+		return nil, errors.NewSQLDMLError(er, "delete Failed")
+	}
+
+	return deleted, nil
+}
+
+func (b *keyspace) Release() {
+}
+
+func (b *keyspace) transaction(f func(tx *dbsql.Tx) error) error {
+	tx, err := b.db().Begin()
+	if err != nil {
+		return err
+	}
+
+	err = f(tx)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Create the table of the keyspace, if needed
+func (b *keyspace) create() error {
+	if b.isCreated() {
+		return nil
+	}
+
+	_, err := b.db().Exec(b.dialect().createTable(b.table))
+	if err != nil {
+		return err
+	}
+
+	b.namespace.Lock()
+	b.created = true
+	b.namespace.Unlock()
+	return nil
+}
+
+func (b *keyspace) isCreated() bool {
+	b.namespace.RLock()
+	defer b.namespace.RUnlock()
+	return b.created
+}
+
+func (b *keyspace) db() *dbsql.DB {
+	return b.namespace.store.db
+}
+
+func (b *keyspace) dialect() *dialect {
+	return b.namespace.store.dialect
+}
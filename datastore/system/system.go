@@ -29,7 +29,9 @@ const KEYSPACE_NAME_KEYSPACES = "keyspaces"
 const KEYSPACE_NAME_INDEXES = "indexes"
 const KEYSPACE_NAME_DUAL = "dual"
 const KEYSPACE_NAME_PREPAREDS = "prepareds"
+const KEYSPACE_NAME_PLAN_BASELINES = "plan_baselines"
 const KEYSPACE_NAME_REQUESTS = "completed_requests"
+const KEYSPACE_NAME_REQUEST_ARCHIVE = "completed_requests_archive"
 const KEYSPACE_NAME_ACTIVE = "active_requests"
 const KEYSPACE_NAME_USER_INFO = "user_info"
 const KEYSPACE_NAME_MY_USER_INFO = "my_user_info"
@@ -220,7 +220,7 @@ func (b *activeRequestsKeyspace) Upsert(upserts []value.Pair) ([]value.Pair, err
 }
 
 func (b *activeRequestsKeyspace) Delete(deletes []string, context datastore.QueryContext) ([]string, errors.Error) {
-	var done bool
+	var deleted []string
 
 	creds, authToken := credsFromContext(context)
 
@@ -228,6 +228,7 @@ func (b *activeRequestsKeyspace) Delete(deletes []string, context datastore.Quer
 	whoAmI := distributed.RemoteAccess().WhoAmI()
 	for i, name := range deletes {
 		node, localKey := distributed.RemoteAccess().SplitKey(name)
+		done := true
 
 		// remote entry
 		if len(node) != 0 && node != whoAmI {
@@ -238,22 +239,28 @@ func (b *activeRequestsKeyspace) Delete(deletes []string, context datastore.Quer
 					context.Warning(warn)
 				},
 				creds, authToken)
-			done = true
 
 			// local entry
 		} else {
 			done = server.ActiveRequestsDelete(localKey)
 		}
 
-		// save memory allocations by making a new slice only on errors
+		// when cancelling in bulk, requests may well complete between
+		// the scan and the delete: that is not a reason to stop.
+		// save memory allocations by making a new slice only on misses
 		if !done {
-			deleted := make([]string, i)
-			if i > 0 {
-				copy(deleted, deletes[0:i-1])
+			context.Warning(errors.NewSystemStmtNotFoundError(nil, name))
+			if deleted == nil {
+				deleted = make([]string, i, len(deletes))
+				copy(deleted, deletes[0:i])
 			}
-			return deleted, errors.NewSystemStmtNotFoundError(nil, name)
+		} else if deleted != nil {
+			deleted = append(deleted, name)
 		}
 	}
+	if deleted != nil {
+		return deleted, nil
+	}
 	return deletes, nil
 }
 
@@ -0,0 +1,191 @@
+//  Copyright (c) 2018 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package system
+
+import (
+	"github.com/couchbase/query/datastore"
+	"github.com/couchbase/query/errors"
+	"github.com/couchbase/query/expression"
+	"github.com/couchbase/query/prepareds"
+	"github.com/couchbase/query/timestamp"
+	"github.com/couchbase/query/value"
+)
+
+// plan baselines are kept by each node: this keyspace only shows
+// those of the node serving the request
+type planBaselinesKeyspace struct {
+	keyspaceBase
+	name    string
+	indexer datastore.Indexer
+}
+
+func (b *planBaselinesKeyspace) Release() {
+}
+
+func (b *planBaselinesKeyspace) NamespaceId() string {
+	return b.namespace.Id()
+}
+
+func (b *planBaselinesKeyspace) Id() string {
+	return b.Name()
+}
+
+func (b *planBaselinesKeyspace) Name() string {
+	return b.name
+}
+
+func (b *planBaselinesKeyspace) Count(context datastore.QueryContext) (int64, errors.Error) {
+	return int64(prepareds.CountBaselines()), nil
+}
+
+func (b *planBaselinesKeyspace) Indexer(name datastore.IndexType) (datastore.Indexer, errors.Error) {
+	return b.indexer, nil
+}
+
+func (b *planBaselinesKeyspace) Indexers() ([]datastore.Indexer, errors.Error) {
+	return []datastore.Indexer{b.indexer}, nil
+}
+
+func (b *planBaselinesKeyspace) Fetch(keys []string, context datastore.QueryContext, subPaths []string) ([]value.AnnotatedPair, []errors.Error) {
+	var errs []errors.Error
+	rv := make([]value.AnnotatedPair, 0, len(keys))
+
+	for _, key := range keys {
+		prepareds.BaselineDo(key, func(baseline *prepareds.Baseline) {
+			itemMap := map[string]interface{}{
+				"name":            key,
+				"statement":       baseline.Text,
+				"encoded_plan":    baseline.EncodedPlan,
+				"indexApiVersion": baseline.IndexApiVersion,
+				"created":         baseline.Created.String(),
+				"uses":            baseline.Uses,
+				"divergences":     baseline.Divergences,
+				"invalidations":   baseline.Invalidations,
+			}
+			if baseline.Divergences > 0 {
+				itemMap["lastDivergence"] = baseline.LastDivergence.String()
+			}
+			if baseline.Invalidations > 0 {
+				itemMap["lastInvalidation"] = baseline.LastInvalidation.String()
+			}
+			item := value.NewAnnotatedValue(itemMap)
+			item.SetAttachment("meta", map[string]interface{}{
+				"id": key,
+			})
+			rv = append(rv, value.AnnotatedPair{
+				Name:  key,
+				Value: item,
+			})
+		})
+	}
+	return rv, errs
+}
+
+func (b *planBaselinesKeyspace) Insert(inserts []value.Pair) ([]value.Pair, errors.Error) {
+	return nil, errors.NewSystemNotImplementedError(nil, "")
+}
+
+func (b *planBaselinesKeyspace) Update(updates []value.Pair) ([]value.Pair, errors.Error) {
+	return nil, errors.NewSystemNotImplementedError(nil, "")
+}
+
+func (b *planBaselinesKeyspace) Upsert(upserts []value.Pair) ([]value.Pair, errors.Error) {
+	return nil, errors.NewSystemNotImplementedError(nil, "")
+}
+
+func (b *planBaselinesKeyspace) Delete(deletes []string, context datastore.QueryContext) ([]string, errors.Error) {
+	for i, name := range deletes {
+		err := prepareds.DeleteBaseline(name)
+		if err != nil {
+			return deletes[0:i], err
+		}
+	}
+	return deletes, nil
+}
+
+func newPlanBaselinesKeyspace(p *namespace) (*planBaselinesKeyspace, errors.Error) {
+	b := new(planBaselinesKeyspace)
+	setKeyspaceBase(&b.keyspaceBase, p)
+	b.name = KEYSPACE_NAME_PLAN_BASELINES
+
+	primary := &planBaselinesIndex{name: "#primary", keyspace: b}
+	b.indexer = newSystemIndexer(b, primary)
+	setIndexBase(&primary.indexBase, b.indexer)
+
+	return b, nil
+}
+
+type planBaselinesIndex struct {
+	indexBase
+	name     string
+	keyspace *planBaselinesKeyspace
+}
+
+func (pi *planBaselinesIndex) KeyspaceId() string {
+	return pi.keyspace.Id()
+}
+
+func (pi *planBaselinesIndex) Id() string {
+	return pi.Name()
+}
+
+func (pi *planBaselinesIndex) Name() string {
+	return pi.name
+}
+
+func (pi *planBaselinesIndex) Type() datastore.IndexType {
+	return datastore.SYSTEM
+}
+
+func (pi *planBaselinesIndex) SeekKey() expression.Expressions {
+	return nil
+}
+
+func (pi *planBaselinesIndex) RangeKey() expression.Expressions {
+	return nil
+}
+
+func (pi *planBaselinesIndex) Condition() expression.Expression {
+	return nil
+}
+
+func (pi *planBaselinesIndex) IsPrimary() bool {
+	return true
+}
+
+func (pi *planBaselinesIndex) State() (state datastore.IndexState, msg string, err errors.Error) {
+	return datastore.ONLINE, "", nil
+}
+
+func (pi *planBaselinesIndex) Statistics(requestId string, span *datastore.Span) (
+	datastore.Statistics, errors.Error) {
+	return nil, nil
+}
+
+func (pi *planBaselinesIndex) Drop(requestId string) errors.Error {
+	return errors.NewSystemIdxNoDropError(nil, "")
+}
+
+func (pi *planBaselinesIndex) Scan(requestId string, span *datastore.Span, distinct bool, limit int64,
+	cons datastore.ScanConsistency, vector timestamp.Vector, conn *datastore.IndexConnection) {
+	pi.ScanEntries(requestId, limit, cons, vector, conn)
+}
+
+func (pi *planBaselinesIndex) ScanEntries(requestId string, limit int64, cons datastore.ScanConsistency,
+	vector timestamp.Vector, conn *datastore.IndexConnection) {
+	defer close(conn.EntryChannel())
+
+	for _, name := range prepareds.NameBaselines() {
+		entry := datastore.IndexEntry{PrimaryKey: name}
+		if !sendSystemKey(conn, &entry) {
+			return
+		}
+	}
+}
@@ -109,10 +109,16 @@ func (b *preparedsKeyspace) Fetch(keys []string, context datastore.QueryContext,
 					"encoded_plan":    entry.Prepared.EncodedPlan(),
 					"indexApiVersion": entry.Prepared.IndexApiVersion(),
 					"featuresControl": entry.Prepared.FeatureControls(),
+					"restored":        entry.Restored,
+					"planGeneration":  entry.Generation,
 				}
 				if node != "" {
 					itemMap["node"] = node
 				}
+				if entry.ReprepareCause != "" {
+					itemMap["lastReprepare"] = entry.LastReprepare.String()
+					itemMap["lastReprepareReason"] = entry.ReprepareCause
+				}
 				if entry.Uses > 0 {
 					itemMap["lastUse"] = entry.LastUse.String()
 					itemMap["avgElapsedTime"] = (time.Duration(entry.RequestTime) /
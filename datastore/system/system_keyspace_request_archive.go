@@ -0,0 +1,292 @@
+//  Copyright (c) 2018 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package system
+
+import (
+	"encoding/json"
+
+	"github.com/couchbase/query/datastore"
+	"github.com/couchbase/query/errors"
+	"github.com/couchbase/query/expression"
+	"github.com/couchbase/query/expression/parser"
+	"github.com/couchbase/query/server"
+	"github.com/couchbase/query/timestamp"
+	"github.com/couchbase/query/value"
+)
+
+// the completed requests archive is kept by each node: this keyspace
+// only shows that of the node serving the request
+type requestArchiveKeyspace struct {
+	keyspaceBase
+	name    string
+	indexer datastore.Indexer
+}
+
+func (b *requestArchiveKeyspace) Release() {
+}
+
+func (b *requestArchiveKeyspace) NamespaceId() string {
+	return b.namespace.Id()
+}
+
+func (b *requestArchiveKeyspace) Id() string {
+	return b.Name()
+}
+
+func (b *requestArchiveKeyspace) Name() string {
+	return b.name
+}
+
+func (b *requestArchiveKeyspace) Count(context datastore.QueryContext) (int64, errors.Error) {
+	var count int64
+
+	files, err := server.RequestsArchiveFiles()
+	if err != nil {
+		return 0, err
+	}
+	for _, f := range files {
+		err = server.RequestsArchiveForeach(f, func(key string, data []byte) bool {
+			count++
+			return true
+		})
+		if err != nil {
+			context.Warning(err)
+		}
+	}
+	return count, nil
+}
+
+func (b *requestArchiveKeyspace) Indexer(name datastore.IndexType) (datastore.Indexer, errors.Error) {
+	return b.indexer, nil
+}
+
+func (b *requestArchiveKeyspace) Indexers() ([]datastore.Indexer, errors.Error) {
+	return []datastore.Indexer{b.indexer}, nil
+}
+
+func (b *requestArchiveKeyspace) Fetch(keys []string, context datastore.QueryContext, subPaths []string) ([]value.AnnotatedPair, []errors.Error) {
+	var errs []errors.Error
+	rv := make([]value.AnnotatedPair, 0, len(keys))
+
+	for _, key := range keys {
+		doc, err := server.RequestsArchiveEntry(key)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		meta := map[string]interface{}{
+			"id": key,
+		}
+		p, ok := doc["plan"]
+		if ok {
+			meta["plan"] = p
+			delete(doc, "plan")
+		}
+		item := value.NewAnnotatedValue(doc)
+		item.SetAttachment("meta", meta)
+		rv = append(rv, value.AnnotatedPair{
+			Name:  key,
+			Value: item,
+		})
+	}
+	return rv, errs
+}
+
+func (b *requestArchiveKeyspace) Insert(inserts []value.Pair) ([]value.Pair, errors.Error) {
+	return nil, errors.NewSystemNotImplementedError(nil, "")
+}
+
+func (b *requestArchiveKeyspace) Update(updates []value.Pair) ([]value.Pair, errors.Error) {
+	return nil, errors.NewSystemNotImplementedError(nil, "")
+}
+
+func (b *requestArchiveKeyspace) Upsert(upserts []value.Pair) ([]value.Pair, errors.Error) {
+	return nil, errors.NewSystemNotImplementedError(nil, "")
+}
+
+func (b *requestArchiveKeyspace) Delete(deletes []string, context datastore.QueryContext) ([]string, errors.Error) {
+	return nil, errors.NewSystemNotImplementedError(nil, "")
+}
+
+func newRequestArchiveKeyspace(p *namespace) (*requestArchiveKeyspace, errors.Error) {
+	b := new(requestArchiveKeyspace)
+	setKeyspaceBase(&b.keyspaceBase, p)
+	b.name = KEYSPACE_NAME_REQUEST_ARCHIVE
+
+	primary := &requestArchiveIndex{
+		name:     "#primary",
+		keyspace: b,
+		primary:  true,
+	}
+	b.indexer = newSystemIndexer(b, primary)
+	setIndexBase(&primary.indexBase, b.indexer)
+
+	// add a secondary index on `requestTime`, so that time ranges
+	// only read the files that cover them
+	expr, err := parser.Parse(`requestTime`)
+
+	if err == nil {
+		key := expression.Expressions{expr}
+		times := &requestArchiveIndex{
+			name:     "#requestTime",
+			keyspace: b,
+			primary:  false,
+			idxKey:   key,
+		}
+		setIndexBase(&times.indexBase, b.indexer)
+		b.indexer.(*systemIndexer).AddIndex(times.name, times)
+	} else {
+		return nil, errors.NewSystemDatastoreError(err, "")
+	}
+
+	return b, nil
+}
+
+type requestArchiveIndex struct {
+	indexBase
+	name     string
+	keyspace *requestArchiveKeyspace
+	primary  bool
+	idxKey   expression.Expressions
+}
+
+func (pi *requestArchiveIndex) KeyspaceId() string {
+	return pi.keyspace.Id()
+}
+
+func (pi *requestArchiveIndex) Id() string {
+	return pi.Name()
+}
+
+func (pi *requestArchiveIndex) Name() string {
+	return pi.name
+}
+
+func (pi *requestArchiveIndex) Type() datastore.IndexType {
+	return datastore.SYSTEM
+}
+
+func (pi *requestArchiveIndex) SeekKey() expression.Expressions {
+	return pi.idxKey
+}
+
+func (pi *requestArchiveIndex) RangeKey() expression.Expressions {
+	return pi.idxKey
+}
+
+func (pi *requestArchiveIndex) Condition() expression.Expression {
+	return nil
+}
+
+func (pi *requestArchiveIndex) IsPrimary() bool {
+	return pi.primary
+}
+
+func (pi *requestArchiveIndex) State() (state datastore.IndexState, msg string, err errors.Error) {
+	if pi.primary || server.RequestsArchiveEnabled() {
+		return datastore.ONLINE, "", nil
+	} else {
+		return datastore.OFFLINE, "", nil
+	}
+}
+
+func (pi *requestArchiveIndex) Statistics(requestId string, span *datastore.Span) (
+	datastore.Statistics, errors.Error) {
+	return nil, nil
+}
+
+func (pi *requestArchiveIndex) Drop(requestId string) errors.Error {
+	return errors.NewSystemIdxNoDropError(nil, "")
+}
+
+func (pi *requestArchiveIndex) Scan(requestId string, span *datastore.Span, distinct bool, limit int64,
+	cons datastore.ScanConsistency, vector timestamp.Vector, conn *datastore.IndexConnection) {
+
+	if span == nil || pi.primary {
+		pi.ScanEntries(requestId, limit, cons, vector, conn)
+		return
+	}
+
+	defer close(conn.EntryChannel())
+
+	spanEvaluator, err := compileSpan(span)
+	if err != nil {
+		conn.Error(err)
+		return
+	}
+	files, err := server.RequestsArchiveFiles()
+	if err != nil {
+		conn.Error(err)
+		return
+	}
+	for _, f := range files {
+
+		// skip the files that end before or start after the span
+		if f.First != "" {
+			if spanEvaluator.isEquals() {
+				if spanEvaluator.key() < f.First || spanEvaluator.key() > f.Last {
+					continue
+				}
+			} else if !spanEvaluator.evalLow(spanEvaluator.low, f.Last) ||
+				!spanEvaluator.evalHigh(spanEvaluator.high, f.First) {
+				continue
+			}
+		}
+
+		sent := true
+		err = server.RequestsArchiveForeach(f, func(key string, data []byte) bool {
+			var entry struct {
+				RequestTime string `json:"requestTime"`
+			}
+
+			if json.Unmarshal(data, &entry) != nil || !spanEvaluator.evaluate(entry.RequestTime) {
+				return true
+			}
+			indexEntry := datastore.IndexEntry{
+				PrimaryKey: key,
+				EntryKey:   value.Values{value.NewValue(entry.RequestTime)},
+			}
+			sent = sendSystemKey(conn, &indexEntry)
+			return sent
+		})
+		if err != nil {
+			conn.Warning(err)
+		}
+		if !sent {
+			return
+		}
+	}
+}
+
+func (pi *requestArchiveIndex) ScanEntries(requestId string, limit int64, cons datastore.ScanConsistency,
+	vector timestamp.Vector, conn *datastore.IndexConnection) {
+	defer close(conn.EntryChannel())
+
+	files, err := server.RequestsArchiveFiles()
+	if err != nil {
+		conn.Error(err)
+		return
+	}
+	for _, f := range files {
+		sent := true
+		err = server.RequestsArchiveForeach(f, func(key string, data []byte) bool {
+			indexEntry := datastore.IndexEntry{PrimaryKey: key}
+			sent = sendSystemKey(conn, &indexEntry)
+			return sent
+		})
+		if err != nil {
+			conn.Warning(err)
+		}
+		if !sent {
+			return
+		}
+	}
+}
@@ -89,6 +89,11 @@ func (b *requestLogKeyspace) Fetch(keys []string, context datastore.QueryContext
 						meta["plan"] = t
 						delete(doc, "timings")
 					}
+					e, ok := doc["explain"]
+					if ok {
+						meta["explain"] = e
+						delete(doc, "explain")
+					}
 					remoteValue := value.NewAnnotatedValue(doc)
 					remoteValue.SetField("node", node)
 					remoteValue.SetAttachment("meta", meta)
@@ -160,6 +165,9 @@ func (b *requestLogKeyspace) Fetch(keys []string, context datastore.QueryContext
 					bytes, _ := json.Marshal(entry.Timings)
 					meta["plan"] = bytes
 				}
+				if entry.Plan != nil && entry.Plan.Operator != nil {
+					meta["explain"] = entry.Plan.Operator
+				}
 				item.SetAttachment("meta", meta)
 				rv = append(rv, value.AnnotatedPair{
 					Name:  key,
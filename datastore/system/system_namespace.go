@@ -118,12 +118,24 @@ func (p *namespace) loadKeyspaces() (e errors.Error) {
 	}
 	p.keyspaces[preps.Name()] = preps
 
+	bases, e := newPlanBaselinesKeyspace(p)
+	if e != nil {
+		return e
+	}
+	p.keyspaces[bases.Name()] = bases
+
 	reqs, e := newRequestsKeyspace(p)
 	if e != nil {
 		return e
 	}
 	p.keyspaces[reqs.Name()] = reqs
 
+	archive, e := newRequestArchiveKeyspace(p)
+	if e != nil {
+		return e
+	}
+	p.keyspaces[archive.Name()] = archive
+
 	actives, e := newActiveRequestsKeyspace(p)
 	if e != nil {
 		return e
@@ -10,7 +10,11 @@
 package system
 
 import (
+	"io/ioutil"
 	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
 	"testing"
 	"time"
 
@@ -19,6 +23,8 @@ import (
 	"github.com/couchbase/query/datastore/mock"
 	"github.com/couchbase/query/distributed"
 	"github.com/couchbase/query/errors"
+	"github.com/couchbase/query/server"
+	"github.com/couchbase/query/value"
 )
 
 type queryContextImpl struct {
@@ -314,3 +320,170 @@ func TestCredsFromContext(t *testing.T) {
 	httpRequest.Header.Set("ns-server-ui", "yes")
 	doTestCredsFromContext(t, httpRequest, credentials, expectedCreds, "a122b9f4f6bd5608fd532990e3194e97")
 }
+
+// active requests that complete when cancelled, unless already gone
+type testActives map[string]bool
+
+func (this testActives) Put(server.Request) errors.Error {
+	return nil
+}
+
+func (this testActives) Get(string, func(server.Request)) errors.Error {
+	return nil
+}
+
+func (this testActives) Delete(id string, stop bool) bool {
+	found := this[id]
+	delete(this, id)
+	return found
+}
+
+func (this testActives) Count() (int, errors.Error) {
+	return len(this), nil
+}
+
+func (this testActives) ForEach(func(string, server.Request) bool, func() bool) {
+}
+
+func TestActiveRequestsDelete(t *testing.T) {
+	keyspace := &activeRequestsKeyspace{}
+	context := &queryContextImpl{t: t}
+
+	var tests = []struct {
+		deletes  []string
+		expected []string
+	}{
+		{[]string{"r1", "r2", "r3"}, []string{"r1", "r2", "r3"}},
+		{[]string{"r1", "gone", "r3"}, []string{"r1", "r3"}},
+		{[]string{"gone", "r2", "r3"}, []string{"r2", "r3"}},
+		{[]string{"r1", "r2", "gone"}, []string{"r1", "r2"}},
+		{[]string{"gone", "r2", "gone"}, []string{"r2"}},
+		{[]string{"gone"}, []string{}},
+	}
+
+	for _, test := range tests {
+		server.SetActives(testActives{"r1": true, "r2": true, "r3": true})
+		deleted, err := keyspace.Delete(test.deletes, context)
+		if err != nil || !reflect.DeepEqual(deleted, test.expected) {
+			t.Errorf("Expected deleting %v to delete %v, got %v, %v", test.deletes, test.expected, deleted, err)
+		}
+	}
+
+	// requests only complete once
+	actives := testActives{"r1": true}
+	server.SetActives(actives)
+	keyspace.Delete([]string{"r1"}, context)
+	deleted, _ := keyspace.Delete([]string{"r1"}, context)
+	if len(deleted) != 0 || len(actives) != 0 {
+		t.Errorf("Expected r1 to be deleted once, got %v", deleted)
+	}
+}
+
+func doArchiveScan(t *testing.T, index datastore.Index, span *datastore.Span) []string {
+	conn := datastore.NewIndexConnection(&testingContext{t})
+	go index.Scan("", span, false, 100, datastore.UNBOUNDED, nil, conn)
+
+	var keys []string
+	for entry := range conn.EntryChannel() {
+		keys = append(keys, entry.PrimaryKey)
+	}
+	return keys
+}
+
+func TestRequestArchive(t *testing.T) {
+	dir, err := ioutil.TempDir("", "archive")
+	if err != nil {
+		t.Fatalf("Cannot create directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	// the first file says it only covers January 1st, so its
+	// March entry is only found by scans that read it all
+	files := map[string][]string{
+		"completed_requests-20180101T000000.000000000_20180101T000000.000000000_20180102T000000.000000000.ndjson": {
+			"2018-01-01T12:00:00.000000000Z", "2018-03-01T12:00:00.000000000Z"},
+		"completed_requests-20180301T000000.000000000_20180301T000000.000000000_20180302T000000.000000000.ndjson": {
+			"2018-03-01T06:00:00.000000000Z", "2018-03-01T18:00:00.000000000Z"},
+	}
+	for name, times := range files {
+		var data []byte
+		for _, rt := range times {
+			data = append(data, `{"requestTime":"`+rt+`","statement":"SELECT 1"}`+"\n"...)
+		}
+		err = ioutil.WriteFile(filepath.Join(dir, name), data, 0600)
+		if err != nil {
+			t.Fatalf("Cannot write file: %v", err)
+		}
+	}
+	cErr := server.RequestsArchiveInit(dir, 1024*1024, time.Hour, 0)
+	if cErr != nil {
+		t.Fatalf("Cannot init archive: %v", cErr)
+	}
+
+	m, cErr := mock.NewDatastore("mock:")
+	if cErr != nil {
+		t.Fatalf("failed to create mock store: %v", cErr)
+	}
+	s, cErr := NewDatastore(m)
+	if cErr != nil {
+		t.Fatalf("failed to create system store: %v", cErr)
+	}
+	p, cErr := s.NamespaceByName("#system")
+	if cErr != nil {
+		t.Fatalf("failed to get system namespace: %v", cErr)
+	}
+	keyspace, cErr := p.KeyspaceByName(KEYSPACE_NAME_REQUEST_ARCHIVE)
+	if cErr != nil {
+		t.Fatalf("failed to get keyspace by name %v", cErr)
+	}
+	indexer, _ := keyspace.Indexer(datastore.SYSTEM)
+	index, cErr := indexer.IndexByName("#requestTime")
+	if cErr != nil {
+		t.Fatalf("failed to get index: %v", cErr)
+	}
+
+	count, cErr := keyspace.Count(datastore.NULL_QUERY_CONTEXT)
+	if cErr != nil || count != 4 {
+		t.Errorf("Expected 4 archived requests, got %v, %v", count, cErr)
+	}
+	keys, _ := doPrimaryIndexScan(t, keyspace)
+	if len(keys) != 4 {
+		t.Errorf("Expected a primary scan to find 4 archived requests, got %v", keys)
+	}
+
+	// keys are the file ids and the offsets of the entries
+	march := []string{"20180301T000000.000000000/0", "20180301T000000.000000000/72"}
+	vals, errs := keyspace.Fetch(march, datastore.NULL_QUERY_CONTEXT, nil)
+	if len(errs) != 0 || len(vals) != 2 {
+		t.Fatalf("Expected to fetch %v, got %v, %v", march, vals, errs)
+	}
+	requestTime, _ := vals[1].Value.Field("requestTime")
+	if requestTime.Actual() != "2018-03-01T18:00:00.000000000Z" {
+		t.Errorf("Expected %v to be the 18:00 request, got %v", march[1], vals[1].Value)
+	}
+
+	// time ranges skip the files that do not cover them
+	var tests = []struct {
+		span     *datastore.Span
+		expected []string
+	}{
+		{&datastore.Span{Range: datastore.Range{
+			Low:       value.Values{value.NewValue("2018-03-01T00:00:00.000000000Z")},
+			High:      value.Values{value.NewValue("2018-04-01T00:00:00.000000000Z")},
+			Inclusion: datastore.BOTH,
+		}}, march},
+		{&datastore.Span{Range: datastore.Range{
+			High:      value.Values{value.NewValue("2018-02-01T00:00:00.000000000Z")},
+			Inclusion: datastore.BOTH,
+		}}, []string{"20180101T000000.000000000/0"}},
+		{&datastore.Span{Seek: value.Values{value.NewValue("2018-03-01T12:00:00.000000000Z")}}, nil},
+		{&datastore.Span{Seek: value.Values{value.NewValue("2018-03-01T06:00:00.000000000Z")}}, march[:1]},
+	}
+
+	for _, test := range tests {
+		keys := doArchiveScan(t, index, test.span)
+		if !reflect.DeepEqual(keys, test.expected) {
+			t.Errorf("Expected span %v to find %v, got %v", test.span, test.expected, keys)
+		}
+	}
+}
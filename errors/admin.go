@@ -149,6 +149,11 @@ func NewCompletedQualifierInvalidArgument(what string, cond interface{}) Error {
 		InternalMsg: "Completed requests qualifier " + what + " cannot accept argument " + condString, InternalCaller: CallerN(1)}
 }
 
+func NewCompletedArchiveError(e error, msg string) Error {
+	return &err{level: EXCEPTION, ICode: 2230, IKey: "admin.accounting.completed_archive", ICause: e,
+		InternalMsg: "Completed requests archive: " + msg, InternalCaller: CallerN(1)}
+}
+
 func NewAdminBadServicePort(port string) Error {
 	return &err{level: EXCEPTION, ICode: 2210, IKey: "admin.clustering.bad_port",
 		InternalMsg: "Invalid service port: " + port, InternalCaller: CallerN(1)}
@@ -158,3 +163,18 @@ func NewAdminBodyError(e error) Error {
 	return &err{level: EXCEPTION, ICode: 2220, IKey: "admin.accounting.bad_body", ICause: e,
 		InternalMsg: "Error getting request body", InternalCaller: CallerN(1)}
 }
+
+func NewAdminConfigFileError(e error, file string) Error {
+	return &err{level: EXCEPTION, ICode: 2240, IKey: "admin.settings.config_file", ICause: e,
+		InternalMsg: "Error accessing configuration file " + file, InternalCaller: CallerN(1)}
+}
+
+func NewAdminHealthCheckExists(name string) Error {
+	return &err{level: EXCEPTION, ICode: 2250, IKey: "admin.accounting.health_check",
+		InternalMsg: "Health check already registered: " + name, InternalCaller: CallerN(1)}
+}
+
+func NewAdminHealthCheckNotFound(name string) Error {
+	return &err{level: EXCEPTION, ICode: 2260, IKey: "admin.accounting.health_check",
+		InternalMsg: "No such health check: " + name, InternalCaller: CallerN(1)}
+}
@@ -0,0 +1,69 @@
+//  Copyright (c) 2018 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package errors
+
+import ()
+
+// Datastore external file error codes
+
+func NewExternalDatastoreError(e error, msg string) Error {
+	return &err{level: EXCEPTION, ICode: 17300, IKey: "datastore.external.generic_external_error", ICause: e,
+		InternalMsg: "Error in external datastore " + msg, InternalCaller: CallerN(1)}
+}
+
+func NewExternalNamespaceNotFoundError(e error, msg string) Error {
+	return &err{level: EXCEPTION, ICode: 17301, IKey: "datastore.external.namespace_not_found", ICause: e,
+		InternalMsg: "Namespace not found " + msg, InternalCaller: CallerN(1)}
+}
+
+func NewExternalKeyspaceNotFoundError(e error, msg string) Error {
+	return &err{level: EXCEPTION, ICode: 17302, IKey: "datastore.external.keyspace_not_found", ICause: e,
+		InternalMsg: "Keyspace not found " + msg, InternalCaller: CallerN(1)}
+}
+
+func NewExternalKeyspaceExistsError(e error, msg string) Error {
+	return &err{level: EXCEPTION, ICode: 17303, IKey: "datastore.external.keyspace_exists", ICause: e,
+		InternalMsg: "Keyspace already exists " + msg, InternalCaller: CallerN(1)}
+}
+
+func NewExternalOptionError(e error, msg string) Error {
+	return &err{level: EXCEPTION, ICode: 17304, IKey: "datastore.external.invalid_option", ICause: e,
+		InternalMsg: "Invalid keyspace option " + msg, InternalCaller: CallerN(1)}
+}
+
+func NewExternalFileError(e error, msg string) Error {
+	return &err{level: EXCEPTION, ICode: 17305, IKey: "datastore.external.file_error", ICause: e,
+		InternalMsg: "Cannot read external file " + msg, InternalCaller: CallerN(1)}
+}
+
+func NewExternalReadOnlyError(e error, msg string) Error {
+	return &err{level: EXCEPTION, ICode: 17306, IKey: "datastore.external.read_only", ICause: e,
+		InternalMsg: "External keyspaces are read-only " + msg, InternalCaller: CallerN(1)}
+}
+
+func NewExternalIdxNotFound(e error, msg string) Error {
+	return &err{level: EXCEPTION, ICode: 17307, IKey: "datastore.external.idx_not_found", ICause: e,
+		InternalMsg: "Index not found " + msg, InternalCaller: CallerN(1)}
+}
+
+func NewExternalNotSupported(e error, msg string) Error {
+	return &err{level: EXCEPTION, ICode: 17308, IKey: "datastore.external.not_supported", ICause: e,
+		InternalMsg: msg, InternalCaller: CallerN(1)}
+}
+
+func NewExternalPrimaryIdxNoDropError(e error, msg string) Error {
+	return &err{level: EXCEPTION, ICode: 17309, IKey: "datastore.external.primary_idx_no_drop", ICause: e,
+		InternalMsg: "Primary Index cannot be dropped " + msg, InternalCaller: CallerN(1)}
+}
+
+func NewExternalIndexScanError(e error, msg string) Error {
+	return &err{level: EXCEPTION, ICode: 17310, IKey: "datastore.external.index_scan_error", ICause: e,
+		InternalMsg: "Error scanning external keyspace " + msg, InternalCaller: CallerN(1)}
+}
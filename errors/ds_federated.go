@@ -0,0 +1,29 @@
+//  Copyright (c) 2018 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package errors
+
+import ()
+
+// Federated datastore error codes
+
+func NewFederatedDatastoreError(e error, msg string) Error {
+	return &err{level: EXCEPTION, ICode: 17200, IKey: "datastore.federated.generic_federated_error", ICause: e,
+		InternalMsg: "Error in federated datastore " + msg, InternalCaller: CallerN(1)}
+}
+
+func NewFederatedNamespaceNotFoundError(e error, msg string) Error {
+	return &err{level: EXCEPTION, ICode: 17201, IKey: "datastore.federated.namespace_not_found", ICause: e,
+		InternalMsg: "Namespace not found " + msg, InternalCaller: CallerN(1)}
+}
+
+func NewFederatedDuplicateNameError(e error, msg string) Error {
+	return &err{level: EXCEPTION, ICode: 17202, IKey: "datastore.federated.duplicate_name", ICause: e,
+		InternalMsg: "Duplicate datastore name " + msg, InternalCaller: CallerN(1)}
+}
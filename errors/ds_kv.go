@@ -0,0 +1,79 @@
+//  Copyright (c) 2018 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package errors
+
+import ()
+
+// Datastore embedded key-value store error codes
+
+func NewKVDatastoreError(e error, msg string) Error {
+	return &err{level: EXCEPTION, ICode: 17000, IKey: "datastore.kv.generic_kv_error", ICause: e,
+		InternalMsg: "Error in kv datastore " + msg, InternalCaller: CallerN(1)}
+}
+
+func NewKVNamespaceNotFoundError(e error, msg string) Error {
+	return &err{level: EXCEPTION, ICode: 17001, IKey: "datastore.kv.namespace_not_found", ICause: e,
+		InternalMsg: "Namespace not found " + msg, InternalCaller: CallerN(1)}
+}
+
+func NewKVNoKeysInsertError(e error, msg string) Error {
+	return &err{level: EXCEPTION, ICode: 17002, IKey: "datastore.kv.no_keys_insert", ICause: e,
+		InternalMsg: "No keys to insert " + msg, InternalCaller: CallerN(1)}
+}
+
+func NewKVKeyExists(e error, msg string) Error {
+	return &err{level: EXCEPTION, ICode: 17003, IKey: "datastore.kv.key_exists", ICause: e,
+		InternalMsg: "Key Exists " + msg, InternalCaller: CallerN(1)}
+}
+
+func NewKVKeyNotFound(e error, msg string) Error {
+	return &err{level: EXCEPTION, ICode: 17004, IKey: "datastore.kv.key_not_found", ICause: e,
+		InternalMsg: "Key not found " + msg, InternalCaller: CallerN(1)}
+}
+
+func NewKVDMLError(e error, msg string) Error {
+	return &err{level: EXCEPTION, ICode: 17005, IKey: "datastore.kv.DML_error", ICause: e,
+		InternalMsg: "DML Error " + msg, InternalCaller: CallerN(1)}
+}
+
+func NewKVIdxNotFound(e error, msg string) Error {
+	return &err{level: EXCEPTION, ICode: 17006, IKey: "datastore.kv.idx_not_found", ICause: e,
+		InternalMsg: "Index not found " + msg, InternalCaller: CallerN(1)}
+}
+
+func NewKVIdxExists(e error, msg string) Error {
+	return &err{level: EXCEPTION, ICode: 17007, IKey: "datastore.kv.idx_exists", ICause: e,
+		InternalMsg: "Index already exists " + msg, InternalCaller: CallerN(1)}
+}
+
+func NewKVPrimaryIdxNoDropError(e error, msg string) Error {
+	return &err{level: EXCEPTION, ICode: 17008, IKey: "datastore.kv.primary_idx_no_drop", ICause: e,
+		InternalMsg: "Primary Index cannot be dropped " + msg, InternalCaller: CallerN(1)}
+}
+
+func NewKVIndexScanError(e error, msg string) Error {
+	return &err{level: EXCEPTION, ICode: 17009, IKey: "datastore.kv.index_scan_error", ICause: e,
+		InternalMsg: "Index scan failed " + msg, InternalCaller: CallerN(1)}
+}
+
+func NewKVKeyspaceNotFoundError(e error, msg string) Error {
+	return &err{level: EXCEPTION, ICode: 17010, IKey: "datastore.kv.keyspace_not_found", ICause: e,
+		InternalMsg: "Keyspace not found " + msg, InternalCaller: CallerN(1)}
+}
+
+func NewKVKeyspaceExistsError(e error, msg string) Error {
+	return &err{level: EXCEPTION, ICode: 17011, IKey: "datastore.kv.keyspace_exists", ICause: e,
+		InternalMsg: "Keyspace already exists " + msg, InternalCaller: CallerN(1)}
+}
+
+func NewKVKeyspaceOptionError(e error, msg string) Error {
+	return &err{level: EXCEPTION, ICode: 17012, IKey: "datastore.kv.invalid_option", ICause: e,
+		InternalMsg: "Invalid keyspace option " + msg, InternalCaller: CallerN(1)}
+}
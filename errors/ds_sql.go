@@ -0,0 +1,64 @@
+//  Copyright (c) 2018 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package errors
+
+import ()
+
+// Datastore SQL database error codes
+
+func NewSQLDatastoreError(e error, msg string) Error {
+	return &err{level: EXCEPTION, ICode: 17100, IKey: "datastore.sql.generic_sql_error", ICause: e,
+		InternalMsg: "Error in sql datastore " + msg, InternalCaller: CallerN(1)}
+}
+
+func NewSQLNamespaceNotFoundError(e error, msg string) Error {
+	return &err{level: EXCEPTION, ICode: 17101, IKey: "datastore.sql.namespace_not_found", ICause: e,
+		InternalMsg: "Namespace not found " + msg, InternalCaller: CallerN(1)}
+}
+
+func NewSQLNoKeysInsertError(e error, msg string) Error {
+	return &err{level: EXCEPTION, ICode: 17102, IKey: "datastore.sql.no_keys_insert", ICause: e,
+		InternalMsg: "No keys to insert " + msg, InternalCaller: CallerN(1)}
+}
+
+func NewSQLKeyExists(e error, msg string) Error {
+	return &err{level: EXCEPTION, ICode: 17103, IKey: "datastore.sql.key_exists", ICause: e,
+		InternalMsg: "Key Exists " + msg, InternalCaller: CallerN(1)}
+}
+
+func NewSQLKeyNotFound(e error, msg string) Error {
+	return &err{level: EXCEPTION, ICode: 17104, IKey: "datastore.sql.key_not_found", ICause: e,
+		InternalMsg: "Key not found " + msg, InternalCaller: CallerN(1)}
+}
+
+func NewSQLDMLError(e error, msg string) Error {
+	return &err{level: EXCEPTION, ICode: 17105, IKey: "datastore.sql.DML_error", ICause: e,
+		InternalMsg: "DML Error " + msg, InternalCaller: CallerN(1)}
+}
+
+func NewSQLIdxNotFound(e error, msg string) Error {
+	return &err{level: EXCEPTION, ICode: 17106, IKey: "datastore.sql.idx_not_found", ICause: e,
+		InternalMsg: "Index not found " + msg, InternalCaller: CallerN(1)}
+}
+
+func NewSQLNotSupported(e error, msg string) Error {
+	return &err{level: EXCEPTION, ICode: 17107, IKey: "datastore.sql.not_supported", ICause: e,
+		InternalMsg: msg, InternalCaller: CallerN(1)}
+}
+
+func NewSQLPrimaryIdxNoDropError(e error, msg string) Error {
+	return &err{level: EXCEPTION, ICode: 17108, IKey: "datastore.sql.primary_idx_no_drop", ICause: e,
+		InternalMsg: "Primary Index cannot be dropped " + msg, InternalCaller: CallerN(1)}
+}
+
+func NewSQLIndexScanError(e error, msg string) Error {
+	return &err{level: EXCEPTION, ICode: 17109, IKey: "datastore.sql.index_scan_error", ICause: e,
+		InternalMsg: "Index scan failed " + msg, InternalCaller: CallerN(1)}
+}
@@ -204,3 +204,21 @@ func NewHashTableGetError(e error) Error {
 		InternalMsg:    fmt.Sprintf("Hash Table Get failed"),
 		InternalCaller: CallerN(1)}
 }
+
+func NewCopyPathError(path string, e error) Error {
+	return &err{level: EXCEPTION, ICode: 5320, IKey: "execution.copy_path_error", ICause: e,
+		InternalMsg:    fmt.Sprintf("COPY file %s is not allowed", path),
+		InternalCaller: CallerN(1)}
+}
+
+func NewCopyFileError(path string, e error) Error {
+	return &err{level: EXCEPTION, ICode: 5330, IKey: "execution.copy_file_error", ICause: e,
+		InternalMsg:    fmt.Sprintf("COPY failed for file %s", path),
+		InternalCaller: CallerN(1)}
+}
+
+func NewCopyOptionError(msg string) Error {
+	return &err{level: EXCEPTION, ICode: 5340, IKey: "execution.copy_option_error",
+		InternalMsg:    "Invalid COPY option " + msg,
+		InternalCaller: CallerN(1)}
+}
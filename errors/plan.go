@@ -90,6 +90,20 @@ func NewEncodingNameMismatchError(name string) Error {
 		InternalMsg: fmt.Sprintf("Prepared name in encoded plan parameter is not %s", name), InternalCaller: CallerN(1)}
 }
 
+const PREPARED_PERSIST = 4091
+
+func NewPreparedPersistError(e error, msg string) Error {
+	return &err{level: EXCEPTION, ICode: PREPARED_PERSIST, IKey: "plan.build_prepared.persist",
+		ICause: e, InternalMsg: fmt.Sprintf("Unable to persist prepared statements: %s", msg), InternalCaller: CallerN(1)}
+}
+
+const NO_SUCH_BASELINE = 4092
+
+func NewNoSuchBaselineError(name string) Error {
+	return &err{level: EXCEPTION, ICode: NO_SUCH_BASELINE, IKey: "plan.build_prepared.no_such_baseline",
+		InternalMsg: fmt.Sprintf("No plan baseline for prepared statement: %s", name), InternalCaller: CallerN(1)}
+}
+
 const NO_INDEX_JOIN = 4100
 
 func NewNoIndexJoinError(alias, op string) Error {
@@ -16,6 +16,7 @@ package execution
 
 import (
 	"fmt"
+	"math"
 	"reflect"
 	"sync"
 	go_atomic "sync/atomic"
@@ -69,6 +70,7 @@ type base struct {
 	servTime       time.Duration
 	inDocs         int64
 	outDocs        int64
+	expected       int64
 	phaseSwitches  int64
 	stopped        bool
 	isRoot         bool
@@ -905,6 +907,23 @@ func (this *base) addOutDocs(d int64) {
 	go_atomic.AddInt64((*int64)(&this.outDocs), d)
 }
 
+// scans that know how many items they are going to produce
+// make it available for progress reporting
+func (this *base) setExpected(n int64) {
+	go_atomic.StoreInt64((*int64)(&this.expected), n)
+}
+
+// limits are an upper bound to what scans produce
+func (this *base) limitExpected(limit int64) {
+	if limit < 0 || limit == math.MaxInt64 {
+		return
+	}
+	expected := go_atomic.LoadInt64((*int64)(&this.expected))
+	if expected == 0 || limit < expected {
+		go_atomic.StoreInt64((*int64)(&this.expected), limit)
+	}
+}
+
 // profile marshaller
 func (this *base) marshalTimes(r map[string]interface{}) {
 	var d time.Duration
@@ -916,6 +935,9 @@ func (this *base) marshalTimes(r map[string]interface{}) {
 	if this.outDocs != 0 {
 		stats["#itemsOut"] = this.outDocs
 	}
+	if this.expected != 0 {
+		stats["#itemsExpected"] = this.expected
+	}
 	if this.phaseSwitches != 0 {
 		stats["#phaseSwitches"] = this.phaseSwitches
 	}
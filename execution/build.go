@@ -159,6 +159,10 @@ func (this *builder) VisitValueScan(plan *plan.ValueScan) (interface{}, error) {
 	return NewValueScan(plan, this.context), nil
 }
 
+func (this *builder) VisitFileScan(plan *plan.FileScan) (interface{}, error) {
+	return NewFileScan(plan, this.context), nil
+}
+
 func (this *builder) VisitDummyScan(plan *plan.DummyScan) (interface{}, error) {
 	return NewDummyScan(plan, this.context), nil
 }
@@ -458,6 +462,11 @@ func (this *builder) VisitMerge(plan *plan.Merge) (interface{}, error) {
 	return NewMerge(plan, this.context, update, delete, insert), nil
 }
 
+// Copy
+func (this *builder) VisitCopyTo(plan *plan.CopyTo) (interface{}, error) {
+	return NewCopyTo(plan, this.context), nil
+}
+
 // Alias
 func (this *builder) VisitAlias(plan *plan.Alias) (interface{}, error) {
 	return NewAlias(plan, this.context), nil
@@ -560,6 +569,16 @@ func (this *builder) VisitBuildIndexes(plan *plan.BuildIndexes) (interface{}, er
 	return NewBuildIndexes(plan, this.context), nil
 }
 
+// CreateKeyspace
+func (this *builder) VisitCreateKeyspace(plan *plan.CreateKeyspace) (interface{}, error) {
+	return NewCreateKeyspace(plan, this.context), nil
+}
+
+// DropKeyspace
+func (this *builder) VisitDropKeyspace(plan *plan.DropKeyspace) (interface{}, error) {
+	return NewDropKeyspace(plan, this.context), nil
+}
+
 // Prepare
 func (this *builder) VisitPrepare(plan *plan.Prepare) (interface{}, error) {
 	return NewPrepare(plan, this.context, plan.Prepared()), nil
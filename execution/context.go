@@ -144,6 +144,7 @@ type Context struct {
 	authenticatedUsers auth.AuthenticatedUsers
 	mutex              sync.RWMutex
 	whitelist          map[string]interface{}
+	copyWhitelist      map[string]interface{}
 }
 
 func NewContext(requestId string, datastore, systemstore datastore.Datastore,
@@ -205,6 +206,14 @@ func (this *Context) GetWhitelist() map[string]interface{} {
 	return this.whitelist
 }
 
+func (this *Context) SetCopyWhitelist(val map[string]interface{}) {
+	this.copyWhitelist = val
+}
+
+func (this *Context) GetCopyWhitelist() map[string]interface{} {
+	return this.copyWhitelist
+}
+
 func (this *Context) DatastoreVersion() string {
 	return this.datastore.Info().Version()
 }
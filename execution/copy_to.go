@@ -0,0 +1,220 @@
+//  Copyright (c) 2018 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package execution
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/couchbase/query/errors"
+	"github.com/couchbase/query/plan"
+	"github.com/couchbase/query/value"
+)
+
+// CopyTo writes the results of a query to a file. Results go to a
+// temporary file in the same directory, which replaces the target
+// only once all of them have been written.
+type CopyTo struct {
+	base
+	plan    *plan.CopyTo
+	options *copyOptions
+	path    string
+	file    *os.File
+	writer  *bufio.Writer
+	csv     *csv.Writer
+	columns []string
+	count   uint64
+	failed  bool
+}
+
+func NewCopyTo(plan *plan.CopyTo, context *Context) *CopyTo {
+	rv := &CopyTo{
+		plan: plan,
+	}
+
+	newBase(&rv.base, context)
+	rv.output = rv
+	return rv
+}
+
+func (this *CopyTo) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitCopyTo(this)
+}
+
+func (this *CopyTo) Copy() Operator {
+	rv := &CopyTo{
+		plan: this.plan,
+	}
+	this.base.copy(&rv.base)
+	return rv
+}
+
+func (this *CopyTo) RunOnce(context *Context, parent value.Value) {
+	this.runConsumer(this, context, parent)
+}
+
+func (this *CopyTo) beforeItems(context *Context, parent value.Value) bool {
+	file := this.plan.File()
+	options, err := newCopyOptions(file, this.plan.With(), true)
+	if err != nil {
+		context.Error(err)
+		return false
+	}
+
+	path, err := copyWhitelistCheck(context.GetCopyWhitelist(), file)
+	if err != nil {
+		context.Error(err)
+		return false
+	}
+
+	if _, e := os.Stat(path); e == nil && !options.overwrite {
+		context.Error(errors.NewCopyFileError(file, fmt.Errorf("file exists")))
+		return false
+	}
+
+	f, e := ioutil.TempFile(filepath.Dir(path), ".copy-")
+	if e != nil {
+		context.Error(errors.NewCopyFileError(file, e))
+		return false
+	}
+
+	this.options = options
+	this.path = path
+	this.file = f
+	this.writer = bufio.NewWriter(f)
+	if options.format == _COPY_CSV {
+		this.csv = csv.NewWriter(this.writer)
+		this.csv.Comma = options.delimiter
+	}
+	return true
+}
+
+func (this *CopyTo) processItem(item value.AnnotatedValue, context *Context) bool {
+	var e error
+	if this.csv != nil {
+		e = this.writeCSV(item)
+	} else {
+		var b []byte
+		b, e = json.Marshal(item)
+		if e == nil {
+			b = append(b, '\n')
+			_, e = this.writer.Write(b)
+		}
+	}
+
+	if e != nil {
+		this.failed = true
+		context.Error(errors.NewCopyFileError(this.plan.File(), e))
+		return false
+	}
+
+	this.count++
+	return true
+}
+
+// Columns are the fields of the first result, in sorted order
+func (this *CopyTo) writeCSV(item value.AnnotatedValue) error {
+	if item.Type() != value.OBJECT {
+		return fmt.Errorf("csv files require object results, not %v", item.Type())
+	}
+
+	if this.columns == nil {
+		fields := item.Fields()
+		this.columns = make([]string, 0, len(fields))
+		for name, _ := range fields {
+			this.columns = append(this.columns, name)
+		}
+		sort.Strings(this.columns)
+
+		if this.options.header {
+			if e := this.csv.Write(this.columns); e != nil {
+				return e
+			}
+		}
+	}
+
+	row := make([]string, len(this.columns))
+	for i, name := range this.columns {
+		v, ok := item.Field(name)
+		if !ok {
+			continue
+		}
+
+		switch v.Type() {
+		case value.NULL, value.MISSING:
+		case value.STRING:
+			row[i] = v.Actual().(string)
+		default:
+			b, e := json.Marshal(v)
+			if e != nil {
+				return e
+			}
+			row[i] = string(b)
+		}
+	}
+	return this.csv.Write(row)
+}
+
+func (this *CopyTo) afterItems(context *Context) {
+	if this.file == nil {
+		return
+	}
+
+	var e error
+	if this.csv != nil {
+		this.csv.Flush()
+		e = this.csv.Error()
+	}
+	if e == nil {
+		e = this.writer.Flush()
+	}
+	if e2 := this.file.Close(); e == nil {
+		e = e2
+	}
+
+	tmp := this.file.Name()
+	this.file = nil
+
+	if this.stopped || this.failed || e != nil {
+		if e != nil && !this.failed {
+			context.Error(errors.NewCopyFileError(this.plan.File(), e))
+		}
+		os.Remove(tmp)
+		return
+	}
+
+	if e = os.Chmod(tmp, 0644); e == nil {
+		e = os.Rename(tmp, this.path)
+	}
+	if e != nil {
+		os.Remove(tmp)
+		context.Error(errors.NewCopyFileError(this.plan.File(), e))
+		return
+	}
+
+	context.AddMutationCount(this.count)
+}
+
+func (this *CopyTo) readonly() bool {
+	return false
+}
+
+func (this *CopyTo) MarshalJSON() ([]byte, error) {
+	r := this.plan.MarshalBase(func(r map[string]interface{}) {
+		this.marshalTimes(r)
+	})
+	return json.Marshal(r)
+}
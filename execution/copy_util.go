@@ -0,0 +1,170 @@
+//  Copyright (c) 2018 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package execution
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/couchbase/query/errors"
+	"github.com/couchbase/query/value"
+)
+
+const (
+	_COPY_NDJSON = "ndjson"
+	_COPY_CSV    = "csv"
+)
+
+// Options of the WITH clause of COPY statements
+type copyOptions struct {
+	format    string
+	header    bool
+	delimiter rune
+	overwrite bool
+}
+
+// The format defaults to the file extension, .csv or else ndjson.
+// Files are only read as ndjson.
+func newCopyOptions(file string, with value.Value, write bool) (*copyOptions, errors.Error) {
+	rv := &copyOptions{
+		format:    _COPY_NDJSON,
+		header:    true,
+		delimiter: ',',
+	}
+
+	if strings.EqualFold(filepath.Ext(file), ".csv") {
+		rv.format = _COPY_CSV
+	}
+
+	if with != nil {
+		if with.Type() != value.OBJECT {
+			return nil, errors.NewCopyOptionError("WITH must be an object")
+		}
+
+		for name, val := range with.Fields() {
+			v := value.NewValue(val)
+			switch name {
+			case "format":
+				s, _ := v.Actual().(string)
+				s = strings.ToLower(s)
+				if s != _COPY_NDJSON && s != _COPY_CSV {
+					return nil, errors.NewCopyOptionError(fmt.Sprintf("format %v: expected ndjson or csv", v))
+				}
+				rv.format = s
+			case "header", "overwrite":
+				if !write || v.Type() != value.BOOLEAN {
+					return nil, errors.NewCopyOptionError(name)
+				}
+				if name == "header" {
+					rv.header = v.Truth()
+				} else {
+					rv.overwrite = v.Truth()
+				}
+			case "delimiter":
+				s, _ := v.Actual().(string)
+				if !write || utf8.RuneCountInString(s) != 1 {
+					return nil, errors.NewCopyOptionError("delimiter must be a single character")
+				}
+				rv.delimiter, _ = utf8.DecodeRuneInString(s)
+			default:
+				return nil, errors.NewCopyOptionError(name)
+			}
+		}
+	}
+
+	if !write && rv.format != _COPY_NDJSON {
+		return nil, errors.NewCopyOptionError("COPY FROM only reads ndjson files")
+	}
+
+	return rv, nil
+}
+
+// Check a COPY file against the whitelist, and return its actual path.
+// The whitelist has the structure of the CURL() whitelist:
+// {
+//  "all_access": true/false,
+//  "allowed_paths": [ list of directories ],
+//  "disallowed_paths": [ list of directories ],
+// }
+// Symbolic links are resolved before checking, and files must be
+// given by their absolute path.
+func copyWhitelistCheck(list map[string]interface{}, file string) (string, errors.Error) {
+	if !filepath.IsAbs(file) {
+		return "", errors.NewCopyPathError(file, fmt.Errorf("COPY files must be absolute paths."))
+	}
+
+	dir, er := filepath.EvalSymlinks(filepath.Dir(filepath.Clean(file)))
+	if er != nil {
+		return "", errors.NewCopyFileError(file, er)
+	}
+
+	path := filepath.Join(dir, filepath.Base(file))
+	if real, er := filepath.EvalSymlinks(path); er == nil {
+		path = real
+	}
+
+	if len(list) == 0 {
+		return "", errors.NewCopyPathError(file, fmt.Errorf("Whitelist for COPY is empty. COPY directories should be whitelisted."))
+	}
+
+	allaccess, ok := list["all_access"].(bool)
+	if !ok {
+		return "", errors.NewCopyPathError(file, fmt.Errorf("Boolean field all_access does not exist in COPY whitelist. It is mandatory"))
+	}
+
+	if allaccess {
+		return path, nil
+	}
+
+	disallowed, er := pathsContain(list["disallowed_paths"], path)
+	if er != nil || disallowed {
+		return "", errors.NewCopyPathError(file, er)
+	}
+
+	allowed, er := pathsContain(list["allowed_paths"], path)
+	if er != nil || !allowed {
+		return "", errors.NewCopyPathError(file, er)
+	}
+
+	return path, nil
+}
+
+// Check if a list of directories contains a path
+func pathsContain(field interface{}, path string) (bool, error) {
+	if field == nil {
+		return false, nil
+	}
+
+	dirs, ok := field.([]interface{})
+	if !ok {
+		return false, fmt.Errorf("allowed_paths and disallowed_paths should be lists of directories.")
+	}
+
+	for _, d := range dirs {
+		dir, ok := d.(string)
+		if !ok || !filepath.IsAbs(dir) {
+			return false, fmt.Errorf("allowed_paths and disallowed_paths should be lists of absolute directories.")
+		}
+
+		dir = filepath.Clean(dir)
+		if real, er := filepath.EvalSymlinks(dir); er == nil {
+			dir = real
+		}
+
+		if path == dir || strings.HasPrefix(path, dir+string(os.PathSeparator)) ||
+			dir == string(os.PathSeparator) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
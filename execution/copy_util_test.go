@@ -0,0 +1,83 @@
+//  Copyright (c) 2018 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package execution
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/couchbase/query/value"
+)
+
+func TestCopyWhitelist(t *testing.T) {
+	dir, err := ioutil.TempDir("", "copy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	dir, _ = filepath.EvalSymlinks(dir)
+
+	allowed := filepath.Join(dir, "allowed")
+	private := filepath.Join(allowed, "private")
+	other := filepath.Join(dir, "allowedother")
+	for _, d := range []string{private, other} {
+		os.MkdirAll(d, 0755)
+	}
+	os.Symlink(private, filepath.Join(allowed, "link"))
+
+	list := map[string]interface{}{
+		"all_access":       false,
+		"allowed_paths":    []interface{}{allowed},
+		"disallowed_paths": []interface{}{private},
+	}
+
+	cases := []struct {
+		file string
+		ok   bool
+	}{
+		{filepath.Join(allowed, "a.json"), true},
+		{filepath.Join(allowed, "..", "a.json"), false},
+		{filepath.Join(other, "a.json"), false},
+		{filepath.Join(private, "a.json"), false},
+		{filepath.Join(allowed, "link", "a.json"), false},
+		{"a.json", false},
+	}
+
+	for _, c := range cases {
+		_, err := copyWhitelistCheck(list, c.file)
+		if (err == nil) != c.ok {
+			t.Errorf("file %s: expected allowed %v, got %v", c.file, c.ok, err)
+		}
+	}
+
+	if _, err := copyWhitelistCheck(nil, filepath.Join(allowed, "a.json")); err == nil {
+		t.Errorf("empty whitelist should not allow access")
+	}
+}
+
+func TestCopyOptions(t *testing.T) {
+	opts, err := newCopyOptions("/tmp/out.csv", nil, true)
+	if err != nil || opts.format != _COPY_CSV || !opts.header {
+		t.Errorf("unexpected options %v %v", opts, err)
+	}
+
+	with := value.NewValue(map[string]interface{}{"format": "csv"})
+	if _, err = newCopyOptions("/tmp/in.json", with, false); err == nil {
+		t.Errorf("COPY FROM should only accept ndjson")
+	}
+
+	with = value.NewValue(map[string]interface{}{"overwrite": true, "delimiter": ";"})
+	opts, err = newCopyOptions("/tmp/out.csv", with, true)
+	if err != nil || !opts.overwrite || opts.delimiter != ';' {
+		t.Errorf("unexpected options %v %v", opts, err)
+	}
+}
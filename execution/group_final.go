@@ -54,14 +54,10 @@ func (this *FinalGroup) RunOnce(context *Context, parent value.Value) {
 
 func (this *FinalGroup) processItem(item value.AnnotatedValue, context *Context) bool {
 	// Generate the group key
-	var gk string
-	if len(this.plan.Keys()) > 0 {
-		var e error
-		gk, e = groupKey(item, this.plan.Keys(), context)
-		if e != nil {
-			context.Fatal(errors.NewEvaluationError(e, "GROUP key"))
-			return false
-		}
+	gk, e := groupItemKey(item, this.plan.Keys(), this.plan.Sets(), context)
+	if e != nil {
+		context.Fatal(errors.NewEvaluationError(e, "GROUP key"))
+		return false
 	}
 
 	// Get or seed the group value
@@ -105,18 +101,41 @@ func (this *FinalGroup) afterItems(context *Context) {
 	}
 
 	// Mo matching inputs, so send default values
-	if len(this.plan.Keys()) == 0 && len(this.groups) == 0 {
-		av := value.NewAnnotatedValue(nil)
-		aggregates := make(map[string]value.Value, len(this.plan.Aggregates()))
-		av.SetAttachment("aggregates", aggregates)
-		for _, agg := range this.plan.Aggregates() {
-			aggregates[agg.String()] = agg.Default()
+	if len(this.plan.Keys()) == 0 && len(this.plan.Sets()) == 0 && len(this.groups) == 0 {
+		this.sendItem(this.defaultGroup())
+	}
+
+	// Empty grouping sets, i.e. grand totals, have a group even
+	// without matching inputs
+	for i, set := range this.plan.Sets() {
+		if len(set) > 0 {
+			continue
+		}
+
+		gk, _ := groupingSetKey(nil, this.plan.Keys(), set, i, context)
+		if this.groups[gk] != nil {
+			continue
 		}
 
-		this.sendItem(av)
+		av := this.defaultGroup()
+		setGroupingSet(av, this.plan.Keys(), set, i)
+		if !this.sendItem(av) {
+			return
+		}
 	}
 }
 
+func (this *FinalGroup) defaultGroup() value.AnnotatedValue {
+	av := value.NewAnnotatedValue(nil)
+	aggregates := make(map[string]value.Value, len(this.plan.Aggregates()))
+	av.SetAttachment("aggregates", aggregates)
+	for _, agg := range this.plan.Aggregates() {
+		aggregates[agg.String()] = agg.Default()
+	}
+
+	return av
+}
+
 func (this *FinalGroup) MarshalJSON() ([]byte, error) {
 	r := this.plan.MarshalBase(func(r map[string]interface{}) {
 		this.marshalTimes(r)
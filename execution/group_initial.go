@@ -54,6 +54,31 @@ func (this *InitialGroup) RunOnce(context *Context, parent value.Value) {
 }
 
 func (this *InitialGroup) processItem(item value.AnnotatedValue, context *Context) bool {
+	// Aggregate the item into every grouping set in one pass
+	if sets := this.plan.Sets(); len(sets) > 0 {
+		for i, set := range sets {
+			gk, e := groupingSetKey(item, this.plan.Keys(), set, i, context)
+			if e != nil {
+				context.Fatal(errors.NewEvaluationError(e, "GROUP key"))
+				return false
+			}
+
+			// Each grouping set seeds its own copy of the item
+			gv := this.groups[gk]
+			if gv == nil {
+				gv = item.Copy().(value.AnnotatedValue)
+				setGroupingSet(gv, this.plan.Keys(), set, i)
+				this.seedGroup(gk, gv)
+			}
+
+			if !this.cumulateGroup(gv, item, context) {
+				return false
+			}
+		}
+
+		return true
+	}
+
 	// Generate the group key
 	var gk string
 	if len(this.plan.Keys()) > 0 {
@@ -69,15 +94,23 @@ func (this *InitialGroup) processItem(item value.AnnotatedValue, context *Contex
 	gv := this.groups[gk]
 	if gv == nil {
 		gv = item
-		this.groups[gk] = gv
+		this.seedGroup(gk, gv)
+	}
 
-		aggregates := make(map[string]value.Value, len(this.plan.Aggregates()))
-		gv.SetAttachment("aggregates", aggregates)
-		for _, agg := range this.plan.Aggregates() {
-			aggregates[agg.String()] = agg.Default()
-		}
+	return this.cumulateGroup(gv, item, context)
+}
+
+func (this *InitialGroup) seedGroup(gk string, gv value.AnnotatedValue) {
+	this.groups[gk] = gv
+
+	aggregates := make(map[string]value.Value, len(this.plan.Aggregates()))
+	gv.SetAttachment("aggregates", aggregates)
+	for _, agg := range this.plan.Aggregates() {
+		aggregates[agg.String()] = agg.Default()
 	}
+}
 
+func (this *InitialGroup) cumulateGroup(gv, item value.AnnotatedValue, context *Context) bool {
 	// Cumulate aggregates
 	aggregates, ok := gv.GetAttachment("aggregates").(map[string]value.Value)
 	if !ok {
@@ -87,6 +120,19 @@ func (this *InitialGroup) processItem(item value.AnnotatedValue, context *Contex
 	}
 
 	for _, agg := range this.plan.Aggregates() {
+		// Only aggregate items satisfying the FILTER clause
+		if filter := agg.Filter(); filter != nil {
+			fv, e := filter.Evaluate(item, context)
+			if e != nil {
+				context.Fatal(errors.NewEvaluationError(e, "aggregate FILTER"))
+				return false
+			}
+
+			if !fv.Truth() {
+				continue
+			}
+		}
+
 		v, e := agg.CumulateInitial(item, aggregates[agg.String()], context)
 		if e != nil {
 			context.Fatal(errors.NewGroupUpdateError(e, "Error updating initial GROUP value."))
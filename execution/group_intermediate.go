@@ -55,14 +55,10 @@ func (this *IntermediateGroup) RunOnce(context *Context, parent value.Value) {
 
 func (this *IntermediateGroup) processItem(item value.AnnotatedValue, context *Context) bool {
 	// Generate the group key
-	var gk string
-	if len(this.plan.Keys()) > 0 {
-		var e error
-		gk, e = groupKey(item, this.plan.Keys(), context)
-		if e != nil {
-			context.Fatal(errors.NewEvaluationError(e, "GROUP key"))
-			return false
-		}
+	gk, e := groupItemKey(item, this.plan.Keys(), this.plan.Sets(), context)
+	if e != nil {
+		context.Fatal(errors.NewEvaluationError(e, "GROUP key"))
+		return false
 	}
 
 	// Get or seed the group value
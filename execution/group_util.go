@@ -10,6 +10,8 @@
 package execution
 
 import (
+	"fmt"
+
 	"github.com/couchbase/query/expression"
 	"github.com/couchbase/query/util"
 	"github.com/couchbase/query/value"
@@ -34,4 +36,60 @@ func groupKey(item value.Value, keys expression.Expressions, context *Context) (
 	return string(bytes), nil
 }
 
+// Group key of an item within one of several grouping sets. Only
+// the keys of the grouping set are evaluated, and the grouping set
+// itself is part of the group key to keep its groups apart.
+func groupingSetKey(item value.Value, keys expression.Expressions, set []int, index int,
+	context *Context) (string, error) {
+	kvs := _GROUP_KEY_POOL.GetCapped(len(set) + 1)
+	defer _GROUP_KEY_POOL.Put(kvs)
+
+	for _, i := range set {
+		k, e := keys[i].Evaluate(item, context)
+		if e != nil {
+			return "", e
+		}
+
+		if k.Type() != value.MISSING {
+			kvs[string(rune(i))] = k
+		}
+	}
+
+	kvs["grouping_set"] = index
+	bytes, _ := value.NewValue(kvs).MarshalJSON()
+	return string(bytes), nil
+}
+
+// Group key of a partially aggregated item, which carries its
+// grouping set, if any, as an attachment.
+func groupItemKey(item value.AnnotatedValue, keys expression.Expressions, sets [][]int,
+	context *Context) (string, error) {
+	if len(sets) == 0 {
+		if len(keys) == 0 {
+			return "", nil
+		}
+
+		return groupKey(item, keys, context)
+	}
+
+	index, ok := item.GetAttachment("grouping_set").(int)
+	if !ok || index < 0 || index >= len(sets) {
+		return "", fmt.Errorf("Invalid or missing grouping set %v.", item.GetAttachment("grouping_set"))
+	}
+
+	return groupingSetKey(item, keys, sets[index], index, context)
+}
+
+// Attach the grouping set to a group. GROUPING() looks up the keys
+// of the grouping set, and further grouping looks up its index.
+func setGroupingSet(gv value.AnnotatedValue, keys expression.Expressions, set []int, index int) {
+	grouping := make(map[string]bool, len(set))
+	for _, i := range set {
+		grouping[keys[i].String()] = true
+	}
+
+	gv.SetAttachment("grouping", grouping)
+	gv.SetAttachment("grouping_set", index)
+}
+
 var _GROUP_KEY_POOL = util.NewStringInterfacePool(16)
@@ -0,0 +1,102 @@
+//  Copyright (c) 2018 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package execution
+
+import (
+	"encoding/json"
+	"sort"
+)
+
+// Progress reports how far along a running operator tree is.
+// We go through the same marshalling used for profiles, rather than visiting
+// the operators, so that parallel copies get added up in the same way.
+func Progress(op Operator) (map[string]interface{}, error) {
+	var tree interface{}
+
+	bytes, err := json.Marshal(op)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(bytes, &tree)
+	if err != nil {
+		return nil, err
+	}
+	return treeProgress(tree), nil
+}
+
+// the overall percentage complete is based on those operators that
+// know how many items they are going to produce, in practice scans:
+// downstream operators follow close behind, as pipelines are bounded
+func treeProgress(tree interface{}) map[string]interface{} {
+	var done, expected float64
+
+	operators := []interface{}{}
+	walkProgress(tree, func(op map[string]interface{}, stats map[string]interface{}) {
+		entry := map[string]interface{}{
+			"#operator": op["#operator"],
+		}
+		itemsIn, _ := stats["#itemsIn"].(float64)
+		itemsOut, _ := stats["#itemsOut"].(float64)
+		itemsExpected, _ := stats["#itemsExpected"].(float64)
+		entry["itemsIn"] = itemsIn
+		entry["itemsOut"] = itemsOut
+		state, ok := stats["state"]
+		if ok {
+			entry["state"] = state
+		}
+		if itemsExpected > 0 {
+			if itemsOut > itemsExpected {
+				itemsOut = itemsExpected
+			}
+			entry["itemsExpected"] = itemsExpected
+			entry["percentComplete"] = 100 * itemsOut / itemsExpected
+			done += itemsOut
+			expected += itemsExpected
+		}
+		operators = append(operators, entry)
+	})
+
+	rv := map[string]interface{}{
+		"operators": operators,
+	}
+	if expected > 0 {
+		rv["percentComplete"] = 100 * done / expected
+	}
+	return rv
+}
+
+// operators nest in fields like "~child", "~children" or "scans",
+// so we just descend anything that is not the operator's stats
+func walkProgress(tree interface{}, f func(map[string]interface{}, map[string]interface{})) {
+	switch tree := tree.(type) {
+	case map[string]interface{}:
+		_, isOp := tree["#operator"]
+		if isOp {
+			stats, _ := tree["#stats"].(map[string]interface{})
+			f(tree, stats)
+		}
+
+		// keep the operators in a consistent order across calls
+		keys := make([]string, 0, len(tree))
+		for k := range tree {
+			if k != "#stats" {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			walkProgress(tree[k], f)
+		}
+	case []interface{}:
+		for _, v := range tree {
+			walkProgress(v, f)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+//  Copyright (c) 2018 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package execution
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestProgress(t *testing.T) {
+	var tree interface{}
+
+	profile := `{"#operator": "Sequence", "#stats": {"state": "running"}, "~children": [
+		{"#operator": "PrimaryScan", "#stats": {"#itemsOut": 250, "#itemsExpected": 1000}},
+		{"#operator": "Parallel", "~child": {"#operator": "Sequence", "~children": [
+			{"#operator": "Fetch", "#stats": {"#itemsIn": 200, "#itemsOut": 200}},
+			{"#operator": "Filter", "#stats": {"#itemsIn": 190, "#itemsOut": 10}}]}},
+		{"#operator": "KeyScan", "#stats": {"#itemsOut": 30, "#itemsExpected": 20}}]}`
+	err := json.Unmarshal([]byte(profile), &tree)
+	if err != nil {
+		t.Fatalf("Cannot unmarshal profile: %v", err)
+	}
+
+	progress := treeProgress(tree)
+	operators := progress["operators"].([]interface{})
+	if len(operators) != 7 {
+		t.Fatalf("Expected 7 operators, got %v", len(operators))
+	}
+	scan := operators[1].(map[string]interface{})
+	if scan["#operator"] != "PrimaryScan" || scan["percentComplete"] != 25.0 {
+		t.Errorf("Expected the primary scan 25%% complete, got %v", scan)
+	}
+	filter := operators[5].(map[string]interface{})
+	if filter["#operator"] != "Filter" || filter["itemsIn"] != 190.0 || filter["percentComplete"] != nil {
+		t.Errorf("Expected the filter with no estimate, got %v", filter)
+	}
+
+	// scans that overrun their estimate count as complete
+	if progress["percentComplete"] != 100*270/1020.0 {
+		t.Errorf("Expected %v overall, got %v", 100*270/1020.0, progress["percentComplete"])
+	}
+}
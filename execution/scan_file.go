@@ -0,0 +1,120 @@
+//  Copyright (c) 2018 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package execution
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/couchbase/query/errors"
+	"github.com/couchbase/query/plan"
+	"github.com/couchbase/query/value"
+)
+
+// Longest document accepted by COPY FROM
+const _COPY_MAX_LINE = 20 * 1024 * 1024
+
+// FileScan reads the documents of a COPY FROM file, one per line.
+type FileScan struct {
+	base
+	plan *plan.FileScan
+}
+
+func NewFileScan(plan *plan.FileScan, context *Context) *FileScan {
+	rv := &FileScan{
+		plan: plan,
+	}
+
+	newBase(&rv.base, context)
+	rv.output = rv
+	return rv
+}
+
+func (this *FileScan) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitFileScan(this)
+}
+
+func (this *FileScan) Copy() Operator {
+	rv := &FileScan{plan: this.plan}
+	this.base.copy(&rv.base)
+	return rv
+}
+
+func (this *FileScan) RunOnce(context *Context, parent value.Value) {
+	this.once.Do(func() {
+		defer context.Recover() // Recover from any panic
+		this.active()
+		defer this.close(context)
+		this.switchPhase(_EXECTIME)
+		defer this.switchPhase(_NOTIME)
+		defer this.notify() // Notify that I have stopped
+
+		file := this.plan.File()
+		_, err := newCopyOptions(file, this.plan.With(), false)
+		if err != nil {
+			context.Error(err)
+			return
+		}
+
+		path, err := copyWhitelistCheck(context.GetCopyWhitelist(), file)
+		if err != nil {
+			context.Error(err)
+			return
+		}
+
+		f, e := os.Open(path)
+		if e != nil {
+			context.Error(errors.NewCopyFileError(file, e))
+			return
+		}
+		defer f.Close()
+
+		scanner := bufio.NewScanner(f)
+		scanner.Buffer(make([]byte, 64*1024), _COPY_MAX_LINE)
+		alias := this.plan.Alias()
+		line := 0
+
+		for scanner.Scan() {
+			line++
+			b := bytes.TrimSpace(scanner.Bytes())
+			if len(b) == 0 {
+				continue
+			}
+
+			if !json.Valid(b) {
+				context.Error(errors.NewCopyFileError(file, fmt.Errorf("invalid JSON on line %d", line)))
+				return
+			}
+
+			doc := make([]byte, len(b))
+			copy(doc, b)
+
+			av := value.NewAnnotatedValue(map[string]interface{}{})
+			av.SetField(alias, value.NewValue(doc))
+			if !this.sendItem(av) {
+				return
+			}
+		}
+
+		if e = scanner.Err(); e != nil {
+			context.Error(errors.NewCopyFileError(file, e))
+		}
+	})
+}
+
+func (this *FileScan) MarshalJSON() ([]byte, error) {
+	r := this.plan.MarshalBase(func(r map[string]interface{}) {
+		this.marshalTimes(r)
+	})
+	return json.Marshal(r)
+}
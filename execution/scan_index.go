@@ -249,6 +249,7 @@ func (this *spanScan) scan(context *Context, conn *datastore.IndexConnection, pa
 	}
 
 	limit := evalLimitOffset(this.plan.Limit(), nil, math.MaxInt64, this.plan.Covering(), context)
+	this.limitExpected(limit)
 
 	keyspaceTerm := this.plan.Term()
 	scanVector := context.ScanVectorSource().ScanVector(keyspaceTerm.Namespace(), keyspaceTerm.Keyspace())
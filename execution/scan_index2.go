@@ -161,6 +161,7 @@ func (this *IndexScan2) scan(context *Context, conn *datastore.IndexConnection,
 
 	offset := evalLimitOffset(this.plan.Offset(), nil, int64(0), this.plan.Covering(), context)
 	limit := evalLimitOffset(this.plan.Limit(), nil, math.MaxInt64, this.plan.Covering(), context)
+	this.limitExpected(limit)
 
 	var indexProjection *datastore.IndexProjection
 
@@ -163,6 +163,7 @@ func (this *IndexScan3) scan(context *Context, conn *datastore.IndexConnection,
 
 	offset := evalLimitOffset(this.plan.Offset(), nil, int64(0), this.plan.Covering(), context)
 	limit := evalLimitOffset(this.plan.Limit(), nil, math.MaxInt64, this.plan.Covering(), context)
+	this.limitExpected(limit)
 	scanVector := context.ScanVectorSource().ScanVector(plan.Term().Namespace(), plan.Term().Keyspace())
 
 	indexProjection, indexOrder, indexGroupAggs := planToScanMapping(plan.Index(), plan.Projection(),
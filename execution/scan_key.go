@@ -68,6 +68,7 @@ func (this *KeyScan) RunOnce(context *Context, parent value.Value) {
 		}
 
 		acts := actuals.([]interface{})
+		this.setExpected(int64(len(acts)))
 
 		for _, key := range acts {
 			cv := value.NewScopeValue(make(map[string]interface{}), parent)
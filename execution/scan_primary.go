@@ -178,6 +178,7 @@ func (this *PrimaryScan) scanEntries(context *Context, conn *datastore.IndexConn
 			limit = int64(lv.Actual().(float64))
 		}
 	}
+	this.limitExpected(limit)
 
 	keyspace := this.plan.Keyspace()
 	scanVector := context.ScanVectorSource().ScanVector(keyspace.NamespaceId(), keyspace.Name())
@@ -208,6 +209,7 @@ func (this *PrimaryScan) newIndexConnection(context *Context) *datastore.IndexCo
 	keyspace := this.plan.Keyspace()
 	size, err := keyspace.Count(context)
 	if err == nil {
+		this.setExpected(size)
 		if size <= 0 {
 			size = 1
 		}
@@ -176,6 +176,7 @@ func (this *PrimaryScan3) scanEntries(context *Context, conn *datastore.IndexCon
 	scanVector := context.ScanVectorSource().ScanVector(keyspace.NamespaceId(), keyspace.Name())
 	offset := evalLimitOffset(this.plan.Offset(), nil, int64(0), false, context)
 	limit := evalLimitOffset(this.plan.Limit(), nil, math.MaxInt64, false, context)
+	this.limitExpected(limit)
 	indexProjection, indexOrder, indexGroupAggs := planToScanMapping(index, this.plan.Projection(),
 		this.plan.OrderTerms(), this.plan.GroupAggs(), nil)
 
@@ -204,6 +205,7 @@ func (this *PrimaryScan3) newIndexConnection(context *Context) *datastore.IndexC
 	keyspace := this.plan.Keyspace()
 	size, err := keyspace.Count(context)
 	if err == nil {
+		this.setExpected(size)
 		if size <= 0 {
 			size = 1
 		}
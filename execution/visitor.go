@@ -19,6 +19,7 @@ type Visitor interface {
 	VisitIndexScan3(op *IndexScan3) (interface{}, error)
 	VisitKeyScan(op *KeyScan) (interface{}, error)
 	VisitValueScan(op *ValueScan) (interface{}, error)
+	VisitFileScan(op *FileScan) (interface{}, error)
 	VisitDummyScan(op *DummyScan) (interface{}, error)
 	VisitCountScan(op *CountScan) (interface{}, error)
 	VisitIndexCountScan(op *IndexCountScan) (interface{}, error)
@@ -94,6 +95,9 @@ type Visitor interface {
 	// Merge
 	VisitMerge(op *Merge) (interface{}, error)
 
+	// Copy
+	VisitCopyTo(op *CopyTo) (interface{}, error)
+
 	// Framework
 	VisitAlias(op *Alias) (interface{}, error)
 	VisitAuthorize(op *Authorize) (interface{}, error)
@@ -111,6 +115,10 @@ type Visitor interface {
 	VisitAlterIndex(op *AlterIndex) (interface{}, error)
 	VisitBuildIndexes(op *BuildIndexes) (interface{}, error)
 
+	// Keyspace DDL
+	VisitCreateKeyspace(op *CreateKeyspace) (interface{}, error)
+	VisitDropKeyspace(op *DropKeyspace) (interface{}, error)
+
 	// Roles
 	VisitGrantRole(op *GrantRole) (interface{}, error)
 	VisitRevokeRole(op *RevokeRole) (interface{}, error)
@@ -55,6 +55,10 @@ func (this *Div) Apply(context Context, first, second value.Value) (value.Value,
 		}
 
 		if first.Type() == value.NUMBER {
+			if value.IsDecimal(first) || value.IsDecimal(second) {
+				return value.DecimalDiv(first, second, -1, value.ROUND_HALF_UP), nil
+			}
+
 			d := first.Actual().(float64) / s
 			return value.NewValue(d), nil
 		}
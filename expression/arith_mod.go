@@ -57,6 +57,10 @@ func (this *Mod) Apply(context Context, first, second value.Value) (value.Value,
 		}
 
 		if first.Type() == value.NUMBER {
+			if value.IsDecimal(first) || value.IsDecimal(second) {
+				return value.DecimalMod(first, second), nil
+			}
+
 			m := math.Mod(first.Actual().(float64), s)
 			return value.NewValue(m), nil
 		}
@@ -158,6 +158,8 @@ func (this *ArrayAvg) Apply(context Context, arg value.Value) (value.Value, erro
 
 	if count == 0 {
 		return value.NULL_VALUE, nil
+	} else if value.IsDecimal(sum) {
+		return value.DecimalDiv(sum, value.NewValue(count), -1, value.ROUND_HALF_UP), nil
 	} else {
 		return value.NewValue(sum.Actual().(float64) / float64(count)), nil
 	}
@@ -0,0 +1,274 @@
+//  Copyright (c) 2018 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package expression
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+
+	"golang.org/x/text/collate"
+	"golang.org/x/text/language"
+
+	"github.com/couchbase/query/value"
+)
+
+///////////////////////////////////////////////////
+//
+// CollationKey
+//
+///////////////////////////////////////////////////
+
+/*
+This represents the function COLLATION_KEY(expr, collation). For a
+string expr, it returns a key string whose byte order is the order
+of expr under the collation, and which is equal for strings the
+collation deems equal. Other values are returned unchanged. Sorting,
+DISTINCT, comparisons and index spans on the keys therefore all
+follow the collation.
+
+The collation is a BCP 47 language tag, such as "fr-FR" or "fr_FR",
+which may carry Unicode collation options such as "-u-ks-level1".
+The suffixes "-ci" and "-ai" make the collation case insensitive
+and accent insensitive; "und" is the root collation.
+*/
+type CollationKey struct {
+	BinaryFunctionBase
+}
+
+func NewCollationKey(first, second Expression) Function {
+	rv := &CollationKey{
+		*NewBinaryFunctionBase("collation_key", first, second),
+	}
+
+	rv.expr = rv
+	return rv
+}
+
+/*
+Visitor pattern.
+*/
+func (this *CollationKey) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitFunction(this)
+}
+
+func (this *CollationKey) Type() value.Type { return this.First().Type() }
+
+func (this *CollationKey) Evaluate(item value.Value, context Context) (value.Value, error) {
+	return this.BinaryEval(this, item, context)
+}
+
+func (this *CollationKey) Apply(context Context, first, second value.Value) (value.Value, error) {
+	if first.Type() == value.MISSING || second.Type() == value.MISSING {
+		return value.MISSING_VALUE, nil
+	} else if second.Type() != value.STRING {
+		return value.NULL_VALUE, nil
+	}
+
+	pool := collatorPool(second.Actual().(string))
+	if pool == nil {
+		return value.NULL_VALUE, nil
+	} else if first.Type() != value.STRING {
+		return first, nil
+	}
+
+	c := pool.Get().(*collator)
+	defer pool.Put(c)
+
+	key := c.collator.KeyFromString(&c.buf, first.Actual().(string))
+	rv := hex.EncodeToString(key)
+	c.buf.Reset()
+	return value.NewValue(rv), nil
+}
+
+/*
+Factory method pattern.
+*/
+func (this *CollationKey) Constructor() FunctionConstructor {
+	return func(operands ...Expression) Function {
+		return NewCollationKey(operands[0], operands[1])
+	}
+}
+
+///////////////////////////////////////////////////
+//
+// Collate
+//
+///////////////////////////////////////////////////
+
+/*
+This represents expr COLLATE collation. It does not change the value
+of expr, which is what a projection returns: the collation only
+applies where expr is compared, or sorted on, through the collation
+keys of CollateOperands and CollateSortKey.
+*/
+type Collate struct {
+	BinaryFunctionBase
+}
+
+func NewCollate(first, second Expression) Function {
+	rv := &Collate{
+		*NewBinaryFunctionBase("collate", first, second),
+	}
+
+	rv.expr = rv
+	return rv
+}
+
+/*
+Visitor pattern.
+*/
+func (this *Collate) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitFunction(this)
+}
+
+func (this *Collate) Type() value.Type { return this.First().Type() }
+
+func (this *Collate) Evaluate(item value.Value, context Context) (value.Value, error) {
+	return this.BinaryEval(this, item, context)
+}
+
+func (this *Collate) Apply(context Context, first, second value.Value) (value.Value, error) {
+	return first, nil
+}
+
+/*
+Factory method pattern.
+*/
+func (this *Collate) Constructor() FunctionConstructor {
+	return func(operands ...Expression) Function {
+		return NewCollate(operands[0], operands[1])
+	}
+}
+
+/*
+CollateOperands compares all the operands of a comparison under the
+collation of any of them, so that a = b COLLATE "und-ci" compares the
+collation keys of both a and b. Operands with different collations
+cannot be compared.
+*/
+func CollateOperands(operands ...Expression) (Expressions, error) {
+	var collation Expression
+	for _, op := range operands {
+		c, ok := op.(*Collate)
+		if !ok {
+			continue
+		}
+
+		if collation == nil {
+			collation = c.Second()
+		} else if !collation.EquivalentTo(c.Second()) {
+			return nil, fmt.Errorf("Mismatched collations %v and %v.", collation, c.Second())
+		}
+	}
+
+	rv := make(Expressions, len(operands))
+	for i, op := range operands {
+		if c, ok := op.(*Collate); ok {
+			op = c.First()
+		}
+
+		if collation != nil {
+			op = NewCollationKey(op, collation)
+		}
+
+		rv[i] = op
+	}
+
+	return rv, nil
+}
+
+/*
+CollateSortKey makes ORDER BY expr COLLATE collation sort on the
+collation keys of expr.
+*/
+func CollateSortKey(expr Expression) Expression {
+	if c, ok := expr.(*Collate); ok {
+		return NewCollationKey(c.First(), c.Second())
+	}
+
+	return expr
+}
+
+/*
+Returns true if the collation is a valid one.
+*/
+func ValidCollation(name string) bool {
+	return collatorPool(name) != nil
+}
+
+/*
+Collators are not safe for concurrent use, so each collation keeps a
+pool of them.
+*/
+type collator struct {
+	collator *collate.Collator
+	buf      collate.Buffer
+}
+
+var collatorsLock sync.RWMutex
+var collators = make(map[string]*sync.Pool, 16)
+
+const _MAX_COLLATIONS = 1024
+
+/*
+Return the pool of collators for a collation, or nil if the
+collation is invalid.
+*/
+func collatorPool(name string) *sync.Pool {
+	collatorsLock.RLock()
+	pool, ok := collators[name]
+	collatorsLock.RUnlock()
+	if ok {
+		return pool
+	}
+
+	tag, options, ok := parseCollation(name)
+	if !ok {
+		return nil
+	}
+
+	pool = &sync.Pool{
+		New: func() interface{} {
+			return &collator{collator: collate.New(tag, options...)}
+		},
+	}
+
+	collatorsLock.Lock()
+	if len(collators) < _MAX_COLLATIONS {
+		collators[name] = pool
+	}
+	collatorsLock.Unlock()
+
+	return pool
+}
+
+func parseCollation(name string) (language.Tag, []collate.Option, bool) {
+	parts := strings.Split(strings.Replace(name, "_", "-", -1), "-")
+	options := make([]collate.Option, 0, 2)
+
+	for len(parts) > 1 {
+		switch strings.ToLower(parts[len(parts)-1]) {
+		case "ci":
+			options = append(options, collate.IgnoreCase)
+		case "ai":
+			options = append(options, collate.IgnoreDiacritics)
+		default:
+			tag, err := language.Parse(strings.Join(parts, "-"))
+			return tag, options, err == nil
+		}
+
+		parts = parts[:len(parts)-1]
+	}
+
+	tag, err := language.Parse(parts[0])
+	return tag, options, err == nil
+}
@@ -0,0 +1,91 @@
+package expression
+
+import (
+	"testing"
+
+	"github.com/couchbase/query/value"
+)
+
+func TestCollationKey(t *testing.T) {
+	key := func(s, collation string) value.Value {
+		rv, err := NewCollationKey(NewConstant(s), NewConstant(collation)).Evaluate(nil, nil)
+		if err != nil {
+			t.Errorf("received error %v", err)
+		}
+		return rv
+	}
+
+	if key("Émile", "fr_FR").Collate(key("Zoe", "fr_FR")) >= 0 {
+		t.Errorf("Expected Émile before Zoe in fr_FR")
+	}
+
+	if key("Émile", "und-ci-ai").Collate(key("EMILE", "und-ci-ai")) != 0 {
+		t.Errorf("Expected equal keys ignoring case and accents")
+	}
+
+	if key("Émile", "und-ci").Collate(key("EMILE", "und-ci")) == 0 {
+		t.Errorf("Expected different keys for different accents")
+	}
+
+	if rv := key("a", "no-such-collation"); rv.Type() != value.NULL {
+		t.Errorf("Expected NULL for an invalid collation, received %v", rv)
+	}
+
+	rv, _ := NewCollationKey(NewConstant(1), NewConstant("und")).Evaluate(nil, nil)
+	if rv.Collate(value.NewValue(1)) != 0 {
+		t.Errorf("Expected non-strings to be unchanged, received %v", rv)
+	}
+}
+
+func TestCollate(t *testing.T) {
+	rv, _ := NewCollate(NewConstant("Émile"), NewConstant("fr_FR")).Evaluate(nil, nil)
+	if rv.Actual() != "Émile" {
+		t.Errorf("Expected COLLATE to leave the value unchanged, received %v", rv)
+	}
+
+	if !ValidCollation("und-ci-ai") || ValidCollation("no-such-collation") {
+		t.Errorf("Expected only und-ci-ai to be a valid collation")
+	}
+}
+
+func TestCollateOperands(t *testing.T) {
+	ops, err := CollateOperands(NewConstant("a"), NewCollate(NewConstant("A"), NewConstant("und-ci")))
+	if err != nil {
+		t.Fatalf("received error %v", err)
+	}
+	for _, op := range ops {
+		if _, ok := op.(*CollationKey); !ok {
+			t.Errorf("Expected the collation to apply to %v", op)
+		}
+	}
+
+	eq := NewEq(ops[0], ops[1])
+	rv, _ := eq.Evaluate(nil, nil)
+	if rv.Truth() != true {
+		t.Errorf("Expected %v to be true", eq)
+	}
+
+	ops, err = CollateOperands(NewConstant("a"), NewConstant("A"))
+	if err != nil || ops[0].String() != `"a"` || ops[1].String() != `"A"` {
+		t.Errorf("Expected operands without a collation to be unchanged, received %v, %v", ops, err)
+	}
+
+	_, err = CollateOperands(NewCollate(NewConstant("a"), NewConstant("und-ci")),
+		NewCollate(NewConstant("A"), NewConstant("fr_FR")))
+	if err == nil {
+		t.Errorf("Expected mismatched collations to be rejected")
+	}
+
+	_, err = CollateOperands(NewCollate(NewConstant("b"), NewConstant("und")),
+		NewConstant("a"), NewCollate(NewConstant("c"), NewConstant("und")))
+	if err != nil {
+		t.Errorf("Expected the same collation on several operands, received %v", err)
+	}
+}
+
+func TestCollateString(t *testing.T) {
+	expr := NewCollate(NewIdentifier("name"), NewConstant("fr_FR"))
+	if expr.String() != "(`name` collate \"fr_FR\")" {
+		t.Errorf("Unexpected %v", expr.String())
+	}
+}
@@ -341,18 +341,19 @@ func (this *ClockUTC) Constructor() FunctionConstructor {
 ///////////////////////////////////////////////////
 
 /*
-This represents the Date function DATE_ADD_MILLIS(expr,n,part).
+This represents the Date function DATE_ADD_MILLIS(expr,n,part,[ tz ]).
 It performs date arithmetic. n and part are used to define an
 interval or duration, which is then added (or subtracted) to
-the UNIX timestamp, returning the result.
+the UNIX timestamp, returning the result. Calendar parts are
+added in the time zone tz, or in the local time zone.
 */
 type DateAddMillis struct {
-	TernaryFunctionBase
+	FunctionBase
 }
 
-func NewDateAddMillis(first, second, third Expression) Function {
+func NewDateAddMillis(operands ...Expression) Function {
 	rv := &DateAddMillis{
-		*NewTernaryFunctionBase("date_add_millis", first, second, third),
+		*NewFunctionBase("date_add_millis", operands...),
 	}
 
 	rv.expr = rv
@@ -369,16 +370,25 @@ func (this *DateAddMillis) Accept(visitor Visitor) (interface{}, error) {
 func (this *DateAddMillis) Type() value.Type { return value.NUMBER }
 
 func (this *DateAddMillis) Evaluate(item value.Value, context Context) (value.Value, error) {
-	return this.TernaryEval(this, item, context)
+	return this.Eval(this, item, context)
 }
 
-func (this *DateAddMillis) Apply(context Context, date, n, part value.Value) (value.Value, error) {
-	if date.Type() == value.MISSING || n.Type() == value.MISSING || part.Type() == value.MISSING {
+func (this *DateAddMillis) Apply(context Context, args ...value.Value) (value.Value, error) {
+	date := args[0]
+	n := args[1]
+	part := args[2]
+
+	if anyMissing(args) {
 		return value.MISSING_VALUE, nil
 	} else if date.Type() != value.NUMBER || n.Type() != value.NUMBER || part.Type() != value.STRING {
 		return value.NULL_VALUE, nil
 	}
 
+	loc, ok := dateLocation(args, 3)
+	if !ok {
+		return value.NULL_VALUE, nil
+	}
+
 	da := date.Actual().(float64)
 	na := n.Actual().(float64)
 	if na != math.Trunc(na) {
@@ -386,7 +396,7 @@ func (this *DateAddMillis) Apply(context Context, date, n, part value.Value) (va
 	}
 
 	pa := part.Actual().(string)
-	t, err := dateAdd(millisToTime(da), int(na), pa)
+	t, err := dateAdd(millisToTimeIn(da, loc), int(na), pa)
 	if err != nil {
 		return value.NULL_VALUE, err
 	}
@@ -394,13 +404,21 @@ func (this *DateAddMillis) Apply(context Context, date, n, part value.Value) (va
 	return value.NewValue(timeToMillis(t)), nil
 }
 
+/*
+Minimum input arguments required.
+*/
+func (this *DateAddMillis) MinArgs() int { return 3 }
+
+/*
+Maximum input arguments allowed.
+*/
+func (this *DateAddMillis) MaxArgs() int { return 4 }
+
 /*
 Factory method pattern.
 */
 func (this *DateAddMillis) Constructor() FunctionConstructor {
-	return func(operands ...Expression) Function {
-		return NewDateAddMillis(operands[0], operands[1], operands[2])
-	}
+	return NewDateAddMillis
 }
 
 ///////////////////////////////////////////////////
@@ -410,18 +428,19 @@ func (this *DateAddMillis) Constructor() FunctionConstructor {
 ///////////////////////////////////////////////////
 
 /*
-This represents the Date function DATE_ADD_STR(expr,n,part).
+This represents the Date function DATE_ADD_STR(expr,n,part,[ tz ]).
 It performs date arithmetic. n and part are used to define an
 interval or duration, which is then added to the date string
-in a supported format, returning the result.
+in a supported format, returning the result. If tz is given,
+the date is converted to that time zone before the addition.
 */
 type DateAddStr struct {
-	TernaryFunctionBase
+	FunctionBase
 }
 
-func NewDateAddStr(first, second, third Expression) Function {
+func NewDateAddStr(operands ...Expression) Function {
 	rv := &DateAddStr{
-		*NewTernaryFunctionBase("date_add_str", first, second, third),
+		*NewFunctionBase("date_add_str", operands...),
 	}
 
 	rv.expr = rv
@@ -438,18 +457,27 @@ func (this *DateAddStr) Accept(visitor Visitor) (interface{}, error) {
 func (this *DateAddStr) Type() value.Type { return value.STRING }
 
 func (this *DateAddStr) Evaluate(item value.Value, context Context) (value.Value, error) {
-	return this.TernaryEval(this, item, context)
+	return this.Eval(this, item, context)
 }
 
-func (this *DateAddStr) Apply(context Context, date, n, part value.Value) (value.Value, error) {
-	if date.Type() == value.MISSING || n.Type() == value.MISSING || part.Type() == value.MISSING {
+func (this *DateAddStr) Apply(context Context, args ...value.Value) (value.Value, error) {
+	date := args[0]
+	n := args[1]
+	part := args[2]
+
+	if anyMissing(args) {
 		return value.MISSING_VALUE, nil
 	} else if date.Type() != value.STRING || n.Type() != value.NUMBER || part.Type() != value.STRING {
 		return value.NULL_VALUE, nil
 	}
 
+	loc, ok := dateLocation(args, 3)
+	if !ok {
+		return value.NULL_VALUE, nil
+	}
+
 	da := date.Actual().(string)
-	t, fmt, err := strToTimeFormat(da)
+	t, fmt, err := strToTimeFormatIn(da, loc)
 	if err != nil {
 		return value.NULL_VALUE, nil
 	}
@@ -468,13 +496,21 @@ func (this *DateAddStr) Apply(context Context, date, n, part value.Value) (value
 	return value.NewValue(timeToStr(t, fmt)), nil
 }
 
+/*
+Minimum input arguments required.
+*/
+func (this *DateAddStr) MinArgs() int { return 3 }
+
+/*
+Maximum input arguments allowed.
+*/
+func (this *DateAddStr) MaxArgs() int { return 4 }
+
 /*
 Factory method pattern.
 */
 func (this *DateAddStr) Constructor() FunctionConstructor {
-	return func(operands ...Expression) Function {
-		return NewDateAddStr(operands[0], operands[1], operands[2])
-	}
+	return NewDateAddStr
 }
 
 ///////////////////////////////////////////////////
@@ -484,17 +520,18 @@ func (this *DateAddStr) Constructor() FunctionConstructor {
 ///////////////////////////////////////////////////
 
 /*
-This represents the Date function DATE_DIFF_MILLIS(expr1,expr2,part).
+This represents the Date function DATE_DIFF_MILLIS(expr1,expr2,part,[ tz ]).
 It performs date arithmetic. It returns the elapsed time between two
-UNIX timestamps, as an integer whose unit is part.
+UNIX timestamps, as an integer whose unit is part. Calendar parts
+are counted in the time zone tz, or in the local time zone.
 */
 type DateDiffMillis struct {
-	TernaryFunctionBase
+	FunctionBase
 }
 
-func NewDateDiffMillis(first, second, third Expression) Function {
+func NewDateDiffMillis(operands ...Expression) Function {
 	rv := &DateDiffMillis{
-		*NewTernaryFunctionBase("date_diff_millis", first, second, third),
+		*NewFunctionBase("date_diff_millis", operands...),
 	}
 
 	rv.expr = rv
@@ -511,20 +548,29 @@ func (this *DateDiffMillis) Accept(visitor Visitor) (interface{}, error) {
 func (this *DateDiffMillis) Type() value.Type { return value.NUMBER }
 
 func (this *DateDiffMillis) Evaluate(item value.Value, context Context) (value.Value, error) {
-	return this.TernaryEval(this, item, context)
+	return this.Eval(this, item, context)
 }
 
-func (this *DateDiffMillis) Apply(context Context, date1, date2, part value.Value) (value.Value, error) {
-	if date1.Type() == value.MISSING || date2.Type() == value.MISSING || part.Type() == value.MISSING {
+func (this *DateDiffMillis) Apply(context Context, args ...value.Value) (value.Value, error) {
+	date1 := args[0]
+	date2 := args[1]
+	part := args[2]
+
+	if anyMissing(args) {
 		return value.MISSING_VALUE, nil
 	} else if date1.Type() != value.NUMBER || date2.Type() != value.NUMBER || part.Type() != value.STRING {
 		return value.NULL_VALUE, nil
 	}
 
+	loc, ok := dateLocation(args, 3)
+	if !ok {
+		return value.NULL_VALUE, nil
+	}
+
 	da1 := date1.Actual().(float64)
 	da2 := date2.Actual().(float64)
 	pa := part.Actual().(string)
-	diff, err := dateDiff(millisToTime(da1), millisToTime(da2), pa)
+	diff, err := dateDiff(millisToTimeIn(da1, loc), millisToTimeIn(da2, loc), pa)
 	if err != nil {
 		return value.NULL_VALUE, err
 	}
@@ -532,13 +578,21 @@ func (this *DateDiffMillis) Apply(context Context, date1, date2, part value.Valu
 	return value.NewValue(diff), nil
 }
 
+/*
+Minimum input arguments required.
+*/
+func (this *DateDiffMillis) MinArgs() int { return 3 }
+
+/*
+Maximum input arguments allowed.
+*/
+func (this *DateDiffMillis) MaxArgs() int { return 4 }
+
 /*
 Factory method pattern.
 */
 func (this *DateDiffMillis) Constructor() FunctionConstructor {
-	return func(operands ...Expression) Function {
-		return NewDateDiffMillis(operands[0], operands[1], operands[2])
-	}
+	return NewDateDiffMillis
 }
 
 ///////////////////////////////////////////////////
@@ -548,18 +602,19 @@ func (this *DateDiffMillis) Constructor() FunctionConstructor {
 ///////////////////////////////////////////////////
 
 /*
-This represents the Date function DATE_DIFF_STR(expr1,expr2,part).
+This represents the Date function DATE_DIFF_STR(expr1,expr2,part,[ tz ]).
 It performs date arithmetic and returns the elapsed time between two
 date strings in a supported format, as an integer whose unit is
-part.
+part. If tz is given, both dates are converted to that time zone
+before the difference is computed.
 */
 type DateDiffStr struct {
-	TernaryFunctionBase
+	FunctionBase
 }
 
-func NewDateDiffStr(first, second, third Expression) Function {
+func NewDateDiffStr(operands ...Expression) Function {
 	rv := &DateDiffStr{
-		*NewTernaryFunctionBase("date_diff_str", first, second, third),
+		*NewFunctionBase("date_diff_str", operands...),
 	}
 
 	rv.expr = rv
@@ -576,24 +631,33 @@ func (this *DateDiffStr) Accept(visitor Visitor) (interface{}, error) {
 func (this *DateDiffStr) Type() value.Type { return value.NUMBER }
 
 func (this *DateDiffStr) Evaluate(item value.Value, context Context) (value.Value, error) {
-	return this.TernaryEval(this, item, context)
+	return this.Eval(this, item, context)
 }
 
-func (this *DateDiffStr) Apply(context Context, date1, date2, part value.Value) (value.Value, error) {
-	if date1.Type() == value.MISSING || date2.Type() == value.MISSING || part.Type() == value.MISSING {
+func (this *DateDiffStr) Apply(context Context, args ...value.Value) (value.Value, error) {
+	date1 := args[0]
+	date2 := args[1]
+	part := args[2]
+
+	if anyMissing(args) {
 		return value.MISSING_VALUE, nil
 	} else if date1.Type() != value.STRING || date2.Type() != value.STRING || part.Type() != value.STRING {
 		return value.NULL_VALUE, nil
 	}
 
+	loc, ok := dateLocation(args, 3)
+	if !ok {
+		return value.NULL_VALUE, nil
+	}
+
 	da1 := date1.Actual().(string)
-	t1, err := strToTime(da1)
+	t1, err := strToTimeIn(da1, loc)
 	if err != nil {
 		return value.NULL_VALUE, nil
 	}
 
 	da2 := date2.Actual().(string)
-	t2, err := strToTime(da2)
+	t2, err := strToTimeIn(da2, loc)
 	if err != nil {
 		return value.NULL_VALUE, nil
 	}
@@ -607,13 +671,21 @@ func (this *DateDiffStr) Apply(context Context, date1, date2, part value.Value)
 	return value.NewValue(diff), nil
 }
 
+/*
+Minimum input arguments required.
+*/
+func (this *DateDiffStr) MinArgs() int { return 3 }
+
+/*
+Maximum input arguments allowed.
+*/
+func (this *DateDiffStr) MaxArgs() int { return 4 }
+
 /*
 Factory method pattern.
 */
 func (this *DateDiffStr) Constructor() FunctionConstructor {
-	return func(operands ...Expression) Function {
-		return NewDateDiffStr(operands[0], operands[1], operands[2])
-	}
+	return NewDateDiffStr
 }
 
 ///////////////////////////////////////////////////
@@ -624,7 +696,8 @@ func (this *DateDiffStr) Constructor() FunctionConstructor {
 
 /*
 This represents the Date function DATE_FORMAT_STR(expr, format).
-It returns the input date in the expected format.
+It returns the input date in the expected format, which is either
+an example date or a strftime or Java style pattern.
 */
 type DateFormatStr struct {
 	BinaryFunctionBase
@@ -795,17 +868,19 @@ func (this *DatePartMillis) Constructor() FunctionConstructor {
 ///////////////////////////////////////////////////
 
 /*
-This represents the Date function DATE_PART_STR(expr, part).  It
-returns the date part as an integer. The date expr is a string in a
-supported format, and part is one of the supported date part strings.
+This represents the Date function DATE_PART_STR(expr, part, [ tz ]).
+It returns the date part as an integer. The date expr is a string in
+a supported format, and part is one of the supported date part
+strings. If tz is given, the date is converted to that time zone
+before the part is extracted.
 */
 type DatePartStr struct {
-	BinaryFunctionBase
+	FunctionBase
 }
 
-func NewDatePartStr(first, second Expression) Function {
+func NewDatePartStr(operands ...Expression) Function {
 	rv := &DatePartStr{
-		*NewBinaryFunctionBase("date_part_str", first, second),
+		*NewFunctionBase("date_part_str", operands...),
 	}
 
 	rv.expr = rv
@@ -822,18 +897,26 @@ func (this *DatePartStr) Accept(visitor Visitor) (interface{}, error) {
 func (this *DatePartStr) Type() value.Type { return value.NUMBER }
 
 func (this *DatePartStr) Evaluate(item value.Value, context Context) (value.Value, error) {
-	return this.BinaryEval(this, item, context)
+	return this.Eval(this, item, context)
 }
 
-func (this *DatePartStr) Apply(context Context, first, second value.Value) (value.Value, error) {
-	if first.Type() == value.MISSING || second.Type() == value.MISSING {
+func (this *DatePartStr) Apply(context Context, args ...value.Value) (value.Value, error) {
+	first := args[0]
+	second := args[1]
+
+	if anyMissing(args) {
 		return value.MISSING_VALUE, nil
 	} else if first.Type() != value.STRING || second.Type() != value.STRING {
 		return value.NULL_VALUE, nil
 	}
 
+	loc, ok := dateLocation(args, 2)
+	if !ok {
+		return value.NULL_VALUE, nil
+	}
+
 	str := first.Actual().(string)
-	t, err := strToTime(str)
+	t, err := strToTimeIn(str, loc)
 	if err != nil {
 		return value.NULL_VALUE, nil
 	}
@@ -847,13 +930,21 @@ func (this *DatePartStr) Apply(context Context, first, second value.Value) (valu
 	return value.NewValue(rv), nil
 }
 
+/*
+Minimum input arguments required.
+*/
+func (this *DatePartStr) MinArgs() int { return 2 }
+
+/*
+Maximum input arguments allowed.
+*/
+func (this *DatePartStr) MaxArgs() int { return 3 }
+
 /*
 Factory method pattern.
 */
 func (this *DatePartStr) Constructor() FunctionConstructor {
-	return func(operands ...Expression) Function {
-		return NewDatePartStr(operands[0], operands[1])
-	}
+	return NewDatePartStr
 }
 
 ///////////////////////////////////////////////////
@@ -1180,17 +1271,18 @@ func (this *DateRangeMillis) Constructor() FunctionConstructor {
 ///////////////////////////////////////////////////
 
 /*
-This represents the Date function DATE_TRUNC_MILLIS(expr, part).
+This represents the Date function DATE_TRUNC_MILLIS(expr, part, [ tz ]).
 It truncates UNIX timestamp so that the given date part string
-is the least significant.
+is the least significant. The timestamp is truncated in the time
+zone tz, or in the local time zone.
 */
 type DateTruncMillis struct {
-	BinaryFunctionBase
+	FunctionBase
 }
 
-func NewDateTruncMillis(first, second Expression) Function {
+func NewDateTruncMillis(operands ...Expression) Function {
 	rv := &DateTruncMillis{
-		*NewBinaryFunctionBase("date_trunc_millis", first, second),
+		*NewFunctionBase("date_trunc_millis", operands...),
 	}
 
 	rv.expr = rv
@@ -1207,19 +1299,27 @@ func (this *DateTruncMillis) Accept(visitor Visitor) (interface{}, error) {
 func (this *DateTruncMillis) Type() value.Type { return value.NUMBER }
 
 func (this *DateTruncMillis) Evaluate(item value.Value, context Context) (value.Value, error) {
-	return this.BinaryEval(this, item, context)
+	return this.Eval(this, item, context)
 }
 
-func (this *DateTruncMillis) Apply(context Context, first, second value.Value) (value.Value, error) {
-	if first.Type() == value.MISSING || second.Type() == value.MISSING {
+func (this *DateTruncMillis) Apply(context Context, args ...value.Value) (value.Value, error) {
+	first := args[0]
+	second := args[1]
+
+	if anyMissing(args) {
 		return value.MISSING_VALUE, nil
 	} else if first.Type() != value.NUMBER || second.Type() != value.STRING {
 		return value.NULL_VALUE, nil
 	}
 
+	loc, ok := dateLocation(args, 2)
+	if !ok {
+		return value.NULL_VALUE, nil
+	}
+
 	millis := first.Actual().(float64)
 	part := second.Actual().(string)
-	t := millisToTime(millis)
+	t := millisToTimeIn(millis, loc)
 
 	var err error
 	t, err = dateTrunc(t, part)
@@ -1230,13 +1330,21 @@ func (this *DateTruncMillis) Apply(context Context, first, second value.Value) (
 	return value.NewValue(timeToMillis(t)), nil
 }
 
+/*
+Minimum input arguments required.
+*/
+func (this *DateTruncMillis) MinArgs() int { return 2 }
+
+/*
+Maximum input arguments allowed.
+*/
+func (this *DateTruncMillis) MaxArgs() int { return 3 }
+
 /*
 Factory method pattern.
 */
 func (this *DateTruncMillis) Constructor() FunctionConstructor {
-	return func(operands ...Expression) Function {
-		return NewDateTruncMillis(operands[0], operands[1])
-	}
+	return NewDateTruncMillis
 }
 
 ///////////////////////////////////////////////////
@@ -1246,17 +1354,18 @@ func (this *DateTruncMillis) Constructor() FunctionConstructor {
 ///////////////////////////////////////////////////
 
 /*
-This represents the Date function DATE_TRUNC_STR(expr, part).
+This represents the Date function DATE_TRUNC_STR(expr, part, [ tz ]).
 It truncates ISO 8601 timestamp so that the given date part
-string is the least significant.
+string is the least significant. If tz is given, the timestamp
+is converted to that time zone and truncated there.
 */
 type DateTruncStr struct {
-	BinaryFunctionBase
+	FunctionBase
 }
 
-func NewDateTruncStr(first, second Expression) Function {
+func NewDateTruncStr(operands ...Expression) Function {
 	rv := &DateTruncStr{
-		*NewBinaryFunctionBase("date_trunc_str", first, second),
+		*NewFunctionBase("date_trunc_str", operands...),
 	}
 
 	rv.expr = rv
@@ -1273,19 +1382,41 @@ func (this *DateTruncStr) Accept(visitor Visitor) (interface{}, error) {
 func (this *DateTruncStr) Type() value.Type { return value.STRING }
 
 func (this *DateTruncStr) Evaluate(item value.Value, context Context) (value.Value, error) {
-	return this.BinaryEval(this, item, context)
+	return this.Eval(this, item, context)
 }
 
-func (this *DateTruncStr) Apply(context Context, first, second value.Value) (value.Value, error) {
-	if first.Type() == value.MISSING || second.Type() == value.MISSING {
+func (this *DateTruncStr) Apply(context Context, args ...value.Value) (value.Value, error) {
+	first := args[0]
+	second := args[1]
+
+	if anyMissing(args) {
 		return value.MISSING_VALUE, nil
 	} else if first.Type() != value.STRING || second.Type() != value.STRING {
 		return value.NULL_VALUE, nil
 	}
 
+	loc, ok := dateLocation(args, 2)
+	if !ok {
+		return value.NULL_VALUE, nil
+	}
+
 	str := first.Actual().(string)
 	part := second.Actual().(string)
 
+	if loc != nil {
+		t, fmt, err := strToTimeFormatIn(str, loc)
+		if err != nil {
+			return value.NULL_VALUE, nil
+		}
+
+		t, err = dateTrunc(t, part)
+		if err != nil {
+			return value.NULL_VALUE, err
+		}
+
+		return value.NewValue(timeToStr(t, fmt)), nil
+	}
+
 	// For date trunc we do not consider the timezone.
 	// This messes up the result of the golang time functions.
 	// To avoid this remove it before processing
@@ -1309,13 +1440,21 @@ func (this *DateTruncStr) Apply(context Context, first, second value.Value) (val
 	return value.NewValue(timeToStr(t, str) + tzComponent), nil
 }
 
+/*
+Minimum input arguments required.
+*/
+func (this *DateTruncStr) MinArgs() int { return 2 }
+
+/*
+Maximum input arguments allowed.
+*/
+func (this *DateTruncStr) MaxArgs() int { return 3 }
+
 /*
 Factory method pattern.
 */
 func (this *DateTruncStr) Constructor() FunctionConstructor {
-	return func(operands ...Expression) Function {
-		return NewDateTruncStr(operands[0], operands[1])
-	}
+	return NewDateTruncStr
 }
 
 ///////////////////////////////////////////////////
@@ -1867,16 +2006,18 @@ func (this *NowUTC) Constructor() FunctionConstructor {
 ///////////////////////////////////////////////////
 
 /*
-This represents the Date function STR_TO_MILLIS(expr).
-It converts date in a supported format to UNIX milliseconds.
+This represents the Date function STR_TO_MILLIS(expr, [ fmt ]).
+It converts date in a supported format to UNIX milliseconds. If
+fmt is given, the date is parsed using that format, which is
+either an example date or a strftime or Java style pattern.
 */
 type StrToMillis struct {
-	UnaryFunctionBase
+	FunctionBase
 }
 
-func NewStrToMillis(operand Expression) Function {
+func NewStrToMillis(operands ...Expression) Function {
 	rv := &StrToMillis{
-		*NewUnaryFunctionBase("str_to_millis", operand),
+		*NewFunctionBase("str_to_millis", operands...),
 	}
 
 	rv.expr = rv
@@ -1893,18 +2034,29 @@ func (this *StrToMillis) Accept(visitor Visitor) (interface{}, error) {
 func (this *StrToMillis) Type() value.Type { return value.NUMBER }
 
 func (this *StrToMillis) Evaluate(item value.Value, context Context) (value.Value, error) {
-	return this.UnaryEval(this, item, context)
+	return this.Eval(this, item, context)
 }
 
-func (this *StrToMillis) Apply(context Context, arg value.Value) (value.Value, error) {
-	if arg.Type() == value.MISSING {
+func (this *StrToMillis) Apply(context Context, args ...value.Value) (value.Value, error) {
+	if anyMissing(args) {
 		return value.MISSING_VALUE, nil
-	} else if arg.Type() != value.STRING {
-		return value.NULL_VALUE, nil
 	}
 
-	str := arg.Actual().(string)
-	t, err := strToTime(str)
+	for _, arg := range args {
+		if arg.Type() != value.STRING {
+			return value.NULL_VALUE, nil
+		}
+	}
+
+	str := args[0].Actual().(string)
+	var t time.Time
+	var err error
+	if len(args) > 1 {
+		t, err = strToTimeLayout(str, args[1].Actual().(string))
+	} else {
+		t, err = strToTime(str)
+	}
+
 	if err != nil {
 		return value.NULL_VALUE, nil
 	}
@@ -1912,13 +2064,21 @@ func (this *StrToMillis) Apply(context Context, arg value.Value) (value.Value, e
 	return value.NewValue(timeToMillis(t)), nil
 }
 
+/*
+Minimum input arguments required.
+*/
+func (this *StrToMillis) MinArgs() int { return 1 }
+
+/*
+Maximum input arguments allowed.
+*/
+func (this *StrToMillis) MaxArgs() int { return 2 }
+
 /*
 Factory method pattern.
 */
 func (this *StrToMillis) Constructor() FunctionConstructor {
-	return func(operands ...Expression) Function {
-		return NewStrToMillis(operands[0])
-	}
+	return NewStrToMillis
 }
 
 ///////////////////////////////////////////////////
@@ -2411,13 +2571,262 @@ func strToTimeFormat(s string) (time.Time, string, error) {
 	return t, _DEFAULT_FORMAT, err
 }
 
+/*
+Parse the input string like strToTimeFormat, but in the time
+zone loc if it is not nil. The result is then converted to loc,
+so that dates carrying another time zone are handled there too.
+*/
+func strToTimeFormatIn(s string, loc *time.Location) (time.Time, string, error) {
+	if loc == nil {
+		return strToTimeFormat(s)
+	}
+
+	var t time.Time
+	var err error
+	for _, f := range _DATE_FORMATS {
+		t, err = time.ParseInLocation(f, s, loc)
+		if err == nil {
+			return t.In(loc), f, nil
+		}
+	}
+
+	return t, _DEFAULT_FORMAT, err
+}
+
+/*
+Parse the input string like strToTime, in the time zone loc
+if it is not nil.
+*/
+func strToTimeIn(s string, loc *time.Location) (time.Time, error) {
+	t, _, err := strToTimeFormatIn(s, loc)
+	return t, err
+}
+
+/*
+Parse the input string using the layout of the input format,
+as returned by formatLayout.
+*/
+func strToTimeLayout(s, format string) (time.Time, error) {
+	layout, ok := formatLayout(format)
+	if !ok {
+		return time.Time{}, fmt.Errorf("Unsupported date format %s.", format)
+	}
+
+	return time.ParseInLocation(layout, s, time.Local)
+}
+
 /*
 It returns a textual representation of the time value formatted
-according to the Format string.
+according to the Format string. Formats that are not supported
+yield the default format.
 */
 func timeToStr(t time.Time, format string) string {
-	_, fmt, _ := strToTimeFormat(format)
-	return t.Format(fmt)
+	layout, ok := formatLayout(format)
+	if !ok {
+		layout = _DEFAULT_FORMAT
+	}
+
+	return t.Format(layout)
+}
+
+/*
+Returns the layout of the time package for a format. The format
+is either an example date in one of the supported formats, a
+strftime pattern such as %Y-%m-%d, or a Java style pattern such
+as yyyy-MM-dd.
+*/
+func formatLayout(format string) (string, bool) {
+	_, layout, err := strToTimeFormat(format)
+	if err == nil {
+		return layout, true
+	}
+
+	if strings.IndexByte(format, '%') >= 0 {
+		return strftimeLayout(format)
+	}
+
+	return javaLayout(format)
+}
+
+/*
+Layouts of the supported strftime conversions.
+*/
+var _STRFTIME_LAYOUTS = map[byte]string{
+	'Y': "2006",
+	'y': "06",
+	'm': "01",
+	'd': "02",
+	'e': "_2",
+	'H': "15",
+	'I': "03",
+	'M': "04",
+	'S': "05",
+	'L': "000",
+	'f': "000000",
+	'p': "PM",
+	'b': "Jan",
+	'h': "Jan",
+	'B': "January",
+	'a': "Mon",
+	'A': "Monday",
+	'z': "-0700",
+	'Z': "MST",
+	'D': "01/02/06",
+	'F': "2006-01-02",
+	'R': "15:04",
+	'T': "15:04:05",
+	'%': "%",
+}
+
+/*
+Convert a strftime pattern to a layout. The conversion %:z is
+the time zone offset with a colon.
+*/
+func strftimeLayout(format string) (string, bool) {
+	buf := make([]byte, 0, 2*len(format))
+	for i := 0; i < len(format); i++ {
+		c := format[i]
+		if c != '%' {
+			buf = append(buf, c)
+			continue
+		}
+
+		i++
+		if strings.HasPrefix(format[i:], ":z") {
+			buf = append(buf, "-07:00"...)
+			i++
+			continue
+		}
+
+		if i >= len(format) {
+			return "", false
+		}
+
+		layout, ok := _STRFTIME_LAYOUTS[format[i]]
+		if !ok {
+			return "", false
+		}
+
+		buf = append(buf, layout...)
+	}
+
+	return string(buf), true
+}
+
+/*
+Convert a Java style pattern to a layout. Text between single
+quotes is literal, and two single quotes are a single quote.
+Any other letter must be a supported pattern letter.
+*/
+func javaLayout(format string) (string, bool) {
+	buf := make([]byte, 0, 2*len(format))
+	fields := 0
+	for i := 0; i < len(format); {
+		c := format[i]
+		if c == '\'' {
+			j := strings.IndexByte(format[i+1:], '\'')
+			if j < 0 {
+				return "", false
+			} else if j == 0 {
+				buf = append(buf, '\'')
+			} else {
+				buf = append(buf, format[i+1:i+1+j]...)
+			}
+
+			i += j + 2
+			continue
+		}
+
+		if (c < 'a' || c > 'z') && (c < 'A' || c > 'Z') {
+			buf = append(buf, c)
+			i++
+			continue
+		}
+
+		n := 1
+		for i+n < len(format) && format[i+n] == c {
+			n++
+		}
+
+		layout, ok := javaFieldLayout(c, n)
+		if !ok {
+			return "", false
+		}
+
+		buf = append(buf, layout...)
+		fields++
+		i += n
+	}
+
+	return string(buf), fields > 0
+}
+
+/*
+Returns the layout of n repetitions of a Java pattern letter.
+*/
+func javaFieldLayout(c byte, n int) (string, bool) {
+	switch c {
+	case 'y':
+		if n == 2 {
+			return "06", true
+		}
+		return "2006", true
+	case 'M':
+		switch {
+		case n >= 4:
+			return "January", true
+		case n == 3:
+			return "Jan", true
+		case n == 2:
+			return "01", true
+		default:
+			return "1", true
+		}
+	case 'd':
+		return paddedLayout(n, "02", "2"), true
+	case 'H':
+		return "15", true
+	case 'h':
+		return paddedLayout(n, "03", "3"), true
+	case 'm':
+		return paddedLayout(n, "04", "4"), true
+	case 's':
+		return paddedLayout(n, "05", "5"), true
+	case 'S':
+		if n > 9 {
+			return "", false
+		}
+		return strings.Repeat("0", n), true
+	case 'a':
+		return "PM", true
+	case 'E':
+		if n >= 4 {
+			return "Monday", true
+		}
+		return "Mon", true
+	case 'Z':
+		return "-0700", true
+	case 'X':
+		switch n {
+		case 1:
+			return "Z07", true
+		case 2:
+			return "Z0700", true
+		default:
+			return "Z07:00", true
+		}
+	case 'z':
+		return "MST", true
+	default:
+		return "", false
+	}
+}
+
+func paddedLayout(n int, padded, unpadded string) string {
+	if n >= 2 {
+		return padded
+	}
+	return unpadded
 }
 
 /*
@@ -2428,6 +2837,54 @@ func millisToTime(millis float64) time.Time {
 	return time.Unix(int64(millis/1000), int64(math.Mod(millis, 1000)*1000000.0))
 }
 
+/*
+Convert input milliseconds to time in the time zone loc, or
+in the local time zone if loc is nil.
+*/
+func millisToTimeIn(millis float64, loc *time.Location) time.Time {
+	t := millisToTime(millis)
+	if loc != nil {
+		t = t.In(loc)
+	}
+
+	return t
+}
+
+/*
+Returns true if any of the input arguments is MISSING.
+*/
+func anyMissing(args []value.Value) bool {
+	for _, arg := range args {
+		if arg.Type() == value.MISSING {
+			return true
+		}
+	}
+
+	return false
+}
+
+/*
+Returns the time zone named by the optional argument n of a
+date function, or nil if the argument is not given. ok is false
+if the argument is not a valid IANA time zone name.
+*/
+func dateLocation(args []value.Value, n int) (*time.Location, bool) {
+	if len(args) <= n {
+		return nil, true
+	}
+
+	if args[n].Type() != value.STRING {
+		return nil, false
+	}
+
+	loc, err := time.LoadLocation(args[n].Actual().(string))
+	if err != nil {
+		return nil, false
+	}
+
+	return loc, true
+}
+
 /*
 Convert input time to milliseconds from nanoseconds returned
 by UnixNano().
@@ -2530,29 +2987,30 @@ func datePart(t time.Time, part string) (int, error) {
 }
 
 /*
-Add part to the input time string using AddDate method from the
-time package. n and part are used to define the interval or duration.
+Add part to the input time string. n and part are used to define
+the interval or duration. Calendar parts are added on the wall
+clock of t, and hours and smaller parts in elapsed time.
 */
 func dateAdd(t time.Time, n int, part string) (time.Time, error) {
 	p := strings.ToLower(part)
 
 	switch p {
 	case "millennium":
-		return t.AddDate(n*1000, 0, 0), nil
+		return addDate(t, n*1000, 0, 0), nil
 	case "century":
-		return t.AddDate(n*100, 0, 0), nil
+		return addDate(t, n*100, 0, 0), nil
 	case "decade":
-		return t.AddDate(n*10, 0, 0), nil
+		return addDate(t, n*10, 0, 0), nil
 	case "year":
-		return t.AddDate(n, 0, 0), nil
+		return addDate(t, n, 0, 0), nil
 	case "quarter":
-		return t.AddDate(0, n*3, 0), nil
+		return addDate(t, 0, n*3, 0), nil
 	case "month":
-		return t.AddDate(0, n, 0), nil
-	case "week":
-		return t.AddDate(0, 0, n*7), nil
+		return addDate(t, 0, n, 0), nil
+	case "week", "iso_week":
+		return addDate(t, 0, 0, n*7), nil
 	case "day":
-		return t.AddDate(0, 0, n), nil
+		return addDate(t, 0, 0, n), nil
 	case "hour":
 		return t.Add(time.Duration(n) * time.Hour), nil
 	case "minute":
@@ -2576,20 +3034,30 @@ func dateTrunc(t time.Time, part string) (time.Time, error) {
 	switch p {
 	case "millennium":
 		t = yearTrunc(t)
-		return t.AddDate(-(t.Year() % 1000), 0, 0), nil
+		return addDate(t, -(t.Year() % 1000), 0, 0), nil
 	case "century":
 		t = yearTrunc(t)
-		return t.AddDate(-(t.Year() % 100), 0, 0), nil
+		return addDate(t, -(t.Year() % 100), 0, 0), nil
 	case "decade":
 		t = yearTrunc(t)
-		return t.AddDate(-(t.Year() % 10), 0, 0), nil
+		return addDate(t, -(t.Year() % 10), 0, 0), nil
 	case "year":
 		return yearTrunc(t), nil
 	case "quarter":
 		t = monthTrunc(t)
-		return t.AddDate(0, -((int(t.Month()) - 1) % 3), 0), nil
+		return addDate(t, 0, -((int(t.Month()) - 1) % 3), 0), nil
 	case "month":
 		return monthTrunc(t), nil
+	case "week":
+		t, _ = timeTrunc(t, "day")
+		return addDate(t, 0, 0, -int(t.Weekday())), nil
+	case "iso_week":
+		t, _ = timeTrunc(t, "day")
+		return addDate(t, 0, 0, -((int(t.Weekday()) + 6) % 7)), nil
+	case "iso_year":
+		_, w := t.ISOWeek()
+		t, _ = dateTrunc(t, "iso_week")
+		return addDate(t, 0, 0, -7*(w-1)), nil
 	default:
 		return timeTrunc(t, p)
 	}
@@ -2601,32 +3069,35 @@ week that year and returns the Time.
 */
 func yearTrunc(t time.Time) time.Time {
 	t, _ = timeTrunc(t, "day")
-	return t.AddDate(0, 0, 1-t.YearDay())
+	return addDate(t, 0, 0, 1-t.YearDay())
 }
 
 /*
 This method returns the time t with the day part truncated out. First
 get Time part as day. Subtract that from the days and then Add the
-given number of years, months and days to t using the AddDate method
-from the time package and return.
+given number of years, months and days to t and return.
 */
 func monthTrunc(t time.Time) time.Time {
 	t, _ = timeTrunc(t, "day")
-	return t.AddDate(0, 0, 1-t.Day())
+	return addDate(t, 0, 0, 1-t.Day())
 }
 
 /*
 Truncate the time string based on the value of the part string.
-If type day convert to hours.
+Days, hours and minutes are truncated on the wall clock of the
+time zone of t, so that they are correct across DST changes and
+in time zones whose offsets are not whole hours.
 */
 func timeTrunc(t time.Time, part string) (time.Time, error) {
 	switch part {
 	case "day":
-		return t.Truncate(time.Duration(24) * time.Hour), nil
+		y, m, d := t.Date()
+		return wallDate(y, m, d, 0, 0, 0, 0, t.Location()), nil
 	case "hour":
-		return t.Truncate(time.Hour), nil
+		return t.Add(-time.Duration(t.Minute())*time.Minute - time.Duration(t.Second())*time.Second -
+			time.Duration(t.Nanosecond())), nil
 	case "minute":
-		return t.Truncate(time.Minute), nil
+		return t.Add(-time.Duration(t.Second())*time.Second - time.Duration(t.Nanosecond())), nil
 	case "second":
 		return t.Truncate(time.Second), nil
 	case "millisecond":
@@ -2636,6 +3107,33 @@ func timeTrunc(t time.Time, part string) (time.Time, error) {
 	}
 }
 
+/*
+Add years, months and days to t on its wall clock, like AddDate.
+*/
+func addDate(t time.Time, years, months, days int) time.Time {
+	y, m, d := t.Date()
+	return wallDate(y+years, m+time.Month(months), d+days, t.Hour(), t.Minute(), t.Second(),
+		t.Nanosecond(), t.Location())
+}
+
+/*
+Return the time with the given wall clock in loc, like time.Date.
+A wall time in a DST gap does not exist, and is moved forward by
+the length of the gap, e.g. 02:30 on the day New York springs
+forward is 03:30, where time.Date can return 01:30.
+*/
+func wallDate(year int, month time.Month, day, hour, min, sec, nsec int, loc *time.Location) time.Time {
+	rv := time.Date(year, month, day, hour, min, sec, nsec, loc)
+	want := time.Date(year, month, day, hour, min, sec, nsec, time.UTC)
+	y, m, d := rv.Date()
+	got := time.Date(y, m, d, rv.Hour(), rv.Minute(), rv.Second(), rv.Nanosecond(), time.UTC)
+	if gap := want.Sub(got); gap > 0 {
+		rv = rv.Add(gap)
+	}
+
+	return rv
+}
+
 /*
 This method returns the difference between the two times. Call
 diffDates to calculate the difference between the 2 time strings
@@ -2645,7 +3143,7 @@ a negative value, return a negative result.
 */
 func dateDiff(t1, t2 time.Time, part string) (int64, error) {
 	sign := 1
-	if t1.String() < t2.String() {
+	if t1.Before(t2) {
 		t1, t2 = t2, t1
 		sign = -1
 	}
@@ -2669,30 +3167,13 @@ func diffPart(t1, t2 time.Time, diff *date, part string) (int64, error) {
 	p := strings.ToLower(part)
 
 	switch p {
-	case "millisecond":
-		sec, e := diffPart(t1, t2, diff, "second")
-		if e != nil {
-			return 0, e
-		}
-		return (sec * 1000) + int64(diff.millisecond), nil
-	case "second":
-		min, e := diffPart(t1, t2, diff, "minute")
-		if e != nil {
-			return 0, e
-		}
-		return (min * 60) + int64(diff.second), nil
-	case "minute":
-		hour, e := diffPart(t1, t2, diff, "hour")
-		if e != nil {
-			return 0, e
-		}
-		return (hour * 60) + int64(diff.minute), nil
-	case "hour":
-		day, e := diffPart(t1, t2, diff, "day")
-		if e != nil {
-			return 0, e
-		}
-		return (day * 24) + int64(diff.hour), nil
+	case "millisecond", "second", "minute", "hour":
+		// Count the part boundaries crossed in elapsed time, which
+		// includes hours skipped or repeated by DST changes.
+		tt1, _ := timeTrunc(t1, p)
+		tt2, _ := timeTrunc(t2, p)
+		millis := (tt1.Unix()-tt2.Unix())*1000 + int64(tt1.Nanosecond()-tt2.Nanosecond())/1000000
+		return millis / _PART_MILLIS[p], nil
 	case "day":
 		days := (diff.year * 365) + diff.doy
 		if diff.year != 0 {
@@ -2705,6 +3186,14 @@ func diffPart(t1, t2 time.Time, diff *date, part string) (int64, error) {
 			return 0, e
 		}
 		return day / 7, nil
+	case "iso_week":
+		w1, _ := dateTrunc(t1, p)
+		w2, _ := dateTrunc(t2, p)
+		day, e := diffPart(w1, w2, diffDates(w1, w2), "day")
+		if e != nil {
+			return 0, e
+		}
+		return day / 7, nil
 	case "month":
 		diff_month := (int64(t1.Year())*12 + int64(t1.Month())) - (int64(t2.Year())*12 + int64(t2.Month()))
 		if diff_month < 0 {
@@ -2731,6 +3220,16 @@ func diffPart(t1, t2 time.Time, diff *date, part string) (int64, error) {
 	}
 }
 
+/*
+Length in milliseconds of the parts of a day.
+*/
+var _PART_MILLIS = map[string]int64{
+	"millisecond": 1,
+	"second":      1000,
+	"minute":      60 * 1000,
+	"hour":        60 * 60 * 1000,
+}
+
 /*
 This method returns the difference between two dates. The input
 arguments to this function are of type Time. We use the setDate
@@ -0,0 +1,189 @@
+//  Copyright (c) 2018 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package expression
+
+import (
+	"github.com/couchbase/query/value"
+)
+
+///////////////////////////////////////////////////
+//
+// DecimalDiv
+//
+///////////////////////////////////////////////////
+
+/*
+This represents the number function DECIMAL_DIV(expr1, expr2
+[, scale [, rounding_mode ]]). It divides expr1 by expr2 exactly,
+rounding the quotient to scale digits after the decimal point.
+Without scale, it behaves as expr1 / expr2 for decimals.
+*/
+type DecimalDiv struct {
+	FunctionBase
+}
+
+func NewDecimalDiv(operands ...Expression) Function {
+	rv := &DecimalDiv{
+		*NewFunctionBase("decimal_div", operands...),
+	}
+
+	rv.expr = rv
+	return rv
+}
+
+/*
+Visitor pattern.
+*/
+func (this *DecimalDiv) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitFunction(this)
+}
+
+func (this *DecimalDiv) Type() value.Type { return value.NUMBER }
+
+func (this *DecimalDiv) Evaluate(item value.Value, context Context) (value.Value, error) {
+	return this.Eval(this, item, context)
+}
+
+func (this *DecimalDiv) Apply(context Context, args ...value.Value) (value.Value, error) {
+	for _, arg := range args {
+		if arg.Type() == value.MISSING {
+			return value.MISSING_VALUE, nil
+		}
+	}
+
+	scale, mode, ok := decimalScaleArgs(args[2:])
+	if !ok {
+		return value.NULL_VALUE, nil
+	}
+
+	return value.DecimalDiv(args[0], args[1], scale, mode), nil
+}
+
+/*
+Minimum input arguments required.
+*/
+func (this *DecimalDiv) MinArgs() int { return 2 }
+
+/*
+Maximum input arguments allowed.
+*/
+func (this *DecimalDiv) MaxArgs() int { return 4 }
+
+/*
+Factory method pattern.
+*/
+func (this *DecimalDiv) Constructor() FunctionConstructor {
+	return NewDecimalDiv
+}
+
+///////////////////////////////////////////////////
+//
+// ToDecimal
+//
+///////////////////////////////////////////////////
+
+/*
+This represents the type conversion function TO_DECIMAL(expr
+[, scale [, rounding_mode ]]). It converts a number or a numeric
+string to a decimal, rounded or padded to scale digits after the
+decimal point if scale is given. Arithmetic, SUM and AVG are exact
+for decimals, and decimals are returned with all their digits.
+
+The rounding modes are "half_up" (the default), "half_even",
+"half_down", "up", "down", "ceiling" and "floor".
+*/
+type ToDecimal struct {
+	FunctionBase
+}
+
+func NewToDecimal(operands ...Expression) Function {
+	rv := &ToDecimal{
+		*NewFunctionBase("to_decimal", operands...),
+	}
+
+	rv.expr = rv
+	return rv
+}
+
+/*
+Visitor pattern.
+*/
+func (this *ToDecimal) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitFunction(this)
+}
+
+func (this *ToDecimal) Type() value.Type { return value.NUMBER }
+
+func (this *ToDecimal) Evaluate(item value.Value, context Context) (value.Value, error) {
+	return this.Eval(this, item, context)
+}
+
+func (this *ToDecimal) Apply(context Context, args ...value.Value) (value.Value, error) {
+	for _, arg := range args {
+		if arg.Type() == value.MISSING {
+			return value.MISSING_VALUE, nil
+		}
+	}
+
+	scale, mode, ok := decimalScaleArgs(args[1:])
+	if !ok {
+		return value.NULL_VALUE, nil
+	}
+
+	return value.NewDecimalValue(args[0], scale, mode), nil
+}
+
+/*
+Minimum input arguments required.
+*/
+func (this *ToDecimal) MinArgs() int { return 1 }
+
+/*
+Maximum input arguments allowed.
+*/
+func (this *ToDecimal) MaxArgs() int { return 3 }
+
+/*
+Factory method pattern.
+*/
+func (this *ToDecimal) Constructor() FunctionConstructor {
+	return NewToDecimal
+}
+
+/*
+Parse the optional scale and rounding mode arguments. The scale is
+-1 if not given.
+*/
+func decimalScaleArgs(args []value.Value) (int, value.RoundingMode, bool) {
+	scale := -1
+	mode := value.ROUND_HALF_UP
+
+	if len(args) > 0 {
+		var ok bool
+		scale, ok = strIntArg(args[0])
+		if !ok || scale < 0 || scale > value.DECIMAL_MAX_SCALE {
+			return 0, mode, false
+		}
+	}
+
+	if len(args) > 1 {
+		if args[1].Type() != value.STRING {
+			return 0, mode, false
+		}
+
+		var ok bool
+		mode, ok = value.NewRoundingMode(args[1].Actual().(string))
+		if !ok {
+			return 0, mode, false
+		}
+	}
+
+	return scale, mode, true
+}
@@ -0,0 +1,52 @@
+package expression
+
+import (
+	"testing"
+
+	"github.com/couchbase/query/value"
+)
+
+func TestDecimalFunctions(t *testing.T) {
+	dec := func(v interface{}) Expression {
+		return NewToDecimal(NewConstant(v))
+	}
+
+	tests := []struct {
+		expr     Expression
+		expected string
+	}{
+		{NewAdd(dec(0.1), dec(0.2)), "0.3"},
+		{NewAdd(dec("0.10"), NewConstant(0.2)), "0.30"},
+		{NewSub(NewConstant(1), dec("0.01")), "0.99"},
+		{NewMult(dec("19.99"), NewConstant(3)), "59.97"},
+		{NewDiv(dec("10"), NewConstant(4)), "2.5"},
+		{NewDiv(dec("1.00"), NewConstant(0)), "null"},
+		{NewMod(dec("10.5"), NewConstant(3)), "1.5"},
+		{NewToDecimal(NewConstant("2.345"), NewConstant(2)), "2.35"},
+		{NewToDecimal(NewConstant(2.345), NewConstant(2), NewConstant("half_even")), "2.34"},
+		{NewToDecimal(NewConstant(2), NewConstant(2)), "2.00"},
+		{NewToDecimal(NewConstant("x")), "null"},
+		{NewToDecimal(NewConstant(1), NewConstant(2), NewConstant("sideways")), "null"},
+		{NewDecimalDiv(NewConstant(2), NewConstant(3), NewConstant(4)), "0.6667"},
+		{NewDecimalDiv(NewConstant(2), NewConstant(3), NewConstant(4), NewConstant("floor")), "0.6666"},
+		{NewRound(dec("2.345"), NewConstant(2)), "2.34"},
+		{NewTrunc(dec("-2.349"), NewConstant(2)), "-2.34"},
+		{NewToString(dec("1.50")), `"1.50"`},
+		{NewArrayAvg(NewArrayConstruct(dec("0.10"), dec("0.20"))), "0.15"},
+	}
+
+	for _, test := range tests {
+		rv, err := test.expr.Evaluate(nil, nil)
+		if err != nil {
+			t.Errorf("Error %v returned by %v", err, test.expr)
+		}
+
+		if b, _ := rv.MarshalJSON(); string(b) != test.expected {
+			t.Errorf("Mismatch for %v: received %s expected %v", test.expr, b, test.expected)
+		}
+	}
+
+	if rv, _ := dec(0.1).Evaluate(nil, nil); !value.IsDecimal(rv) {
+		t.Errorf("Expected TO_DECIMAL to return a decimal, received %v", rv)
+	}
+}
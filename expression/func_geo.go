@@ -0,0 +1,848 @@
+//  Copyright (c) 2018 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package expression
+
+import (
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/couchbase/query/value"
+)
+
+///////////////////////////////////////////////////
+//
+// GeoCells
+//
+///////////////////////////////////////////////////
+
+/*
+This represents the geospatial function GEO_CELLS(point, [ precision ]).
+It returns the geohash of the point, a string of precision characters
+(12 by default) naming the cell of the geohash grid that contains
+the point. The cells containing it at coarser precisions are named
+by the prefixes of the geohash, so that an index on GEO_CELLS(point)
+can serve GEO_WITHIN_RADIUS() through a few prefix ranges.
+*/
+type GeoCells struct {
+	FunctionBase
+}
+
+func NewGeoCells(operands ...Expression) Function {
+	rv := &GeoCells{
+		*NewFunctionBase("geo_cells", operands...),
+	}
+
+	rv.expr = rv
+	return rv
+}
+
+/*
+Visitor pattern.
+*/
+func (this *GeoCells) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitFunction(this)
+}
+
+func (this *GeoCells) Type() value.Type { return value.STRING }
+
+func (this *GeoCells) Evaluate(item value.Value, context Context) (value.Value, error) {
+	return this.Eval(this, item, context)
+}
+
+func (this *GeoCells) Apply(context Context, args ...value.Value) (value.Value, error) {
+	for _, arg := range args {
+		if arg.Type() == value.MISSING {
+			return value.MISSING_VALUE, nil
+		}
+	}
+
+	lat, lon, ok := geoPoint(args[0].Actual())
+	if !ok {
+		return value.NULL_VALUE, nil
+	}
+
+	precision := _GEO_DEFAULT_PRECISION
+	if len(args) > 1 {
+		precision, ok = geoPrecision(args[1])
+		if !ok {
+			return value.NULL_VALUE, nil
+		}
+	}
+
+	return value.NewValue(geohashEncode(lat, lon, precision)), nil
+}
+
+/*
+Return the constant precision of the geohashes, or -1 if it is
+not constant.
+*/
+func (this *GeoCells) Precision() int {
+	if len(this.operands) < 2 {
+		return _GEO_DEFAULT_PRECISION
+	}
+
+	precision, ok := geoPrecision(this.operands[1].Value())
+	if !ok {
+		return -1
+	}
+
+	return precision
+}
+
+/*
+Minimum input arguments required.
+*/
+func (this *GeoCells) MinArgs() int { return 1 }
+
+/*
+Maximum input arguments allowed.
+*/
+func (this *GeoCells) MaxArgs() int { return 2 }
+
+/*
+Factory method pattern.
+*/
+func (this *GeoCells) Constructor() FunctionConstructor {
+	return NewGeoCells
+}
+
+///////////////////////////////////////////////////
+//
+// GeoCellStart
+//
+///////////////////////////////////////////////////
+
+/*
+This represents the geospatial function GEO_CELL_START(center,
+radius, precision, n). It returns the n-th of the geohash cells,
+of at most the given precision, that cover the circle of radius
+meters around center, or MISSING if there are fewer cells. The
+planner uses it for the index spans of GEO_WITHIN_RADIUS().
+*/
+type GeoCellStart struct {
+	FunctionBase
+}
+
+func NewGeoCellStart(operands ...Expression) Function {
+	rv := &GeoCellStart{
+		*NewFunctionBase("geo_cell_start", operands...),
+	}
+
+	rv.expr = rv
+	return rv
+}
+
+/*
+Visitor pattern.
+*/
+func (this *GeoCellStart) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitFunction(this)
+}
+
+func (this *GeoCellStart) Type() value.Type { return value.STRING }
+
+func (this *GeoCellStart) Evaluate(item value.Value, context Context) (value.Value, error) {
+	return this.Eval(this, item, context)
+}
+
+func (this *GeoCellStart) Apply(context Context, args ...value.Value) (value.Value, error) {
+	cell, ok := geoCoverCell(args)
+	if !ok {
+		return value.MISSING_VALUE, nil
+	}
+
+	return value.NewValue(cell), nil
+}
+
+/*
+Minimum input arguments required.
+*/
+func (this *GeoCellStart) MinArgs() int { return 4 }
+
+/*
+Maximum input arguments allowed.
+*/
+func (this *GeoCellStart) MaxArgs() int { return 4 }
+
+/*
+Factory method pattern.
+*/
+func (this *GeoCellStart) Constructor() FunctionConstructor {
+	return NewGeoCellStart
+}
+
+///////////////////////////////////////////////////
+//
+// GeoCellStop
+//
+///////////////////////////////////////////////////
+
+/*
+This represents the geospatial function GEO_CELL_STOP(center,
+radius, precision, n). It returns the smallest value greater than
+the geohashes within the cell returned by GEO_CELL_START() for the
+same arguments.
+*/
+type GeoCellStop struct {
+	FunctionBase
+}
+
+func NewGeoCellStop(operands ...Expression) Function {
+	rv := &GeoCellStop{
+		*NewFunctionBase("geo_cell_stop", operands...),
+	}
+
+	rv.expr = rv
+	return rv
+}
+
+/*
+Visitor pattern.
+*/
+func (this *GeoCellStop) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitFunction(this)
+}
+
+func (this *GeoCellStop) Type() value.Type { return value.JSON }
+
+func (this *GeoCellStop) Evaluate(item value.Value, context Context) (value.Value, error) {
+	return this.Eval(this, item, context)
+}
+
+func (this *GeoCellStop) Apply(context Context, args ...value.Value) (value.Value, error) {
+	cell, ok := geoCoverCell(args)
+	if !ok {
+		return value.MISSING_VALUE, nil
+	}
+
+	return GeoCellStopValue(cell), nil
+}
+
+/*
+Minimum input arguments required.
+*/
+func (this *GeoCellStop) MinArgs() int { return 4 }
+
+/*
+Maximum input arguments allowed.
+*/
+func (this *GeoCellStop) MaxArgs() int { return 4 }
+
+/*
+Factory method pattern.
+*/
+func (this *GeoCellStop) Constructor() FunctionConstructor {
+	return NewGeoCellStop
+}
+
+///////////////////////////////////////////////////
+//
+// GeoDistance
+//
+///////////////////////////////////////////////////
+
+/*
+This represents the geospatial function GEO_DISTANCE(point1, point2,
+[ unit ]). It returns the great-circle distance between the points,
+in meters, or in the unit "m", "km", "mi", "ft" or "nmi".
+*/
+type GeoDistance struct {
+	FunctionBase
+}
+
+func NewGeoDistance(operands ...Expression) Function {
+	rv := &GeoDistance{
+		*NewFunctionBase("geo_distance", operands...),
+	}
+
+	rv.expr = rv
+	return rv
+}
+
+/*
+Visitor pattern.
+*/
+func (this *GeoDistance) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitFunction(this)
+}
+
+func (this *GeoDistance) Type() value.Type { return value.NUMBER }
+
+func (this *GeoDistance) Evaluate(item value.Value, context Context) (value.Value, error) {
+	return this.Eval(this, item, context)
+}
+
+func (this *GeoDistance) Apply(context Context, args ...value.Value) (value.Value, error) {
+	for _, arg := range args {
+		if arg.Type() == value.MISSING {
+			return value.MISSING_VALUE, nil
+		}
+	}
+
+	lat1, lon1, ok1 := geoPoint(args[0].Actual())
+	lat2, lon2, ok2 := geoPoint(args[1].Actual())
+	if !ok1 || !ok2 {
+		return value.NULL_VALUE, nil
+	}
+
+	unit := 1.0
+	if len(args) > 2 {
+		if args[2].Type() != value.STRING {
+			return value.NULL_VALUE, nil
+		}
+
+		var ok bool
+		unit, ok = _GEO_UNITS[strings.ToLower(args[2].Actual().(string))]
+		if !ok {
+			return value.NULL_VALUE, nil
+		}
+	}
+
+	return value.NewValue(geoDistance(lat1, lon1, lat2, lon2) / unit), nil
+}
+
+/*
+Minimum input arguments required.
+*/
+func (this *GeoDistance) MinArgs() int { return 2 }
+
+/*
+Maximum input arguments allowed.
+*/
+func (this *GeoDistance) MaxArgs() int { return 3 }
+
+/*
+Factory method pattern.
+*/
+func (this *GeoDistance) Constructor() FunctionConstructor {
+	return NewGeoDistance
+}
+
+///////////////////////////////////////////////////
+//
+// GeoPoint
+//
+///////////////////////////////////////////////////
+
+/*
+This represents the geospatial function GEO_POINT(expr). It returns
+the point expr as a GeoJSON Point, or NULL if expr is not a point.
+Points are GeoJSON Points, objects with lat and lon (or lng) fields,
+or [lon, lat] arrays as in GeoJSON, with an optional altitude.
+*/
+type GeoPoint struct {
+	UnaryFunctionBase
+}
+
+func NewGeoPoint(operand Expression) Function {
+	rv := &GeoPoint{
+		*NewUnaryFunctionBase("geo_point", operand),
+	}
+
+	rv.expr = rv
+	return rv
+}
+
+/*
+Visitor pattern.
+*/
+func (this *GeoPoint) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitFunction(this)
+}
+
+func (this *GeoPoint) Type() value.Type { return value.OBJECT }
+
+func (this *GeoPoint) Evaluate(item value.Value, context Context) (value.Value, error) {
+	return this.UnaryEval(this, item, context)
+}
+
+func (this *GeoPoint) Apply(context Context, arg value.Value) (value.Value, error) {
+	if arg.Type() == value.MISSING {
+		return value.MISSING_VALUE, nil
+	}
+
+	lat, lon, ok := geoPoint(arg.Actual())
+	if !ok {
+		return value.NULL_VALUE, nil
+	}
+
+	return value.NewValue(map[string]interface{}{
+		"type":        "Point",
+		"coordinates": []interface{}{lon, lat},
+	}), nil
+}
+
+/*
+Factory method pattern.
+*/
+func (this *GeoPoint) Constructor() FunctionConstructor {
+	return func(operands ...Expression) Function {
+		return NewGeoPoint(operands[0])
+	}
+}
+
+///////////////////////////////////////////////////
+//
+// GeoWithinPolygon
+//
+///////////////////////////////////////////////////
+
+/*
+This represents the geospatial function GEO_WITHIN_POLYGON(point,
+polygon). It returns true if the point lies within the polygon,
+which is a GeoJSON Polygon, whose first ring is the boundary and
+whose other rings are holes, or an array of the points of the
+boundary. Edges are straight lines in longitude and latitude.
+*/
+type GeoWithinPolygon struct {
+	BinaryFunctionBase
+}
+
+func NewGeoWithinPolygon(first, second Expression) Function {
+	rv := &GeoWithinPolygon{
+		*NewBinaryFunctionBase("geo_within_polygon", first, second),
+	}
+
+	rv.expr = rv
+	return rv
+}
+
+/*
+Visitor pattern.
+*/
+func (this *GeoWithinPolygon) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitFunction(this)
+}
+
+func (this *GeoWithinPolygon) Type() value.Type { return value.BOOLEAN }
+
+func (this *GeoWithinPolygon) Evaluate(item value.Value, context Context) (value.Value, error) {
+	return this.BinaryEval(this, item, context)
+}
+
+func (this *GeoWithinPolygon) Apply(context Context, first, second value.Value) (value.Value, error) {
+	if first.Type() == value.MISSING || second.Type() == value.MISSING {
+		return value.MISSING_VALUE, nil
+	}
+
+	lat, lon, ok := geoPoint(first.Actual())
+	if !ok {
+		return value.NULL_VALUE, nil
+	}
+
+	rings, ok := geoPolygon(second.Actual())
+	if !ok {
+		return value.NULL_VALUE, nil
+	}
+
+	within := geoInRing(lat, lon, rings[0])
+	for _, hole := range rings[1:] {
+		within = within && !geoInRing(lat, lon, hole)
+	}
+
+	return value.NewValue(within), nil
+}
+
+/*
+Factory method pattern.
+*/
+func (this *GeoWithinPolygon) Constructor() FunctionConstructor {
+	return func(operands ...Expression) Function {
+		return NewGeoWithinPolygon(operands[0], operands[1])
+	}
+}
+
+///////////////////////////////////////////////////
+//
+// GeoWithinRadius
+//
+///////////////////////////////////////////////////
+
+/*
+This represents the geospatial function GEO_WITHIN_RADIUS(point,
+center, radius). It returns true if the point lies within radius
+meters of center. It can use an index on GEO_CELLS(point).
+*/
+type GeoWithinRadius struct {
+	TernaryFunctionBase
+}
+
+func NewGeoWithinRadius(first, second, third Expression) Function {
+	rv := &GeoWithinRadius{
+		*NewTernaryFunctionBase("geo_within_radius", first, second, third),
+	}
+
+	rv.expr = rv
+	return rv
+}
+
+/*
+Visitor pattern.
+*/
+func (this *GeoWithinRadius) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitFunction(this)
+}
+
+func (this *GeoWithinRadius) Type() value.Type { return value.BOOLEAN }
+
+func (this *GeoWithinRadius) Evaluate(item value.Value, context Context) (value.Value, error) {
+	return this.TernaryEval(this, item, context)
+}
+
+func (this *GeoWithinRadius) Apply(context Context, first, second, third value.Value) (value.Value, error) {
+	if first.Type() == value.MISSING || second.Type() == value.MISSING || third.Type() == value.MISSING {
+		return value.MISSING_VALUE, nil
+	}
+
+	lat, lon, ok1 := geoPoint(first.Actual())
+	clat, clon, ok2 := geoPoint(second.Actual())
+	radius, ok3 := geoNumber(third.Actual())
+	if !ok1 || !ok2 || !ok3 {
+		return value.NULL_VALUE, nil
+	}
+
+	return value.NewValue(geoDistance(lat, lon, clat, clon) <= radius), nil
+}
+
+/*
+Factory method pattern.
+*/
+func (this *GeoWithinRadius) Constructor() FunctionConstructor {
+	return func(operands ...Expression) Function {
+		return NewGeoWithinRadius(operands[0], operands[1], operands[2])
+	}
+}
+
+/*
+Mean radius of the Earth in meters, and the length of a degree of
+latitude on it.
+*/
+const _GEO_EARTH_RADIUS = 6371008.8
+const _GEO_METERS_PER_DEGREE = _GEO_EARTH_RADIUS * math.Pi / 180
+
+/*
+Default and maximum precision of GEO_CELLS().
+*/
+const _GEO_DEFAULT_PRECISION = 12
+const _GEO_MAX_PRECISION = 12
+
+/*
+Covering cells are chosen slightly larger than the radius, to allow
+for the curvature of the Earth.
+*/
+const _GEO_COVER_MARGIN = 1.05
+
+/*
+The number of geohash cells covering a circle is at most 9, the cell
+of the center and its neighbors.
+*/
+const GEO_COVER_CELLS = 9
+
+const _GEOHASH_BASE32 = "0123456789bcdefghjkmnpqrstuvwxyz"
+
+var _GEO_UNITS = map[string]float64{
+	"m":   1.0,
+	"km":  1000.0,
+	"mi":  1609.344,
+	"ft":  0.3048,
+	"nmi": 1852.0,
+}
+
+/*
+Return the great-circle distance in meters between two points, using
+the haversine formula.
+*/
+func geoDistance(lat1, lon1, lat2, lon2 float64) float64 {
+	rlat1 := lat1 * math.Pi / 180
+	rlat2 := lat2 * math.Pi / 180
+	dlat := rlat2 - rlat1
+	dlon := (lon2 - lon1) * math.Pi / 180
+
+	a := math.Sin(dlat/2)*math.Sin(dlat/2) +
+		math.Cos(rlat1)*math.Cos(rlat2)*math.Sin(dlon/2)*math.Sin(dlon/2)
+	return 2 * _GEO_EARTH_RADIUS * math.Asin(math.Min(1, math.Sqrt(a)))
+}
+
+func geoNumber(actual interface{}) (float64, bool) {
+	switch n := value.NewValue(actual).Actual().(type) {
+	case float64:
+		return n, true
+	case int64:
+		return float64(n), true
+	default:
+		return 0, false
+	}
+}
+
+/*
+Parse a point, which is a GeoJSON Point, an object with lat and
+lon (or lng) fields, or a [lon, lat] array.
+*/
+func geoPoint(actual interface{}) (lat, lon float64, ok bool) {
+	switch p := value.NewValue(actual).Actual().(type) {
+	case map[string]interface{}:
+		if t, found := p["type"]; found {
+			if value.NewValue(t).Actual() != "Point" {
+				return 0, 0, false
+			}
+			return geoPoint(p["coordinates"])
+		}
+
+		lonv, found := p["lon"]
+		if !found {
+			lonv = p["lng"]
+		}
+
+		var ok1, ok2 bool
+		lat, ok1 = geoNumber(p["lat"])
+		lon, ok2 = geoNumber(lonv)
+		ok = ok1 && ok2
+	case []interface{}:
+		if len(p) < 2 || len(p) > 3 {
+			return 0, 0, false
+		}
+
+		var ok1, ok2 bool
+		lon, ok1 = geoNumber(p[0])
+		lat, ok2 = geoNumber(p[1])
+		ok = ok1 && ok2
+	}
+
+	if !ok || lat < -90 || lat > 90 || lon < -180 || lon > 180 {
+		return 0, 0, false
+	}
+
+	return lat, lon, true
+}
+
+/*
+Parse a polygon, which is a GeoJSON Polygon or an array of points,
+into its rings of [lon, lat] points.
+*/
+func geoPolygon(actual interface{}) ([][][2]float64, bool) {
+	var rings []interface{}
+
+	switch p := value.NewValue(actual).Actual().(type) {
+	case map[string]interface{}:
+		if value.NewValue(p["type"]).Actual() != "Polygon" {
+			return nil, false
+		}
+
+		rings, _ = value.NewValue(p["coordinates"]).Actual().([]interface{})
+	case []interface{}:
+		rings = []interface{}{p}
+	}
+
+	if len(rings) == 0 {
+		return nil, false
+	}
+
+	rv := make([][][2]float64, len(rings))
+	for i, ring := range rings {
+		points, _ := value.NewValue(ring).Actual().([]interface{})
+		if len(points) < 3 {
+			return nil, false
+		}
+
+		rv[i] = make([][2]float64, len(points))
+		for j, point := range points {
+			lat, lon, ok := geoPoint(point)
+			if !ok {
+				return nil, false
+			}
+			rv[i][j] = [2]float64{lon, lat}
+		}
+	}
+
+	return rv, true
+}
+
+/*
+Return true if the point lies within the ring, by counting the edges
+crossed by a ray from the point.
+*/
+func geoInRing(lat, lon float64, ring [][2]float64) bool {
+	in := false
+	for i, j := 0, len(ring)-1; i < len(ring); j, i = i, i+1 {
+		xi, yi := ring[i][0], ring[i][1]
+		xj, yj := ring[j][0], ring[j][1]
+		if (yi > lat) != (yj > lat) && lon < (xj-xi)*(lat-yi)/(yj-yi)+xi {
+			in = !in
+		}
+	}
+
+	return in
+}
+
+func geoPrecision(val value.Value) (int, bool) {
+	if val == nil {
+		return 0, false
+	}
+
+	p, ok := geoNumber(val.Actual())
+	if !ok || p != math.Trunc(p) || p < 1 || p > _GEO_MAX_PRECISION {
+		return 0, false
+	}
+
+	return int(p), true
+}
+
+/*
+Return the geohash of a point, with precision characters.
+*/
+func geohashEncode(lat, lon float64, precision int) string {
+	latLo, latHi := -90.0, 90.0
+	lonLo, lonHi := -180.0, 180.0
+	buf := make([]byte, 0, precision)
+
+	even := true
+	bits, ch := 0, 0
+	for len(buf) < precision {
+		if even {
+			mid := (lonLo + lonHi) / 2
+			if lon >= mid {
+				ch = ch<<1 | 1
+				lonLo = mid
+			} else {
+				ch <<= 1
+				lonHi = mid
+			}
+		} else {
+			mid := (latLo + latHi) / 2
+			if lat >= mid {
+				ch = ch<<1 | 1
+				latLo = mid
+			} else {
+				ch <<= 1
+				latHi = mid
+			}
+		}
+
+		even = !even
+		bits++
+		if bits == 5 {
+			buf = append(buf, _GEOHASH_BASE32[ch])
+			bits, ch = 0, 0
+		}
+	}
+
+	return string(buf)
+}
+
+/*
+Return the height and width in degrees of the geohash cells with
+precision characters.
+*/
+func geohashCellSize(precision int) (float64, float64) {
+	bits := 5 * precision
+	latBits := bits / 2
+	lonBits := bits - latBits
+	return 180 / math.Pow(2, float64(latBits)), 360 / math.Pow(2, float64(lonBits))
+}
+
+/*
+GeoCoverCells returns the sorted geohash cells, of at most the given
+precision, that cover the circle of radius meters around center. The
+cells are those of the finest precision whose cells are at least as
+large as the circle, being the cell of the center and its neighbors.
+Near the poles, the single empty cell covers the whole Earth. ok is
+false if center or radius is invalid.
+*/
+func GeoCoverCells(center, radius value.Value, precision int) (cells []string, ok bool) {
+	lat, lon, ok := geoPoint(center.Actual())
+	if !ok {
+		return nil, false
+	}
+
+	r, ok := geoNumber(radius.Actual())
+	if !ok {
+		return nil, false
+	}
+
+	rdeg := math.Max(r, 0) * _GEO_COVER_MARGIN / _GEO_METERS_PER_DEGREE
+	if math.Abs(lat)+rdeg >= 90 {
+		return []string{""}, true
+	}
+
+	cos := math.Cos((math.Abs(lat) + rdeg) * math.Pi / 180)
+	for ; precision > 0; precision-- {
+		height, width := geohashCellSize(precision)
+		if height >= rdeg && width*cos >= rdeg {
+			break
+		}
+	}
+
+	if precision == 0 {
+		return []string{""}, true
+	}
+
+	height, width := geohashCellSize(precision)
+	set := make(map[string]bool, GEO_COVER_CELLS)
+	for _, dlat := range []float64{-height, 0, height} {
+		clat := math.Max(-90, math.Min(90, lat+dlat))
+		for _, dlon := range []float64{-width, 0, width} {
+			clon := lon + dlon
+			if clon < -180 {
+				clon += 360
+			} else if clon > 180 {
+				clon -= 360
+			}
+			set[geohashEncode(clat, clon, precision)] = true
+		}
+	}
+
+	cells = make([]string, 0, len(set))
+	for cell := range set {
+		cells = append(cells, cell)
+	}
+
+	sort.Strings(cells)
+	return cells, true
+}
+
+/*
+GeoCellStopValue returns the smallest value greater than all the
+geohashes within a cell. It is the cell with its last character
+incremented, or an empty array, which is greater than all strings,
+for the empty cell.
+*/
+func GeoCellStopValue(cell string) value.Value {
+	if cell == "" {
+		return value.EMPTY_ARRAY_VALUE
+	}
+
+	bytes := []byte(cell)
+	bytes[len(bytes)-1]++
+	return value.NewValue(string(bytes))
+}
+
+/*
+Return the n-th covering cell for the arguments of GEO_CELL_START()
+and GEO_CELL_STOP().
+*/
+func geoCoverCell(args []value.Value) (string, bool) {
+	precision, ok := geoPrecision(args[2])
+	if !ok {
+		return "", false
+	}
+
+	n, ok := geoNumber(args[3].Actual())
+	if !ok {
+		return "", false
+	}
+
+	cells, ok := GeoCoverCells(args[0], args[1], precision)
+	if !ok || n < 0 || int(n) >= len(cells) {
+		return "", false
+	}
+
+	return cells[int(n)], true
+}
@@ -0,0 +1,68 @@
+package expression
+
+import (
+	"testing"
+
+	"github.com/couchbase/query/value"
+)
+
+func TestGeoFunctions(t *testing.T) {
+	sf := NewConstant(map[string]interface{}{"lat": 37.7749, "lon": -122.4194})
+	tests := []struct {
+		expr     Expression
+		expected interface{}
+	}{
+		{NewGeoCells(NewConstant([]interface{}{10.40744, 57.64911}), NewConstant(11)), "u4pruydqqvj"},
+		{NewGeoCells(NewConstant([]interface{}{10.40744, 57.64911}), NewConstant(13)), nil},
+		{NewGeoPoint(NewConstant(map[string]interface{}{"lat": 1, "lng": 2})),
+			map[string]interface{}{"type": "Point", "coordinates": []interface{}{2, 1}}},
+		{NewGeoPoint(NewConstant(map[string]interface{}{"type": "LineString"})), nil},
+		{NewGeoWithinRadius(NewConstant([]interface{}{-122.418, 37.776}), sf, NewConstant(200)), true},
+		{NewGeoWithinRadius(NewConstant([]interface{}{-122.418, 37.776}), sf, NewConstant(150)), false},
+		{NewGeoWithinPolygon(NewConstant([]interface{}{2, 5}),
+			NewConstant([]interface{}{[]interface{}{0, 0}, []interface{}{10, 0}, []interface{}{10, 10}})), false},
+		{NewGeoWithinPolygon(NewConstant([]interface{}{8, 5}),
+			NewConstant([]interface{}{[]interface{}{0, 0}, []interface{}{10, 0}, []interface{}{10, 10}})), true},
+		{NewGeoCellStart(sf, NewConstant(5000), NewConstant(12), NewConstant(4)), "9q8y"},
+		{NewGeoCellStop(sf, NewConstant(5000), NewConstant(12), NewConstant(4)), "9q8z"},
+	}
+
+	for _, test := range tests {
+		rv, err := test.expr.Evaluate(nil, nil)
+		if err != nil {
+			t.Errorf("Error %v returned by %v", err, test.expr)
+		}
+
+		if rv.Collate(value.NewValue(test.expected)) != 0 {
+			t.Errorf("Mismatch for %v: received %v expected %v", test.expr, rv, test.expected)
+		}
+	}
+
+	rv, _ := NewGeoDistance(NewConstant([]interface{}{-0.1246, 51.5007}),
+		NewConstant([]interface{}{-74.0445, 40.6892}), NewConstant("km")).Evaluate(nil, nil)
+	if d, ok := rv.Actual().(float64); !ok || d < 5574 || d > 5576 {
+		t.Errorf("Expected a distance of 5575 km, received %v", rv)
+	}
+}
+
+func TestGeoCoverCells(t *testing.T) {
+	center := value.NewValue(map[string]interface{}{"lat": 37.7749, "lon": -122.4194})
+	cells, ok := GeoCoverCells(center, value.NewValue(5000), 12)
+	if !ok || len(cells) != GEO_COVER_CELLS || cells[4] != "9q8y" {
+		t.Errorf("Unexpected cover %v", cells)
+	}
+
+	cells, ok = GeoCoverCells(center, value.NewValue(500), 4)
+	if !ok || len(cells) != GEO_COVER_CELLS || len(cells[0]) != 4 {
+		t.Errorf("Unexpected cover at precision 4 %v", cells)
+	}
+
+	cells, ok = GeoCoverCells(value.NewValue([]interface{}{0, 89.99}), value.NewValue(1000), 12)
+	if !ok || len(cells) != 1 || cells[0] != "" {
+		t.Errorf("Expected the whole Earth near the pole, received %v", cells)
+	}
+
+	if _, ok = GeoCoverCells(center, value.NewValue("far"), 12); ok {
+		t.Errorf("Expected an invalid radius")
+	}
+}
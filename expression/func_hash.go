@@ -0,0 +1,377 @@
+//  Copyright (c) 2018 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package expression
+
+import (
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"hash"
+	"strings"
+
+	"github.com/couchbase/query/util"
+	"github.com/couchbase/query/value"
+)
+
+///////////////////////////////////////////////////
+//
+// MD5
+//
+///////////////////////////////////////////////////
+
+/*
+This represents the function MD5(expr). It returns the MD5
+digest of the canonical JSON encoding of expr, as a string of
+hexadecimal digits.
+*/
+type MD5 struct {
+	UnaryFunctionBase
+}
+
+func NewMD5(operand Expression) Function {
+	rv := &MD5{
+		*NewUnaryFunctionBase("md5", operand),
+	}
+
+	rv.expr = rv
+	return rv
+}
+
+/*
+Visitor pattern.
+*/
+func (this *MD5) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitFunction(this)
+}
+
+func (this *MD5) Type() value.Type { return value.STRING }
+
+func (this *MD5) Evaluate(item value.Value, context Context) (value.Value, error) {
+	return this.UnaryEval(this, item, context)
+}
+
+func (this *MD5) Apply(context Context, arg value.Value) (value.Value, error) {
+	return hashDigest(md5.New, arg), nil
+}
+
+/*
+Factory method pattern.
+*/
+func (this *MD5) Constructor() FunctionConstructor {
+	return func(operands ...Expression) Function {
+		return NewMD5(operands[0])
+	}
+}
+
+///////////////////////////////////////////////////
+//
+// SHA1
+//
+///////////////////////////////////////////////////
+
+/*
+This represents the function SHA1(expr). It returns the SHA-1
+digest of the canonical JSON encoding of expr, as a string of
+hexadecimal digits.
+*/
+type SHA1 struct {
+	UnaryFunctionBase
+}
+
+func NewSHA1(operand Expression) Function {
+	rv := &SHA1{
+		*NewUnaryFunctionBase("sha1", operand),
+	}
+
+	rv.expr = rv
+	return rv
+}
+
+/*
+Visitor pattern.
+*/
+func (this *SHA1) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitFunction(this)
+}
+
+func (this *SHA1) Type() value.Type { return value.STRING }
+
+func (this *SHA1) Evaluate(item value.Value, context Context) (value.Value, error) {
+	return this.UnaryEval(this, item, context)
+}
+
+func (this *SHA1) Apply(context Context, arg value.Value) (value.Value, error) {
+	return hashDigest(sha1.New, arg), nil
+}
+
+/*
+Factory method pattern.
+*/
+func (this *SHA1) Constructor() FunctionConstructor {
+	return func(operands ...Expression) Function {
+		return NewSHA1(operands[0])
+	}
+}
+
+///////////////////////////////////////////////////
+//
+// SHA256
+//
+///////////////////////////////////////////////////
+
+/*
+This represents the function SHA256(expr). It returns the SHA-256
+digest of the canonical JSON encoding of expr, as a string of
+hexadecimal digits.
+*/
+type SHA256 struct {
+	UnaryFunctionBase
+}
+
+func NewSHA256(operand Expression) Function {
+	rv := &SHA256{
+		*NewUnaryFunctionBase("sha256", operand),
+	}
+
+	rv.expr = rv
+	return rv
+}
+
+/*
+Visitor pattern.
+*/
+func (this *SHA256) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitFunction(this)
+}
+
+func (this *SHA256) Type() value.Type { return value.STRING }
+
+func (this *SHA256) Evaluate(item value.Value, context Context) (value.Value, error) {
+	return this.UnaryEval(this, item, context)
+}
+
+func (this *SHA256) Apply(context Context, arg value.Value) (value.Value, error) {
+	return hashDigest(sha256.New, arg), nil
+}
+
+/*
+Factory method pattern.
+*/
+func (this *SHA256) Constructor() FunctionConstructor {
+	return func(operands ...Expression) Function {
+		return NewSHA256(operands[0])
+	}
+}
+
+///////////////////////////////////////////////////
+//
+// SHA512
+//
+///////////////////////////////////////////////////
+
+/*
+This represents the function SHA512(expr). It returns the SHA-512
+digest of the canonical JSON encoding of expr, as a string of
+hexadecimal digits.
+*/
+type SHA512 struct {
+	UnaryFunctionBase
+}
+
+func NewSHA512(operand Expression) Function {
+	rv := &SHA512{
+		*NewUnaryFunctionBase("sha512", operand),
+	}
+
+	rv.expr = rv
+	return rv
+}
+
+/*
+Visitor pattern.
+*/
+func (this *SHA512) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitFunction(this)
+}
+
+func (this *SHA512) Type() value.Type { return value.STRING }
+
+func (this *SHA512) Evaluate(item value.Value, context Context) (value.Value, error) {
+	return this.UnaryEval(this, item, context)
+}
+
+func (this *SHA512) Apply(context Context, arg value.Value) (value.Value, error) {
+	return hashDigest(sha512.New, arg), nil
+}
+
+/*
+Factory method pattern.
+*/
+func (this *SHA512) Constructor() FunctionConstructor {
+	return func(operands ...Expression) Function {
+		return NewSHA512(operands[0])
+	}
+}
+
+///////////////////////////////////////////////////
+//
+// Hmac
+//
+///////////////////////////////////////////////////
+
+/*
+This represents the function HMAC(alg, key, expr). It returns the
+HMAC of the canonical JSON encoding of expr, using the hash
+algorithm alg, one of "md5", "sha1", "sha256" and "sha512", and
+the secret string key, as a string of hexadecimal digits.
+*/
+type Hmac struct {
+	TernaryFunctionBase
+}
+
+func NewHmac(first, second, third Expression) Function {
+	rv := &Hmac{
+		*NewTernaryFunctionBase("hmac", first, second, third),
+	}
+
+	rv.expr = rv
+	return rv
+}
+
+/*
+Visitor pattern.
+*/
+func (this *Hmac) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitFunction(this)
+}
+
+func (this *Hmac) Type() value.Type { return value.STRING }
+
+func (this *Hmac) Evaluate(item value.Value, context Context) (value.Value, error) {
+	return this.TernaryEval(this, item, context)
+}
+
+/*
+It returns NULL if alg is not a supported hash algorithm or the
+key is not a string.
+*/
+func (this *Hmac) Apply(context Context, alg, key, arg value.Value) (value.Value, error) {
+	if alg.Type() == value.MISSING || key.Type() == value.MISSING || arg.Type() == value.MISSING {
+		return value.MISSING_VALUE, nil
+	} else if alg.Type() != value.STRING || key.Type() != value.STRING {
+		return value.NULL_VALUE, nil
+	}
+
+	newHash, ok := _HASH_ALGORITHMS[strings.ToLower(alg.Actual().(string))]
+	if !ok {
+		return value.NULL_VALUE, nil
+	}
+
+	mac := hmac.New(newHash, []byte(key.Actual().(string)))
+	mac.Write(hashInput(arg))
+	return value.NewValue(hex.EncodeToString(mac.Sum(nil))), nil
+}
+
+/*
+Factory method pattern.
+*/
+func (this *Hmac) Constructor() FunctionConstructor {
+	return func(operands ...Expression) Function {
+		return NewHmac(operands[0], operands[1], operands[2])
+	}
+}
+
+///////////////////////////////////////////////////
+//
+// Hash64
+//
+///////////////////////////////////////////////////
+
+/*
+This represents the function HASH64(expr). It returns a fast,
+non-cryptographic 64-bit hash of the canonical JSON encoding
+of expr, as a number.
+*/
+type Hash64 struct {
+	UnaryFunctionBase
+}
+
+func NewHash64(operand Expression) Function {
+	rv := &Hash64{
+		*NewUnaryFunctionBase("hash64", operand),
+	}
+
+	rv.expr = rv
+	return rv
+}
+
+/*
+Visitor pattern.
+*/
+func (this *Hash64) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitFunction(this)
+}
+
+func (this *Hash64) Type() value.Type { return value.NUMBER }
+
+func (this *Hash64) Evaluate(item value.Value, context Context) (value.Value, error) {
+	return this.UnaryEval(this, item, context)
+}
+
+func (this *Hash64) Apply(context Context, arg value.Value) (value.Value, error) {
+	if arg.Type() == value.MISSING {
+		return value.MISSING_VALUE, nil
+	}
+
+	return value.NewValue(int64(util.SeaHashSum64(hashInput(arg)))), nil
+}
+
+/*
+Factory method pattern.
+*/
+func (this *Hash64) Constructor() FunctionConstructor {
+	return func(operands ...Expression) Function {
+		return NewHash64(operands[0])
+	}
+}
+
+/*
+The canonical JSON encoding of a value, with object fields in
+sorted order, so that equal values have equal hashes. BINARY
+values are hashed as is.
+*/
+func hashInput(arg value.Value) []byte {
+	if arg.Type() == value.BINARY {
+		return arg.Actual().([]byte)
+	}
+
+	bytes, _ := arg.MarshalJSON()
+	return bytes
+}
+
+func hashDigest(newHash func() hash.Hash, arg value.Value) value.Value {
+	if arg.Type() == value.MISSING {
+		return value.MISSING_VALUE
+	}
+
+	h := newHash()
+	h.Write(hashInput(arg))
+	return value.NewValue(hex.EncodeToString(h.Sum(nil)))
+}
+
+var _HASH_ALGORITHMS = map[string]func() hash.Hash{
+	"md5":    md5.New,
+	"sha1":   sha1.New,
+	"sha256": sha256.New,
+	"sha512": sha512.New,
+}
@@ -0,0 +1,51 @@
+package expression
+
+import (
+	"testing"
+
+	"github.com/couchbase/query/value"
+)
+
+func TestHashDigests(t *testing.T) {
+	tests := []struct {
+		expr     Expression
+		expected interface{}
+	}{
+		{NewMD5(NewConstant("abc")), "ebd9f4c7b06cb0aaf5d13d80e49d8b90"},
+		{NewSHA1(NewConstant(nil)), "2be88ca4242c76e8253ac62474851065032d6833"},
+		{NewSHA256(NewConstant(map[string]interface{}{"b": 2, "a": 1})),
+			"43258cff783fe7036d8a43033f830adfc60ec037382473548ac742b888292777"},
+		{NewHmac(NewConstant("SHA256"), NewConstant("key"), NewConstant("abc")),
+			"7b83606bf498b0a6d97232e7581e80cb5f99ff53da44822a398a0d1385025627"},
+		{NewHmac(NewConstant("crc32"), NewConstant("key"), NewConstant("abc")), nil},
+	}
+
+	for _, test := range tests {
+		rv, err := test.expr.Evaluate(nil, nil)
+		if err != nil {
+			t.Errorf("Error %v returned by %v", err, test.expr)
+		}
+
+		if rv.Collate(value.NewValue(test.expected)) != 0 {
+			t.Errorf("Mismatch for %v: received %v expected %v", test.expr, rv, test.expected)
+		}
+	}
+
+	rv, _ := NewMD5(NewConstant(value.MISSING_VALUE)).Evaluate(nil, nil)
+	if rv.Type() != value.MISSING {
+		t.Errorf("Expected MISSING, received %v", rv)
+	}
+}
+
+func TestHash64(t *testing.T) {
+	first, _ := NewHash64(NewConstant(map[string]interface{}{"a": 1, "b": []interface{}{1, 2}})).Evaluate(nil, nil)
+	second, _ := NewHash64(NewConstant(map[string]interface{}{"b": []interface{}{1, 2}, "a": 1})).Evaluate(nil, nil)
+	if first.Type() != value.NUMBER || first.Collate(second) != 0 {
+		t.Errorf("Expected equal hashes of equal objects, received %v and %v", first, second)
+	}
+
+	third, _ := NewHash64(NewConstant(map[string]interface{}{"a": 2, "b": []interface{}{1, 2}})).Evaluate(nil, nil)
+	if first.Collate(third) == 0 {
+		t.Errorf("Expected different hashes of different objects, received %v", first)
+	}
+}
@@ -0,0 +1,415 @@
+//  Copyright (c) 2018 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package expression
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/couchbase/query/value"
+)
+
+///////////////////////////////////////////////////
+//
+// JSONPatch
+//
+///////////////////////////////////////////////////
+
+/*
+This represents the json function JSON_PATCH(expr, ops). It applies
+the RFC 6902 JSON Patch ops, an array of operations such as
+{"op": "add", "path": "/a/b", "value": 1}, to a copy of expr and
+returns the result. The operations are add, remove, replace, move,
+copy and test. If any operation fails, the result is NULL.
+*/
+type JSONPatch struct {
+	BinaryFunctionBase
+}
+
+func NewJSONPatch(first, second Expression) Function {
+	rv := &JSONPatch{
+		*NewBinaryFunctionBase("json_patch", first, second),
+	}
+
+	rv.expr = rv
+	return rv
+}
+
+/*
+Visitor pattern.
+*/
+func (this *JSONPatch) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitFunction(this)
+}
+
+func (this *JSONPatch) Type() value.Type { return value.JSON }
+
+func (this *JSONPatch) Evaluate(item value.Value, context Context) (value.Value, error) {
+	return this.BinaryEval(this, item, context)
+}
+
+func (this *JSONPatch) Apply(context Context, first, second value.Value) (value.Value, error) {
+	if first.Type() == value.MISSING || second.Type() == value.MISSING {
+		return value.MISSING_VALUE, nil
+	} else if second.Type() != value.ARRAY {
+		return value.NULL_VALUE, nil
+	}
+
+	doc := jsonCopy(first.Actual())
+	for _, op := range jsonCopy(second.Actual()).([]interface{}) {
+		var err error
+		doc, err = jsonPatchOp(doc, op)
+		if err != nil {
+			return value.NULL_VALUE, nil
+		}
+	}
+
+	return value.NewValue(doc), nil
+}
+
+/*
+Factory method pattern.
+*/
+func (this *JSONPatch) Constructor() FunctionConstructor {
+	return func(operands ...Expression) Function {
+		return NewJSONPatch(operands[0], operands[1])
+	}
+}
+
+///////////////////////////////////////////////////
+//
+// JSONMergePatch
+//
+///////////////////////////////////////////////////
+
+/*
+This represents the json function JSON_MERGE_PATCH(expr, patch).
+It applies the RFC 7396 JSON Merge Patch patch to expr: the fields
+of an object patch are merged recursively into expr, and a field
+whose value is null is removed. Any other patch replaces expr.
+*/
+type JSONMergePatch struct {
+	BinaryFunctionBase
+}
+
+func NewJSONMergePatch(first, second Expression) Function {
+	rv := &JSONMergePatch{
+		*NewBinaryFunctionBase("json_merge_patch", first, second),
+	}
+
+	rv.expr = rv
+	return rv
+}
+
+/*
+Visitor pattern.
+*/
+func (this *JSONMergePatch) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitFunction(this)
+}
+
+func (this *JSONMergePatch) Type() value.Type { return value.JSON }
+
+func (this *JSONMergePatch) Evaluate(item value.Value, context Context) (value.Value, error) {
+	return this.BinaryEval(this, item, context)
+}
+
+func (this *JSONMergePatch) Apply(context Context, first, second value.Value) (value.Value, error) {
+	if first.Type() == value.MISSING || second.Type() == value.MISSING {
+		return value.MISSING_VALUE, nil
+	}
+
+	doc := jsonMergePatch(jsonCopy(first.Actual()), jsonCopy(second.Actual()))
+	return value.NewValue(doc), nil
+}
+
+/*
+Factory method pattern.
+*/
+func (this *JSONMergePatch) Constructor() FunctionConstructor {
+	return func(operands ...Expression) Function {
+		return NewJSONMergePatch(operands[0], operands[1])
+	}
+}
+
+func jsonMergePatch(target, patch interface{}) interface{} {
+	fields, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	obj, ok := target.(map[string]interface{})
+	if !ok {
+		obj = make(map[string]interface{}, len(fields))
+	}
+
+	for name, field := range fields {
+		if field == nil {
+			delete(obj, name)
+		} else {
+			obj[name] = jsonMergePatch(obj[name], field)
+		}
+	}
+
+	return obj
+}
+
+/*
+Apply a single JSON Patch operation to doc.
+*/
+func jsonPatchOp(doc, op interface{}) (interface{}, error) {
+	fields, ok := op.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("JSON patch operation must be an object.")
+	}
+
+	name, _ := fields["op"].(string)
+	path, err := jsonPatchPointer(fields, "path")
+	if err != nil {
+		return nil, err
+	}
+
+	switch name {
+	case "add":
+		val, ok := fields["value"]
+		if !ok {
+			return nil, fmt.Errorf("JSON patch add requires a value.")
+		}
+		return jsonPointerAdd(doc, path, val)
+	case "remove":
+		_, doc, err = jsonPointerRemove(doc, path)
+		return doc, err
+	case "replace":
+		val, ok := fields["value"]
+		if !ok {
+			return nil, fmt.Errorf("JSON patch replace requires a value.")
+		}
+		if _, doc, err = jsonPointerRemove(doc, path); err != nil {
+			return nil, err
+		}
+		return jsonPointerAdd(doc, path, val)
+	case "move":
+		from, err := jsonPatchPointer(fields, "from")
+		if err != nil {
+			return nil, err
+		}
+		if len(path) > len(from) && jsonPointerHasPrefix(path, from) {
+			return nil, fmt.Errorf("JSON patch cannot move a value into itself.")
+		}
+		var val interface{}
+		val, doc, err = jsonPointerRemove(doc, from)
+		if err != nil {
+			return nil, err
+		}
+		return jsonPointerAdd(doc, path, val)
+	case "copy":
+		from, err := jsonPatchPointer(fields, "from")
+		if err != nil {
+			return nil, err
+		}
+		val, ok := jsonPointerGet(doc, from)
+		if !ok {
+			return nil, fmt.Errorf("JSON patch copy from a missing value.")
+		}
+		return jsonPointerAdd(doc, path, jsonCopy(val))
+	case "test":
+		val, ok := jsonPointerGet(doc, path)
+		if !ok || value.NewValue(val).Collate(value.NewValue(fields["value"])) != 0 {
+			return nil, fmt.Errorf("JSON patch test failed.")
+		}
+		return doc, nil
+	default:
+		return nil, fmt.Errorf("Unsupported JSON patch operation %v.", fields["op"])
+	}
+}
+
+func jsonPatchPointer(fields map[string]interface{}, name string) ([]string, error) {
+	s, ok := fields[name].(string)
+	if !ok {
+		return nil, fmt.Errorf("JSON patch operation requires a %s.", name)
+	}
+
+	return parseJSONPointer(s)
+}
+
+/*
+Parse an RFC 6901 JSON Pointer, such as /a/b/0, into its reference
+tokens. In a token, ~1 stands for / and ~0 for ~.
+*/
+func parseJSONPointer(s string) ([]string, error) {
+	if s == "" {
+		return []string{}, nil
+	}
+
+	if s[0] != '/' {
+		return nil, fmt.Errorf("JSON pointer %s must start with /.", s)
+	}
+
+	tokens := strings.Split(s[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.Replace(strings.Replace(token, "~1", "/", -1), "~0", "~", -1)
+	}
+
+	return tokens, nil
+}
+
+func jsonPointerHasPrefix(path, prefix []string) bool {
+	for i, token := range prefix {
+		if path[i] != token {
+			return false
+		}
+	}
+
+	return true
+}
+
+func jsonPointerGet(doc interface{}, path []string) (interface{}, bool) {
+	for _, token := range path {
+		var ok bool
+		doc, ok = jsonPointerChild(doc, token)
+		if !ok {
+			return nil, false
+		}
+	}
+
+	return doc, true
+}
+
+func jsonPointerChild(node interface{}, token string) (interface{}, bool) {
+	switch node := node.(type) {
+	case map[string]interface{}:
+		child, ok := node[token]
+		return child, ok
+	case []interface{}:
+		i, ok := jsonPointerIndex(token, len(node))
+		if !ok {
+			return nil, false
+		}
+		return node[i], true
+	default:
+		return nil, false
+	}
+}
+
+/*
+Array positions are unsigned integers without leading zeros.
+*/
+func jsonPointerIndex(token string, length int) (int, bool) {
+	if token == "" || (len(token) > 1 && token[0] == '0') {
+		return 0, false
+	}
+
+	i, err := strconv.Atoi(token)
+	if err != nil || i < 0 || i >= length {
+		return 0, false
+	}
+
+	return i, true
+}
+
+/*
+Apply op to the parent of the last token of path within node, and
+return the modified node.
+*/
+func jsonPointerApply(node interface{}, path []string,
+	op func(parent interface{}, token string) (interface{}, error)) (interface{}, error) {
+	if len(path) == 1 {
+		return op(node, path[0])
+	}
+
+	child, ok := jsonPointerChild(node, path[0])
+	if !ok {
+		return nil, fmt.Errorf("JSON pointer names a missing value.")
+	}
+
+	child, err := jsonPointerApply(child, path[1:], op)
+	if err != nil {
+		return nil, err
+	}
+
+	switch node := node.(type) {
+	case map[string]interface{}:
+		node[path[0]] = child
+	case []interface{}:
+		i, _ := jsonPointerIndex(path[0], len(node))
+		node[i] = child
+	}
+
+	return node, nil
+}
+
+/*
+Add val at path. The token - appends to an array, and other array
+positions insert before the existing element.
+*/
+func jsonPointerAdd(doc interface{}, path []string, val interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return val, nil
+	}
+
+	return jsonPointerApply(doc, path, func(parent interface{}, token string) (interface{}, error) {
+		switch parent := parent.(type) {
+		case map[string]interface{}:
+			parent[token] = val
+			return parent, nil
+		case []interface{}:
+			if token == "-" {
+				return append(parent, val), nil
+			}
+
+			i, ok := jsonPointerIndex(token, len(parent)+1)
+			if !ok {
+				return nil, fmt.Errorf("Invalid array position %s in JSON pointer.", token)
+			}
+
+			parent = append(parent, nil)
+			copy(parent[i+1:], parent[i:])
+			parent[i] = val
+			return parent, nil
+		default:
+			return nil, fmt.Errorf("JSON pointer names a field of a scalar.")
+		}
+	})
+}
+
+/*
+Remove the value at path, and return it along with the modified doc.
+*/
+func jsonPointerRemove(doc interface{}, path []string) (interface{}, interface{}, error) {
+	if len(path) == 0 {
+		return doc, nil, nil
+	}
+
+	var removed interface{}
+	doc, err := jsonPointerApply(doc, path, func(parent interface{}, token string) (interface{}, error) {
+		switch parent := parent.(type) {
+		case map[string]interface{}:
+			child, ok := parent[token]
+			if !ok {
+				return nil, fmt.Errorf("JSON pointer names a missing field %s.", token)
+			}
+			removed = child
+			delete(parent, token)
+			return parent, nil
+		case []interface{}:
+			i, ok := jsonPointerIndex(token, len(parent))
+			if !ok {
+				return nil, fmt.Errorf("Invalid array position %s in JSON pointer.", token)
+			}
+			removed = parent[i]
+			return append(parent[:i:i], parent[i+1:]...), nil
+		default:
+			return nil, fmt.Errorf("JSON pointer names a field of a scalar.")
+		}
+	})
+
+	return removed, doc, err
+}
@@ -0,0 +1,596 @@
+//  Copyright (c) 2018 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package expression
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/couchbase/query/value"
+)
+
+///////////////////////////////////////////////////
+//
+// JSONPathGet
+//
+///////////////////////////////////////////////////
+
+/*
+This represents the json function JSON_PATH_GET(expr, path). It
+returns the value at the JSONPath path, such as '$.a.b[2]', within
+expr, or MISSING if there is none. The path may only contain
+fields and array positions.
+*/
+type JSONPathGet struct {
+	BinaryFunctionBase
+}
+
+func NewJSONPathGet(first, second Expression) Function {
+	rv := &JSONPathGet{
+		*NewBinaryFunctionBase("json_path_get", first, second),
+	}
+
+	rv.expr = rv
+	return rv
+}
+
+/*
+Visitor pattern.
+*/
+func (this *JSONPathGet) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitFunction(this)
+}
+
+func (this *JSONPathGet) Type() value.Type { return value.JSON }
+
+func (this *JSONPathGet) Evaluate(item value.Value, context Context) (value.Value, error) {
+	return this.BinaryEval(this, item, context)
+}
+
+func (this *JSONPathGet) Apply(context Context, first, second value.Value) (value.Value, error) {
+	if first.Type() == value.MISSING || second.Type() == value.MISSING {
+		return value.MISSING_VALUE, nil
+	} else if second.Type() != value.STRING {
+		return value.NULL_VALUE, nil
+	}
+
+	path, err := parseJSONPath(second.Actual().(string))
+	if err != nil || !path.definite() {
+		return value.NULL_VALUE, nil
+	}
+
+	matches := path.query(jsonCopy(first.Actual()), nil)
+	if len(matches) == 0 {
+		return value.MISSING_VALUE, nil
+	}
+
+	return value.NewValue(matches[0]), nil
+}
+
+/*
+Factory method pattern.
+*/
+func (this *JSONPathGet) Constructor() FunctionConstructor {
+	return func(operands ...Expression) Function {
+		return NewJSONPathGet(operands[0], operands[1])
+	}
+}
+
+///////////////////////////////////////////////////
+//
+// JSONPathQuery
+//
+///////////////////////////////////////////////////
+
+/*
+This represents the json function JSON_PATH_QUERY(expr, path). It
+returns an array of all the values matching the JSONPath path
+within expr. Besides fields and array positions, the path may
+contain the wildcards .* and [*], and the recursive descent ..,
+as in '$..price' or '$.items[*].id'.
+*/
+type JSONPathQuery struct {
+	BinaryFunctionBase
+}
+
+func NewJSONPathQuery(first, second Expression) Function {
+	rv := &JSONPathQuery{
+		*NewBinaryFunctionBase("json_path_query", first, second),
+	}
+
+	rv.expr = rv
+	return rv
+}
+
+/*
+Visitor pattern.
+*/
+func (this *JSONPathQuery) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitFunction(this)
+}
+
+func (this *JSONPathQuery) Type() value.Type { return value.ARRAY }
+
+func (this *JSONPathQuery) Evaluate(item value.Value, context Context) (value.Value, error) {
+	return this.BinaryEval(this, item, context)
+}
+
+func (this *JSONPathQuery) Apply(context Context, first, second value.Value) (value.Value, error) {
+	if first.Type() == value.MISSING || second.Type() == value.MISSING {
+		return value.MISSING_VALUE, nil
+	} else if second.Type() != value.STRING {
+		return value.NULL_VALUE, nil
+	}
+
+	path, err := parseJSONPath(second.Actual().(string))
+	if err != nil {
+		return value.NULL_VALUE, nil
+	}
+
+	matches := path.query(jsonCopy(first.Actual()), make([]interface{}, 0, 8))
+	return value.NewValue(matches), nil
+}
+
+/*
+Factory method pattern.
+*/
+func (this *JSONPathQuery) Constructor() FunctionConstructor {
+	return func(operands ...Expression) Function {
+		return NewJSONPathQuery(operands[0], operands[1])
+	}
+}
+
+///////////////////////////////////////////////////
+//
+// JSONPathRemove
+//
+///////////////////////////////////////////////////
+
+/*
+This represents the json function JSON_PATH_REMOVE(expr, path). It
+returns a copy of expr with the value at the JSONPath path removed.
+Removing an array element shifts the elements that follow it. If
+there is no value at path, expr is returned unchanged.
+*/
+type JSONPathRemove struct {
+	BinaryFunctionBase
+}
+
+func NewJSONPathRemove(first, second Expression) Function {
+	rv := &JSONPathRemove{
+		*NewBinaryFunctionBase("json_path_remove", first, second),
+	}
+
+	rv.expr = rv
+	return rv
+}
+
+/*
+Visitor pattern.
+*/
+func (this *JSONPathRemove) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitFunction(this)
+}
+
+func (this *JSONPathRemove) Type() value.Type { return value.JSON }
+
+func (this *JSONPathRemove) Evaluate(item value.Value, context Context) (value.Value, error) {
+	return this.BinaryEval(this, item, context)
+}
+
+func (this *JSONPathRemove) Apply(context Context, first, second value.Value) (value.Value, error) {
+	if first.Type() == value.MISSING || second.Type() == value.MISSING {
+		return value.MISSING_VALUE, nil
+	} else if second.Type() != value.STRING {
+		return value.NULL_VALUE, nil
+	}
+
+	path, err := parseJSONPath(second.Actual().(string))
+	if err != nil || !path.definite() || len(path) == 0 {
+		return value.NULL_VALUE, nil
+	}
+
+	return value.NewValue(path.update(jsonCopy(first.Actual()), nil, true)), nil
+}
+
+/*
+Factory method pattern.
+*/
+func (this *JSONPathRemove) Constructor() FunctionConstructor {
+	return func(operands ...Expression) Function {
+		return NewJSONPathRemove(operands[0], operands[1])
+	}
+}
+
+///////////////////////////////////////////////////
+//
+// JSONPathSet
+//
+///////////////////////////////////////////////////
+
+/*
+This represents the json function JSON_PATH_SET(expr, path, value).
+It returns a copy of expr with value at the JSONPath path. Missing
+objects along path are created, and an array position one past the
+end appends to the array. If the value is MISSING, this function
+behaves like JSON_PATH_REMOVE. If path cannot be set, for instance
+because it names a field of a number, expr is returned unchanged.
+*/
+type JSONPathSet struct {
+	TernaryFunctionBase
+}
+
+func NewJSONPathSet(first, second, third Expression) Function {
+	rv := &JSONPathSet{
+		*NewTernaryFunctionBase("json_path_set", first, second, third),
+	}
+
+	rv.expr = rv
+	return rv
+}
+
+/*
+Visitor pattern.
+*/
+func (this *JSONPathSet) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitFunction(this)
+}
+
+func (this *JSONPathSet) Type() value.Type { return value.JSON }
+
+func (this *JSONPathSet) Evaluate(item value.Value, context Context) (value.Value, error) {
+	return this.TernaryEval(this, item, context)
+}
+
+func (this *JSONPathSet) PropagatesMissing() bool {
+	return false
+}
+
+func (this *JSONPathSet) PropagatesNull() bool {
+	return false
+}
+
+func (this *JSONPathSet) Apply(context Context, first, second, third value.Value) (value.Value, error) {
+	if first.Type() == value.MISSING || second.Type() == value.MISSING {
+		return value.MISSING_VALUE, nil
+	} else if second.Type() != value.STRING {
+		return value.NULL_VALUE, nil
+	}
+
+	path, err := parseJSONPath(second.Actual().(string))
+	if err != nil || !path.definite() {
+		return value.NULL_VALUE, nil
+	}
+
+	remove := third.Type() == value.MISSING
+	if len(path) == 0 {
+		if remove {
+			return value.MISSING_VALUE, nil
+		}
+		return third, nil
+	}
+
+	return value.NewValue(path.update(jsonCopy(first.Actual()), jsonCopy(third.Actual()), remove)), nil
+}
+
+/*
+Factory method pattern.
+*/
+func (this *JSONPathSet) Constructor() FunctionConstructor {
+	return func(operands ...Expression) Function {
+		return NewJSONPathSet(operands[0], operands[1], operands[2])
+	}
+}
+
+/*
+The kinds of steps of a JSONPath.
+*/
+const (
+	_JSON_PATH_FIELD = iota
+	_JSON_PATH_INDEX
+	_JSON_PATH_WILDCARD
+	_JSON_PATH_DESCENT
+)
+
+type jsonPathStep struct {
+	kind  int
+	field string
+	index int
+}
+
+/*
+A parsed JSONPath, without its leading $.
+*/
+type jsonPath []jsonPathStep
+
+/*
+Parse a JSONPath. It starts with $, followed by any number of
+.name, ['name'], [n], .*, [*] and .. steps. Negative positions
+count from the end of an array.
+*/
+func parseJSONPath(s string) (jsonPath, error) {
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, "$") {
+		return nil, fmt.Errorf("JSON path %s must start with $.", s)
+	}
+
+	path := make(jsonPath, 0, 8)
+	for i := 1; i < len(s); {
+		switch s[i] {
+		case '.':
+			i++
+			if i < len(s) && s[i] == '.' {
+				path = append(path, jsonPathStep{kind: _JSON_PATH_DESCENT})
+				i++
+				if i < len(s) && s[i] == '[' {
+					continue
+				}
+			}
+
+			if i < len(s) && s[i] == '*' {
+				path = append(path, jsonPathStep{kind: _JSON_PATH_WILDCARD})
+				i++
+				continue
+			}
+
+			j := i
+			for j < len(s) && s[j] != '.' && s[j] != '[' {
+				j++
+			}
+
+			if j == i {
+				return nil, fmt.Errorf("Missing field name at position %d in JSON path %s.", i, s)
+			}
+
+			path = append(path, jsonPathStep{kind: _JSON_PATH_FIELD, field: s[i:j]})
+			i = j
+		case '[':
+			step, n, err := parseJSONPathBracket(s[i:])
+			if err != nil {
+				return nil, fmt.Errorf("%v in JSON path %s.", err, s)
+			}
+
+			path = append(path, step)
+			i += n
+		default:
+			return nil, fmt.Errorf("Unexpected %c at position %d in JSON path %s.", s[i], i, s)
+		}
+	}
+
+	if len(path) > 0 && path[len(path)-1].kind == _JSON_PATH_DESCENT {
+		return nil, fmt.Errorf("JSON path %s ends in a recursive descent.", s)
+	}
+
+	return path, nil
+}
+
+/*
+Parse a bracketed step, and return it along with its length.
+*/
+func parseJSONPathBracket(s string) (jsonPathStep, int, error) {
+	end := strings.IndexByte(s, ']')
+	if end < 0 {
+		return jsonPathStep{}, 0, fmt.Errorf("Unterminated [")
+	}
+
+	if s[1] == '\'' || s[1] == '"' {
+		quote := s[1]
+		buf := make([]byte, 0, len(s))
+		for i := 2; i < len(s); i++ {
+			switch s[i] {
+			case '\\':
+				i++
+				if i < len(s) {
+					buf = append(buf, s[i])
+				}
+			case quote:
+				if i+1 >= len(s) || s[i+1] != ']' {
+					return jsonPathStep{}, 0, fmt.Errorf("Expected ] after quoted name")
+				}
+				return jsonPathStep{kind: _JSON_PATH_FIELD, field: string(buf)}, i + 2, nil
+			default:
+				buf = append(buf, s[i])
+			}
+		}
+
+		return jsonPathStep{}, 0, fmt.Errorf("Unterminated quoted name")
+	}
+
+	inner := strings.TrimSpace(s[1:end])
+	if inner == "*" {
+		return jsonPathStep{kind: _JSON_PATH_WILDCARD}, end + 1, nil
+	}
+
+	index, err := strconv.Atoi(inner)
+	if err != nil {
+		return jsonPathStep{}, 0, fmt.Errorf("Invalid array position %s", inner)
+	}
+
+	return jsonPathStep{kind: _JSON_PATH_INDEX, index: index}, end + 1, nil
+}
+
+/*
+A definite path names at most one value, as it contains no
+wildcards or recursive descents.
+*/
+func (this jsonPath) definite() bool {
+	for _, step := range this {
+		if step.kind != _JSON_PATH_FIELD && step.kind != _JSON_PATH_INDEX {
+			return false
+		}
+	}
+
+	return true
+}
+
+/*
+Append the values within node that match the path to rv. Object
+fields are visited in name order, so that results are stable.
+*/
+func (this jsonPath) query(node interface{}, rv []interface{}) []interface{} {
+	if len(this) == 0 {
+		return append(rv, node)
+	}
+
+	step := this[0]
+	rest := this[1:]
+
+	switch step.kind {
+	case _JSON_PATH_FIELD:
+		if obj, ok := node.(map[string]interface{}); ok {
+			if child, ok := obj[step.field]; ok {
+				rv = rest.query(child, rv)
+			}
+		}
+	case _JSON_PATH_INDEX:
+		if arr, ok := node.([]interface{}); ok {
+			if i, ok := jsonIndex(step.index, len(arr)); ok {
+				rv = rest.query(arr[i], rv)
+			}
+		}
+	case _JSON_PATH_WILDCARD:
+		for _, child := range jsonChildren(node) {
+			rv = rest.query(child, rv)
+		}
+	case _JSON_PATH_DESCENT:
+		rv = rest.query(node, rv)
+		for _, child := range jsonChildren(node) {
+			rv = this.query(child, rv)
+		}
+	}
+
+	return rv
+}
+
+/*
+Set val at the definite path within node, or remove the value at
+path. node is modified in place, and the result must replace it,
+since arrays may be reallocated.
+*/
+func (this jsonPath) update(node, val interface{}, remove bool) interface{} {
+	step := this[0]
+	last := len(this) == 1
+
+	switch node := node.(type) {
+	case map[string]interface{}:
+		if step.kind != _JSON_PATH_FIELD {
+			return node
+		}
+
+		if last {
+			if remove {
+				delete(node, step.field)
+			} else {
+				node[step.field] = val
+			}
+			return node
+		}
+
+		child, ok := node[step.field]
+		if !ok {
+			if remove || this[1].kind != _JSON_PATH_FIELD {
+				return node
+			}
+			child = make(map[string]interface{}, 1)
+		}
+
+		node[step.field] = this[1:].update(child, val, remove)
+		return node
+	case []interface{}:
+		if step.kind != _JSON_PATH_INDEX {
+			return node
+		}
+
+		i, ok := jsonIndex(step.index, len(node))
+		if last {
+			switch {
+			case ok && remove:
+				return append(node[:i:i], node[i+1:]...)
+			case ok:
+				node[i] = val
+			case !remove && step.index == len(node):
+				return append(node, val)
+			}
+			return node
+		}
+
+		if ok {
+			node[i] = this[1:].update(node[i], val, remove)
+		}
+		return node
+	default:
+		return node
+	}
+}
+
+/*
+Resolve a possibly negative array position.
+*/
+func jsonIndex(index, length int) (int, bool) {
+	if index < 0 {
+		index += length
+	}
+
+	return index, index >= 0 && index < length
+}
+
+/*
+Return the elements of an array, or the field values of an object
+in name order.
+*/
+func jsonChildren(node interface{}) []interface{} {
+	switch node := node.(type) {
+	case []interface{}:
+		return node
+	case map[string]interface{}:
+		names := make([]string, 0, len(node))
+		for name := range node {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		children := make([]interface{}, len(names))
+		for i, name := range names {
+			children[i] = node[name]
+		}
+		return children
+	default:
+		return nil
+	}
+}
+
+/*
+Return a deep copy of a JSON value made of plain maps and slices,
+which the path and patch functions can modify in place.
+*/
+func jsonCopy(node interface{}) interface{} {
+	if v, ok := node.(value.Value); ok {
+		node = v.Actual()
+	}
+
+	switch node := node.(type) {
+	case map[string]interface{}:
+		rv := make(map[string]interface{}, len(node))
+		for name, child := range node {
+			rv[name] = jsonCopy(child)
+		}
+		return rv
+	case []interface{}:
+		rv := make([]interface{}, len(node))
+		for i, child := range node {
+			rv[i] = jsonCopy(child)
+		}
+		return rv
+	default:
+		return value.NewValue(node).Actual()
+	}
+}
@@ -0,0 +1,104 @@
+package expression
+
+import (
+	"testing"
+
+	"github.com/couchbase/query/value"
+)
+
+func testJSONFunc(expr Expression, er value.Value, t *testing.T) {
+	rv, err := expr.Evaluate(nil, nil)
+	if err != nil {
+		t.Errorf("received error %v", err)
+	}
+	if er.Collate(rv) != 0 {
+		t.Errorf("mismatch for %v received %v expected %v", expr, rv.Actual(), er.Actual())
+	}
+}
+
+func testJSONDoc() Expression {
+	return NewConstant(value.NewValue(map[string]interface{}{
+		"a": map[string]interface{}{"b": []interface{}{1, 2, map[string]interface{}{"c": 3}}},
+		"store": map[string]interface{}{
+			"book": []interface{}{map[string]interface{}{"price": 8}, map[string]interface{}{"price": 12}},
+		},
+	}))
+}
+
+func TestJSONPathGet(t *testing.T) {
+	testJSONFunc(NewJSONPathGet(testJSONDoc(), NewConstant("$.a.b[2].c")), value.NewValue(3), t)
+	testJSONFunc(NewJSONPathGet(testJSONDoc(), NewConstant("$['a'].b[-3]")), value.NewValue(1), t)
+	testJSONFunc(NewJSONPathGet(testJSONDoc(), NewConstant("$.a.*")), value.NULL_VALUE, t)
+	testJSONFunc(NewJSONPathGet(testJSONDoc(), NewConstant("a.b")), value.NULL_VALUE, t)
+
+	rv, _ := NewJSONPathGet(testJSONDoc(), NewConstant("$.a.x")).Evaluate(nil, nil)
+	if rv.Type() != value.MISSING {
+		t.Errorf("Expected MISSING, received %v", rv)
+	}
+}
+
+func TestJSONPathQuery(t *testing.T) {
+	testJSONFunc(NewJSONPathQuery(testJSONDoc(), NewConstant("$..price")),
+		value.NewValue([]interface{}{8, 12}), t)
+	testJSONFunc(NewJSONPathQuery(testJSONDoc(), NewConstant("$.store.book[*].price")),
+		value.NewValue([]interface{}{8, 12}), t)
+	testJSONFunc(NewJSONPathQuery(testJSONDoc(), NewConstant("$.a.x")), value.NewValue([]interface{}{}), t)
+}
+
+func TestJSONPathSet(t *testing.T) {
+	er := value.NewValue(map[string]interface{}{"f1": map[string]interface{}{"f2": map[string]interface{}{"f3": 1}}})
+	testJSONFunc(NewJSONPathSet(NewConstant(map[string]interface{}{}), NewConstant("$.f1.f2.f3"), NewConstant(1)), er, t)
+
+	er = value.NewValue(map[string]interface{}{"f1": []interface{}{1, 2, 3}})
+	testJSONFunc(NewJSONPathSet(NewConstant(map[string]interface{}{"f1": []interface{}{1, 2}}),
+		NewConstant("$.f1[2]"), NewConstant(3)), er, t)
+
+	er = value.NewValue(map[string]interface{}{"f1": []interface{}{1}})
+	testJSONFunc(NewJSONPathSet(NewConstant(map[string]interface{}{"f1": []interface{}{1, 2}}),
+		NewConstant("$.f1[1]"), NewConstant(value.MISSING_VALUE)), er, t)
+
+	er = value.NewValue(map[string]interface{}{"f1": 1})
+	testJSONFunc(NewJSONPathSet(NewConstant(map[string]interface{}{"f1": 1}),
+		NewConstant("$.f1.f2"), NewConstant(2)), er, t)
+}
+
+func TestJSONPathRemove(t *testing.T) {
+	er := value.NewValue(map[string]interface{}{"f1": map[string]interface{}{"f3": 3}})
+	testJSONFunc(NewJSONPathRemove(NewConstant(map[string]interface{}{"f1": map[string]interface{}{"f2": 2, "f3": 3}}),
+		NewConstant("$.f1.f2")), er, t)
+}
+
+func TestJSONPatch(t *testing.T) {
+	doc := NewConstant(map[string]interface{}{"a": []interface{}{1, 2}, "b": "x", "c": map[string]interface{}{"d": 1}})
+	ops := NewConstant([]interface{}{
+		map[string]interface{}{"op": "add", "path": "/a/1", "value": 5},
+		map[string]interface{}{"op": "add", "path": "/a/-", "value": 9},
+		map[string]interface{}{"op": "remove", "path": "/b"},
+		map[string]interface{}{"op": "replace", "path": "/c/d", "value": 2},
+		map[string]interface{}{"op": "copy", "from": "/c", "path": "/e"},
+		map[string]interface{}{"op": "move", "from": "/c/d", "path": "/f"},
+		map[string]interface{}{"op": "test", "path": "/e/d", "value": 2},
+	})
+	er := value.NewValue(map[string]interface{}{
+		"a": []interface{}{1, 5, 2, 9},
+		"c": map[string]interface{}{},
+		"e": map[string]interface{}{"d": 2},
+		"f": 2,
+	})
+	testJSONFunc(NewJSONPatch(doc, ops), er, t)
+
+	failed := NewConstant([]interface{}{
+		map[string]interface{}{"op": "add", "path": "/g", "value": 1},
+		map[string]interface{}{"op": "test", "path": "/b", "value": "y"},
+	})
+	testJSONFunc(NewJSONPatch(doc, failed), value.NULL_VALUE, t)
+}
+
+func TestJSONMergePatch(t *testing.T) {
+	doc := NewConstant(map[string]interface{}{"a": "b", "c": map[string]interface{}{"d": "e", "f": "g"}})
+	patch := NewConstant(map[string]interface{}{"a": "z", "c": map[string]interface{}{"f": nil}})
+	er := value.NewValue(map[string]interface{}{"a": "z", "c": map[string]interface{}{"d": "e"}})
+	testJSONFunc(NewJSONMergePatch(doc, patch), er, t)
+
+	testJSONFunc(NewJSONMergePatch(doc, NewConstant([]interface{}{"c"})), value.NewValue([]interface{}{"c"}), t)
+}
@@ -1273,7 +1273,8 @@ func (this *Random) Constructor() FunctionConstructor {
 This represents the number function ROUND(expr [, digits ]).
 It rounds the value to the given number of integer digits
 to the right of the decimal point (left if digits is
-negative). digits is 0 if not given.
+negative). digits is 0 if not given. A decimal is rounded
+exactly, half to even, to a decimal.
 */
 type Round struct {
 	FunctionBase
@@ -1312,6 +1313,10 @@ func (this *Round) Apply(context Context, args ...value.Value) (value.Value, err
 	v := arg.Actual().(float64)
 
 	if len(this.operands) == 1 {
+		if value.IsDecimal(arg) {
+			return value.NewDecimalValue(arg, 0, value.ROUND_HALF_EVEN), nil
+		}
+
 		return value.NewValue(roundFloat(v, 0)), nil
 	}
 
@@ -1329,6 +1334,10 @@ func (this *Round) Apply(context Context, args ...value.Value) (value.Value, err
 		p = int(pf)
 	}
 
+	if value.IsDecimal(arg) && p >= 0 {
+		return value.NewDecimalValue(arg, p, value.ROUND_HALF_EVEN), nil
+	}
+
 	return value.NewValue(roundFloat(v, p)), nil
 }
 
@@ -1610,7 +1619,8 @@ func (this *Tan) Constructor() FunctionConstructor {
 This represents the number function TRUNC(expr [, digits ]).
 It truncates the number to the given number of integer
 digits to the right of the decimal point (left if digits is
-negative). digits is 0 if not given.
+negative). digits is 0 if not given. A decimal is truncated
+exactly to a decimal.
 */
 type Trunc struct {
 	FunctionBase
@@ -1655,6 +1665,10 @@ func (this *Trunc) Apply(context Context, args ...value.Value) (value.Value, err
 	v := arg.Actual().(float64)
 
 	if len(this.operands) == 1 {
+		if value.IsDecimal(arg) {
+			return value.NewDecimalValue(arg, 0, value.ROUND_DOWN), nil
+		}
+
 		return value.NewValue(truncateFloat(v, 0)), nil
 	}
 
@@ -1672,6 +1686,10 @@ func (this *Trunc) Apply(context Context, args ...value.Value) (value.Value, err
 		p = int(pf)
 	}
 
+	if value.IsDecimal(arg) && p >= 0 {
+		return value.NewDecimalValue(arg, p, value.ROUND_DOWN), nil
+	}
+
 	return value.NewValue(truncateFloat(v, p)), nil
 }
 
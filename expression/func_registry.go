@@ -140,29 +140,43 @@ var _FUNCTIONS = map[string]Function{
 	"weekday_str":         &WeekdayStr{},
 
 	// String
-	"contains":  &Contains{},
-	"initcap":   &Title{},
-	"length":    &Length{},
-	"lower":     &Lower{},
-	"ltrim":     &LTrim{},
-	"position":  &Position0{},
-	"pos":       &Position0{},
-	"position0": &Position0{},
-	"pos0":      &Position0{},
-	"position1": &Position1{},
-	"pos1":      &Position1{},
-	"repeat":    &Repeat{},
-	"replace":   &Replace{},
-	"reverse":   &Reverse{},
-	"rtrim":     &RTrim{},
-	"split":     &Split{},
-	"substr":    &Substr0{},
-	"substr0":   &Substr0{},
-	"substr1":   &Substr1{},
-	"suffixes":  &Suffixes{},
-	"title":     &Title{},
-	"trim":      &Trim{},
-	"upper":     &Upper{},
+	"collation_key": &CollationKey{},
+	"contains":      &Contains{},
+	"edit_distance": &Levenshtein{},
+	"format":        &Printf{},
+	"initcap":       &Title{},
+	"left":          &Left{},
+	"length":        &Length{},
+	"levenshtein":   &Levenshtein{},
+	"lower":         &Lower{},
+	"lpad":          &LPad{},
+	"ltrim":         &LTrim{},
+	"mask":          &Mask{},
+	"metaphone":     &Metaphone{},
+	"normalize":     &Normalize{},
+	"position":      &Position0{},
+	"pos":           &Position0{},
+	"position0":     &Position0{},
+	"pos0":          &Position0{},
+	"position1":     &Position1{},
+	"pos1":          &Position1{},
+	"printf":        &Printf{},
+	"repeat":        &Repeat{},
+	"replace":       &Replace{},
+	"reverse":       &Reverse{},
+	"right":         &Right{},
+	"rpad":          &RPad{},
+	"rtrim":         &RTrim{},
+	"soundex":       &Soundex{},
+	"split":         &Split{},
+	"substr":        &Substr0{},
+	"substr0":       &Substr0{},
+	"substr1":       &Substr1{},
+	"suffixes":      &Suffixes{},
+	"title":         &Title{},
+	"translate":     &Translate{},
+	"trim":          &Trim{},
+	"upper":         &Upper{},
 
 	// Regular expressions
 	"contains_regex":   &RegexpContains{},
@@ -187,37 +201,38 @@ var _FUNCTIONS = map[string]Function{
 	"regexp_replace":   &RegexpReplace{},
 
 	// Numeric
-	"abs":     &Abs{},
-	"acos":    &Acos{},
-	"asin":    &Asin{},
-	"atan":    &Atan{},
-	"atan2":   &Atan2{},
-	"ceil":    &Ceil{},
-	"cos":     &Cos{},
-	"deg":     &Degrees{},
-	"degrees": &Degrees{},
-	"e":       &E{},
-	"exp":     &Exp{},
-	"ln":      &Ln{},
-	"log":     &Log{},
-	"floor":   &Floor{},
-	"inf":     &PosInf{},
-	"nan":     &NaN{},
-	"neginf":  &NegInf{},
-	"neg_inf": &NegInf{},
-	"pi":      &PI{},
-	"posinf":  &PosInf{},
-	"pos_inf": &PosInf{},
-	"power":   &Power{},
-	"rad":     &Radians{},
-	"radians": &Radians{},
-	"random":  &Random{},
-	"round":   &Round{},
-	"sign":    &Sign{},
-	"sin":     &Sin{},
-	"sqrt":    &Sqrt{},
-	"tan":     &Tan{},
-	"trunc":   &Trunc{},
+	"abs":         &Abs{},
+	"acos":        &Acos{},
+	"asin":        &Asin{},
+	"atan":        &Atan{},
+	"atan2":       &Atan2{},
+	"ceil":        &Ceil{},
+	"cos":         &Cos{},
+	"decimal_div": &DecimalDiv{},
+	"deg":         &Degrees{},
+	"degrees":     &Degrees{},
+	"e":           &E{},
+	"exp":         &Exp{},
+	"ln":          &Ln{},
+	"log":         &Log{},
+	"floor":       &Floor{},
+	"inf":         &PosInf{},
+	"nan":         &NaN{},
+	"neginf":      &NegInf{},
+	"neg_inf":     &NegInf{},
+	"pi":          &PI{},
+	"posinf":      &PosInf{},
+	"pos_inf":     &PosInf{},
+	"power":       &Power{},
+	"rad":         &Radians{},
+	"radians":     &Radians{},
+	"random":      &Random{},
+	"round":       &Round{},
+	"sign":        &Sign{},
+	"sin":         &Sin{},
+	"sqrt":        &Sqrt{},
+	"tan":         &Tan{},
+	"trunc":       &Trunc{},
 
 	// Bitwise
 	"bitand":   &BitAnd{},
@@ -284,13 +299,19 @@ var _FUNCTIONS = map[string]Function{
 	"object_values":       &ObjectValues{},
 
 	// JSON
-	"decode_json":  &JSONDecode{},
-	"encode_json":  &JSONEncode{},
-	"encoded_size": &EncodedSize{},
-	"json_decode":  &JSONDecode{},
-	"json_encode":  &JSONEncode{},
-	"pairs":        &Pairs{},
-	"poly_length":  &PolyLength{},
+	"decode_json":      &JSONDecode{},
+	"encode_json":      &JSONEncode{},
+	"encoded_size":     &EncodedSize{},
+	"json_decode":      &JSONDecode{},
+	"json_encode":      &JSONEncode{},
+	"json_merge_patch": &JSONMergePatch{},
+	"json_patch":       &JSONPatch{},
+	"json_path_get":    &JSONPathGet{},
+	"json_path_query":  &JSONPathQuery{},
+	"json_path_remove": &JSONPathRemove{},
+	"json_path_set":    &JSONPathSet{},
+	"pairs":            &Pairs{},
+	"poly_length":      &PolyLength{},
 
 	// Base64
 	"base64":        &Base64Encode{},
@@ -299,6 +320,23 @@ var _FUNCTIONS = map[string]Function{
 	"decode_base64": &Base64Decode{},
 	"encode_base64": &Base64Encode{},
 
+	// Hash
+	"hash64": &Hash64{},
+	"hmac":   &Hmac{},
+	"md5":    &MD5{},
+	"sha1":   &SHA1{},
+	"sha256": &SHA256{},
+	"sha512": &SHA512{},
+
+	// Geo
+	"geo_cell_start":     &GeoCellStart{},
+	"geo_cell_stop":      &GeoCellStop{},
+	"geo_cells":          &GeoCells{},
+	"geo_distance":       &GeoDistance{},
+	"geo_point":          &GeoPoint{},
+	"geo_within_polygon": &GeoWithinPolygon{},
+	"geo_within_radius":  &GeoWithinRadius{},
+
 	// Comparison
 	"greatest":  &Greatest{},
 	"least":     &Least{},
@@ -384,6 +422,7 @@ var _FUNCTIONS = map[string]Function{
 	"to_atom":    &ToAtom{},
 	"to_bool":    &ToBoolean{},
 	"to_boolean": &ToBoolean{},
+	"to_decimal": &ToDecimal{},
 	"to_num":     &ToNumber{},
 	"to_number":  &ToNumber{},
 	"to_obj":     &ToObject{},
@@ -394,6 +433,7 @@ var _FUNCTIONS = map[string]Function{
 	"toatom":     &ToAtom{},
 	"tobool":     &ToBoolean{},
 	"toboolean":  &ToBoolean{},
+	"todecimal":  &ToDecimal{},
 	"tonum":      &ToNumber{},
 	"tonumber":   &ToNumber{},
 	"toobj":      &ToObject{},
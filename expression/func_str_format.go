@@ -0,0 +1,768 @@
+//  Copyright (c) 2018 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package expression
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strings"
+
+	"golang.org/x/text/unicode/norm"
+
+	"github.com/couchbase/query/errors"
+	"github.com/couchbase/query/value"
+)
+
+///////////////////////////////////////////////////
+//
+// Left
+//
+///////////////////////////////////////////////////
+
+/*
+This represents the String function LEFT(expr, n). It returns
+the first n characters of the string.
+*/
+type Left struct {
+	BinaryFunctionBase
+}
+
+func NewLeft(first, second Expression) Function {
+	rv := &Left{
+		*NewBinaryFunctionBase("left", first, second),
+	}
+
+	rv.expr = rv
+	return rv
+}
+
+/*
+Visitor pattern.
+*/
+func (this *Left) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitFunction(this)
+}
+
+func (this *Left) Type() value.Type { return value.STRING }
+
+func (this *Left) Evaluate(item value.Value, context Context) (value.Value, error) {
+	return this.BinaryEval(this, item, context)
+}
+
+func (this *Left) Apply(context Context, first, second value.Value) (value.Value, error) {
+	return strLeftRightApply(first, second, true)
+}
+
+/*
+Factory method pattern.
+*/
+func (this *Left) Constructor() FunctionConstructor {
+	return func(operands ...Expression) Function {
+		return NewLeft(operands[0], operands[1])
+	}
+}
+
+///////////////////////////////////////////////////
+//
+// LPad
+//
+///////////////////////////////////////////////////
+
+/*
+This represents the String function LPAD(expr, length [, pad ]).
+It returns the string left-padded with pad (a space by default)
+to length characters, or its first length characters if it is
+longer.
+*/
+type LPad struct {
+	FunctionBase
+}
+
+func NewLPad(operands ...Expression) Function {
+	rv := &LPad{
+		*NewFunctionBase("lpad", operands...),
+	}
+
+	rv.expr = rv
+	return rv
+}
+
+/*
+Visitor pattern.
+*/
+func (this *LPad) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitFunction(this)
+}
+
+func (this *LPad) Type() value.Type { return value.STRING }
+
+func (this *LPad) Evaluate(item value.Value, context Context) (value.Value, error) {
+	return this.Eval(this, item, context)
+}
+
+func (this *LPad) Apply(context Context, args ...value.Value) (value.Value, error) {
+	return strPadApply(args, true)
+}
+
+/*
+Minimum input arguments required for the LPAD function
+is 2.
+*/
+func (this *LPad) MinArgs() int { return 2 }
+
+/*
+Maximum input arguments required for the LPAD function
+is 3.
+*/
+func (this *LPad) MaxArgs() int { return 3 }
+
+/*
+Factory method pattern.
+*/
+func (this *LPad) Constructor() FunctionConstructor {
+	return NewLPad
+}
+
+///////////////////////////////////////////////////
+//
+// Mask
+//
+///////////////////////////////////////////////////
+
+/*
+This represents the String function MASK(expr [, options ]).
+It returns the string with its characters replaced by a mask
+character, for redacting personal data. options is an object
+with the optional fields:
+
+mask       - the mask character, "*" by default
+keep_first - the number of leading characters to keep, 0 by default
+keep_last  - the number of trailing characters to keep, 0 by default
+keep       - a string of characters never masked, "" by default
+*/
+type Mask struct {
+	FunctionBase
+}
+
+func NewMask(operands ...Expression) Function {
+	rv := &Mask{
+		*NewFunctionBase("mask", operands...),
+	}
+
+	rv.expr = rv
+	return rv
+}
+
+/*
+Visitor pattern.
+*/
+func (this *Mask) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitFunction(this)
+}
+
+func (this *Mask) Type() value.Type { return value.STRING }
+
+func (this *Mask) Evaluate(item value.Value, context Context) (value.Value, error) {
+	return this.Eval(this, item, context)
+}
+
+func (this *Mask) Apply(context Context, args ...value.Value) (value.Value, error) {
+	for _, arg := range args {
+		if arg.Type() == value.MISSING {
+			return value.MISSING_VALUE, nil
+		}
+	}
+
+	if args[0].Type() != value.STRING {
+		return value.NULL_VALUE, nil
+	}
+
+	mask := '*'
+	keepFirst, keepLast, keep := 0, 0, ""
+
+	if len(args) > 1 {
+		if args[1].Type() != value.OBJECT {
+			return value.NULL_VALUE, nil
+		}
+
+		options := args[1]
+		if m, ok := options.Field("mask"); ok {
+			s, ok := m.Actual().(string)
+			if !ok || len([]rune(s)) != 1 {
+				return value.NULL_VALUE, nil
+			}
+			mask = []rune(s)[0]
+		}
+
+		if k, ok := options.Field("keep_first"); ok {
+			keepFirst, ok = strIntArg(k)
+			if !ok || keepFirst < 0 {
+				return value.NULL_VALUE, nil
+			}
+		}
+
+		if k, ok := options.Field("keep_last"); ok {
+			keepLast, ok = strIntArg(k)
+			if !ok || keepLast < 0 {
+				return value.NULL_VALUE, nil
+			}
+		}
+
+		if k, ok := options.Field("keep"); ok {
+			keep, ok = k.Actual().(string)
+			if !ok {
+				return value.NULL_VALUE, nil
+			}
+		}
+	}
+
+	runes := []rune(args[0].Actual().(string))
+	for i, r := range runes {
+		if i >= keepFirst && i < len(runes)-keepLast && !strings.ContainsRune(keep, r) {
+			runes[i] = mask
+		}
+	}
+
+	return value.NewValue(string(runes)), nil
+}
+
+/*
+Minimum input arguments required.
+*/
+func (this *Mask) MinArgs() int { return 1 }
+
+/*
+Maximum input arguments allowed.
+*/
+func (this *Mask) MaxArgs() int { return 2 }
+
+/*
+Factory method pattern.
+*/
+func (this *Mask) Constructor() FunctionConstructor {
+	return NewMask
+}
+
+///////////////////////////////////////////////////
+//
+// Normalize
+//
+///////////////////////////////////////////////////
+
+/*
+This represents the String function NORMALIZE(expr [, form ]).
+It returns the string in the Unicode normalization form "NFC"
+(the default), "NFD", "NFKC" or "NFKD".
+*/
+type Normalize struct {
+	FunctionBase
+}
+
+func NewNormalize(operands ...Expression) Function {
+	rv := &Normalize{
+		*NewFunctionBase("normalize", operands...),
+	}
+
+	rv.expr = rv
+	return rv
+}
+
+/*
+Visitor pattern.
+*/
+func (this *Normalize) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitFunction(this)
+}
+
+func (this *Normalize) Type() value.Type { return value.STRING }
+
+func (this *Normalize) Evaluate(item value.Value, context Context) (value.Value, error) {
+	return this.Eval(this, item, context)
+}
+
+func (this *Normalize) Apply(context Context, args ...value.Value) (value.Value, error) {
+	null := false
+
+	for _, a := range args {
+		if a.Type() == value.MISSING {
+			return value.MISSING_VALUE, nil
+		} else if a.Type() != value.STRING {
+			null = true
+		}
+	}
+
+	if null {
+		return value.NULL_VALUE, nil
+	}
+
+	form := norm.NFC
+	if len(args) > 1 {
+		var ok bool
+		form, ok = _NORMALIZATION_FORMS[strings.ToUpper(args[1].Actual().(string))]
+		if !ok {
+			return value.NULL_VALUE, nil
+		}
+	}
+
+	rv := form.String(args[0].Actual().(string))
+	return value.NewValue(rv), nil
+}
+
+/*
+Minimum input arguments required.
+*/
+func (this *Normalize) MinArgs() int { return 1 }
+
+/*
+Maximum input arguments allowed.
+*/
+func (this *Normalize) MaxArgs() int { return 2 }
+
+/*
+Factory method pattern.
+*/
+func (this *Normalize) Constructor() FunctionConstructor {
+	return NewNormalize
+}
+
+var _NORMALIZATION_FORMS = map[string]norm.Form{
+	"NFC":  norm.NFC,
+	"NFD":  norm.NFD,
+	"NFKC": norm.NFKC,
+	"NFKD": norm.NFKD,
+}
+
+///////////////////////////////////////////////////
+//
+// Printf
+//
+///////////////////////////////////////////////////
+
+/*
+This represents the String function PRINTF(format, args...), also
+called FORMAT. It returns the format string with each conversion
+replaced by the next argument, formatted as by printf. The
+conversions are %d, %x, %X, %o and %b for integers, %e, %E, %f, %F,
+%g and %G for numbers, %s for strings (other values are formatted
+as JSON), %v for JSON and %% for a percent sign, each with optional
+flags, width and precision. Widths and precisions count characters.
+If an argument is missing or of the wrong type, it returns NULL.
+*/
+type Printf struct {
+	FunctionBase
+}
+
+func NewPrintf(operands ...Expression) Function {
+	rv := &Printf{
+		*NewFunctionBase("printf", operands...),
+	}
+
+	rv.expr = rv
+	return rv
+}
+
+/*
+Visitor pattern.
+*/
+func (this *Printf) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitFunction(this)
+}
+
+func (this *Printf) Type() value.Type { return value.STRING }
+
+func (this *Printf) Evaluate(item value.Value, context Context) (value.Value, error) {
+	return this.Eval(this, item, context)
+}
+
+func (this *Printf) Apply(context Context, args ...value.Value) (value.Value, error) {
+	for _, arg := range args {
+		if arg.Type() == value.MISSING {
+			return value.MISSING_VALUE, nil
+		}
+	}
+
+	if args[0].Type() != value.STRING {
+		return value.NULL_VALUE, nil
+	}
+
+	rv, ok := strPrintf(args[0].Actual().(string), args[1:])
+	if !ok {
+		return value.NULL_VALUE, nil
+	}
+
+	return value.NewValue(rv), nil
+}
+
+/*
+Minimum input arguments required.
+*/
+func (this *Printf) MinArgs() int { return 1 }
+
+/*
+Maximum input arguments allowed.
+*/
+func (this *Printf) MaxArgs() int { return math.MaxInt16 }
+
+/*
+Factory method pattern.
+*/
+func (this *Printf) Constructor() FunctionConstructor {
+	return NewPrintf
+}
+
+///////////////////////////////////////////////////
+//
+// Right
+//
+///////////////////////////////////////////////////
+
+/*
+This represents the String function RIGHT(expr, n). It returns
+the last n characters of the string.
+*/
+type Right struct {
+	BinaryFunctionBase
+}
+
+func NewRight(first, second Expression) Function {
+	rv := &Right{
+		*NewBinaryFunctionBase("right", first, second),
+	}
+
+	rv.expr = rv
+	return rv
+}
+
+/*
+Visitor pattern.
+*/
+func (this *Right) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitFunction(this)
+}
+
+func (this *Right) Type() value.Type { return value.STRING }
+
+func (this *Right) Evaluate(item value.Value, context Context) (value.Value, error) {
+	return this.BinaryEval(this, item, context)
+}
+
+func (this *Right) Apply(context Context, first, second value.Value) (value.Value, error) {
+	return strLeftRightApply(first, second, false)
+}
+
+/*
+Factory method pattern.
+*/
+func (this *Right) Constructor() FunctionConstructor {
+	return func(operands ...Expression) Function {
+		return NewRight(operands[0], operands[1])
+	}
+}
+
+///////////////////////////////////////////////////
+//
+// RPad
+//
+///////////////////////////////////////////////////
+
+/*
+This represents the String function RPAD(expr, length [, pad ]).
+It returns the string right-padded with pad (a space by default)
+to length characters, or its first length characters if it is
+longer.
+*/
+type RPad struct {
+	FunctionBase
+}
+
+func NewRPad(operands ...Expression) Function {
+	rv := &RPad{
+		*NewFunctionBase("rpad", operands...),
+	}
+
+	rv.expr = rv
+	return rv
+}
+
+/*
+Visitor pattern.
+*/
+func (this *RPad) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitFunction(this)
+}
+
+func (this *RPad) Type() value.Type { return value.STRING }
+
+func (this *RPad) Evaluate(item value.Value, context Context) (value.Value, error) {
+	return this.Eval(this, item, context)
+}
+
+func (this *RPad) Apply(context Context, args ...value.Value) (value.Value, error) {
+	return strPadApply(args, false)
+}
+
+/*
+Minimum input arguments required for the RPAD function
+is 2.
+*/
+func (this *RPad) MinArgs() int { return 2 }
+
+/*
+Maximum input arguments required for the RPAD function
+is 3.
+*/
+func (this *RPad) MaxArgs() int { return 3 }
+
+/*
+Factory method pattern.
+*/
+func (this *RPad) Constructor() FunctionConstructor {
+	return NewRPad
+}
+
+///////////////////////////////////////////////////
+//
+// Translate
+//
+///////////////////////////////////////////////////
+
+/*
+This represents the String function TRANSLATE(expr, from, to).
+It returns the string with each character of from replaced by
+the character at the same position in to. Characters of from
+without a counterpart in to are removed.
+*/
+type Translate struct {
+	TernaryFunctionBase
+}
+
+func NewTranslate(first, second, third Expression) Function {
+	rv := &Translate{
+		*NewTernaryFunctionBase("translate", first, second, third),
+	}
+
+	rv.expr = rv
+	return rv
+}
+
+/*
+Visitor pattern.
+*/
+func (this *Translate) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitFunction(this)
+}
+
+func (this *Translate) Type() value.Type { return value.STRING }
+
+func (this *Translate) Evaluate(item value.Value, context Context) (value.Value, error) {
+	return this.TernaryEval(this, item, context)
+}
+
+func (this *Translate) Apply(context Context, first, second, third value.Value) (value.Value, error) {
+	if first.Type() == value.MISSING || second.Type() == value.MISSING || third.Type() == value.MISSING {
+		return value.MISSING_VALUE, nil
+	} else if first.Type() != value.STRING || second.Type() != value.STRING || third.Type() != value.STRING {
+		return value.NULL_VALUE, nil
+	}
+
+	from := []rune(second.Actual().(string))
+	to := []rune(third.Actual().(string))
+	mapping := make(map[rune]rune, len(from))
+	for i, r := range from {
+		if _, ok := mapping[r]; ok {
+			continue
+		}
+
+		if i < len(to) {
+			mapping[r] = to[i]
+		} else {
+			mapping[r] = -1
+		}
+	}
+
+	rv := strings.Map(func(r rune) rune {
+		if m, ok := mapping[r]; ok {
+			return m
+		}
+		return r
+	}, first.Actual().(string))
+
+	return value.NewValue(rv), nil
+}
+
+/*
+Factory method pattern.
+*/
+func (this *Translate) Constructor() FunctionConstructor {
+	return func(operands ...Expression) Function {
+		return NewTranslate(operands[0], operands[1], operands[2])
+	}
+}
+
+func strIntArg(arg value.Value) (int, bool) {
+	if arg.Type() != value.NUMBER {
+		return 0, false
+	}
+
+	f := arg.Actual().(float64)
+	if f != math.Trunc(f) {
+		return 0, false
+	}
+
+	return int(f), true
+}
+
+func strLeftRightApply(first, second value.Value, left bool) (value.Value, error) {
+	if first.Type() == value.MISSING || second.Type() == value.MISSING {
+		return value.MISSING_VALUE, nil
+	} else if first.Type() != value.STRING {
+		return value.NULL_VALUE, nil
+	}
+
+	n, ok := strIntArg(second)
+	if !ok || n < 0 {
+		return value.NULL_VALUE, nil
+	}
+
+	runes := []rune(first.Actual().(string))
+	if n >= len(runes) {
+		return first, nil
+	}
+
+	if left {
+		return value.NewValue(string(runes[:n])), nil
+	}
+
+	return value.NewValue(string(runes[len(runes)-n:])), nil
+}
+
+func strPadApply(args []value.Value, left bool) (value.Value, error) {
+	for _, arg := range args {
+		if arg.Type() == value.MISSING {
+			return value.MISSING_VALUE, nil
+		}
+	}
+
+	if args[0].Type() != value.STRING {
+		return value.NULL_VALUE, nil
+	}
+
+	length, ok := strIntArg(args[1])
+	if !ok || length < 0 {
+		return value.NULL_VALUE, nil
+	}
+
+	if length > RANGE_LIMIT {
+		if left {
+			return nil, errors.NewRangeError("LPAD()")
+		}
+		return nil, errors.NewRangeError("RPAD()")
+	}
+
+	pad := []rune(" ")
+	if len(args) > 2 {
+		if args[2].Type() != value.STRING {
+			return value.NULL_VALUE, nil
+		}
+		pad = []rune(args[2].Actual().(string))
+	}
+
+	runes := []rune(args[0].Actual().(string))
+	if length <= len(runes) {
+		return value.NewValue(string(runes[:length])), nil
+	}
+
+	if len(pad) == 0 {
+		return value.NULL_VALUE, nil
+	}
+
+	padding := make([]rune, length-len(runes))
+	for i := range padding {
+		padding[i] = pad[i%len(pad)]
+	}
+
+	if left {
+		return value.NewValue(string(padding) + string(runes)), nil
+	}
+
+	return value.NewValue(string(runes) + string(padding)), nil
+}
+
+/*
+Format args as printf does, returning false if an argument is
+missing or of the wrong type for its conversion.
+*/
+func strPrintf(format string, args []value.Value) (string, bool) {
+	var buf bytes.Buffer
+	n := 0
+
+	for i := 0; i < len(format); {
+		if format[i] != '%' {
+			buf.WriteByte(format[i])
+			i++
+			continue
+		}
+
+		j := i + 1
+		for j < len(format) && strings.IndexByte(_PRINTF_FLAGS, format[j]) >= 0 {
+			j++
+		}
+
+		if j >= len(format) {
+			return "", false
+		}
+
+		spec, verb := format[i:j+1], format[j]
+		i = j + 1
+
+		if verb == '%' {
+			if spec != "%%" {
+				return "", false
+			}
+			buf.WriteByte('%')
+			continue
+		}
+
+		if n >= len(args) {
+			return "", false
+		}
+
+		arg := args[n]
+		n++
+
+		switch verb {
+		case 'd', 'x', 'X', 'o', 'b':
+			if arg.Type() != value.NUMBER {
+				return "", false
+			}
+			fmt.Fprintf(&buf, spec, int64(arg.Actual().(float64)))
+		case 'e', 'E', 'f', 'F', 'g', 'G':
+			if arg.Type() != value.NUMBER {
+				return "", false
+			}
+			fmt.Fprintf(&buf, spec, arg.Actual().(float64))
+		case 's':
+			if arg.Type() == value.STRING {
+				fmt.Fprintf(&buf, spec, arg.Actual().(string))
+			} else {
+				fmt.Fprintf(&buf, spec, arg.String())
+			}
+		case 'v':
+			fmt.Fprintf(&buf, spec[:len(spec)-1]+"s", arg.String())
+		default:
+			return "", false
+		}
+	}
+
+	return buf.String(), true
+}
+
+const _PRINTF_FLAGS = "-+# 0123456789."
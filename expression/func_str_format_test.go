@@ -0,0 +1,65 @@
+package expression
+
+import (
+	"testing"
+
+	"github.com/couchbase/query/value"
+)
+
+func TestStrFormatFunctions(t *testing.T) {
+	tests := []struct {
+		expr     Expression
+		expected interface{}
+	}{
+		{NewLPad(NewConstant("héllo"), NewConstant(8), NewConstant("ab")), "abahéllo"},
+		{NewRPad(NewConstant("héllo"), NewConstant(2)), "hé"},
+		{NewRPad(NewConstant("a"), NewConstant(3), NewConstant("")), nil},
+		{NewLeft(NewConstant("héllo"), NewConstant(10)), "héllo"},
+		{NewRight(NewConstant("héllo"), NewConstant(-1)), nil},
+		{NewPrintf(NewConstant("%s=%5.1f %x"), NewConstant("pi"), NewConstant(3.14159), NewConstant(255)), "pi=  3.1 ff"},
+		{NewPrintf(NewConstant("%d %d"), NewConstant(1)), nil},
+		{NewTranslate(NewConstant("abcab"), NewConstant("abc"), NewConstant("x")), "xx"},
+		{NewNormalize(NewConstant("é"), NewConstant("nfc")), "é"},
+		{NewNormalize(NewConstant("e"), NewConstant("nfx")), nil},
+		{NewMask(NewConstant("555-1234"), NewConstant(map[string]interface{}{"keep_last": 2, "keep": "-"})), "***-**34"},
+		{NewMask(NewConstant("abc"), NewConstant(map[string]interface{}{"mask": "##"})), nil},
+	}
+
+	for _, test := range tests {
+		rv, err := test.expr.Evaluate(nil, nil)
+		if err != nil {
+			t.Errorf("Error %v returned by %v", err, test.expr)
+		}
+
+		if rv.Collate(value.NewValue(test.expected)) != 0 {
+			t.Errorf("Mismatch for %v: received %v expected %v", test.expr, rv, test.expected)
+		}
+	}
+}
+
+func TestStrFuzzyFunctions(t *testing.T) {
+	tests := []struct {
+		expr     Expression
+		expected interface{}
+	}{
+		{NewSoundex(NewConstant("Ashcraft")), "A261"},
+		{NewSoundex(NewConstant("Tymczak")), "T522"},
+		{NewSoundex(NewConstant("123")), ""},
+		{NewMetaphone(NewConstant("Smith")), "SM0"},
+		{NewMetaphone(NewConstant("Wright")), "RT"},
+		{NewLevenshtein(NewConstant("kitten"), NewConstant("sitting")), 3},
+		{NewLevenshtein(NewConstant("héllo"), NewConstant("hello")), 1},
+		{NewLevenshtein(NewConstant("abcdef"), NewConstant("a"), NewConstant(2)), 3},
+	}
+
+	for _, test := range tests {
+		rv, err := test.expr.Evaluate(nil, nil)
+		if err != nil {
+			t.Errorf("Error %v returned by %v", err, test.expr)
+		}
+
+		if rv.Collate(value.NewValue(test.expected)) != 0 {
+			t.Errorf("Mismatch for %v: received %v expected %v", test.expr, rv, test.expected)
+		}
+	}
+}
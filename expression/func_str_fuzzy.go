@@ -0,0 +1,481 @@
+//  Copyright (c) 2018 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package expression
+
+import (
+	"bytes"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+
+	"github.com/couchbase/query/value"
+)
+
+///////////////////////////////////////////////////
+//
+// Levenshtein
+//
+///////////////////////////////////////////////////
+
+/*
+This represents the String function LEVENSHTEIN(expr1, expr2
+[, max ]), also called EDIT_DISTANCE. It returns the number of
+single-character insertions, deletions and substitutions that
+turn one string into the other. If max is given, distances
+greater than max are returned as max + 1, which bounds the cost
+of fuzzy matching filters such as LEVENSHTEIN(a, b, 2) <= 2.
+*/
+type Levenshtein struct {
+	FunctionBase
+}
+
+func NewLevenshtein(operands ...Expression) Function {
+	rv := &Levenshtein{
+		*NewFunctionBase("levenshtein", operands...),
+	}
+
+	rv.expr = rv
+	return rv
+}
+
+/*
+Visitor pattern.
+*/
+func (this *Levenshtein) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitFunction(this)
+}
+
+func (this *Levenshtein) Type() value.Type { return value.NUMBER }
+
+func (this *Levenshtein) Evaluate(item value.Value, context Context) (value.Value, error) {
+	return this.Eval(this, item, context)
+}
+
+func (this *Levenshtein) Apply(context Context, args ...value.Value) (value.Value, error) {
+	for _, arg := range args {
+		if arg.Type() == value.MISSING {
+			return value.MISSING_VALUE, nil
+		}
+	}
+
+	if args[0].Type() != value.STRING || args[1].Type() != value.STRING {
+		return value.NULL_VALUE, nil
+	}
+
+	max := -1
+	if len(args) > 2 {
+		var ok bool
+		max, ok = strIntArg(args[2])
+		if !ok || max < 0 {
+			return value.NULL_VALUE, nil
+		}
+	}
+
+	rv := levenshtein([]rune(args[0].Actual().(string)), []rune(args[1].Actual().(string)), max)
+	return value.NewValue(rv), nil
+}
+
+/*
+Minimum input arguments required.
+*/
+func (this *Levenshtein) MinArgs() int { return 2 }
+
+/*
+Maximum input arguments allowed.
+*/
+func (this *Levenshtein) MaxArgs() int { return 3 }
+
+/*
+Factory method pattern.
+*/
+func (this *Levenshtein) Constructor() FunctionConstructor {
+	return NewLevenshtein
+}
+
+///////////////////////////////////////////////////
+//
+// Metaphone
+//
+///////////////////////////////////////////////////
+
+/*
+This represents the String function METAPHONE(expr). It returns
+the Metaphone phonetic key of an English word, in which similar
+sounding words have the same key. Non-letters are ignored, and
+the sound "th" is written 0.
+*/
+type Metaphone struct {
+	UnaryFunctionBase
+}
+
+func NewMetaphone(operand Expression) Function {
+	rv := &Metaphone{
+		*NewUnaryFunctionBase("metaphone", operand),
+	}
+
+	rv.expr = rv
+	return rv
+}
+
+/*
+Visitor pattern.
+*/
+func (this *Metaphone) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitFunction(this)
+}
+
+func (this *Metaphone) Type() value.Type { return value.STRING }
+
+func (this *Metaphone) Evaluate(item value.Value, context Context) (value.Value, error) {
+	return this.UnaryEval(this, item, context)
+}
+
+func (this *Metaphone) Apply(context Context, arg value.Value) (value.Value, error) {
+	if arg.Type() == value.MISSING {
+		return value.MISSING_VALUE, nil
+	} else if arg.Type() != value.STRING {
+		return value.NULL_VALUE, nil
+	}
+
+	rv := metaphone(arg.Actual().(string))
+	return value.NewValue(rv), nil
+}
+
+/*
+Factory method pattern.
+*/
+func (this *Metaphone) Constructor() FunctionConstructor {
+	return func(operands ...Expression) Function {
+		return NewMetaphone(operands[0])
+	}
+}
+
+///////////////////////////////////////////////////
+//
+// Soundex
+//
+///////////////////////////////////////////////////
+
+/*
+This represents the String function SOUNDEX(expr). It returns
+the four character American Soundex code of a name, such as
+R163 for both Robert and Rupert. Non-letters are ignored, and
+a string without letters returns "".
+*/
+type Soundex struct {
+	UnaryFunctionBase
+}
+
+func NewSoundex(operand Expression) Function {
+	rv := &Soundex{
+		*NewUnaryFunctionBase("soundex", operand),
+	}
+
+	rv.expr = rv
+	return rv
+}
+
+/*
+Visitor pattern.
+*/
+func (this *Soundex) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitFunction(this)
+}
+
+func (this *Soundex) Type() value.Type { return value.STRING }
+
+func (this *Soundex) Evaluate(item value.Value, context Context) (value.Value, error) {
+	return this.UnaryEval(this, item, context)
+}
+
+func (this *Soundex) Apply(context Context, arg value.Value) (value.Value, error) {
+	if arg.Type() == value.MISSING {
+		return value.MISSING_VALUE, nil
+	} else if arg.Type() != value.STRING {
+		return value.NULL_VALUE, nil
+	}
+
+	rv := soundex(arg.Actual().(string))
+	return value.NewValue(rv), nil
+}
+
+/*
+Factory method pattern.
+*/
+func (this *Soundex) Constructor() FunctionConstructor {
+	return func(operands ...Expression) Function {
+		return NewSoundex(operands[0])
+	}
+}
+
+/*
+Return the edit distance between s and t, computed a row at a
+time. If max is not negative, stop as soon as a row exceeds it.
+*/
+func levenshtein(s, t []rune, max int) int {
+	if len(s) < len(t) {
+		s, t = t, s
+	}
+
+	if max >= 0 && len(s)-len(t) > max {
+		return max + 1
+	}
+
+	prev := make([]int, len(t)+1)
+	curr := make([]int, len(t)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(s); i++ {
+		curr[0] = i
+		least := curr[0]
+
+		for j := 1; j <= len(t); j++ {
+			cost := 1
+			if s[i-1] == t[j-1] {
+				cost = 0
+			}
+
+			curr[j] = prev[j-1] + cost
+			if prev[j]+1 < curr[j] {
+				curr[j] = prev[j] + 1
+			}
+			if curr[j-1]+1 < curr[j] {
+				curr[j] = curr[j-1] + 1
+			}
+
+			if curr[j] < least {
+				least = curr[j]
+			}
+		}
+
+		if max >= 0 && least > max {
+			return max + 1
+		}
+
+		prev, curr = curr, prev
+	}
+
+	if max >= 0 && prev[len(t)] > max {
+		return max + 1
+	}
+
+	return prev[len(t)]
+}
+
+/*
+Soundex digits of the letters A to Z. Vowels and Y are 0, and
+H and W are -1, as they do not separate equal digits.
+*/
+var _SOUNDEX_CODES = [26]int8{
+	0, 1, 2, 3, 0, 1, 2, -1, 0, 2, 2, 4, 5,
+	5, 0, 1, 2, 6, 2, 3, 0, 1, -1, 2, 0, 2,
+}
+
+/*
+Remove the accents from letters, e.g. É becomes E, by decomposing
+them and dropping the combining marks, so that phonetic codes do
+not skip accented letters.
+*/
+func foldAccents(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.Is(unicode.Mn, r) {
+			return -1
+		}
+		return r
+	}, norm.NFD.String(s))
+}
+
+func soundex(s string) string {
+	rv := make([]byte, 0, 4)
+	var last int8
+
+	for _, r := range strings.ToUpper(foldAccents(s)) {
+		if r < 'A' || r > 'Z' {
+			continue
+		}
+
+		code := _SOUNDEX_CODES[r-'A']
+		if len(rv) == 0 {
+			rv = append(rv, byte(r))
+			last = code
+			continue
+		}
+
+		if code > 0 && code != last {
+			rv = append(rv, byte('0'+code))
+			if len(rv) == 4 {
+				break
+			}
+		}
+
+		if code >= 0 {
+			last = code
+		}
+	}
+
+	if len(rv) == 0 {
+		return ""
+	}
+
+	for len(rv) < 4 {
+		rv = append(rv, '0')
+	}
+
+	return string(rv)
+}
+
+func metaphone(s string) string {
+	word := make([]byte, 0, len(s))
+	for _, r := range strings.ToUpper(foldAccents(s)) {
+		if r >= 'A' && r <= 'Z' {
+			word = append(word, byte(r))
+		}
+	}
+
+	if len(word) == 0 {
+		return ""
+	}
+
+	at := func(i int) byte {
+		if i < 0 || i >= len(word) {
+			return 0
+		}
+		return word[i]
+	}
+
+	isVowel := func(c byte) bool {
+		return c != 0 && strings.IndexByte("AEIOU", c) >= 0
+	}
+
+	isFrontVowel := func(c byte) bool {
+		return c != 0 && strings.IndexByte("EIY", c) >= 0
+	}
+
+	var buf bytes.Buffer
+	i := 0
+
+	switch {
+	case bytes.HasPrefix(word, []byte("AE")), bytes.HasPrefix(word, []byte("GN")),
+		bytes.HasPrefix(word, []byte("KN")), bytes.HasPrefix(word, []byte("PN")),
+		bytes.HasPrefix(word, []byte("WR")):
+		i = 1
+	case word[0] == 'X':
+		buf.WriteByte('S')
+		i = 1
+	case bytes.HasPrefix(word, []byte("WH")):
+		buf.WriteByte('W')
+		i = 2
+	}
+
+	for ; i < len(word); i++ {
+		c := word[i]
+		if c == at(i-1) && c != 'C' {
+			continue
+		}
+
+		switch c {
+		case 'A', 'E', 'I', 'O', 'U':
+			if i == 0 {
+				buf.WriteByte(c)
+			}
+		case 'B':
+			if !(at(i-1) == 'M' && i == len(word)-1) {
+				buf.WriteByte('B')
+			}
+		case 'C':
+			switch {
+			case at(i+1) == 'I' && at(i+2) == 'A':
+				buf.WriteByte('X')
+			case at(i+1) == 'H':
+				if at(i-1) == 'S' {
+					buf.WriteByte('K')
+				} else {
+					buf.WriteByte('X')
+				}
+				i++
+			case isFrontVowel(at(i + 1)):
+				if at(i-1) != 'S' {
+					buf.WriteByte('S')
+				}
+			default:
+				buf.WriteByte('K')
+			}
+		case 'D':
+			if at(i+1) == 'G' && isFrontVowel(at(i+2)) {
+				buf.WriteByte('J')
+				i++
+			} else {
+				buf.WriteByte('T')
+			}
+		case 'G':
+			switch {
+			case at(i+1) == 'H' && i+2 < len(word) && !isVowel(at(i+2)):
+			case at(i+1) == 'N' && (i+2 == len(word) ||
+				(at(i+2) == 'E' && at(i+3) == 'D' && i+4 == len(word))):
+			case isFrontVowel(at(i+1)) && at(i-1) != 'G':
+				buf.WriteByte('J')
+			default:
+				buf.WriteByte('K')
+			}
+		case 'H':
+			if isVowel(at(i+1)) && strings.IndexByte("CGPST", at(i-1)) < 0 {
+				buf.WriteByte('H')
+			}
+		case 'K':
+			if at(i-1) != 'C' {
+				buf.WriteByte('K')
+			}
+		case 'P':
+			if at(i+1) == 'H' {
+				buf.WriteByte('F')
+				i++
+			} else {
+				buf.WriteByte('P')
+			}
+		case 'Q':
+			buf.WriteByte('K')
+		case 'S':
+			if at(i+1) == 'H' {
+				buf.WriteByte('X')
+				i++
+			} else if at(i+1) == 'I' && (at(i+2) == 'O' || at(i+2) == 'A') {
+				buf.WriteByte('X')
+			} else {
+				buf.WriteByte('S')
+			}
+		case 'T':
+			if at(i+1) == 'I' && (at(i+2) == 'O' || at(i+2) == 'A') {
+				buf.WriteByte('X')
+			} else if at(i+1) == 'H' {
+				buf.WriteByte('0')
+				i++
+			} else if !(at(i+1) == 'C' && at(i+2) == 'H') {
+				buf.WriteByte('T')
+			}
+		case 'V':
+			buf.WriteByte('F')
+		case 'W', 'Y':
+			if isVowel(at(i + 1)) {
+				buf.WriteByte(c)
+			}
+		case 'X':
+			buf.WriteString("KS")
+		case 'Z':
+			buf.WriteByte('S')
+		default:
+			buf.WriteByte(c)
+		}
+	}
+
+	return buf.String()
+}
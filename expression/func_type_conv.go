@@ -395,6 +395,10 @@ func (this *ToString) Apply(context Context, arg value.Value) (value.Value, erro
 	case value.BOOLEAN:
 		return value.NewValue(fmt.Sprint(arg.Actual())), nil
 	case value.NUMBER:
+		if value.IsDecimal(arg) {
+			return value.NewValue(arg.String()), nil
+		}
+
 		var s string
 		actual := arg.ActualForIndex()
 		switch actual := actual.(type) {
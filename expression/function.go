@@ -75,6 +75,23 @@ Factory method pattern.
 */
 type FunctionConstructor func(operands ...Expression) Function
 
+/*
+Aggregate functions, which are defined in package algebra, may
+have an ORDER BY clause after their operands and a FILTER clause.
+The Stringer represents both through this interface.
+*/
+type AggregateClauses interface {
+	/*
+	   The ORDER BY clause, or an empty string.
+	*/
+	OrderString() string
+
+	/*
+	   The condition of the FILTER clause, or nil.
+	*/
+	Filter() Expression
+}
+
 /*
 A unary function is one that has on operand. It inherits
 from Function and contains one additional method to return
@@ -580,6 +580,16 @@ func (this *Stringer) VisitSelf(expr *Self) (interface{}, error) {
 // Function
 func (this *Stringer) VisitFunction(expr Function) (interface{}, error) {
 	var buf bytes.Buffer
+
+	// COLLATE is not a function that can be called by name
+	if c, ok := expr.(*Collate); ok {
+		buf.WriteString("(")
+		buf.WriteString(this.Visit(c.First()))
+		buf.WriteString(" collate ")
+		buf.WriteString(this.Visit(c.Second()))
+		buf.WriteString(")")
+		return buf.String(), nil
+	}
 	buf.WriteString(expr.Name())
 	buf.WriteString("(")
 
@@ -599,7 +609,19 @@ func (this *Stringer) VisitFunction(expr Function) (interface{}, error) {
 		}
 	}
 
+	clauses, ok := expr.(AggregateClauses)
+	if ok {
+		buf.WriteString(clauses.OrderString())
+	}
+
 	buf.WriteString(")")
+
+	if ok && clauses.Filter() != nil {
+		buf.WriteString(" filter (where ")
+		buf.WriteString(this.Visit(clauses.Filter()))
+		buf.WriteString(")")
+	}
+
 	return buf.String(), nil
 }
 
@@ -12,6 +12,38 @@ import "github.com/couchbase/query/value"
 func logDebugGrammar(format string, v ...interface{}) {
     clog.To("PARSER", format, v...)
 }
+
+func setAggregateFilter(yylex yyLexer, expr expression.Expression, filter expression.Expression) {
+    if filter == nil {
+        return
+    }
+
+    agg, ok := expr.(algebra.Aggregate)
+    if !ok {
+        yylex.Error("FILTER is only allowed in aggregate functions.")
+        return
+    }
+
+    agg.SetFilter(filter)
+}
+
+func collate(yylex yyLexer, expr expression.Expression, collation string) expression.Expression {
+    if !expression.ValidCollation(collation) {
+        yylex.Error(fmt.Sprintf("Invalid collation %s.", collation))
+    }
+
+    return expression.NewCollate(expr, expression.NewConstant(collation))
+}
+
+func collateOperands(yylex yyLexer, operands ...expression.Expression) expression.Expressions {
+    rv, err := expression.CollateOperands(operands...)
+    if err != nil {
+        yylex.Error(err.Error())
+        return operands
+    }
+
+    return rv
+}
 %}
 
 %union {
@@ -46,6 +78,7 @@ indexRef         *algebra.IndexRef
 subqueryTerm     *algebra.SubqueryTerm
 path             expression.Path
 group            *algebra.Group
+groupingSets     []expression.Expressions
 resultTerm       *algebra.ResultTerm
 resultTerms      algebra.ResultTerms
 projection       *algebra.Projection
@@ -106,9 +139,11 @@ tokOffset	 int
 %token COMMIT
 %token CONNECT
 %token CONTINUE
+%token COPY
 %token CORRELATE
 %token COVER
 %token CREATE
+%token CUBE
 %token DATABASE
 %token DATASET
 %token DATASTORE
@@ -133,6 +168,7 @@ tokOffset	 int
 %token EXPLAIN
 %token FALSE
 %token FETCH
+%token FILTER
 %token FIRST
 %token FLATTEN
 %token FOR
@@ -142,6 +178,7 @@ tokOffset	 int
 %token FUNCTION
 %token GRANT
 %token GROUP
+%token GROUPING
 %token GSI
 %token HASH
 %token HAVING
@@ -215,12 +252,14 @@ tokOffset	 int
 %token RIGHT
 %token ROLE
 %token ROLLBACK
+%token ROLLUP
 %token SATISFIES
 %token SCHEMA
 %token SELECT
 %token SELF
 %token SEMI
 %token SET
+%token SETS
 %token SHOW
 %token SOME
 %token START
@@ -280,6 +319,7 @@ tokOffset	 int
 %left           CONCAT
 %left           PLUS MINUS
 %left           STAR DIV MOD
+%left           COLLATE
 
 /* Unary operators */
 %right          COVER
@@ -289,10 +329,12 @@ tokOffset	 int
 
 /* Override precedence */
 %left           LPAREN RPAREN
+%nonassoc       FILTER                          /* FILTER after a function call is its filter clause, not an alias */
 
 /* Types */
 %type <s>                STR
 %type <s>                IDENT IDENT_ICASE
+%type <s>                COPY CUBE FILTER GROUPING ROLLUP SETS
 %type <s>                NAMED_PARAM
 %type <f>                NUM
 %type <n>                INT
@@ -307,7 +349,7 @@ tokOffset	 int
 %type <binding>          binding
 %type <bindings>         bindings
 
-%type <s>                alias as_alias opt_as_alias variable opt_name
+%type <s>                alias as_alias opt_as_alias variable opt_name ident
 
 %type <expr>             case_expr simple_or_searched_case simple_case searched_case opt_else
 %type <whenTerms>        when_thens
@@ -318,7 +360,7 @@ tokOffset	 int
 %type <expr>             satisfies
 %type <expr>             opt_when
 
-%type <expr>             function_expr
+%type <expr>             function_expr opt_filter
 %type <s>                function_name
 
 %type <expr>             paren_expr
@@ -342,6 +384,7 @@ tokOffset	 int
 %type <bindings>         opt_let let
 %type <expr>             opt_where where
 %type <group>            opt_group group
+%type <groupingSets>     group_terms group_term grouping_sets grouping_set
 %type <bindings>         opt_letting letting
 %type <expr>             opt_having having
 %type <resultTerm>       project
@@ -357,7 +400,9 @@ tokOffset	 int
 %type <statement>        stmt explain prepare execute select_stmt dml_stmt ddl_stmt
 %type <statement>        infer infer_keyspace
 %type <statement>        insert upsert delete update merge
+%type <statement>        copy_from copy_to
 %type <statement>        index_stmt create_index drop_index alter_index build_index
+%type <statement>        keyspace_stmt create_keyspace drop_keyspace
 %type <statement>        role_stmt grant_role revoke_role
 
 %type <keyspaceRef>      keyspace_ref
@@ -392,6 +437,10 @@ tokOffset	 int
 %type <s>		funcName
 %type <inferenceType>    opt_infer_using
 %type <val>              infer_with opt_infer_with
+%type <s>                keyspace_using
+%type <val>              opt_keyspace_with
+%type <expr>             opt_copy_key
+%type <val>              opt_copy_with
 
 %type <ss>               user_list
 %type <ss>               keyspace_list
@@ -547,10 +596,16 @@ delete
 update
 |
 merge
+|
+copy_from
+|
+copy_to
 ;
 
 ddl_stmt:
 index_stmt
+|
+keyspace_stmt
 ;
 
 role_stmt:
@@ -789,7 +844,24 @@ AS alias
 ;
 
 alias:
+ident
+;
+
+/* Identifiers, and the keywords that are not reserved */
+ident:
 IDENT
+|
+COPY
+|
+CUBE
+|
+FILTER
+|
+GROUPING
+|
+ROLLUP
+|
+SETS
 ;
 
 
@@ -1017,11 +1089,11 @@ SYSTEM
 ;
 
 namespace_name:
-IDENT
+ident
 ;
 
 keyspace_name:
-IDENT
+ident
 ;
 
 opt_use:
@@ -1261,9 +1333,9 @@ group
 ;
 
 group:
-GROUP BY exprs opt_letting opt_having
+GROUP BY group_terms opt_letting opt_having
 {
-    $$ = algebra.NewGroup($3, $4, $5)
+    $$ = algebra.NewGroupingSets($3, $4, $5)
 }
 |
 letting
@@ -1272,6 +1344,63 @@ letting
 }
 ;
 
+group_terms:
+group_term
+|
+group_terms COMMA group_term
+{
+    $$ = algebra.CrossGroupingSets($1, $3)
+}
+;
+
+group_term:
+expr
+{
+    $$ = []expression.Expressions{expression.Expressions{$1}}
+}
+|
+ROLLUP LPAREN exprs RPAREN
+{
+    $$ = algebra.RollupSets($3)
+}
+|
+CUBE LPAREN exprs RPAREN
+{
+    $$ = algebra.CubeSets($3)
+}
+|
+GROUPING SETS LPAREN grouping_sets RPAREN
+{
+    $$ = $4
+}
+;
+
+grouping_sets:
+grouping_set
+|
+grouping_sets COMMA grouping_set
+{
+    $$ = append($1, $3...)
+}
+;
+
+grouping_set:
+LPAREN opt_exprs RPAREN
+{
+    $$ = []expression.Expressions{$2}
+}
+|
+ROLLUP LPAREN exprs RPAREN
+{
+    $$ = algebra.RollupSets($3)
+}
+|
+CUBE LPAREN exprs RPAREN
+{
+    $$ = algebra.CubeSets($3)
+}
+;
+
 exprs:
 expr
 {
@@ -1354,7 +1483,7 @@ sort_terms COMMA sort_term
 sort_term:
 expr opt_dir
 {
-    $$ = algebra.NewSortTerm($1, $2)
+    $$ = algebra.NewSortTerm(expression.CollateSortKey($1), $2)
 }
 ;
 
@@ -1695,7 +1824,7 @@ variable COLON variable WITHIN expr
 ;
 
 variable:
-IDENT
+ident
 ;
 
 opt_when:
@@ -1737,6 +1866,50 @@ path opt_update_for
 ;
 
 
+/*************************************************
+ *
+ * COPY
+ *
+ *************************************************/
+
+copy_from:
+COPY keyspace_ref FROM STR opt_copy_key opt_copy_with
+{
+    $$ = algebra.NewCopyFrom($2, $4, $5, $6)
+}
+;
+
+copy_to:
+COPY LPAREN fullselect RPAREN TO STR opt_copy_with
+{
+    $$ = algebra.NewCopyTo($3, $6, $7)
+}
+;
+
+opt_copy_key:
+/* empty */
+{
+    $$ = nil
+}
+|
+key_expr
+;
+
+opt_copy_with:
+/* empty */
+{
+    $$ = nil
+}
+|
+WITH expr
+{
+    $$ = $2.Value()
+    if $$ == nil {
+	yylex.Error("WITH value must be static.")
+    }
+}
+;
+
 /*************************************************
  *
  * MERGE
@@ -1984,7 +2157,7 @@ index_name
 ;
 
 index_name:
-IDENT
+ident
 ;
 
 named_keyspace_ref:
@@ -2179,6 +2352,55 @@ index_names COMMA index_name
 }
 ;
 
+/*************************************************
+ *
+ * CREATE KEYSPACE
+ * DROP KEYSPACE
+ *
+ *************************************************/
+
+keyspace_stmt:
+create_keyspace
+|
+drop_keyspace
+;
+
+create_keyspace:
+CREATE KEYSPACE named_keyspace_ref keyspace_using opt_keyspace_with
+{
+    $$ = algebra.NewCreateKeyspace($3, $4, $5)
+}
+;
+
+keyspace_using:
+USING IDENT
+{
+    $$ = $2
+}
+;
+
+opt_keyspace_with:
+/* empty */
+{
+    $$ = nil
+}
+|
+WITH expr
+{
+    $$ = $2.Value()
+    if $$ == nil {
+	yylex.Error("WITH value must be static.")
+    }
+}
+;
+
+drop_keyspace:
+DROP KEYSPACE named_keyspace_ref
+{
+    $$ = algebra.NewDropKeyspace($3)
+}
+;
+
 
 /*************************************************
  *
@@ -2187,12 +2409,12 @@ index_names COMMA index_name
  *************************************************/
 
 path:
-IDENT
+ident
 {
     $$ = expression.NewIdentifier($1)
 }
 |
-path DOT IDENT
+path DOT ident
 {
     $$ = expression.NewField($1, expression.NewFieldName($3, false))
 }
@@ -2233,7 +2455,7 @@ expr:
 c_expr
 |
 /* Nested */
-expr DOT IDENT
+expr DOT ident
 {
     $$ = expression.NewField($1, expression.NewFieldName($3, false))
 }
@@ -2309,6 +2531,12 @@ expr CONCAT expr
     $$ = expression.NewConcat($1, $3)
 }
 |
+/* Collation */
+expr COLLATE STR
+{
+    $$ = collate(yylex, $1, $3)
+}
+|
 /* Logical */
 expr AND expr
 {
@@ -2328,47 +2556,56 @@ NOT expr
 /* Comparison */
 expr EQ expr
 {
-    $$ = expression.NewEq($1, $3)
+    ops := collateOperands(yylex, $1, $3)
+    $$ = expression.NewEq(ops[0], ops[1])
 }
 |
 expr DEQ expr
 {
-    $$ = expression.NewEq($1, $3)
+    ops := collateOperands(yylex, $1, $3)
+    $$ = expression.NewEq(ops[0], ops[1])
 }
 |
 expr NE expr
 {
-    $$ = expression.NewNE($1, $3)
+    ops := collateOperands(yylex, $1, $3)
+    $$ = expression.NewNE(ops[0], ops[1])
 }
 |
 expr LT expr
 {
-    $$ = expression.NewLT($1, $3)
+    ops := collateOperands(yylex, $1, $3)
+    $$ = expression.NewLT(ops[0], ops[1])
 }
 |
 expr GT expr
 {
-    $$ = expression.NewGT($1, $3)
+    ops := collateOperands(yylex, $1, $3)
+    $$ = expression.NewGT(ops[0], ops[1])
 }
 |
 expr LE expr
 {
-    $$ = expression.NewLE($1, $3)
+    ops := collateOperands(yylex, $1, $3)
+    $$ = expression.NewLE(ops[0], ops[1])
 }
 |
 expr GE expr
 {
-    $$ = expression.NewGE($1, $3)
+    ops := collateOperands(yylex, $1, $3)
+    $$ = expression.NewGE(ops[0], ops[1])
 }
 |
 expr BETWEEN b_expr AND b_expr
 {
-    $$ = expression.NewBetween($1, $3, $5)
+    ops := collateOperands(yylex, $1, $3, $5)
+    $$ = expression.NewBetween(ops[0], ops[1], ops[2])
 }
 |
 expr NOT BETWEEN b_expr AND b_expr
 {
-    $$ = expression.NewNotBetween($1, $4, $6)
+    ops := collateOperands(yylex, $1, $4, $6)
+    $$ = expression.NewNotBetween(ops[0], ops[1], ops[2])
 }
 |
 expr LIKE expr
@@ -2451,7 +2688,7 @@ literal
 construction_expr
 |
 /* Identifier */
-IDENT
+ident
 {
     $$ = expression.NewIdentifier($1)
 }
@@ -2502,7 +2739,7 @@ b_expr:
 c_expr
 |
 /* Nested */
-b_expr DOT IDENT
+b_expr DOT ident
 {
     $$ = expression.NewField($1, expression.NewFieldName($3, false))
 }
@@ -2577,6 +2814,12 @@ b_expr CONCAT b_expr
 {
     $$ = expression.NewConcat($1, $3)
 }
+|
+/* Collation */
+b_expr COLLATE STR
+{
+    $$ = collate(yylex, $1, $3)
+}
 ;
 
 
@@ -2794,7 +3037,7 @@ ELSE expr
  *************************************************/
 
 function_expr:
-function_name LPAREN opt_exprs RPAREN
+function_name LPAREN opt_exprs RPAREN opt_filter
 {
     $$ = nil;
     f, ok := expression.GetFunction($1);
@@ -2807,23 +3050,41 @@ function_name LPAREN opt_exprs RPAREN
             yylex.Error(fmt.Sprintf("Wrong number of arguments to function %s.", $1));
         } else {
             $$ = f.Constructor()($3...);
+            setAggregateFilter(yylex, $$, $5);
         }
     } else {
         yylex.Error(fmt.Sprintf("Invalid function %s.", $1));
     }
 }
 |
-function_name LPAREN DISTINCT expr RPAREN
+function_name LPAREN exprs order_by RPAREN opt_filter
+{
+    $$ = nil;
+    agg, ok := algebra.GetOrderedAggregate($1);
+    if !ok {
+        yylex.Error(fmt.Sprintf("Invalid ordered aggregate function %s.", $1));
+    } else if len($3) < agg.MinArgs() || len($3) > agg.MaxArgs() {
+        yylex.Error(fmt.Sprintf("Wrong number of arguments to function %s.", $1));
+    } else {
+        agg = agg.Constructor()($3...).(algebra.Aggregate);
+        agg.SetOrder($4);
+        $$ = agg;
+        setAggregateFilter(yylex, $$, $6);
+    }
+}
+|
+function_name LPAREN DISTINCT expr RPAREN opt_filter
 {
     agg, ok := algebra.GetAggregate($1, true);
     if ok {
         $$ = agg.Constructor()($4);
+        setAggregateFilter(yylex, $$, $6);
     } else {
         yylex.Error(fmt.Sprintf("Invalid aggregate function %s.", $1));
     }
 }
 |
-function_name LPAREN STAR RPAREN
+function_name LPAREN STAR RPAREN opt_filter
 {
     if strings.ToLower($1) != "count" {
         yylex.Error(fmt.Sprintf("Invalid aggregate function %s(*).", $1));
@@ -2831,15 +3092,43 @@ function_name LPAREN STAR RPAREN
         agg, ok := algebra.GetAggregate($1, false);
         if ok {
             $$ = agg.Constructor()(nil);
+            setAggregateFilter(yylex, $$, $5);
         } else {
             yylex.Error(fmt.Sprintf("Invalid aggregate function %s.", $1));
         }
     }
 }
+|
+GROUPING LPAREN exprs RPAREN
+{
+    $$ = algebra.NewGrouping($3...)
+}
+;
+
+opt_filter:
+/* empty */ %prec LPAREN
+{
+    $$ = nil
+}
+|
+FILTER LPAREN WHERE expr RPAREN
+{
+    $$ = $4
+}
 ;
 
 function_name:
 IDENT
+|
+LEFT
+{
+    $$ = "left"
+}
+|
+RIGHT
+{
+    $$ = "right"
+}
 ;
 
 
@@ -0,0 +1,85 @@
+//  Copyright (c) 2018 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package plan
+
+import (
+	"encoding/json"
+
+	"github.com/couchbase/query/value"
+)
+
+// CopyTo writes the results of a query to a file, for COPY ... TO.
+type CopyTo struct {
+	readwrite
+	file string
+	with value.Value
+}
+
+func NewCopyTo(file string, with value.Value) *CopyTo {
+	return &CopyTo{
+		file: file,
+		with: with,
+	}
+}
+
+func (this *CopyTo) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitCopyTo(this)
+}
+
+func (this *CopyTo) New() Operator {
+	return &CopyTo{}
+}
+
+func (this *CopyTo) File() string {
+	return this.file
+}
+
+func (this *CopyTo) With() value.Value {
+	return this.with
+}
+
+func (this *CopyTo) MarshalJSON() ([]byte, error) {
+	return json.Marshal(this.MarshalBase(nil))
+}
+
+func (this *CopyTo) MarshalBase(f func(map[string]interface{})) map[string]interface{} {
+	r := map[string]interface{}{"#operator": "CopyTo"}
+	r["file"] = this.file
+
+	if this.with != nil {
+		r["with"] = this.with
+	}
+
+	if f != nil {
+		f(r)
+	}
+	return r
+}
+
+func (this *CopyTo) UnmarshalJSON(body []byte) error {
+	var _unmarshalled struct {
+		_    string          `json:"#operator"`
+		File string          `json:"file"`
+		With json.RawMessage `json:"with"`
+	}
+
+	err := json.Unmarshal(body, &_unmarshalled)
+	if err != nil {
+		return err
+	}
+
+	this.file = _unmarshalled.File
+
+	if len(_unmarshalled.With) > 0 {
+		this.with = value.NewValue([]byte(_unmarshalled.With))
+	}
+
+	return nil
+}
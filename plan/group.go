@@ -21,12 +21,14 @@ import (
 type InitialGroup struct {
 	readonly
 	keys       expression.Expressions
+	sets       [][]int
 	aggregates algebra.Aggregates
 }
 
-func NewInitialGroup(keys expression.Expressions, aggregates algebra.Aggregates) *InitialGroup {
+func NewInitialGroup(keys expression.Expressions, sets [][]int, aggregates algebra.Aggregates) *InitialGroup {
 	return &InitialGroup{
 		keys:       keys,
+		sets:       sets,
 		aggregates: aggregates,
 	}
 }
@@ -43,6 +45,10 @@ func (this *InitialGroup) Keys() expression.Expressions {
 	return this.keys
 }
 
+func (this *InitialGroup) Sets() [][]int {
+	return this.sets
+}
+
 func (this *InitialGroup) Aggregates() algebra.Aggregates {
 	return this.aggregates
 }
@@ -58,6 +64,9 @@ func (this *InitialGroup) MarshalBase(f func(map[string]interface{})) map[string
 		keylist = append(keylist, expression.NewStringer().Visit(key))
 	}
 	r["group_keys"] = keylist
+	if this.sets != nil {
+		r["grouping_sets"] = this.sets
+	}
 	s := make([]interface{}, 0, len(this.aggregates))
 	for _, agg := range this.aggregates {
 		s = append(s, expression.NewStringer().Visit(agg))
@@ -73,6 +82,7 @@ func (this *InitialGroup) UnmarshalJSON(body []byte) error {
 	var _unmarshalled struct {
 		_    string   `json:"#operator"`
 		Keys []string `json:"group_keys"`
+		Sets [][]int  `json:"grouping_sets"`
 		Aggs []string `json:"aggregates"`
 	}
 
@@ -90,6 +100,8 @@ func (this *InitialGroup) UnmarshalJSON(body []byte) error {
 		this.keys[i] = key_expr
 	}
 
+	this.sets = _unmarshalled.Sets
+
 	this.aggregates = make(algebra.Aggregates, len(_unmarshalled.Aggs))
 	for i, agg := range _unmarshalled.Aggs {
 		agg_expr, err := parser.Parse(agg)
@@ -106,12 +118,14 @@ func (this *InitialGroup) UnmarshalJSON(body []byte) error {
 type IntermediateGroup struct {
 	readonly
 	keys       expression.Expressions
+	sets       [][]int
 	aggregates algebra.Aggregates
 }
 
-func NewIntermediateGroup(keys expression.Expressions, aggregates algebra.Aggregates) *IntermediateGroup {
+func NewIntermediateGroup(keys expression.Expressions, sets [][]int, aggregates algebra.Aggregates) *IntermediateGroup {
 	return &IntermediateGroup{
 		keys:       keys,
+		sets:       sets,
 		aggregates: aggregates,
 	}
 }
@@ -128,6 +142,10 @@ func (this *IntermediateGroup) Keys() expression.Expressions {
 	return this.keys
 }
 
+func (this *IntermediateGroup) Sets() [][]int {
+	return this.sets
+}
+
 func (this *IntermediateGroup) Aggregates() algebra.Aggregates {
 	return this.aggregates
 }
@@ -143,6 +161,9 @@ func (this *IntermediateGroup) MarshalBase(f func(map[string]interface{})) map[s
 		keylist = append(keylist, expression.NewStringer().Visit(key))
 	}
 	r["group_keys"] = keylist
+	if this.sets != nil {
+		r["grouping_sets"] = this.sets
+	}
 	s := make([]interface{}, 0, len(this.aggregates))
 	for _, agg := range this.aggregates {
 		s = append(s, expression.NewStringer().Visit(agg))
@@ -158,6 +179,7 @@ func (this *IntermediateGroup) UnmarshalJSON(body []byte) error {
 	var _unmarshalled struct {
 		_    string   `json:"#operator"`
 		Keys []string `json:"group_keys"`
+		Sets [][]int  `json:"grouping_sets"`
 		Aggs []string `json:"aggregates"`
 	}
 
@@ -175,6 +197,8 @@ func (this *IntermediateGroup) UnmarshalJSON(body []byte) error {
 		this.keys[i] = key_expr
 	}
 
+	this.sets = _unmarshalled.Sets
+
 	this.aggregates = make(algebra.Aggregates, len(_unmarshalled.Aggs))
 	for i, agg := range _unmarshalled.Aggs {
 		agg_expr, err := parser.Parse(agg)
@@ -191,12 +215,14 @@ func (this *IntermediateGroup) UnmarshalJSON(body []byte) error {
 type FinalGroup struct {
 	readonly
 	keys       expression.Expressions
+	sets       [][]int
 	aggregates algebra.Aggregates
 }
 
-func NewFinalGroup(keys expression.Expressions, aggregates algebra.Aggregates) *FinalGroup {
+func NewFinalGroup(keys expression.Expressions, sets [][]int, aggregates algebra.Aggregates) *FinalGroup {
 	return &FinalGroup{
 		keys:       keys,
+		sets:       sets,
 		aggregates: aggregates,
 	}
 }
@@ -213,6 +239,10 @@ func (this *FinalGroup) Keys() expression.Expressions {
 	return this.keys
 }
 
+func (this *FinalGroup) Sets() [][]int {
+	return this.sets
+}
+
 func (this *FinalGroup) Aggregates() algebra.Aggregates {
 	return this.aggregates
 }
@@ -228,6 +258,9 @@ func (this *FinalGroup) MarshalBase(f func(map[string]interface{})) map[string]i
 		keylist = append(keylist, expression.NewStringer().Visit(key))
 	}
 	r["group_keys"] = keylist
+	if this.sets != nil {
+		r["grouping_sets"] = this.sets
+	}
 	s := make([]interface{}, 0, len(this.aggregates))
 	for _, agg := range this.aggregates {
 		s = append(s, expression.NewStringer().Visit(agg))
@@ -243,6 +276,7 @@ func (this *FinalGroup) UnmarshalJSON(body []byte) error {
 	var _unmarshalled struct {
 		_    string   `json:"#operator"`
 		Keys []string `json:"group_keys"`
+		Sets [][]int  `json:"grouping_sets"`
 		Aggs []string `json:"aggregates"`
 	}
 
@@ -260,6 +294,8 @@ func (this *FinalGroup) UnmarshalJSON(body []byte) error {
 		this.keys[i] = key_expr
 	}
 
+	this.sets = _unmarshalled.Sets
+
 	this.aggregates = make(algebra.Aggregates, len(_unmarshalled.Aggs))
 	for i, agg := range _unmarshalled.Aggs {
 		agg_expr, err := parser.Parse(agg)
@@ -0,0 +1,98 @@
+//  Copyright (c) 2018 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package plan
+
+import (
+	"encoding/json"
+
+	"github.com/couchbase/query/algebra"
+	"github.com/couchbase/query/datastore"
+	"github.com/couchbase/query/value"
+)
+
+// Create keyspace
+type CreateKeyspace struct {
+	readwrite
+	namespace datastore.Namespace
+	node      *algebra.CreateKeyspace
+}
+
+func NewCreateKeyspace(namespace datastore.Namespace, node *algebra.CreateKeyspace) *CreateKeyspace {
+	return &CreateKeyspace{
+		namespace: namespace,
+		node:      node,
+	}
+}
+
+func (this *CreateKeyspace) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitCreateKeyspace(this)
+}
+
+func (this *CreateKeyspace) New() Operator {
+	return &CreateKeyspace{}
+}
+
+func (this *CreateKeyspace) Namespace() datastore.Namespace {
+	return this.namespace
+}
+
+func (this *CreateKeyspace) Node() *algebra.CreateKeyspace {
+	return this.node
+}
+
+func (this *CreateKeyspace) MarshalJSON() ([]byte, error) {
+	return json.Marshal(this.MarshalBase(nil))
+}
+
+func (this *CreateKeyspace) MarshalBase(f func(map[string]interface{})) map[string]interface{} {
+	r := map[string]interface{}{"#operator": "CreateKeyspace"}
+	r["namespace"] = this.namespace.Name()
+	r["keyspace"] = this.node.Keyspace().Keyspace()
+	r["using"] = this.node.Using()
+
+	if this.node.With() != nil {
+		r["with"] = this.node.With()
+	}
+
+	if f != nil {
+		f(r)
+	}
+	return r
+}
+
+func (this *CreateKeyspace) UnmarshalJSON(body []byte) error {
+	var _unmarshalled struct {
+		_         string          `json:"#operator"`
+		Namespace string          `json:"namespace"`
+		Keyspace  string          `json:"keyspace"`
+		Using     string          `json:"using"`
+		With      json.RawMessage `json:"with"`
+	}
+
+	err := json.Unmarshal(body, &_unmarshalled)
+	if err != nil {
+		return err
+	}
+
+	this.namespace, err = datastore.GetNamespace(_unmarshalled.Namespace)
+	if err != nil {
+		return err
+	}
+
+	ksref := algebra.NewKeyspaceRef(_unmarshalled.Namespace, _unmarshalled.Keyspace, "")
+
+	var with value.Value
+	if len(_unmarshalled.With) > 0 {
+		with = value.NewValue([]byte(_unmarshalled.With))
+	}
+
+	this.node = algebra.NewCreateKeyspace(ksref, _unmarshalled.Using, with)
+	return nil
+}
@@ -0,0 +1,83 @@
+//  Copyright (c) 2018 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package plan
+
+import (
+	"encoding/json"
+
+	"github.com/couchbase/query/algebra"
+	"github.com/couchbase/query/datastore"
+)
+
+// Drop keyspace
+type DropKeyspace struct {
+	readwrite
+	namespace datastore.Namespace
+	node      *algebra.DropKeyspace
+}
+
+func NewDropKeyspace(namespace datastore.Namespace, node *algebra.DropKeyspace) *DropKeyspace {
+	return &DropKeyspace{
+		namespace: namespace,
+		node:      node,
+	}
+}
+
+func (this *DropKeyspace) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitDropKeyspace(this)
+}
+
+func (this *DropKeyspace) New() Operator {
+	return &DropKeyspace{}
+}
+
+func (this *DropKeyspace) Namespace() datastore.Namespace {
+	return this.namespace
+}
+
+func (this *DropKeyspace) Node() *algebra.DropKeyspace {
+	return this.node
+}
+
+func (this *DropKeyspace) MarshalJSON() ([]byte, error) {
+	return json.Marshal(this.MarshalBase(nil))
+}
+
+func (this *DropKeyspace) MarshalBase(f func(map[string]interface{})) map[string]interface{} {
+	r := map[string]interface{}{"#operator": "DropKeyspace"}
+	r["namespace"] = this.namespace.Name()
+	r["keyspace"] = this.node.Keyspace().Keyspace()
+	if f != nil {
+		f(r)
+	}
+	return r
+}
+
+func (this *DropKeyspace) UnmarshalJSON(body []byte) error {
+	var _unmarshalled struct {
+		_         string `json:"#operator"`
+		Namespace string `json:"namespace"`
+		Keyspace  string `json:"keyspace"`
+	}
+
+	err := json.Unmarshal(body, &_unmarshalled)
+	if err != nil {
+		return err
+	}
+
+	this.namespace, err = datastore.GetNamespace(_unmarshalled.Namespace)
+	if err != nil {
+		return err
+	}
+
+	ksref := algebra.NewKeyspaceRef(_unmarshalled.Namespace, _unmarshalled.Keyspace, "")
+	this.node = algebra.NewDropKeyspace(ksref)
+	return nil
+}
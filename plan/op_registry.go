@@ -48,6 +48,7 @@ var _OPERATORS = map[string]Operator{
 	"IndexScan3":              &IndexScan3{},
 	"KeyScan":                 &KeyScan{},
 	"ValueScan":               &ValueScan{},
+	"FileScan":                &FileScan{},
 	"DummyScan":               &DummyScan{},
 	"CountScan":               &CountScan{},
 	"IndexCountScan":          &IndexCountScan{},
@@ -126,6 +127,9 @@ var _OPERATORS = map[string]Operator{
 	// Merge
 	"Merge": &Merge{},
 
+	// Copy
+	"CopyTo": &CopyTo{},
+
 	// Framework
 	"Alias":     &Alias{},
 	"Authorize": &Authorize{},
@@ -142,6 +146,10 @@ var _OPERATORS = map[string]Operator{
 	"AlterIndex":         &AlterIndex{},
 	"BuildIndexes":       &BuildIndexes{},
 
+	// Keyspace DDL
+	"CreateKeyspace": &CreateKeyspace{},
+	"DropKeyspace":   &DropKeyspace{},
+
 	// Roles
 	"GrantRole":  &GrantRole{},
 	"RevokeRole": &RevokeRole{},
@@ -36,6 +36,7 @@ type Prepared struct {
 type idxVersion struct {
 	indexer datastore.Indexer
 	version uint64
+	indexes []datastore.Index // the indexes the plan uses
 }
 
 type nsVersion struct {
@@ -181,16 +182,23 @@ func (this *Prepared) MismatchingEncodedPlan(encoded_plan string) bool {
 }
 
 // Locking is handled by the top level caller!
-func (this *Prepared) addIndexer(indexer datastore.Indexer) {
+func (this *Prepared) addIndex(index datastore.Index, indexer datastore.Indexer) {
 	indexer.Refresh()
 	version := indexer.MetadataVersion()
-	for _, idx := range this.indexers {
+	for i := range this.indexers {
+		idx := &this.indexers[i]
 		if idx.indexer == indexer {
 			idx.version = version
+			for _, ix := range idx.indexes {
+				if ix.Id() == index.Id() {
+					return
+				}
+			}
+			idx.indexes = append(idx.indexes, index)
 			return
 		}
 	}
-	this.indexers = append(this.indexers, idxVersion{indexer, version})
+	this.indexers = append(this.indexers, idxVersion{indexer, version, []datastore.Index{index}})
 }
 
 // Locking is handled by the top level caller!
@@ -206,22 +214,36 @@ func (this *Prepared) addNamespace(namespace datastore.Namespace) {
 }
 
 func (this *Prepared) MetadataCheck() bool {
+	return this.MetadataChange() == ""
+}
+
+// MetadataChange returns why the plan is stale, or "" if the indexes
+// and keyspaces it was verified against have not changed since.
+// Any change makes the plan stale, even if the indexes it uses still
+// exist, as a better index may have been created.
+func (this *Prepared) MetadataChange() string {
 
 	// check that metadata is the same for the indexers involved
 	for _, idx := range this.indexers {
 		idx.indexer.Refresh()
 		if idx.indexer.MetadataVersion() != idx.version {
-			return false
+			for _, index := range idx.indexes {
+				ix, err := idx.indexer.IndexById(index.Id())
+				if ix == nil || err != nil {
+					return "index " + index.Name() + " dropped"
+				}
+			}
+			return "index metadata changed"
 		}
 	}
 
 	// now check that metadata is good for the namespaces involved
 	for _, ns := range this.namespaces {
 		if ns.namespace.MetadataVersion() != ns.version {
-			return false
+			return "keyspace metadata changed"
 		}
 	}
-	return true
+	return ""
 }
 
 func (this *Prepared) Verify() bool {
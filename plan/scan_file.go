@@ -0,0 +1,95 @@
+//  Copyright (c) 2018 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package plan
+
+import (
+	"encoding/json"
+
+	"github.com/couchbase/query/value"
+)
+
+// FileScan reads the documents of a file, for COPY ... FROM. Each
+// document is sent under the keyspace alias.
+type FileScan struct {
+	readonly
+	file  string
+	alias string
+	with  value.Value
+}
+
+func NewFileScan(file, alias string, with value.Value) *FileScan {
+	return &FileScan{
+		file:  file,
+		alias: alias,
+		with:  with,
+	}
+}
+
+func (this *FileScan) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitFileScan(this)
+}
+
+func (this *FileScan) New() Operator {
+	return &FileScan{}
+}
+
+func (this *FileScan) File() string {
+	return this.file
+}
+
+func (this *FileScan) Alias() string {
+	return this.alias
+}
+
+func (this *FileScan) With() value.Value {
+	return this.with
+}
+
+func (this *FileScan) MarshalJSON() ([]byte, error) {
+	return json.Marshal(this.MarshalBase(nil))
+}
+
+func (this *FileScan) MarshalBase(f func(map[string]interface{})) map[string]interface{} {
+	r := map[string]interface{}{"#operator": "FileScan"}
+	r["file"] = this.file
+	r["alias"] = this.alias
+
+	if this.with != nil {
+		r["with"] = this.with
+	}
+
+	if f != nil {
+		f(r)
+	}
+	return r
+}
+
+func (this *FileScan) UnmarshalJSON(body []byte) error {
+	var _unmarshalled struct {
+		_     string          `json:"#operator"`
+		File  string          `json:"file"`
+		Alias string          `json:"alias"`
+		With  json.RawMessage `json:"with"`
+	}
+
+	err := json.Unmarshal(body, &_unmarshalled)
+	if err != nil {
+		return err
+	}
+
+	this.file = _unmarshalled.File
+	this.alias = _unmarshalled.Alias
+
+	if len(_unmarshalled.With) > 0 {
+		this.with = value.NewValue([]byte(_unmarshalled.With))
+	}
+
+	return nil
+}
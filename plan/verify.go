@@ -36,7 +36,7 @@ func verifyIndex(index datastore.Index, indexer datastore.Indexer, prepared *Pre
 
 	// amend prepared statement version so that next time we avoid checks
 	if prepared != nil {
-		prepared.addIndexer(indexer)
+		prepared.addIndex(index, indexer)
 	}
 	return true
 }
@@ -0,0 +1,52 @@
+//  Copyright (c) 2018 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package planner
+
+import (
+	"github.com/couchbase/query/algebra"
+	"github.com/couchbase/query/expression"
+	"github.com/couchbase/query/plan"
+)
+
+// COPY ... FROM upserts the documents of the file, each under the
+// keyspace alias, so that the key expression can refer to them
+func (this *builder) VisitCopyFrom(stmt *algebra.CopyFrom) (interface{}, error) {
+	ksref := stmt.KeyspaceRef()
+	ksref.SetDefaultNamespace(this.namespace)
+
+	keyspace, err := this.getNameKeyspace(ksref.Namespace(), ksref.Keyspace())
+	if err != nil {
+		return nil, err
+	}
+
+	doc := expression.NewIdentifier(ksref.Alias())
+	doc.SetKeyspaceAlias(true)
+
+	children := make([]plan.Operator, 0, 2)
+	children = append(children, plan.NewFileScan(stmt.File(), ksref.Alias(), stmt.With()))
+
+	subChildren := make([]plan.Operator, 0, 2)
+	subChildren = append(subChildren, plan.NewSendUpsert(keyspace, ksref.Alias(), stmt.Key(), doc))
+	subChildren = append(subChildren, plan.NewDiscard())
+
+	parallel := plan.NewParallel(plan.NewSequence(subChildren...), this.maxParallelism)
+	children = append(children, parallel)
+	return plan.NewSequence(children...), nil
+}
+
+// COPY ... TO writes the results of the query instead of streaming them
+func (this *builder) VisitCopyTo(stmt *algebra.CopyTo) (interface{}, error) {
+	sel, err := stmt.Select().Accept(this)
+	if err != nil {
+		return nil, err
+	}
+
+	return plan.NewSequence(sel.(plan.Operator), plan.NewCopyTo(stmt.File(), stmt.With())), nil
+}
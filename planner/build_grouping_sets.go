@@ -0,0 +1,150 @@
+//  Copyright (c) 2018 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package planner
+
+import (
+	"fmt"
+
+	"github.com/couchbase/query/algebra"
+	"github.com/couchbase/query/expression"
+)
+
+/*
+With ROLLUP, CUBE and GROUPING SETS, a group key that is not part
+of the grouping set of a group is NULL in that group. References to
+group keys after grouping are mapped accordingly.
+*/
+func (this *builder) mapGroupingSets(node *algebra.Subselect, group *algebra.Group) error {
+	mapper := NewGroupingSetsMapper(group.By())
+
+	err := group.MapGroupedExpressions(mapper)
+	if err != nil {
+		return err
+	}
+
+	err = node.Projection().MapGroupedExpressions(mapper)
+	if err != nil {
+		return err
+	}
+
+	if this.order != nil {
+		err = this.order.MapExpressions(mapper)
+	}
+
+	return err
+}
+
+/*
+GROUPING() is evaluated over the groups, so it is not allowed in
+the clauses that come before grouping, nor in a query that does
+not group at all. Its operands are checked against the group keys
+along with the other grouped expressions.
+*/
+func checkGrouping(node *algebra.Subselect, order *algebra.Order) error {
+	for _, binding := range node.Let() {
+		if hasGrouping(binding.Expression()) {
+			return fmt.Errorf("GROUPING() not allowed in LET.")
+		}
+	}
+
+	if hasGrouping(node.Where()) {
+		return fmt.Errorf("GROUPING() not allowed in WHERE.")
+	}
+
+	group := node.Group()
+	if group != nil {
+		if hasGrouping(group.By()...) {
+			return fmt.Errorf("GROUPING() not allowed in GROUP BY.")
+		}
+
+		return nil
+	}
+
+	for _, term := range node.Projection().Terms() {
+		if hasGrouping(term.Expression()) {
+			return fmt.Errorf("GROUPING() requires GROUP BY.")
+		}
+	}
+
+	if order != nil && hasGrouping(order.Expressions()...) {
+		return fmt.Errorf("GROUPING() requires GROUP BY.")
+	}
+
+	return nil
+}
+
+func hasGrouping(exprs ...expression.Expression) bool {
+	for _, expr := range exprs {
+		if expr == nil {
+			continue
+		}
+
+		switch expr.(type) {
+		case *algebra.Grouping:
+			return true
+		case *algebra.Subquery:
+			continue
+		}
+
+		if hasGrouping(expr.Children()...) {
+			return true
+		}
+	}
+
+	return false
+}
+
+/*
+GroupingSetsMapper maps each group key k, outside of aggregates and
+GROUPING(), to CASE WHEN GROUPING(k) = 1 THEN NULL ELSE k END.
+*/
+type GroupingSetsMapper struct {
+	expression.MapperBase
+
+	keys expression.Expressions
+}
+
+func NewGroupingSetsMapper(keys expression.Expressions) *GroupingSetsMapper {
+	rv := &GroupingSetsMapper{
+		keys: keys,
+	}
+
+	rv.SetMapper(rv)
+	rv.SetMapFunc(func(expr expression.Expression) (expression.Expression, error) {
+		switch expr.(type) {
+		case algebra.Aggregate, *algebra.Grouping:
+			return expr, nil
+		}
+
+		for _, key := range rv.keys {
+			if expr.EquivalentTo(key) {
+				grouped := expression.NewEq(algebra.NewGrouping(key.Copy()), expression.ONE_EXPR)
+				whenTerms := expression.WhenTerms{
+					&expression.WhenTerm{When: grouped, Then: expression.NULL_EXPR},
+				}
+				return expression.NewSearchedCase(whenTerms, expr), nil
+			}
+		}
+
+		return expr, expr.MapChildren(rv)
+	})
+
+	return rv
+}
+
+// Parameters
+
+func (this *GroupingSetsMapper) VisitNamedParameter(expr expression.NamedParameter) (interface{}, error) {
+	return expr, nil
+}
+
+func (this *GroupingSetsMapper) VisitPositionalParameter(expr expression.PositionalParameter) (interface{}, error) {
+	return expr, nil
+}
@@ -0,0 +1,65 @@
+//  Copyright (c) 2018 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package planner
+
+import (
+	"strings"
+
+	"github.com/couchbase/query/algebra"
+	"github.com/couchbase/query/datastore"
+	"github.com/couchbase/query/errors"
+	"github.com/couchbase/query/plan"
+)
+
+func (this *builder) VisitCreateKeyspace(stmt *algebra.CreateKeyspace) (interface{}, error) {
+	namespace, err := this.getManagedNamespace(stmt.Keyspace().Namespace(), "CREATE KEYSPACE")
+	if err != nil {
+		return nil, err
+	}
+
+	return plan.NewCreateKeyspace(namespace, stmt), nil
+}
+
+func (this *builder) VisitDropKeyspace(stmt *algebra.DropKeyspace) (interface{}, error) {
+	namespace, err := this.getManagedNamespace(stmt.Keyspace().Namespace(), "DROP KEYSPACE")
+	if err != nil {
+		return nil, err
+	}
+
+	// the keyspace must exist
+	_, err = namespace.KeyspaceByName(stmt.Keyspace().Keyspace())
+	if err != nil {
+		return nil, err
+	}
+
+	return plan.NewDropKeyspace(namespace, stmt), nil
+}
+
+// Keyspace DDL requires a namespace that manages its keyspaces
+func (this *builder) getManagedNamespace(ns, op string) (datastore.Namespace, error) {
+	if ns == "" {
+		ns = this.namespace
+	}
+
+	if strings.ToLower(ns) == "#system" {
+		return nil, errors.NewOtherNotSupportedError(nil, op+" on namespace "+ns)
+	}
+
+	namespace, err := this.datastore.NamespaceByName(ns)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := namespace.(datastore.KeyspaceManager); !ok {
+		return nil, errors.NewOtherNotSupportedError(nil, op+" on namespace "+ns)
+	}
+
+	return namespace, nil
+}
@@ -38,6 +38,7 @@ func (this *builder) VisitPrepare(stmt *algebra.Prepare) (interface{}, error) {
 	pl.SetType(stmt.Type())
 	pl.SetIndexApiVersion(this.indexApiVersion)
 	pl.SetFeatureControls(this.featureControls)
+	pl = UsePlanBaseline(pl)
 
 	json_bytes, err := pl.MarshalJSON()
 	if err != nil {
@@ -10,6 +10,9 @@
 package planner
 
 import (
+	"bytes"
+	"encoding/json"
+
 	"github.com/couchbase/query/algebra"
 	"github.com/couchbase/query/datastore"
 	"github.com/couchbase/query/plan"
@@ -28,3 +31,38 @@ func BuildPrepared(stmt algebra.Statement, datastore, systemstore datastore.Data
 	signature := stmt.Signature()
 	return plan.NewPrepared(operator, signature), nil
 }
+
+// PlanBaselines supplies the plans pinned for prepared statements.
+type PlanBaselines interface {
+	Baseline(prepared *plan.Prepared) *plan.Prepared // Fresh copy of the pinned plan, if still valid
+	Diverged(baseline, prepared *plan.Prepared)      // The plan just built differs from the pinned one
+}
+
+var planBaselines PlanBaselines
+
+func SetPlanBaselines(baselines PlanBaselines) {
+	planBaselines = baselines
+}
+
+// A named statement with a pinned plan keeps using it for as long as
+// it remains valid, whatever the plan just built for it.
+// The prepared statement must already have its name and text set.
+func UsePlanBaseline(prepared *plan.Prepared) *plan.Prepared {
+	if planBaselines == nil || prepared.Name() == "" {
+		return prepared
+	}
+	baseline := planBaselines.Baseline(prepared)
+	if baseline == nil {
+		return prepared
+	}
+	if !samePlan(baseline, prepared) {
+		planBaselines.Diverged(baseline, prepared)
+	}
+	return baseline
+}
+
+func samePlan(p1, p2 *plan.Prepared) bool {
+	b1, err1 := json.Marshal(p1.Operator)
+	b2, err2 := json.Marshal(p2.Operator)
+	return err1 == nil && err2 == nil && bytes.Equal(b1, b2)
+}
@@ -88,6 +88,11 @@ func (this *builder) VisitSubselect(node *algebra.Subselect) (interface{}, error
 		return nil, err
 	}
 
+	err = checkGrouping(node, this.order)
+	if err != nil {
+		return nil, err
+	}
+
 	// Infer WHERE clause from aggregates
 	group := node.Group()
 	if group == nil && len(aggs) > 0 {
@@ -153,6 +158,14 @@ func (this *builder) VisitSubselect(node *algebra.Subselect) (interface{}, error
 				}
 			}
 		}
+
+		// Group keys outside of a grouping set are NULL
+		if group.Sets() != nil {
+			err = this.mapGroupingSets(node, group)
+			if err != nil {
+				return nil, err
+			}
+		}
 		this.resetProjection()
 	}
 
@@ -162,6 +175,10 @@ func (this *builder) VisitSubselect(node *algebra.Subselect) (interface{}, error
 		for _, term := range node.Projection().Terms() {
 			switch expr := term.Expression().(type) {
 			case *algebra.Count, *algebra.CountDistinct, *algebra.Min, *algebra.Max:
+				if expr.(algebra.Aggregate).Filter() != nil {
+					this.oldAggregates = false
+					break loop
+				}
 				this.oldAggregates = true
 			default:
 				if expr.Value() == nil {
@@ -287,11 +304,11 @@ func (this *builder) visitGroup(group *algebra.Group, aggs algebra.Aggregates) {
 
 	if partial {
 		aggv := sortAggregatesSlice(aggs)
-		this.subChildren = append(this.subChildren, plan.NewInitialGroup(group.By(), aggv))
+		this.subChildren = append(this.subChildren, plan.NewInitialGroup(group.By(), group.Sets(), aggv))
 		this.children = append(this.children,
 			plan.NewParallel(plan.NewSequence(this.subChildren...), this.maxParallelism))
-		this.children = append(this.children, plan.NewIntermediateGroup(group.By(), aggv))
-		this.children = append(this.children, plan.NewFinalGroup(group.By(), aggv))
+		this.children = append(this.children, plan.NewIntermediateGroup(group.By(), group.Sets(), aggv))
+		this.children = append(this.children, plan.NewFinalGroup(group.By(), group.Sets(), aggv))
 		this.subChildren = make([]plan.Operator, 0, 8)
 	}
 
@@ -680,6 +697,12 @@ func skipFixedOrderTerms(order *algebra.Order, pred expression.Expression) *alge
 func (this *builder) setIndexGroupAggs(group *algebra.Group, aggs algebra.Aggregates, let expression.Bindings) {
 
 	if group != nil {
+		// Grouping sets are not pushed to the index
+		if group.Sets() != nil {
+			this.resetPushDowns()
+			return
+		}
+
 		// Group or Aggregates Depends on LET disable pushdowns
 		for _, expr := range group.By() {
 			if !expr.IndexAggregatable() || dependsOnLet(expr, let) {
@@ -689,8 +712,10 @@ func (this *builder) setIndexGroupAggs(group *algebra.Group, aggs algebra.Aggreg
 		}
 
 		for _, agg := range aggs {
+			// FILTER and ORDER BY clauses are not pushed to the index
 			aggIndexProperties := aggToIndexAgg(agg)
-			if !aggIndexProperties.supported {
+			if aggIndexProperties == nil || !aggIndexProperties.supported ||
+				agg.Filter() != nil || agg.Order() != nil {
 				this.resetPushDowns()
 				return
 			}
@@ -292,6 +292,27 @@ func TestStatementPrivileges(t *testing.T) {
 			expectedPrivs: &auth.Privileges{List: []auth.PrivilegePair{
 				auth.PrivilegePair{Target: ":testbucket", Priv: auth.PRIV_QUERY_DROP_INDEX},
 			}}},
+		//
+		// COPY statements, which access files on the query node
+		//
+		testCase{id: "Copy To constant",
+			text: "copy (select 1) to '/tmp/x.json' with {'overwrite': true}",
+			expectedPrivs: &auth.Privileges{List: []auth.PrivilegePair{
+				auth.PrivilegePair{Target: "", Priv: auth.PRIV_QUERY_EXTERNAL_ACCESS},
+			}}},
+		testCase{id: "Copy To",
+			text: "copy (select * from testbucket) to '/tmp/x.json'",
+			expectedPrivs: &auth.Privileges{List: []auth.PrivilegePair{
+				auth.PrivilegePair{Target: "", Priv: auth.PRIV_QUERY_EXTERNAL_ACCESS},
+				auth.PrivilegePair{Target: ":testbucket", Priv: auth.PRIV_QUERY_SELECT},
+			}}},
+		testCase{id: "Copy From",
+			text: "copy testbucket from '/tmp/x.json'",
+			expectedPrivs: &auth.Privileges{List: []auth.PrivilegePair{
+				auth.PrivilegePair{Target: "", Priv: auth.PRIV_QUERY_EXTERNAL_ACCESS},
+				auth.PrivilegePair{Target: ":testbucket", Priv: auth.PRIV_QUERY_INSERT},
+				auth.PrivilegePair{Target: ":testbucket", Priv: auth.PRIV_QUERY_UPDATE},
+			}}},
 	}
 
 	for _, testCase := range testCases {
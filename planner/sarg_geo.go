@@ -0,0 +1,73 @@
+//  Copyright (c) 2018 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package planner
+
+import (
+	"github.com/couchbase/query/datastore"
+	"github.com/couchbase/query/expression"
+	"github.com/couchbase/query/plan"
+	"github.com/couchbase/query/value"
+)
+
+/*
+An index on GEO_CELLS(point) serves GEO_WITHIN_RADIUS(point, center,
+radius) through one prefix range for each of the geohash cells that
+cover the circle. The spans are never exact.
+*/
+func (this *sarg) visitGeoWithinRadius(pred *expression.GeoWithinRadius) (interface{}, error) {
+	if SubsetOf(pred, this.key) {
+		return _SELF_SPANS, nil
+	}
+
+	cells, ok := this.key.(*expression.GeoCells)
+	if !ok || !pred.First().EquivalentTo(cells.Operands()[0]) || cells.Precision() < 0 {
+		return this.visitDefault(pred)
+	}
+
+	center := this.getSarg(pred.Second())
+	radius := this.getSarg(pred.Third())
+	if center == nil || radius == nil {
+		return _VALUED_SPANS, nil
+	}
+
+	precision := cells.Precision()
+	centerVal := center.Value()
+	radiusVal := radius.Value()
+	if centerVal != nil && radiusVal != nil {
+		return geoCellSpans(centerVal, radiusVal, precision), nil
+	}
+
+	spans := make([]*plan.Span2, expression.GEO_COVER_CELLS)
+	for i := range spans {
+		args := expression.Expressions{center, radius,
+			expression.NewConstant(precision), expression.NewConstant(i)}
+		range2 := plan.NewRange2(expression.NewGeoCellStart(args...),
+			expression.NewGeoCellStop(args...), datastore.LOW)
+		spans[i] = plan.NewSpan2(nil, plan.Ranges2{range2}, false)
+	}
+
+	return NewTermSpans(spans...), nil
+}
+
+func geoCellSpans(center, radius value.Value, precision int) SargSpans {
+	cells, ok := expression.GeoCoverCells(center, radius, precision)
+	if !ok {
+		return _EMPTY_SPANS
+	}
+
+	spans := make([]*plan.Span2, len(cells))
+	for i, cell := range cells {
+		range2 := plan.NewRange2(expression.NewConstant(cell),
+			expression.NewConstant(expression.GeoCellStopValue(cell)), datastore.LOW)
+		spans[i] = plan.NewSpan2(nil, plan.Ranges2{range2}, false)
+	}
+
+	return NewTermSpans(spans...)
+}
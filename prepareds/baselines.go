@@ -0,0 +1,233 @@
+//  Copyright (c) 2018 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package prepareds
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/couchbase/query/errors"
+	"github.com/couchbase/query/logging"
+	"github.com/couchbase/query/plan"
+	"github.com/couchbase/query/value"
+)
+
+// A plan baseline pins the plan of a prepared statement: whenever the
+// statement is prepared again, the pinned plan is used for as long as
+// it remains valid, and plans that differ from it are reported.
+// Baselines are kept by each node, and saved with its prepared
+// statements if they are persisted.
+type Baseline struct {
+	Name            string
+	Text            string
+	EncodedPlan     string
+	IndexApiVersion int
+	Created         time.Time
+
+	// times the pinned plan was used in place of a new one
+	Uses int32
+
+	// times a new plan differed from the pinned one, and the last time
+	Divergences    int32
+	LastDivergence time.Time
+
+	// times the pinned plan could not be used, and the last time
+	Invalidations    int32
+	LastInvalidation time.Time
+}
+
+// reason for repreparing when a baseline is set
+const _REASON_BASELINE = "plan baseline"
+
+type baselineCache struct {
+	sync.Mutex
+	baselines map[string]*Baseline
+}
+
+var baselines = &baselineCache{baselines: make(map[string]*Baseline)}
+
+// pin the plan currently used by a prepared statement
+
+func CaptureBaseline(name string) errors.Error {
+	prepared, err := GetPrepared(value.NewValue(name), 0, nil)
+	if err != nil {
+		return err
+	}
+	return SetBaseline(name, prepared.EncodedPlan())
+}
+
+// pin an encoded plan, and switch the prepared statement to it if possible
+
+func SetBaseline(name string, encoded_plan string) errors.Error {
+	pinned, err := decodeBaseline(encoded_plan)
+	if err != nil {
+		return err
+	}
+	if pinned.Name() != name {
+		return errors.NewEncodingNameMismatchError(name)
+	}
+
+	baselines.Lock()
+	baselines.baselines[name] = &Baseline{
+		Name:            name,
+		Text:            pinned.Text(),
+		EncodedPlan:     encoded_plan,
+		IndexApiVersion: pinned.IndexApiVersion(),
+		Created:         time.Now(),
+	}
+	baselines.Unlock()
+	markPersist()
+
+	var current *plan.Prepared
+	PreparedDo(name, func(ce *CacheEntry) {
+		current = ce.Prepared
+	})
+	if current != nil && current.Text() == pinned.Text() &&
+		current.MismatchingEncodedPlan(encoded_plan) && pinned.Verify() {
+		addReprepared(current, pinned, _REASON_BASELINE)
+	}
+	return nil
+}
+
+func DeleteBaseline(name string) errors.Error {
+	baselines.Lock()
+	defer baselines.Unlock()
+
+	_, ok := baselines.baselines[name]
+	if !ok {
+		return errors.NewNoSuchBaselineError(name)
+	}
+	delete(baselines.baselines, name)
+	markPersist()
+	return nil
+}
+
+func CountBaselines() int {
+	baselines.Lock()
+	defer baselines.Unlock()
+	return len(baselines.baselines)
+}
+
+func NameBaselines() []string {
+	baselines.Lock()
+	names := make([]string, 0, len(baselines.baselines))
+	for name := range baselines.baselines {
+		names = append(names, name)
+	}
+	baselines.Unlock()
+	sort.Strings(names)
+	return names
+}
+
+func BaselineDo(name string, f func(*Baseline)) {
+	baselines.Lock()
+	defer baselines.Unlock()
+
+	baseline, ok := baselines.baselines[name]
+	if ok {
+		f(baseline)
+	}
+}
+
+// the pinned plans, for saving with the prepared statements
+
+func snapshotBaselines() []*BaselineSnapshot {
+	baselines.Lock()
+	defer baselines.Unlock()
+
+	snapshots := make([]*BaselineSnapshot, 0, len(baselines.baselines))
+	for _, baseline := range baselines.baselines {
+		snapshots = append(snapshots, &BaselineSnapshot{
+			Name:            baseline.Name,
+			Text:            baseline.Text,
+			EncodedPlan:     baseline.EncodedPlan,
+			IndexApiVersion: baseline.IndexApiVersion,
+			Created:         baseline.Created,
+		})
+	}
+	return snapshots
+}
+
+// Restored baselines start with no statistics.
+// Baselines set in the meantime take precedence.
+func restoreBaseline(snapshot *BaselineSnapshot) {
+	baselines.Lock()
+	defer baselines.Unlock()
+
+	if _, ok := baselines.baselines[snapshot.Name]; ok {
+		return
+	}
+	baselines.baselines[snapshot.Name] = &Baseline{
+		Name:            snapshot.Name,
+		Text:            snapshot.Text,
+		EncodedPlan:     snapshot.EncodedPlan,
+		IndexApiVersion: snapshot.IndexApiVersion,
+		Created:         snapshot.Created,
+	}
+}
+
+// planner.PlanBaselines
+
+func (this *baselineCache) Baseline(prepared *plan.Prepared) *plan.Prepared {
+	this.Lock()
+	baseline, ok := this.baselines[prepared.Name()]
+	if !ok || baseline.Text != prepared.Text() ||
+		baseline.IndexApiVersion != prepared.IndexApiVersion() {
+		this.Unlock()
+		return nil
+	}
+	encoded_plan := baseline.EncodedPlan
+	this.Unlock()
+
+	// every user gets its own copy, as verifying sets metadata versions
+	pinned, err := decodeBaseline(encoded_plan)
+	if err == nil && pinned.Verify() {
+		this.Lock()
+		baseline.Uses++
+		this.Unlock()
+		pinned.SetType(prepared.Type())
+		pinned.SetFeatureControls(prepared.FeatureControls())
+		return pinned
+	}
+
+	this.Lock()
+	baseline.Invalidations++
+	baseline.LastInvalidation = time.Now()
+	this.Unlock()
+	logging.Warnp("Plan baseline no longer valid", logging.Pair{"name", prepared.Name()})
+	return nil
+}
+
+func (this *baselineCache) Diverged(pinned, prepared *plan.Prepared) {
+	this.Lock()
+	baseline, ok := this.baselines[prepared.Name()]
+	if ok {
+		baseline.Divergences++
+		baseline.LastDivergence = time.Now()
+	}
+	this.Unlock()
+	logging.Warnp("New plan differs from plan baseline", logging.Pair{"name", prepared.Name()})
+}
+
+// unlike DecodePrepared, never reprepare: that would consult the baseline again
+func decodeBaseline(encoded_plan string) (*plan.Prepared, errors.Error) {
+	prepared_bytes, err := decodePlan(encoded_plan)
+	if err != nil {
+		return nil, err
+	}
+	prepared := plan.NewPrepared(nil, nil)
+	er := prepared.UnmarshalJSON(prepared_bytes)
+	if er != nil {
+		return nil, errors.NewPreparedDecodingError(er)
+	}
+	prepared.SetEncodedPlan(encoded_plan)
+	return prepared, nil
+}
@@ -0,0 +1,76 @@
+//  Copyright (c) 2018 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package prepareds
+
+import (
+	"testing"
+
+	"github.com/couchbase/query/datastore"
+	"github.com/couchbase/query/plan"
+	"github.com/couchbase/query/planner"
+)
+
+func newTestPlan(name, text string, operator plan.Operator) *plan.Prepared {
+	prepared := plan.NewPrepared(operator, nil)
+	prepared.SetName(name)
+	prepared.SetText(text)
+	prepared.SetIndexApiVersion(datastore.INDEX_API_MAX)
+	bytes, _ := prepared.MarshalJSON()
+	prepared.BuildEncodedPlan(bytes)
+	return prepared
+}
+
+func TestPlanBaseline(t *testing.T) {
+	PreparedsInit(16)
+
+	pinned := newTestPlan("b", "PREPARE b AS SELECT 1", plan.NewSequence(plan.NewDiscard()))
+	if err := AddPrepared(pinned); err != nil {
+		t.Fatalf("Cannot add statement: %v", err)
+	}
+	if err := CaptureBaseline("b"); err != nil {
+		t.Fatalf("Cannot capture baseline: %v", err)
+	}
+
+	// a new plan for the statement gives way to the pinned one
+	fresh := newTestPlan("b", "PREPARE b AS SELECT 1", plan.NewSequence(plan.NewStream()))
+	used := planner.UsePlanBaseline(fresh)
+	if used == fresh || used.MismatchingEncodedPlan(pinned.EncodedPlan()) {
+		t.Errorf("Expected the pinned plan to be used")
+	}
+	BaselineDo("b", func(baseline *Baseline) {
+		if baseline.Uses != 1 || baseline.Divergences != 1 {
+			t.Errorf("Expected 1 use and 1 divergence, got %v and %v",
+				baseline.Uses, baseline.Divergences)
+		}
+	})
+
+	// other statements are unaffected
+	other := newTestPlan("b", "PREPARE b AS SELECT 2", plan.NewSequence(plan.NewStream()))
+	if planner.UsePlanBaseline(other) != other {
+		t.Errorf("Expected the baseline to be ignored for a different statement")
+	}
+
+	// pinning a different plan switches the cached statement to it
+	if err := SetBaseline("b", fresh.EncodedPlan()); err != nil {
+		t.Fatalf("Cannot set baseline: %v", err)
+	}
+	PreparedDo("b", func(ce *CacheEntry) {
+		if ce.Prepared.MismatchingEncodedPlan(fresh.EncodedPlan()) || ce.ReprepareCause != _REASON_BASELINE {
+			t.Errorf("Expected the statement to use the new baseline, reason %v", ce.ReprepareCause)
+		}
+	})
+
+	if err := DeleteBaseline("b"); err != nil {
+		t.Fatalf("Cannot delete baseline: %v", err)
+	}
+	if planner.UsePlanBaseline(fresh) != fresh || DeleteBaseline("b") == nil {
+		t.Errorf("Expected the baseline to be gone")
+	}
+}
@@ -0,0 +1,237 @@
+//  Copyright (c) 2018 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package prepareds
+
+import (
+	"io/ioutil"
+	"math"
+	"os"
+	"sync"
+	"time"
+
+	atomic "github.com/couchbase/go-couchbase/platform"
+	json "github.com/couchbase/go_json"
+	"github.com/couchbase/query/errors"
+	"github.com/couchbase/query/logging"
+	"github.com/couchbase/query/plan"
+	"github.com/couchbase/query/util"
+)
+
+// PreparedStore keeps the prepared statements of a node across
+// restarts. Only the statement and the settings it was prepared
+// with are kept: plans are rebuilt when statements are next used,
+// against the indexes that exist at that time. Plan baselines are
+// kept along with the statements, pinned plans included.
+type PreparedStore interface {
+	Load() ([]*PreparedSnapshot, []*BaselineSnapshot, errors.Error)                 // Prepared statements and baselines saved by the last Save
+	Save(snapshots []*PreparedSnapshot, baselines []*BaselineSnapshot) errors.Error // Replace the saved prepared statements and baselines
+}
+
+type PreparedSnapshot struct {
+	Name            string `json:"name"`
+	Text            string `json:"text"`
+	Type            string `json:"reqType,omitempty"`
+	IndexApiVersion int    `json:"indexApiVersion"`
+	FeatureControls uint64 `json:"featureControls"`
+}
+
+type BaselineSnapshot struct {
+	Name            string    `json:"name"`
+	Text            string    `json:"text"`
+	EncodedPlan     string    `json:"encoded_plan"`
+	IndexApiVersion int       `json:"indexApiVersion"`
+	Created         time.Time `json:"created"`
+}
+
+var persister struct {
+	sync.Mutex
+	store PreparedStore
+	dirty uint32
+}
+
+// restore saved statements and start saving changes every interval
+
+func PreparedsPersistInit(store PreparedStore, interval time.Duration) errors.Error {
+	persister.Lock()
+	persister.store = store
+	persister.Unlock()
+
+	snapshots, pinned, err := store.Load()
+	if err != nil {
+		return err
+	}
+
+	for _, baseline := range pinned {
+		restoreBaseline(baseline)
+	}
+	if len(pinned) > 0 {
+		logging.Infop("Restored plan baselines", logging.Pair{"count", len(pinned)})
+	}
+
+	for _, snapshot := range snapshots {
+		restorePrepared(snapshot)
+	}
+	if len(snapshots) > 0 {
+		logging.Infop("Restored prepared statements", logging.Pair{"count", len(snapshots)})
+	}
+
+	if interval > 0 {
+		go func() {
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+			for range ticker.C {
+				if atomic.LoadUint32(&persister.dirty) != 0 {
+					if err := PreparedsPersist(); err != nil {
+						logging.Errorp("Cannot save prepared statements", logging.Pair{"error", err})
+					}
+				}
+			}
+		}()
+	}
+	return nil
+}
+
+// save the cache now, eg on shutdown
+
+func PreparedsPersist() errors.Error {
+	persister.Lock()
+	defer persister.Unlock()
+
+	if persister.store == nil {
+		return nil
+	}
+
+	atomic.StoreUint32(&persister.dirty, 0)
+	snapshots := make([]*PreparedSnapshot, 0, CountPrepareds())
+	PreparedsForeach(func(name string, ce *CacheEntry) bool {
+		prepared := ce.Prepared
+		snapshots = append(snapshots, &PreparedSnapshot{
+			Name:            name,
+			Text:            prepared.Text(),
+			Type:            prepared.Type(),
+			IndexApiVersion: prepared.IndexApiVersion(),
+			FeatureControls: prepared.FeatureControls(),
+		})
+		return true
+	}, nil)
+
+	err := persister.store.Save(snapshots, snapshotBaselines())
+	if err != nil {
+		atomic.StoreUint32(&persister.dirty, 1)
+	}
+	return err
+}
+
+func markPersist() {
+	atomic.StoreUint32(&persister.dirty, 1)
+}
+
+// Restored entries have no plan until they are first used.
+// Statements prepared in the meantime take precedence.
+func restorePrepared(snapshot *PreparedSnapshot) {
+	prepared := plan.NewPrepared(nil, nil)
+	prepared.SetName(snapshot.Name)
+	prepared.SetText(snapshot.Text)
+	prepared.SetType(snapshot.Type)
+	prepared.SetIndexApiVersion(snapshot.IndexApiVersion)
+	prepared.SetFeatureControls(snapshot.FeatureControls)
+
+	ce := &CacheEntry{
+		Prepared:       prepared,
+		MinServiceTime: math.MaxUint64,
+		MinRequestTime: math.MaxUint64,
+		Restored:       true,
+	}
+	prepareds.cache.Add(ce, snapshot.Name, func(entry interface{}) util.Operation {
+		return util.IGNORE
+	})
+}
+
+// Build the plan of a restored entry on first use.
+func (this *CacheEntry) restore(phaseTime *time.Duration) (*plan.Prepared, errors.Error) {
+	this.Lock()
+	defer this.Unlock()
+
+	// check again, somebody might have done it in the interim
+	if this.Prepared.Operator != nil {
+		return this.Prepared, nil
+	}
+
+	prepared, err := reprepare(this.Prepared, phaseTime)
+	if err != nil {
+		return nil, err
+	}
+	this.Prepared = prepared
+	this.populated = false
+	atomic.AddInt32(&this.Generation, 1)
+	this.LastReprepare = time.Now()
+	this.ReprepareCause = _REASON_RESTORED
+	return prepared, nil
+}
+
+const _PREPAREDS_FILE_VERSION = 1
+
+type fileStore struct {
+	path string
+}
+
+// NewFileStore returns a PreparedStore that keeps prepared statements
+// in a JSON file.
+func NewFileStore(path string) PreparedStore {
+	return &fileStore{path: path}
+}
+
+// files without baselines are still version 1
+type preparedsFile struct {
+	Version   int                 `json:"version"`
+	Prepareds []*PreparedSnapshot `json:"prepareds"`
+	Baselines []*BaselineSnapshot `json:"baselines,omitempty"`
+}
+
+func (this *fileStore) Load() ([]*PreparedSnapshot, []*BaselineSnapshot, errors.Error) {
+	bytes, er := ioutil.ReadFile(this.path)
+	if os.IsNotExist(er) {
+		return nil, nil, nil
+	} else if er != nil {
+		return nil, nil, errors.NewPreparedPersistError(er, "cannot read "+this.path)
+	}
+
+	var file preparedsFile
+	er = json.Unmarshal(bytes, &file)
+	if er != nil {
+		return nil, nil, errors.NewPreparedPersistError(er, "cannot parse "+this.path)
+	}
+	if file.Version > _PREPAREDS_FILE_VERSION {
+		return nil, nil, errors.NewPreparedPersistError(nil, "unknown version of "+this.path)
+	}
+	return file.Prepareds, file.Baselines, nil
+}
+
+func (this *fileStore) Save(snapshots []*PreparedSnapshot, baselines []*BaselineSnapshot) errors.Error {
+	bytes, er := json.Marshal(&preparedsFile{
+		Version:   _PREPAREDS_FILE_VERSION,
+		Prepareds: snapshots,
+		Baselines: baselines,
+	})
+	if er != nil {
+		return errors.NewPreparedPersistError(er, "cannot write "+this.path)
+	}
+
+	// the file is replaced, not rewritten, so that it is never partial
+	er = ioutil.WriteFile(this.path+".tmp", bytes, 0600)
+	if er == nil {
+		er = os.Rename(this.path+".tmp", this.path)
+	}
+	if er != nil {
+		os.Remove(this.path + ".tmp")
+		return errors.NewPreparedPersistError(er, "cannot write "+this.path)
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+//  Copyright (c) 2018 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package prepareds
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/couchbase/query/plan"
+)
+
+func TestPersistRestore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "prepareds")
+	if err != nil {
+		t.Fatalf("Cannot create directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	store := NewFileStore(filepath.Join(dir, "prepareds.json"))
+	snapshots, _, perr := store.Load()
+	if perr != nil || len(snapshots) != 0 {
+		t.Fatalf("Expected no saved statements, got %v, %v", snapshots, perr)
+	}
+
+	perr = store.Save([]*PreparedSnapshot{
+		{Name: "p1", Text: "PREPARE p1 AS SELECT 1", IndexApiVersion: 3, FeatureControls: 4},
+		{Name: "p2", Text: "PREPARE p2 AS SELECT 2", IndexApiVersion: 2},
+	}, nil)
+	if perr != nil {
+		t.Fatalf("Cannot save statements: %v", perr)
+	}
+
+	PreparedsInit(16)
+	perr = PreparedsPersistInit(store, 0)
+	if perr != nil {
+		t.Fatalf("Cannot restore statements: %v", perr)
+	}
+
+	if CountPrepareds() != 2 {
+		t.Errorf("Expected 2 restored statements, got %v", CountPrepareds())
+	}
+
+	PreparedDo("p1", func(ce *CacheEntry) {
+		if !ce.Restored || ce.Prepared.Operator != nil {
+			t.Errorf("Expected p1 to be restored without a plan")
+		}
+		if ce.Prepared.Text() != "PREPARE p1 AS SELECT 1" || ce.Prepared.IndexApiVersion() != 3 ||
+			ce.Prepared.FeatureControls() != 4 {
+			t.Errorf("Unexpected restored statement %v", ce.Prepared.Text())
+		}
+	})
+
+	DeletePrepared("p2")
+	perr = PreparedsPersist()
+	if perr != nil {
+		t.Fatalf("Cannot save statements: %v", perr)
+	}
+
+	snapshots, _, perr = store.Load()
+	if perr != nil || len(snapshots) != 1 || snapshots[0].Name != "p1" {
+		t.Errorf("Expected p1 to be saved, got %v, %v", snapshots, perr)
+	}
+}
+
+func TestPersistBaselines(t *testing.T) {
+	dir, err := ioutil.TempDir("", "prepareds")
+	if err != nil {
+		t.Fatalf("Cannot create directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	store := NewFileStore(filepath.Join(dir, "prepareds.json"))
+	PreparedsInit(16)
+	perr := PreparedsPersistInit(store, 0)
+	if perr != nil {
+		t.Fatalf("Cannot restore statements: %v", perr)
+	}
+
+	pinned := newTestPlan("pb", "PREPARE pb AS SELECT 1", plan.NewSequence(plan.NewDiscard()))
+	if err := AddPrepared(pinned); err != nil {
+		t.Fatalf("Cannot add statement: %v", err)
+	}
+	if err := CaptureBaseline("pb"); err != nil {
+		t.Fatalf("Cannot capture baseline: %v", err)
+	}
+	perr = PreparedsPersist()
+	if perr != nil {
+		t.Fatalf("Cannot save statements: %v", perr)
+	}
+
+	// a restart loses the cache, but not the baseline
+	DeleteBaseline("pb")
+	PreparedsInit(16)
+	perr = PreparedsPersistInit(store, 0)
+	if perr != nil {
+		t.Fatalf("Cannot restore statements: %v", perr)
+	}
+
+	restored := false
+	BaselineDo("pb", func(baseline *Baseline) {
+		restored = baseline.Text == pinned.Text() && baseline.EncodedPlan == pinned.EncodedPlan()
+	})
+	if !restored {
+		t.Errorf("Expected the baseline to be restored")
+	}
+
+	DeleteBaseline("pb")
+	perr = PreparedsPersist()
+	if perr != nil {
+		t.Fatalf("Cannot save statements: %v", perr)
+	}
+	_, baselines, perr := store.Load()
+	if perr != nil || len(baselines) != 0 {
+		t.Errorf("Expected no saved baselines, got %v, %v", baselines, perr)
+	}
+}
@@ -57,6 +57,14 @@ type CacheEntry struct {
 	// FIXME add moving averages, latency
 	// This requires the use of metrics
 
+	// restored from a PreparedStore, plan built on first use
+	Restored bool
+
+	// plans built for the statement, and why the last was built
+	Generation     int32
+	LastReprepare  time.Time
+	ReprepareCause string
+
 	sync.Mutex // for concurrent checking
 	populated  bool
 }
@@ -70,6 +78,7 @@ var namespace string
 
 func PreparedsInit(limit int) {
 	prepareds.cache = util.NewGenCache(limit)
+	planner.SetPlanBaselines(baselines)
 }
 
 func PreparedsReprepareInit(ds, sy datastore.Datastore, ns string) {
@@ -123,6 +132,7 @@ func (this *preparedCache) add(prepared *plan.Prepared, populated bool, process
 		Prepared:       prepared,
 		MinServiceTime: math.MaxUint64,
 		MinRequestTime: math.MaxUint64,
+		Generation:     1,
 		populated:      populated,
 	}
 	prepareds.cache.Add(ce, prepared.Name(), func(entry interface{}) util.Operation {
@@ -135,6 +145,9 @@ func (this *preparedCache) add(prepared *plan.Prepared, populated bool, process
 			cont = process(oldEntry)
 		}
 		if cont {
+			if oldEntry.Prepared != prepared {
+				atomic.AddInt32(&oldEntry.Generation, 1)
+			}
 			oldEntry.Prepared = prepared
 			oldEntry.populated = false
 		} else {
@@ -185,13 +198,54 @@ func AddPrepared(prepared *plan.Prepared) errors.Error {
 		return errors.NewPreparedNameError(
 			fmt.Sprintf("duplicate name: %s", prepared.Name()))
 	} else {
+		markPersist()
 		distributePrepared(prepared.Name(), prepared.EncodedPlan())
 		return nil
 	}
 }
 
+// reasons for repreparing other than metadata changes
+const (
+	_REASON_INVALID  = "plan no longer valid"
+	_REASON_RESTORED = "restored"
+)
+
+// Replace a stale plan, unless another request has already done so.
+func addReprepared(stale, prepared *plan.Prepared, reason string) {
+	added := true
+	when := time.Now()
+
+	// the entry may have gone in the interim
+	ce := &CacheEntry{
+		Prepared:       prepared,
+		MinServiceTime: math.MaxUint64,
+		MinRequestTime: math.MaxUint64,
+		Generation:     1,
+		LastReprepare:  when,
+		ReprepareCause: reason,
+	}
+	prepareds.cache.Add(ce, prepared.Name(), func(entry interface{}) util.Operation {
+		oldEntry := entry.(*CacheEntry)
+		if oldEntry.Prepared != stale {
+			added = false
+			return util.IGNORE
+		}
+		oldEntry.Prepared = prepared
+		oldEntry.populated = false
+		atomic.AddInt32(&oldEntry.Generation, 1)
+		oldEntry.LastReprepare = when
+		oldEntry.ReprepareCause = reason
+		return util.AMEND
+	})
+	if added {
+		markPersist()
+		distributePrepared(prepared.Name(), prepared.EncodedPlan())
+	}
+}
+
 func DeletePrepared(name string) errors.Error {
 	if prepareds.cache.Delete(name, nil) {
+		markPersist()
 		return nil
 	}
 	return errors.NewNoSuchPreparedError(name)
@@ -211,34 +265,36 @@ func GetPrepared(prepared_stmt value.Value, options uint32, phaseTime *time.Dura
 		ce := prepareds.get(value.NewValue(name), track)
 		if ce != nil {
 			prepared = ce.Prepared
+			if prepared.Operator == nil {
+				prepared, err = ce.restore(phaseTime)
+				if err != nil {
+					return nil, err
+				}
+			}
 		}
 		if prepared == nil && remote && host != "" && host != distributed.RemoteAccess().WhoAmI() {
 			distributed.RemoteAccess().GetRemoteDoc(host, name, "prepareds", "GET",
 				func(doc map[string]interface{}) {
 					encoded_plan, ok := doc["encoded_plan"].(string)
-					if ok {
+					if ok && encoded_plan != "" {
 						prepared, err = DecodePrepared(name, encoded_plan, false, false, phaseTime)
 					}
 				},
 				func(warn errors.Error) {
 				}, distributed.NO_CREDS, "")
 		} else if prepared != nil && verify {
-			var good bool
+			var reason string
 
 			// things have already been set up
 			// take the short way home
 			if ce.populated {
 
-				// note that it's fine to check and repopulate without a lock
+				// note that it's fine to check without a lock
 				// since the structure of the plan tree won't change, nor the
-				// keyspaces and indexers, the worse that is going to happen is
-				// two requests amending the same counter
-				good = prepared.MetadataCheck()
-
-				// counters have changed. fetch new values
-				if !good {
-					good = prepared.Verify()
-				}
+				// keyspaces and indexers
+				// if the counters have changed, the plan is stale: even if
+				// it is still valid, a better index may have been created
+				reason = prepared.MetadataChange()
 			} else {
 
 				// we have to proceed under a lock to avoid multiple
@@ -246,14 +302,13 @@ func GetPrepared(prepared_stmt value.Value, options uint32, phaseTime *time.Dura
 				ce.Lock()
 
 				// check again, somebody might have done it in the interim
-				if ce.populated {
-					good = true
-				} else {
+				if !ce.populated {
 
 					// nada - have to go the long way
-					good = prepared.Verify()
-					if good {
+					if prepared.Verify() {
 						ce.populated = true
+					} else {
+						reason = _REASON_INVALID
 					}
 				}
 				ce.Unlock()
@@ -263,12 +318,13 @@ func GetPrepared(prepared_stmt value.Value, options uint32, phaseTime *time.Dura
 			// here we are going to accept multiple requests creating a new
 			// plan concurrently as we don't have a good way to serialize
 			// without blocking the whole prepared cacheline
-			// locking will occur at adding time: both requests will insert,
-			// the last wins
-			if !good {
-				prepared, err = reprepare(prepared, phaseTime)
+			// locking will occur at adding time: only the first request
+			// to insert replaces the stale plan
+			if reason != "" {
+				stale := prepared
+				prepared, err = reprepare(stale, phaseTime)
 				if err == nil {
-					err = AddPrepared(prepared)
+					addReprepared(stale, prepared, reason)
 				}
 			}
 		}
@@ -283,6 +339,9 @@ func GetPrepared(prepared_stmt value.Value, options uint32, phaseTime *time.Dura
 		name_value, has_name := prepared_stmt.Field("name")
 		if has_name {
 			if ce := prepareds.get(name_value, track); ce != nil {
+				if ce.Prepared.Operator == nil {
+					return ce.restore(phaseTime)
+				}
 				return ce.Prepared, nil
 			}
 		}
@@ -325,19 +384,9 @@ func RecordPreparedMetrics(prepared *plan.Prepared, requestTime, serviceTime tim
 func DecodePrepared(prepared_name string, prepared_stmt string, track bool, distribute bool, phaseTime *time.Duration) (*plan.Prepared, errors.Error) {
 	added := true
 
-	decoded, err := base64.StdEncoding.DecodeString(prepared_stmt)
-	if err != nil {
-		return nil, errors.NewPreparedDecodingError(err)
-	}
-	var buf bytes.Buffer
-	buf.Write(decoded)
-	reader, err := gzip.NewReader(&buf)
+	prepared_bytes, err := decodePlan(prepared_stmt)
 	if err != nil {
-		return nil, errors.NewPreparedDecodingError(err)
-	}
-	prepared_bytes, err := ioutil.ReadAll(reader)
-	if err != nil {
-		return nil, errors.NewPreparedDecodingError(err)
+		return nil, err
 	}
 	prepared, err := unmarshalPrepared(prepared_bytes, phaseTime)
 	if err != nil {
@@ -398,6 +447,7 @@ func DecodePrepared(prepared_name string, prepared_stmt string, track bool, dist
 		})
 
 	if added {
+		markPersist()
 		if distribute {
 			distributePrepared(prepared.Name(), prepared_stmt)
 		}
@@ -407,6 +457,24 @@ func DecodePrepared(prepared_name string, prepared_stmt string, track bool, dist
 	}
 }
 
+func decodePlan(encoded_plan string) ([]byte, errors.Error) {
+	decoded, err := base64.StdEncoding.DecodeString(encoded_plan)
+	if err != nil {
+		return nil, errors.NewPreparedDecodingError(err)
+	}
+	var buf bytes.Buffer
+	buf.Write(decoded)
+	reader, err := gzip.NewReader(&buf)
+	if err != nil {
+		return nil, errors.NewPreparedDecodingError(err)
+	}
+	prepared_bytes, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return nil, errors.NewPreparedDecodingError(err)
+	}
+	return prepared_bytes, nil
+}
+
 func unmarshalPrepared(bytes []byte, phaseTime *time.Duration) (*plan.Prepared, errors.Error) {
 	prepared := plan.NewPrepared(nil, nil)
 	err := prepared.UnmarshalJSON(bytes)
@@ -464,6 +532,7 @@ func reprepare(prepared *plan.Prepared, phaseTime *time.Duration) (*plan.Prepare
 	pl.SetType(prepared.Type())
 	pl.SetIndexApiVersion(prepared.IndexApiVersion())
 	pl.SetFeatureControls(prepared.FeatureControls())
+	pl = planner.UsePlanBaseline(pl)
 
 	json_bytes, err := pl.MarshalJSON()
 	if err != nil {
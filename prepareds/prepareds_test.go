@@ -0,0 +1,52 @@
+//  Copyright (c) 2018 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package prepareds
+
+import (
+	"testing"
+
+	"github.com/couchbase/query/plan"
+)
+
+func newTestPrepared(name, text string) *plan.Prepared {
+	prepared := plan.NewPrepared(nil, nil)
+	prepared.SetName(name)
+	prepared.SetText(text)
+	return prepared
+}
+
+func TestReprepareGeneration(t *testing.T) {
+	PreparedsInit(16)
+
+	first := newTestPrepared("p", "PREPARE p AS SELECT 1")
+	if err := AddPrepared(first); err != nil {
+		t.Fatalf("Cannot add statement: %v", err)
+	}
+
+	second := newTestPrepared("p", "PREPARE p AS SELECT 1")
+	addReprepared(first, second, "index metadata changed")
+
+	// a concurrent reprepare of the same stale plan is ignored
+	addReprepared(first, newTestPrepared("p", "PREPARE p AS SELECT 1"), "keyspace metadata changed")
+
+	PreparedDo("p", func(ce *CacheEntry) {
+		if ce.Prepared != second {
+			t.Errorf("Expected the first reprepared plan to be kept")
+		}
+		if ce.Generation != 2 || ce.ReprepareCause != "index metadata changed" {
+			t.Errorf("Expected generation 2 for index metadata changed, got %v for %v",
+				ce.Generation, ce.ReprepareCause)
+		}
+	})
+
+	if err := AddPrepared(newTestPrepared("p", "PREPARE p AS SELECT 2")); err == nil {
+		t.Errorf("Expected an error for a different statement with the same name")
+	}
+}
@@ -17,6 +17,7 @@ import (
 	"runtime"
 	"runtime/debug"
 	"runtime/pprof"
+	"strings"
 	"syscall"
 	"time"
 
@@ -30,15 +31,18 @@ import (
 	datastore_package "github.com/couchbase/query/datastore"
 	"github.com/couchbase/query/datastore/resolver"
 	"github.com/couchbase/query/datastore/system"
+	"github.com/couchbase/query/errors"
 	"github.com/couchbase/query/logging"
 	log_resolver "github.com/couchbase/query/logging/resolver"
 	"github.com/couchbase/query/prepareds"
 	"github.com/couchbase/query/server"
 	"github.com/couchbase/query/server/http"
+	"github.com/couchbase/query/server/settings"
 	"github.com/couchbase/query/util"
 )
 
-var DATASTORE = flag.String("datastore", "", "Datastore address (http://URL or dir:PATH or mock:)")
+var CONFIG = flag.String("config", "", "JSON file (.json) to read flags and settings from, and to save settings changed at runtime to")
+var DATASTORE = flag.String("datastore", "", "Datastore address (http://URL or dir:PATH or kv:PATH or ext:PATH or sqlite:PATH or postgres://URL or mock:, or NAME=ADDRESS,... to federate several)")
 var CONFIGSTORE = flag.String("configstore", "stub:", "Configuration store address (http://URL or stub:)")
 var ACCTSTORE = flag.String("acctstore", "gometrics:", "Accounting store address (http://URL or stub:)")
 var NAMESPACE = flag.String("namespace", "default", "Default namespace")
@@ -76,6 +80,7 @@ var PIPELINE_BATCH = flag.Int("pipeline-batch", 16, "Number of items execution o
 var ENTERPRISE = flag.Bool("enterprise", true, "Enterprise mode")
 var MAX_INDEX_API = flag.Int("max-index-api", datastore_package.INDEX_API_MAX, "Max Index API")
 var N1QL_FEAT_CTRL = flag.Uint64("n1ql-feat-ctrl", util.DEF_N1QL_FEAT_CTRL, "N1QL Feature Controls")
+var HEALTH_MEMORY_LIMIT = flag.Uint64("health-memory-limit", 0, "Memory in bytes above which the server reports it is not ready; use zero to disable")
 
 //cpu and memory profiling flags
 var CPU_PROFILE = flag.String("cpuprofile", "", "write cpu profile to file")
@@ -83,9 +88,19 @@ var MEM_PROFILE = flag.String("memprofile", "", "write memory profile to this fi
 
 // Monitoring API
 var COMPLETED_THRESHOLD = flag.Int("completed-threshold", 1000, "cache completed query lasting longer than this many milliseconds")
+var COMPLETED_SLOW_THRESHOLD = flag.Int("completed-slow-threshold", 0, "cache completed query lasting longer than this many milliseconds with its plan and timings; use zero or negative value to disable")
 var COMPLETED_LIMIT = flag.Int("completed-limit", 4000, "maximum number of completed requests")
+var COMPLETED_ARCHIVE = flag.String("completed-archive", "", "Directory to archive completed requests in; empty to disable")
+var COMPLETED_ARCHIVE_SIZE = flag.Int64("completed-archive-size", 64*1024*1024, "Size at which completed requests archive files are rotated")
+var COMPLETED_ARCHIVE_AGE = flag.Duration("completed-archive-age", 24*time.Hour, "Age at which completed requests archive files are rotated")
+var COMPLETED_ARCHIVE_FILES = flag.Int("completed-archive-files", 7, "Number of rotated completed requests archive files to keep; use zero or negative value to keep all")
 
 var PREPARED_LIMIT = flag.Int("prepared-limit", 16384, "maximum number of prepared statements")
+var PREPARED_STORE = flag.String("prepared-store", "", "File to keep prepared statements and plan baselines in across restarts; empty to disable")
+var PREPARED_SAVE_INTERVAL = flag.Duration("prepared-save-interval", 10*time.Second, "How often changed prepared statements are saved to the prepared-store")
+
+// COPY statements
+var COPY_DIRS = flag.String("copy-dirs", "", "Comma separated list of directories COPY statements may read and write")
 
 // GOGC
 var _GOGC_PERCENT = 200
@@ -103,6 +118,18 @@ func main() {
 	defer HideConsole(false)
 	flag.Parse()
 
+	// the configuration file fills in the flags not given on the command line
+	var fileSettings map[string]interface{}
+	if *CONFIG != "" {
+		var cfgErr errors.Error
+
+		fileSettings, cfgErr = settings.LoadConfigFile(*CONFIG, flag.CommandLine)
+		if cfgErr != nil {
+			fmt.Printf("Invalid configuration file: %v\n", cfgErr)
+			os.Exit(1)
+		}
+	}
+
 	// Set Ipv6 or Ipv4
 	server.SetIP(*IPv6)
 
@@ -200,6 +227,14 @@ func main() {
 
 	// Start the completed requests log
 	server.RequestsInit(*COMPLETED_THRESHOLD, *COMPLETED_LIMIT)
+	server.RequestsSetSlowThreshold(*COMPLETED_SLOW_THRESHOLD)
+	if *COMPLETED_ARCHIVE != "" {
+		err := server.RequestsArchiveInit(*COMPLETED_ARCHIVE, *COMPLETED_ARCHIVE_SIZE,
+			*COMPLETED_ARCHIVE_AGE, *COMPLETED_ARCHIVE_FILES)
+		if err != nil {
+			logging.Errorp("Cannot archive completed requests", logging.Pair{"error", err})
+		}
+	}
 
 	// Initialized the prepared statement cache
 	if *PREPARED_LIMIT <= 0 {
@@ -230,6 +265,12 @@ func main() {
 
 	datastore_package.SetSystemstore(server.Systemstore())
 	prepareds.PreparedsReprepareInit(datastore, sys, *NAMESPACE)
+	if *PREPARED_STORE != "" {
+		err := prepareds.PreparedsPersistInit(prepareds.NewFileStore(*PREPARED_STORE), *PREPARED_SAVE_INTERVAL)
+		if err != nil {
+			logging.Errorp("Cannot restore prepared statements", logging.Pair{"error", err})
+		}
+	}
 
 	server.SetCpuProfile(*CPU_PROFILE)
 	server.SetKeepAlive(*KEEP_ALIVE_LENGTH)
@@ -240,13 +281,34 @@ func main() {
 	server.SetRequestSizeCap(*REQUEST_SIZE_CAP)
 	server.SetScanCap(*SCAN_CAP)
 	server.SetMaxIndexAPI(*MAX_INDEX_API)
+	if *COPY_DIRS != "" {
+		dirs := []interface{}{}
+		for _, dir := range strings.Split(*COPY_DIRS, ",") {
+			dirs = append(dirs, dir)
+		}
+		server.SetCopyWhitelist(map[string]interface{}{"all_access": false, "allowed_paths": dirs})
+	}
 	if *ENTERPRISE {
 		util.SetN1qlFeatureControl(*N1QL_FEAT_CTRL)
 	} else {
 		util.SetN1qlFeatureControl(*N1QL_FEAT_CTRL | util.CE_N1QL_FEAT_CTRL)
 	}
 
-	audit.StartAuditService(*DATASTORE, *SERVICERS+*PLUS_SERVICERS)
+	// settings that have no flag can only be applied now
+	if len(fileSettings) > 0 {
+		err := applySettings(fileSettings, server)
+		if err != nil {
+			logging.Errorp("Invalid settings in configuration file", logging.Pair{"error", err})
+			os.Exit(1)
+		}
+	}
+
+	err = server.RegisterHealthChecks(*HEALTH_MEMORY_LIMIT)
+	if err != nil {
+		logging.Errorp("Cannot register health checks", logging.Pair{"error", err})
+	}
+
+	audit.StartAuditService(resolver.AuditAddress(*DATASTORE), *SERVICERS+*PLUS_SERVICERS)
 
 	go server.Serve()
 	go server.PlusServe()
@@ -288,6 +350,11 @@ func main() {
 	signalCatcher(server, endpoint)
 }
 
+// the server variable in main hides the package
+func applySettings(fileSettings map[string]interface{}, srvr *server.Server) errors.Error {
+	return server.ProcessSettings(fileSettings, srvr)
+}
+
 // signalCatcher blocks until a signal is received and then takes appropriate action
 func signalCatcher(server *server.Server, endpoint *http.HttpEndpoint) {
 	sig_chan := make(chan os.Signal, 4)
@@ -312,6 +379,9 @@ func signalCatcher(server *server.Server, endpoint *http.HttpEndpoint) {
 			f.Close()
 		}
 	}
+	if err := prepareds.PreparedsPersist(); err != nil {
+		logging.Errorp("Cannot save prepared statements", logging.Pair{"error", err})
+	}
 	if s == os.Interrupt {
 		// Interrupt (ctrl-C) => Immediate (ungraceful) exit
 		logging.Infop("Shutting down immediately")
@@ -19,7 +19,11 @@
 package server
 
 import (
+	"math"
+	"math/rand"
 	"net/http"
+	"regexp"
+	"strings"
 	"sync"
 	"time"
 
@@ -62,12 +66,25 @@ type qualifier interface {
 	unique() bool
 	condition() interface{}
 	isCondition(c interface{}) bool
-	evaluate(request *BaseRequest) bool
+	evaluate(request *BaseRequest, outcome *requestOutcome) bool
+}
+
+// what is known of a request only once it has completed
+type requestOutcome struct {
+	resultCount int
+	resultSize  int
+	errorCount  int
+	req         *http.Request
 }
 
 type RequestLog struct {
 	sync.RWMutex
 	qualifiers []qualifier
+	all        bool
+
+	// requests running longer than this are always logged, with
+	// their plan and timings, whatever the qualifiers and profile
+	slowThreshold time.Duration
 
 	cache *util.GenCache
 }
@@ -100,65 +117,190 @@ func RequestsSetLimit(limit int) {
 	requestLog.cache.SetLimit(limit)
 }
 
-func RequestsAddQualifier(name string, condition interface{}) errors.Error {
-	var q qualifier
-	var err errors.Error
+func RequestsSlowThreshold() int {
+	requestLog.RLock()
+	defer requestLog.RUnlock()
+	return int(requestLog.slowThreshold / time.Millisecond)
+}
 
+// zero or negative disables the slow query log
+func RequestsSetSlowThreshold(threshold int) {
+	requestLog.Lock()
+	requestLog.slowThreshold = time.Duration(threshold) * time.Millisecond
+	requestLog.Unlock()
+}
+
+func RequestsAddQualifier(name string, condition interface{}) errors.Error {
 	requestLog.Lock()
 	defer requestLog.Unlock()
-	for _, q := range requestLog.qualifiers {
-		if q.name() == name && q.unique() {
-			return errors.NewCompletedQualifierExists(name)
-		}
+	qualifiers, err := addQualifier(requestLog.qualifiers, name, condition)
+	if err == nil {
+		requestLog.qualifiers = qualifiers
 	}
-	switch name {
-	case "threshold":
-		q, err = newTimeThreshold(condition)
-	default:
-		return errors.NewCompletedQualifierUnknown(name)
+	return err
+}
+
+func RequestsUpdateQualifier(name string, condition interface{}) errors.Error {
+	requestLog.Lock()
+	defer requestLog.Unlock()
+	qualifiers, err := updateQualifier(requestLog.qualifiers, name, condition)
+	if err == nil {
+		requestLog.qualifiers = qualifiers
 	}
-	if q != nil {
-		requestLog.qualifiers = append(requestLog.qualifiers, q)
+	return err
+}
+
+func RequestsRemoveQualifier(name string, condition interface{}) errors.Error {
+	requestLog.Lock()
+	defer requestLog.Unlock()
+	qualifiers, err := removeQualifier(requestLog.qualifiers, name, condition)
+	if err == nil {
+		requestLog.qualifiers = qualifiers
 	}
 	return err
 }
 
-func RequestsUpdateQualifier(name string, condition interface{}) errors.Error {
-	var q qualifier
-	var err errors.Error
+// qualifiers are satisfied by default if any of them is, but can be
+// required to all be satisfied
+func RequestsSetCombine(all bool) {
+	requestLog.Lock()
+	requestLog.all = all
+	requestLog.Unlock()
+}
 
+func RequestsCombine() bool {
+	requestLog.RLock()
+	defer requestLog.RUnlock()
+	return requestLog.all
+}
+
+// process the qualifier changes sent through the admin settings:
+// "-name" removes, "+name" adds and plain "name" replaces the qualifier,
+// non unique qualifiers accepting a list of conditions, while "combine"
+// sets whether "and" or "or" applies to the qualifiers and "replace"
+// drops all the existing qualifiers first.
+// Either all the changes apply, or none does.
+func RequestsProcessQualifiers(settings map[string]interface{}) errors.Error {
 	requestLog.Lock()
 	defer requestLog.Unlock()
-	for i, q := range requestLog.qualifiers {
-		if q.name() == name {
-			if !q.unique() {
-				return errors.NewCompletedQualifierNotUnique(name)
+
+	qualifiers, all, err := processQualifiers(settings)
+	if err != nil {
+		return err
+	}
+	requestLog.qualifiers = qualifiers
+	requestLog.all = all
+	return nil
+}
+
+// check that the changes apply to the current qualifiers, without applying them
+func RequestsCheckQualifiers(settings map[string]interface{}) errors.Error {
+	requestLog.RLock()
+	defer requestLog.RUnlock()
+
+	_, _, err := processQualifiers(settings)
+	return err
+}
+
+// the qualifiers and combine that the changes result in, with the log locked
+func processQualifiers(settings map[string]interface{}) ([]qualifier, bool, errors.Error) {
+	var qualifiers []qualifier
+	if settings["replace"] != true {
+		qualifiers = make([]qualifier, len(requestLog.qualifiers))
+		copy(qualifiers, requestLog.qualifiers)
+	}
+	all := requestLog.all
+
+	// removals first, so that qualifiers can be replaced in one go
+	for _, op := range []byte{'-', 0, '+'} {
+		for key, c := range settings {
+			if key == "combine" || key == "replace" {
+				continue
+			}
+			name := key
+			if key[0] == '-' || key[0] == '+' {
+				if key[0] != op {
+					continue
+				}
+				name = key[1:]
+			} else if op != 0 {
+				continue
+			}
+
+			conditions, isList := c.([]interface{})
+			if !isList {
+				conditions = []interface{}{c}
+			}
+			var err errors.Error
+			switch op {
+			case '-':
+				for _, c := range conditions {
+					qualifiers, err = removeQualifier(qualifiers, name, c)
+					if err != nil {
+						break
+					}
+				}
+			case '+':
+				for _, c := range conditions {
+					qualifiers, err = addQualifier(qualifiers, name, c)
+					if err != nil {
+						break
+					}
+				}
+			default:
+				if isList {
+					qualifiers = removeQualifiers(qualifiers, name)
+					for _, c := range conditions {
+						qualifiers, err = addQualifier(qualifiers, name, c)
+						if err != nil {
+							break
+						}
+					}
+				} else {
+					qualifiers, err = updateQualifier(qualifiers, name, c)
+				}
+			}
+			if err != nil {
+				return nil, false, err
 			}
-			requestLog.qualifiers = append(requestLog.qualifiers[:i], requestLog.qualifiers[i+1:]...)
 		}
 	}
-	switch name {
-	case "threshold":
-		q, err = newTimeThreshold(condition)
-	default:
-		return errors.NewCompletedQualifierUnknown(name)
-	}
-	if q != nil {
-		requestLog.qualifiers = append(requestLog.qualifiers, q)
+
+	c, ok := settings["combine"]
+	if ok {
+		combine, _ := c.(string)
+		switch combine {
+		case "and":
+			all = true
+		case "or":
+			all = false
+		default:
+			return nil, false, errors.NewCompletedQualifierInvalidArgument("combine", c)
+		}
 	}
-	return err
+	return qualifiers, all, nil
 }
 
-func RequestsRemoveQualifier(name string, condition interface{}) errors.Error {
-	requestLog.Lock()
-	defer requestLog.Unlock()
-	for i, q := range requestLog.qualifiers {
-		if q.name() == name && (q.unique() || q.isCondition(condition)) {
-			requestLog.qualifiers = append(requestLog.qualifiers[:i], requestLog.qualifiers[i+1:]...)
-			return nil
+// the current qualifiers in the same format RequestsProcessQualifiers takes
+func RequestsQualifiersSettings() map[string]interface{} {
+	requestLog.RLock()
+	defer requestLog.RUnlock()
+
+	settings := make(map[string]interface{}, len(requestLog.qualifiers)+1)
+	for _, q := range requestLog.qualifiers {
+		if q.unique() {
+			settings[q.name()] = q.condition()
+		} else {
+			conditions, _ := settings[q.name()].([]interface{})
+			settings[q.name()] = append(conditions, q.condition())
 		}
 	}
-	return errors.NewCompletedQualifierNotFound(name, condition)
+	if requestLog.all {
+		settings["combine"] = "and"
+	} else {
+		settings["combine"] = "or"
+	}
+	return settings
 }
 
 func RequestsGetQualifier(name string) (interface{}, errors.Error) {
@@ -244,17 +386,27 @@ func LogRequest(request_time time.Duration, service_time time.Duration,
 	requestLog.RLock()
 	defer requestLog.RUnlock()
 
-	// apply all the qualifiers until one is satisfied
+	// apply all the qualifiers until one is satisfied, or, if they
+	// all have to be, until one is not
+	outcome := &requestOutcome{
+		resultCount: result_count,
+		resultSize:  result_size,
+		errorCount:  error_count,
+		req:         req,
+	}
 	doLog := false
 	for _, q := range requestLog.qualifiers {
-		doLog = q.evaluate(request)
-		if doLog {
+		doLog = q.evaluate(request, outcome)
+		if doLog != requestLog.all {
 			break
 		}
 	}
 
+	// slow requests are always logged
+	slow := requestLog.slowThreshold > 0 && service_time >= requestLog.slowThreshold
+
 	// request does not qualify
-	if !doLog {
+	if !doLog && !slow {
 		return
 	}
 
@@ -292,10 +444,17 @@ func LogRequest(request_time time.Duration, service_time time.Duration,
 	// once timings get stored in completed_requests, it's this
 	// module that's responsible for cleaning after them, hence
 	// we nillify request.timings to signal that
+	// operator timings are gathered anyway, so for slow requests we
+	// just hold on to them as if timings had been requested, together
+	// with the plan as EXPLAIN would have produced it
 	prof := request.Profile()
 	if prof == ProfUnset {
 		prof = server.Profile()
 	}
+	if slow {
+		prof = ProfOn
+		re.Plan = request.Plan()
+	}
 	if prof != ProfOff {
 		re.PhaseTimes = request.FmtPhaseTimes()
 	}
@@ -323,9 +482,93 @@ func LogRequest(request_time time.Duration, service_time time.Duration,
 		re.UserAgent = userAgent
 	}
 
+	if archive != nil {
+		archive.add(re, request, plan)
+	}
 	requestLog.cache.Add(re, id, nil)
 }
 
+// qualifier list handling, with the log locked: the list is changed
+// in place and returned, unless there is an error
+
+func addQualifier(qualifiers []qualifier, name string, condition interface{}) ([]qualifier, errors.Error) {
+	for _, q := range qualifiers {
+		if q.name() == name && (q.unique() || q.isCondition(condition)) {
+			return nil, errors.NewCompletedQualifierExists(name)
+		}
+	}
+	q, err := newQualifier(name, condition)
+	if err != nil {
+		return nil, err
+	}
+	return append(qualifiers, q), nil
+}
+
+func updateQualifier(qualifiers []qualifier, name string, condition interface{}) ([]qualifier, errors.Error) {
+	for _, q := range qualifiers {
+		if q.name() == name && !q.unique() {
+			return nil, errors.NewCompletedQualifierNotUnique(name)
+		}
+	}
+	q, err := newQualifier(name, condition)
+	if err != nil {
+		return nil, err
+	}
+	return append(removeQualifiers(qualifiers, name), q), nil
+}
+
+func removeQualifier(qualifiers []qualifier, name string, condition interface{}) ([]qualifier, errors.Error) {
+	for i, q := range qualifiers {
+		if q.name() == name && (q.unique() || q.isCondition(condition)) {
+			return append(qualifiers[:i], qualifiers[i+1:]...), nil
+		}
+	}
+	return nil, errors.NewCompletedQualifierNotFound(name, condition)
+}
+
+func removeQualifiers(qualifiers []qualifier, name string) []qualifier {
+	rv := qualifiers[:0]
+	for _, q := range qualifiers {
+		if q.name() != name {
+			rv = append(rv, q)
+		}
+	}
+	return rv
+}
+
+func newQualifier(name string, condition interface{}) (qualifier, errors.Error) {
+	switch name {
+	case "threshold":
+		return newTimeThreshold(condition)
+	case "error":
+		return newErrorQualifier(condition)
+	case "user":
+		return newUserQualifier(condition)
+	case "statement":
+		return newStatementQualifier(condition)
+	case "resultCount":
+		return newResultCountThreshold(condition)
+	case "resultSize":
+		return newResultSizeThreshold(condition)
+	case "sample":
+		return newSampleQualifier(condition)
+	}
+	return nil, errors.NewCompletedQualifierUnknown(name)
+}
+
+// conditions come as ints when set internally and as floats from JSON
+func intCondition(c interface{}) (int, bool) {
+	switch c := c.(type) {
+	case int:
+		return c, true
+	case float64:
+		if c == math.Trunc(c) {
+			return int(c), true
+		}
+	}
+	return 0, false
+}
+
 // request qualifiers
 
 // 1- threshold
@@ -334,9 +577,9 @@ type timeThreshold struct {
 }
 
 func newTimeThreshold(c interface{}) (*timeThreshold, errors.Error) {
-	switch c.(type) {
-	case int:
-		return &timeThreshold{threshold: time.Duration(c.(int))}, nil
+	threshold, ok := intCondition(c)
+	if ok {
+		return &timeThreshold{threshold: time.Duration(threshold)}, nil
 	}
 	return nil, errors.NewCompletedQualifierInvalidArgument("threshold", c)
 }
@@ -354,14 +597,11 @@ func (this *timeThreshold) condition() interface{} {
 }
 
 func (this *timeThreshold) isCondition(c interface{}) bool {
-	switch c.(type) {
-	case int:
-		return time.Duration(c.(int)) == this.threshold
-	}
-	return false
+	threshold, ok := intCondition(c)
+	return ok && time.Duration(threshold) == this.threshold
 }
 
-func (this *timeThreshold) evaluate(request *BaseRequest) bool {
+func (this *timeThreshold) evaluate(request *BaseRequest, outcome *requestOutcome) bool {
 
 	// negative threshold means log nothing
 	// zero threshold means log everything (no threshold)
@@ -372,3 +612,248 @@ func (this *timeThreshold) evaluate(request *BaseRequest) bool {
 	}
 	return true
 }
+
+// 2- errors, either any or with a specific code
+type errorQualifier struct {
+	code int
+}
+
+func newErrorQualifier(c interface{}) (*errorQualifier, errors.Error) {
+	code, ok := intCondition(c)
+	if ok && code >= 0 {
+		return &errorQualifier{code: code}, nil
+	}
+	return nil, errors.NewCompletedQualifierInvalidArgument("error", c)
+}
+
+func (this *errorQualifier) name() string {
+	return "error"
+}
+
+func (this *errorQualifier) unique() bool {
+	return false
+}
+
+func (this *errorQualifier) condition() interface{} {
+	return this.code
+}
+
+func (this *errorQualifier) isCondition(c interface{}) bool {
+	code, ok := intCondition(c)
+	return ok && code == this.code
+}
+
+func (this *errorQualifier) evaluate(request *BaseRequest, outcome *requestOutcome) bool {
+	codes := request.ErrorCodes()
+
+	// zero means any error
+	if this.code == 0 {
+		return outcome.errorCount > 0 || len(codes) > 0
+	}
+	for _, code := range codes {
+		if code == this.code {
+			return true
+		}
+	}
+	return false
+}
+
+// 3- users
+type userQualifier struct {
+	user string
+}
+
+func newUserQualifier(c interface{}) (*userQualifier, errors.Error) {
+	user, ok := c.(string)
+	if ok && user != "" {
+		return &userQualifier{user: user}, nil
+	}
+	return nil, errors.NewCompletedQualifierInvalidArgument("user", c)
+}
+
+func (this *userQualifier) name() string {
+	return "user"
+}
+
+func (this *userQualifier) unique() bool {
+	return false
+}
+
+func (this *userQualifier) condition() interface{} {
+	return this.user
+}
+
+func (this *userQualifier) isCondition(c interface{}) bool {
+	user, ok := c.(string)
+	return ok && user == this.user
+}
+
+func (this *userQualifier) evaluate(request *BaseRequest, outcome *requestOutcome) bool {
+	users := datastore.CredsString(request.Credentials(), outcome.req)
+	for _, user := range strings.Split(users, ",") {
+		if user == this.user {
+			return true
+		}
+	}
+	return false
+}
+
+// 4- statements matching a pattern
+type statementQualifier struct {
+	pattern string
+	re      *regexp.Regexp
+}
+
+func newStatementQualifier(c interface{}) (*statementQualifier, errors.Error) {
+	pattern, ok := c.(string)
+	if ok {
+		re, err := regexp.Compile(pattern)
+		if err == nil {
+			return &statementQualifier{pattern: pattern, re: re}, nil
+		}
+	}
+	return nil, errors.NewCompletedQualifierInvalidArgument("statement", c)
+}
+
+func (this *statementQualifier) name() string {
+	return "statement"
+}
+
+func (this *statementQualifier) unique() bool {
+	return false
+}
+
+func (this *statementQualifier) condition() interface{} {
+	return this.pattern
+}
+
+func (this *statementQualifier) isCondition(c interface{}) bool {
+	pattern, ok := c.(string)
+	return ok && pattern == this.pattern
+}
+
+func (this *statementQualifier) evaluate(request *BaseRequest, outcome *requestOutcome) bool {
+	if this.re.MatchString(request.Statement()) {
+		return true
+	}
+
+	// for EXECUTE, also try the statement that was prepared
+	prepared := request.Prepared()
+	return prepared != nil && this.re.MatchString(prepared.Text())
+}
+
+// 5- result count threshold
+type resultCountThreshold struct {
+	threshold int
+}
+
+func newResultCountThreshold(c interface{}) (*resultCountThreshold, errors.Error) {
+	threshold, ok := intCondition(c)
+	if ok && threshold >= 0 {
+		return &resultCountThreshold{threshold: threshold}, nil
+	}
+	return nil, errors.NewCompletedQualifierInvalidArgument("resultCount", c)
+}
+
+func (this *resultCountThreshold) name() string {
+	return "resultCount"
+}
+
+func (this *resultCountThreshold) unique() bool {
+	return true
+}
+
+func (this *resultCountThreshold) condition() interface{} {
+	return this.threshold
+}
+
+func (this *resultCountThreshold) isCondition(c interface{}) bool {
+	threshold, ok := intCondition(c)
+	return ok && threshold == this.threshold
+}
+
+func (this *resultCountThreshold) evaluate(request *BaseRequest, outcome *requestOutcome) bool {
+	return outcome.resultCount >= this.threshold
+}
+
+// 6- result size threshold
+type resultSizeThreshold struct {
+	threshold int
+}
+
+func newResultSizeThreshold(c interface{}) (*resultSizeThreshold, errors.Error) {
+	threshold, ok := intCondition(c)
+	if ok && threshold >= 0 {
+		return &resultSizeThreshold{threshold: threshold}, nil
+	}
+	return nil, errors.NewCompletedQualifierInvalidArgument("resultSize", c)
+}
+
+func (this *resultSizeThreshold) name() string {
+	return "resultSize"
+}
+
+func (this *resultSizeThreshold) unique() bool {
+	return true
+}
+
+func (this *resultSizeThreshold) condition() interface{} {
+	return this.threshold
+}
+
+func (this *resultSizeThreshold) isCondition(c interface{}) bool {
+	threshold, ok := intCondition(c)
+	return ok && threshold == this.threshold
+}
+
+func (this *resultSizeThreshold) evaluate(request *BaseRequest, outcome *requestOutcome) bool {
+	return outcome.resultSize >= this.threshold
+}
+
+// 7- random sampling, as a percentage of requests
+type sampleQualifier struct {
+	percentage float64
+}
+
+func newSampleQualifier(c interface{}) (*sampleQualifier, errors.Error) {
+	var percentage float64
+
+	switch c := c.(type) {
+	case int:
+		percentage = float64(c)
+	case float64:
+		percentage = c
+	default:
+		return nil, errors.NewCompletedQualifierInvalidArgument("sample", c)
+	}
+	if percentage < 0 || percentage > 100 {
+		return nil, errors.NewCompletedQualifierInvalidArgument("sample", c)
+	}
+	return &sampleQualifier{percentage: percentage}, nil
+}
+
+func (this *sampleQualifier) name() string {
+	return "sample"
+}
+
+func (this *sampleQualifier) unique() bool {
+	return true
+}
+
+func (this *sampleQualifier) condition() interface{} {
+	return this.percentage
+}
+
+func (this *sampleQualifier) isCondition(c interface{}) bool {
+	switch c := c.(type) {
+	case int:
+		return float64(c) == this.percentage
+	case float64:
+		return c == this.percentage
+	}
+	return false
+}
+
+func (this *sampleQualifier) evaluate(request *BaseRequest, outcome *requestOutcome) bool {
+	return rand.Float64()*100 < this.percentage
+}
@@ -0,0 +1,468 @@
+//  Copyright (c) 2018 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package server
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	atomic "github.com/couchbase/go-couchbase/platform"
+	"github.com/couchbase/query/errors"
+	"github.com/couchbase/query/logging"
+	"github.com/couchbase/query/plan"
+)
+
+// requestTime of archived entries: fixed width UTC, so that times compare as strings
+const ARCHIVE_TIME_FORMAT = "2006-01-02T15:04:05.000000000Z07:00"
+
+const (
+	_ARCHIVE_PREFIX = "completed_requests-"
+	_ARCHIVE_SUFFIX = ".ndjson"
+	_ARCHIVE_STAMP  = "20060102T150405.000000000"
+	_ARCHIVE_QUEUE  = 1024
+
+	// how often the age of the file being written is checked when idle
+	_ARCHIVE_AGE_CHECK = time.Minute
+)
+
+type archiveEntry struct {
+	time time.Time
+	data []byte
+}
+
+// The completed requests archive keeps a durable history of the completed requests log.
+// Every entry added to the log is also appended, as a line of JSON, to a file in the
+// archive directory. Files are rotated when they grow too large or too old, whether
+// or not requests are still coming in, and only the most recent ones are kept.
+// The file being written is named after the time it was opened. When rotated, it is
+// renamed to also carry the times of its first and last request, so that scans for a
+// time range can skip whole files.
+// Writing is done by a single goroutine, so that requests never wait on the disk: if
+// the writer falls behind, entries are dropped from the archive, though not from the log.
+type requestsArchive struct {
+	dropped  atomic.AlignedUint64
+	dir      string
+	maxSize  int64
+	maxAge   time.Duration
+	maxFiles int
+	entries  chan *archiveEntry
+
+	// the file being written, only accessed by the writer
+	file     *os.File
+	id       string
+	opened   time.Time
+	size     int64
+	first    time.Time
+	last     time.Time
+	reported uint64
+}
+
+var archive *requestsArchive
+
+// An archive file, and the times of its first and last requests.
+// Times are empty for the file being written.
+type RequestsArchiveFile struct {
+	Id    string
+	First string
+	Last  string
+	name  string
+}
+
+// init completed requests archive
+
+func RequestsArchiveInit(dir string, maxSize int64, maxAge time.Duration, maxFiles int) errors.Error {
+	err := os.MkdirAll(dir, 0700)
+	if err != nil {
+		return errors.NewCompletedArchiveError(err, "cannot create "+dir)
+	}
+	this := &requestsArchive{
+		dir:      dir,
+		maxSize:  maxSize,
+		maxAge:   maxAge,
+		maxFiles: maxFiles,
+		entries:  make(chan *archiveEntry, _ARCHIVE_QUEUE),
+	}
+
+	// files left behind by a previous run are closed out now
+	files, er := this.files()
+	if er != nil {
+		return er
+	}
+	for _, f := range files {
+		if f.First == "" {
+			this.seal(f)
+		}
+	}
+	this.prune()
+
+	go this.write()
+	archive = this
+	return nil
+}
+
+func RequestsArchiveEnabled() bool {
+	return archive != nil
+}
+
+// archive files, oldest first
+
+func RequestsArchiveFiles() ([]*RequestsArchiveFile, errors.Error) {
+	if archive == nil {
+		return nil, nil
+	}
+	return archive.files()
+}
+
+// scan the entries of an archive file
+// keys are made of the file id and the position of the entry in the file
+
+func RequestsArchiveForeach(f *RequestsArchiveFile, entry func(key string, data []byte) bool) errors.Error {
+	if archive == nil {
+		return nil
+	}
+	return archive.foreach(f, entry)
+}
+
+func (this *requestsArchive) foreach(f *RequestsArchiveFile, entry func(key string, data []byte) bool) errors.Error {
+	file, err := this.open(f.Id)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	var offset int64
+	reader := bufio.NewReader(file)
+	for {
+		line, er := reader.ReadBytes('\n')
+
+		// a partial line is an entry still being written
+		if er == io.EOF {
+			return nil
+		} else if er != nil {
+			return errors.NewCompletedArchiveError(er, "cannot read "+f.Id)
+		}
+		if !entry(f.Id+"/"+strconv.FormatInt(offset, 10), line) {
+			return nil
+		}
+		offset += int64(len(line))
+	}
+}
+
+func RequestsArchiveEntry(key string) (map[string]interface{}, errors.Error) {
+	var doc map[string]interface{}
+
+	slash := strings.IndexByte(key, '/')
+	if archive == nil || slash < 0 {
+		return nil, errors.NewSystemStmtNotFoundError(nil, key)
+	}
+	offset, er := strconv.ParseInt(key[slash+1:], 10, 64)
+	if er != nil {
+		return nil, errors.NewSystemStmtNotFoundError(er, key)
+	}
+	file, err := archive.open(key[:slash])
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	_, er = file.Seek(offset, io.SeekStart)
+	if er == nil {
+		var line []byte
+
+		line, er = bufio.NewReader(file).ReadBytes('\n')
+		if er == nil {
+			er = json.Unmarshal(line, &doc)
+		}
+	}
+	if er != nil {
+		return nil, errors.NewSystemStmtNotFoundError(er, key)
+	}
+	return doc, nil
+}
+
+// called by LogRequest before the entry is added to the log
+
+func (this *requestsArchive) add(re *RequestLogEntry, request *BaseRequest, prepared *plan.Prepared) {
+	record := map[string]interface{}{
+		"requestId":       re.RequestId,
+		"state":           re.State,
+		"elapsedTime":     re.ElapsedTime.String(),
+		"serviceTime":     re.ServiceTime.String(),
+		"resultCount":     re.ResultCount,
+		"resultSize":      re.ResultSize,
+		"errorCount":      re.ErrorCount,
+		"requestTime":     re.Time.UTC().Format(ARCHIVE_TIME_FORMAT),
+		"scanConsistency": re.ScanConsistency,
+	}
+	if re.ClientId != "" {
+		record["clientContextID"] = re.ClientId
+	}
+	if re.Statement != "" {
+		record["statement"] = re.Statement
+	}
+	if re.PreparedName != "" {
+		record["preparedName"] = re.PreparedName
+		record["preparedText"] = re.PreparedText
+	}
+
+	// the log only keeps what profiling asks for, but the disk is
+	// not as tight as memory
+	if re.PhaseTimes != nil {
+		record["phaseTimes"] = re.PhaseTimes
+	} else {
+		record["phaseTimes"] = request.FmtPhaseTimes()
+	}
+	if re.PhaseCounts != nil {
+		record["phaseCounts"] = re.PhaseCounts
+	}
+	if re.PhaseOperators != nil {
+		record["phaseOperators"] = re.PhaseOperators
+	}
+	if re.PositionalArgs != nil {
+		record["positionalArgs"] = re.PositionalArgs
+	}
+	if re.NamedArgs != nil {
+		record["namedArgs"] = re.NamedArgs
+	}
+	if re.Users != "" {
+		record["users"] = re.Users
+	}
+	if re.RemoteAddr != "" {
+		record["remoteAddr"] = re.RemoteAddr
+	}
+	if re.UserAgent != "" {
+		record["userAgent"] = re.UserAgent
+	}
+	if re.Timings != nil {
+		record["plan"] = re.Timings
+	} else if prepared != nil && prepared.Operator != nil {
+		record["plan"] = prepared.Operator
+	}
+	if re.Plan != nil {
+		record["explain"] = re.Plan.Operator
+	}
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		logging.Errorp("Cannot archive completed request", logging.Pair{"requestId", re.RequestId},
+			logging.Pair{"error", err})
+		return
+	}
+	select {
+	case this.entries <- &archiveEntry{time: re.Time, data: append(data, '\n')}:
+	default:
+		atomic.AddUint64(&this.dropped, 1)
+	}
+}
+
+func (this *requestsArchive) write() {
+	interval := _ARCHIVE_AGE_CHECK
+	if this.maxAge > 0 && this.maxAge < interval {
+		interval = this.maxAge
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case entry, ok := <-this.entries:
+			if !ok {
+				return
+			}
+			this.writeEntry(entry)
+		case <-ticker.C:
+
+			// files are not left open past their age for want of requests
+			this.checkAge()
+		}
+	}
+}
+
+func (this *requestsArchive) writeEntry(entry *archiveEntry) {
+	if this.file != nil && this.size >= this.maxSize {
+		this.rotate()
+	} else {
+		this.checkAge()
+	}
+	if this.file == nil {
+		err := this.create()
+		if err != nil {
+			logging.Errorp("Cannot archive completed requests", logging.Pair{"error", err})
+			return
+		}
+	}
+
+	n, err := this.file.Write(entry.data)
+	this.size += int64(n)
+	if err != nil {
+		logging.Errorp("Cannot archive completed requests", logging.Pair{"error", err})
+		return
+	}
+	if this.first.IsZero() || entry.time.Before(this.first) {
+		this.first = entry.time
+	}
+	if entry.time.After(this.last) {
+		this.last = entry.time
+	}
+
+	dropped := atomic.LoadUint64(&this.dropped)
+	if dropped != this.reported {
+		logging.Warnp("Completed requests archive falling behind",
+			logging.Pair{"dropped", dropped - this.reported})
+		this.reported = dropped
+	}
+}
+
+func (this *requestsArchive) checkAge() {
+	if this.file != nil && time.Since(this.opened) >= this.maxAge {
+		this.rotate()
+	}
+}
+
+func (this *requestsArchive) create() error {
+	this.opened = time.Now()
+	this.id = this.opened.UTC().Format(_ARCHIVE_STAMP)
+	file, err := os.OpenFile(filepath.Join(this.dir, _ARCHIVE_PREFIX+this.id+_ARCHIVE_SUFFIX),
+		os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	this.file = file
+	this.size = 0
+	this.first = time.Time{}
+	this.last = time.Time{}
+	return nil
+}
+
+func (this *requestsArchive) rotate() {
+	this.file.Close()
+	this.file = nil
+	if this.first.IsZero() {
+		os.Remove(filepath.Join(this.dir, _ARCHIVE_PREFIX+this.id+_ARCHIVE_SUFFIX))
+	} else {
+		this.rename(this.id, this.first, this.last)
+	}
+	this.prune()
+}
+
+func (this *requestsArchive) rename(id string, first, last time.Time) {
+	err := os.Rename(filepath.Join(this.dir, _ARCHIVE_PREFIX+id+_ARCHIVE_SUFFIX),
+		filepath.Join(this.dir, _ARCHIVE_PREFIX+id+"_"+first.UTC().Format(_ARCHIVE_STAMP)+"_"+
+			last.UTC().Format(_ARCHIVE_STAMP)+_ARCHIVE_SUFFIX))
+	if err != nil {
+		logging.Errorp("Cannot rotate completed requests archive", logging.Pair{"error", err})
+	}
+}
+
+// close out a file that was being written when the engine stopped
+func (this *requestsArchive) seal(f *RequestsArchiveFile) {
+	var first, last time.Time
+
+	this.foreach(f, func(key string, data []byte) bool {
+		var entry struct {
+			RequestTime string `json:"requestTime"`
+		}
+
+		if json.Unmarshal(data, &entry) == nil {
+			t, err := time.Parse(ARCHIVE_TIME_FORMAT, entry.RequestTime)
+			if err == nil {
+				if first.IsZero() || t.Before(first) {
+					first = t
+				}
+				if t.After(last) {
+					last = t
+				}
+			}
+		}
+		return true
+	})
+	if first.IsZero() {
+		os.Remove(filepath.Join(this.dir, f.name))
+	} else {
+		this.rename(f.Id, first, last)
+	}
+}
+
+// only keep the most recent files
+func (this *requestsArchive) prune() {
+	if this.maxFiles <= 0 {
+		return
+	}
+	files, err := this.files()
+	if err != nil {
+		logging.Errorp("Cannot prune completed requests archive", logging.Pair{"error", err})
+		return
+	}
+	for len(files) > this.maxFiles {
+		os.Remove(filepath.Join(this.dir, files[0].name))
+		files = files[1:]
+	}
+}
+
+func (this *requestsArchive) files() ([]*RequestsArchiveFile, errors.Error) {
+	infos, err := ioutil.ReadDir(this.dir)
+	if err != nil {
+		return nil, errors.NewCompletedArchiveError(err, "cannot read "+this.dir)
+	}
+
+	files := make([]*RequestsArchiveFile, 0, len(infos))
+	for _, info := range infos {
+		name := info.Name()
+		if info.IsDir() || !strings.HasPrefix(name, _ARCHIVE_PREFIX) ||
+			!strings.HasSuffix(name, _ARCHIVE_SUFFIX) {
+			continue
+		}
+		stamps := strings.Split(name[len(_ARCHIVE_PREFIX):len(name)-len(_ARCHIVE_SUFFIX)], "_")
+		f := &RequestsArchiveFile{Id: stamps[0], name: name}
+		if len(stamps) == 3 {
+			f.First = archiveTime(stamps[1])
+			f.Last = archiveTime(stamps[2])
+		}
+		files = append(files, f)
+	}
+
+	// ids are fixed width times
+	sort.Slice(files, func(i, j int) bool { return files[i].Id < files[j].Id })
+	return files, nil
+}
+
+// files may get renamed at any time, so they are located by id
+func (this *requestsArchive) open(id string) (*os.File, errors.Error) {
+	_, err := time.Parse(_ARCHIVE_STAMP, id)
+	if err != nil {
+		return nil, errors.NewSystemStmtNotFoundError(err, id)
+	}
+	for i := 0; i < 2; i++ {
+		names, _ := filepath.Glob(filepath.Join(this.dir, _ARCHIVE_PREFIX+id+"*"+_ARCHIVE_SUFFIX))
+		for _, name := range names {
+			file, err := os.Open(name)
+			if err == nil {
+				return file, nil
+			}
+		}
+	}
+	return nil, errors.NewSystemStmtNotFoundError(nil, id)
+}
+
+func archiveTime(stamp string) string {
+	t, err := time.Parse(_ARCHIVE_STAMP, stamp)
+	if err != nil {
+		return ""
+	}
+	return t.Format(ARCHIVE_TIME_FORMAT)
+}
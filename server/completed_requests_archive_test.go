@@ -0,0 +1,197 @@
+//  Copyright (c) 2018 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package server
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newTestArchive(t *testing.T, maxSize int64, maxFiles int) *requestsArchive {
+	dir, err := ioutil.TempDir("", "archive")
+	if err != nil {
+		t.Fatalf("Cannot create directory: %v", err)
+	}
+	return &requestsArchive{
+		dir:      dir,
+		maxSize:  maxSize,
+		maxAge:   time.Hour,
+		maxFiles: maxFiles,
+		entries:  make(chan *archiveEntry, 1),
+	}
+}
+
+func archiveTestEntry(t *testing.T, requestTime time.Time) *archiveEntry {
+	data, err := json.Marshal(map[string]interface{}{
+		"requestTime": requestTime.UTC().Format(ARCHIVE_TIME_FORMAT),
+	})
+	if err != nil {
+		t.Fatalf("Cannot marshal entry: %v", err)
+	}
+	return &archiveEntry{time: requestTime, data: append(data, '\n')}
+}
+
+func archiveFiles(t *testing.T, archive *requestsArchive) []*RequestsArchiveFile {
+	files, err := archive.files()
+	if err != nil {
+		t.Fatalf("Cannot list archive files: %v", err)
+	}
+	return files
+}
+
+func TestArchiveRotation(t *testing.T) {
+	archive := newTestArchive(t, 90, 2)
+	defer os.RemoveAll(archive.dir)
+	start := time.Date(2018, 5, 15, 10, 0, 0, 0, time.UTC)
+
+	// entries are 49 bytes, so files rotate every second entry
+	for i := 0; i < 4; i++ {
+		archive.writeEntry(archiveTestEntry(t, start.Add(time.Duration(i)*time.Minute)))
+	}
+	files := archiveFiles(t, archive)
+	if len(files) != 2 || files[1].First != "" {
+		t.Fatalf("Expected a rotated file and the one being written, got %v", files)
+	}
+	if files[0].First != "2018-05-15T10:00:00.000000000Z" || files[0].Last != "2018-05-15T10:01:00.000000000Z" {
+		t.Errorf("Expected the rotated file to cover 10:00 to 10:01, got %v to %v", files[0].First, files[0].Last)
+	}
+
+	// only the most recent rotated files are kept
+	for i := 4; i < 10; i++ {
+		archive.writeEntry(archiveTestEntry(t, start.Add(time.Duration(i)*time.Minute)))
+	}
+	files = archiveFiles(t, archive)
+	if len(files) != 3 || files[0].First != "2018-05-15T10:04:00.000000000Z" ||
+		files[1].Last != "2018-05-15T10:07:00.000000000Z" || files[2].First != "" {
+		t.Errorf("Expected 2 rotated files from 10:04 to 10:07 and the one being written, got %v", files)
+	}
+
+	// files that are too old are rotated, even without new entries
+	archive.opened = archive.opened.Add(-2 * time.Hour)
+	archive.checkAge()
+	files = archiveFiles(t, archive)
+	if archive.file != nil || len(files) != 2 || files[1].Last != "2018-05-15T10:09:00.000000000Z" {
+		t.Errorf("Expected the old file to be rotated, got %v", files)
+	}
+
+	// and files without entries are dropped
+	err := archive.create()
+	if err != nil {
+		t.Fatalf("Cannot create file: %v", err)
+	}
+	archive.opened = archive.opened.Add(-2 * time.Hour)
+	archive.checkAge()
+	if files := archiveFiles(t, archive); len(files) != 2 {
+		t.Errorf("Expected an empty file to be dropped, got %v", files)
+	}
+}
+
+func TestArchiveInit(t *testing.T) {
+	dir, err := ioutil.TempDir("", "archive")
+	if err != nil {
+		t.Fatalf("Cannot create directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	defer func() { archive = nil }()
+
+	// files left behind by a previous run, one of them empty
+	start := time.Date(2018, 5, 15, 10, 0, 0, 0, time.UTC)
+	var entries []*archiveEntry
+	var data []byte
+	for _, minute := range []time.Duration{2, 1, 3} {
+		entry := archiveTestEntry(t, start.Add(minute*time.Minute))
+		entries = append(entries, entry)
+		data = append(data, entry.data...)
+	}
+	left := []string{
+		_ARCHIVE_PREFIX + "20180515T100000.000000000" + _ARCHIVE_SUFFIX,
+		_ARCHIVE_PREFIX + "20180516T100000.000000000" + _ARCHIVE_SUFFIX,
+		"other.ndjson",
+	}
+	for i, name := range left {
+		content := data
+		if i == 1 {
+			content = nil
+		}
+		err = ioutil.WriteFile(filepath.Join(dir, name), content, 0600)
+		if err != nil {
+			t.Fatalf("Cannot write file: %v", err)
+		}
+	}
+
+	cErr := RequestsArchiveInit(dir, 1024, time.Hour, 0)
+	if cErr != nil {
+		t.Fatalf("Cannot init archive: %v", cErr)
+	}
+	files, cErr := RequestsArchiveFiles()
+	if cErr != nil || len(files) != 1 {
+		t.Fatalf("Expected the leftover file to be sealed and the empty one dropped, got %v, %v", files, cErr)
+	}
+	if files[0].Id != "20180515T100000.000000000" || files[0].First != "2018-05-15T10:01:00.000000000Z" ||
+		files[0].Last != "2018-05-15T10:03:00.000000000Z" {
+		t.Errorf("Expected the leftover file to cover 10:01 to 10:03, got %v", files[0])
+	}
+
+	// keys locate the entries
+	var keys []string
+	cErr = RequestsArchiveForeach(files[0], func(key string, data []byte) bool {
+		keys = append(keys, key)
+		return true
+	})
+	if cErr != nil || len(keys) != 3 {
+		t.Fatalf("Expected 3 entries, got %v, %v", keys, cErr)
+	}
+	for i, key := range keys {
+		var expected map[string]interface{}
+		json.Unmarshal(entries[i].data, &expected)
+
+		doc, cErr := RequestsArchiveEntry(key)
+		if cErr != nil || doc["requestTime"] != expected["requestTime"] {
+			t.Errorf("Expected entry %v to be %v, got %v, %v", key, expected, doc, cErr)
+		}
+	}
+	for _, key := range []string{"20180515T100000.000000000/5", "20180515T100000.000000000", "nofile/0", "other/0"} {
+		_, cErr = RequestsArchiveEntry(key)
+		if cErr == nil {
+			t.Errorf("Expected no entry for %v", key)
+		}
+	}
+}
+
+func TestArchiveAdd(t *testing.T) {
+	archive := newTestArchive(t, 1024, 0)
+	defer os.RemoveAll(archive.dir)
+
+	request := newLogTestRequest("SELECT 1", "u1")
+	entry := &RequestLogEntry{
+		RequestId: request.Id().String(),
+		Statement: request.Statement(),
+		Users:     "u1",
+		Time:      time.Now(),
+	}
+	archive.add(entry, request, nil)
+
+	// entries are dropped rather than waited for
+	archive.add(entry, request, nil)
+	if archive.dropped != 1 {
+		t.Errorf("Expected an entry to be dropped, got %v", archive.dropped)
+	}
+
+	var record map[string]interface{}
+	err := json.Unmarshal((<-archive.entries).data, &record)
+	if err != nil || record["requestId"] != entry.RequestId || record["statement"] != "SELECT 1" ||
+		record["users"] != "u1" || record["requestTime"] != entry.Time.UTC().Format(ARCHIVE_TIME_FORMAT) {
+		t.Errorf("Unexpected archive record %v, %v", record, err)
+	}
+}
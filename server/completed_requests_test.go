@@ -0,0 +1,294 @@
+//  Copyright (c) 2018 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package server
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/couchbase/query/auth"
+	"github.com/couchbase/query/errors"
+	"github.com/couchbase/query/execution"
+	"github.com/couchbase/query/plan"
+	"github.com/couchbase/query/util"
+)
+
+func resetRequestLog(qualifiers map[string]interface{}) {
+	requestLog = &RequestLog{cache: util.NewGenCache(10)}
+	if qualifiers != nil {
+		RequestsProcessQualifiers(qualifiers)
+	}
+}
+
+func newLogTestRequest(statement string, users ...string) *BaseRequest {
+	creds := auth.Credentials{}
+	for _, user := range users {
+		creds[user] = "password"
+	}
+	rv := &BaseRequest{}
+	NewBaseRequest(rv, statement, nil, nil, nil, "default", 1, 0, 0, 0,
+		0, 0, 0, 0, nil, "", creds, "", "")
+	return rv
+}
+
+func isLogged(request *BaseRequest) bool {
+	return requestLog.cache.Get(request.Id().String(), nil) != nil
+}
+
+func checkQualifiers(t *testing.T, what string, expected map[string]interface{}) {
+	actual := RequestsQualifiersSettings()
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("%s: expected qualifiers %v, got %v", what, expected, actual)
+	}
+}
+
+func TestProcessQualifiers(t *testing.T) {
+	resetRequestLog(nil)
+	RequestsInit(1000, 10)
+	checkQualifiers(t, "init", map[string]interface{}{"threshold": time.Duration(1000), "combine": "or"})
+
+	// removals apply before replacements, and replacements before additions
+	err := RequestsProcessQualifiers(map[string]interface{}{
+		"+threshold": 500,
+		"-threshold": 1000,
+		"+error":     []interface{}{float64(1065), float64(5000)},
+		"user":       "u1",
+		"combine":    "and",
+	})
+	if err != nil {
+		t.Fatalf("Cannot process qualifiers: %v", err)
+	}
+	checkQualifiers(t, "add", map[string]interface{}{
+		"threshold": time.Duration(500),
+		"error":     []interface{}{1065, 5000},
+		"user":      []interface{}{"u1"},
+		"combine":   "and",
+	})
+
+	// a list replaces all the conditions of a qualifier
+	err = RequestsProcessQualifiers(map[string]interface{}{
+		"error":   []interface{}{float64(12)},
+		"-user":   "u1",
+		"combine": "or",
+	})
+	if err != nil {
+		t.Fatalf("Cannot process qualifiers: %v", err)
+	}
+	checkQualifiers(t, "replace list", map[string]interface{}{
+		"threshold": time.Duration(500),
+		"error":     []interface{}{12},
+		"combine":   "or",
+	})
+
+	// either all the changes apply, or none does
+	for _, settings := range []map[string]interface{}{
+		{"-threshold": 500, "+error": float64(12)},
+		{"-error": float64(12), "+sample": float64(101)},
+		{"threshold": 200, "-user": "u1"},
+		{"error": float64(13)},
+		{"threshold": 200, "combine": "xor"},
+		{"threshold": 200, "unknown": 1},
+	} {
+		err = RequestsCheckQualifiers(settings)
+		if err == nil {
+			t.Errorf("Expected %v to be rejected by a check", settings)
+		}
+		err = RequestsProcessQualifiers(settings)
+		if err == nil {
+			t.Errorf("Expected %v to be rejected", settings)
+		}
+		checkQualifiers(t, "rejected", map[string]interface{}{
+			"threshold": time.Duration(500),
+			"error":     []interface{}{12},
+			"combine":   "or",
+		})
+	}
+
+	// checks do not apply changes
+	err = RequestsCheckQualifiers(map[string]interface{}{"-threshold": 500})
+	if err != nil {
+		t.Errorf("Unexpected check error: %v", err)
+	}
+	checkQualifiers(t, "check", map[string]interface{}{
+		"threshold": time.Duration(500),
+		"error":     []interface{}{12},
+		"combine":   "or",
+	})
+
+	// replace drops the existing qualifiers first
+	err = RequestsProcessQualifiers(map[string]interface{}{
+		"replace":   true,
+		"statement": "^SELECT",
+		"sample":    float64(50),
+	})
+	if err != nil {
+		t.Fatalf("Cannot process qualifiers: %v", err)
+	}
+	checkQualifiers(t, "replace", map[string]interface{}{
+		"statement": []interface{}{"^SELECT"},
+		"sample":    float64(50),
+		"combine":   "or",
+	})
+}
+
+func TestQualifiers(t *testing.T) {
+	request := newLogTestRequest("SELECT 1", "u1", "u2")
+	outcome := &requestOutcome{resultCount: 10, resultSize: 100}
+
+	var tests = []struct {
+		name      string
+		condition interface{}
+		expected  bool
+	}{
+		{"threshold", 0, true},
+		{"threshold", -1, false},
+		{"threshold", 100000, false},
+		{"error", 0, false},
+		{"user", "u2", true},
+		{"user", "u3", false},
+		{"statement", "^SELECT", true},
+		{"statement", "^EXECUTE", false},
+		{"resultCount", 10, true},
+		{"resultCount", 11, false},
+		{"resultSize", float64(100), true},
+		{"resultSize", 101, false},
+		{"sample", 100, true},
+		{"sample", float64(0), false},
+	}
+
+	for _, test := range tests {
+		q, err := newQualifier(test.name, test.condition)
+		if err != nil {
+			t.Errorf("Cannot create qualifier %v %v: %v", test.name, test.condition, err)
+			continue
+		}
+		if !q.isCondition(test.condition) {
+			t.Errorf("Expected qualifier %v to have condition %v", test.name, test.condition)
+		}
+		if q.evaluate(request, outcome) != test.expected {
+			t.Errorf("Expected qualifier %v %v to evaluate to %v", test.name, test.condition, test.expected)
+		}
+	}
+
+	for name, condition := range map[string]interface{}{
+		"threshold":   1.5,
+		"error":       -1,
+		"user":        "",
+		"statement":   "(",
+		"resultCount": -1,
+		"resultSize":  "1",
+		"sample":      101,
+		"unknown":     1,
+	} {
+		_, err := newQualifier(name, condition)
+		if err == nil {
+			t.Errorf("Expected qualifier %v %v to be rejected", name, condition)
+		}
+	}
+
+	// errors, with a code or not
+	any, _ := newQualifier("error", 0)
+	code, _ := newQualifier("error", 5000)
+	if !any.evaluate(request, &requestOutcome{errorCount: 1}) || code.evaluate(request, outcome) {
+		t.Errorf("Expected only errors to qualify")
+	}
+	request.Error(errors.NewError(nil, "first"))
+	request.Error(errors.NewError(nil, "second"))
+	if !any.evaluate(request, outcome) || !code.evaluate(request, outcome) {
+		t.Errorf("Expected error 5000 to qualify")
+	}
+	if codes := request.ErrorCodes(); len(codes) != 1 || codes[0] != 5000 {
+		t.Errorf("Expected error codes to be kept once, got %v", codes)
+	}
+
+	// prepared statements are matched by their text
+	prepared := plan.NewPrepared(nil, nil)
+	prepared.SetText("SELECT 1")
+	request = newLogTestRequest("EXECUTE p1")
+	request.SetPrepared(prepared)
+	statement, _ := newQualifier("statement", "^SELECT")
+	if !statement.evaluate(request, outcome) {
+		t.Errorf("Expected the prepared statement to qualify")
+	}
+}
+
+func TestLogRequestQualifiers(t *testing.T) {
+	srv := &Server{}
+	srv.SetProfile(ProfOff)
+	defer resetRequestLog(nil)
+
+	logRequest := func(resultCount int, users ...string) *BaseRequest {
+		request := newLogTestRequest("SELECT 1", users...)
+		LogRequest(time.Millisecond, time.Millisecond, resultCount, 0, 0, nil, request, srv)
+		return request
+	}
+
+	// any qualifier is enough
+	resetRequestLog(map[string]interface{}{"user": "u1", "resultCount": 10})
+	if !isLogged(logRequest(10)) || !isLogged(logRequest(0, "u1")) || isLogged(logRequest(0, "u2")) {
+		t.Errorf("Expected requests satisfying any qualifier to be logged")
+	}
+
+	// all qualifiers have to be satisfied
+	resetRequestLog(map[string]interface{}{"user": "u1", "resultCount": 10, "combine": "and"})
+	if !isLogged(logRequest(10, "u1")) || isLogged(logRequest(10)) || isLogged(logRequest(0, "u1")) {
+		t.Errorf("Expected only requests satisfying all qualifiers to be logged")
+	}
+
+	// no qualifier, no logging
+	resetRequestLog(nil)
+	if isLogged(logRequest(10, "u1")) {
+		t.Errorf("Expected requests not to be logged without qualifiers")
+	}
+
+	// nor without space to log them
+	resetRequestLog(map[string]interface{}{"threshold": 0})
+	RequestsSetLimit(0)
+	if isLogged(logRequest(10)) {
+		t.Errorf("Expected requests not to be logged with a zero limit")
+	}
+}
+
+func TestLogRequestSlow(t *testing.T) {
+	srv := &Server{}
+	srv.SetProfile(ProfOff)
+	defer resetRequestLog(nil)
+
+	resetRequestLog(map[string]interface{}{"threshold": 0})
+	RequestsSetSlowThreshold(100)
+
+	logRequest := func(serviceTime time.Duration) *RequestLogEntry {
+		request := newLogTestRequest("SELECT 1")
+		request.SetPlan(plan.NewPrepared(plan.NewDummyScan(), nil))
+		request.SetTimings(execution.NewDummyScan(plan.NewDummyScan(), nil))
+		LogRequest(serviceTime, serviceTime, 1, 0, 0, nil, request, srv)
+		return RequestEntry(request.Id().String())
+	}
+
+	// slow requests keep their plan and timings, even with profiling off
+	entry := logRequest(200 * time.Millisecond)
+	if entry.Timings == nil || entry.Plan == nil {
+		t.Errorf("Expected a slow request to keep its timings and plan, got %v, %v", entry.Timings, entry.Plan)
+	}
+
+	entry = logRequest(10 * time.Millisecond)
+	if entry.Timings != nil || entry.Plan != nil {
+		t.Errorf("Expected a fast request not to keep its timings and plan, got %v, %v", entry.Timings, entry.Plan)
+	}
+
+	// slow requests are logged whatever the qualifiers
+	resetRequestLog(nil)
+	RequestsSetSlowThreshold(100)
+	entry = logRequest(200 * time.Millisecond)
+	if entry == nil || entry.Timings == nil {
+		t.Errorf("Expected a slow request to be logged without qualifiers, got %v", entry)
+	}
+}
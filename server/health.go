@@ -0,0 +1,182 @@
+//  Copyright (c) 2018 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package server
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/couchbase/query/accounting"
+	"github.com/couchbase/query/datastore"
+	"github.com/couchbase/query/errors"
+)
+
+// Names of the readiness checks
+const (
+	HEALTH_DATASTORE = "datastore"
+	HEALTH_INDEXERS  = "indexers"
+	HEALTH_QUEUES    = "queues"
+	HEALTH_MEMORY    = "memory"
+)
+
+// The indexers check walks every index, so probes reuse its result for a while
+const _INDEXERS_TTL = 10 * time.Second
+
+// Register the checks that tell whether the server is ready to take requests.
+// memoryLimit is in bytes, zero to only report memory usage
+func (this *Server) RegisterHealthChecks(memoryLimit uint64) errors.Error {
+	if this.acctstore == nil {
+		return nil
+	}
+
+	checks := map[string]accounting.HealthCheckFunc{
+		HEALTH_DATASTORE: this.checkDatastore,
+		HEALTH_INDEXERS:  newCachedCheck(this.checkIndexers, _INDEXERS_TTL),
+		HEALTH_QUEUES:    this.checkQueues,
+		HEALTH_MEMORY: func() (accounting.HealthCheckResult, errors.Error) {
+			return checkMemory(memoryLimit)
+		},
+	}
+
+	registry := this.acctstore.HealthCheckRegistry()
+	for name, check := range checks {
+		err := registry.Register(name, check)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// A check whose result is kept for ttl; concurrent probes wait for the
+// one that runs the check, rather than all running it
+type cachedCheck struct {
+	sync.Mutex
+	check   accounting.HealthCheckFunc
+	ttl     time.Duration
+	expires time.Time
+	result  accounting.HealthCheckResult
+	err     errors.Error
+}
+
+func newCachedCheck(check accounting.HealthCheckFunc, ttl time.Duration) accounting.HealthCheckFunc {
+	rv := &cachedCheck{check: check, ttl: ttl}
+	return rv.run
+}
+
+func (this *cachedCheck) run() (accounting.HealthCheckResult, errors.Error) {
+	this.Lock()
+	defer this.Unlock()
+
+	if time.Now().Before(this.expires) {
+		return this.result, this.err
+	}
+	this.result, this.err = this.check()
+	this.expires = time.Now().Add(this.ttl)
+	return this.result, this.err
+}
+
+func (this *Server) checkDatastore() (accounting.HealthCheckResult, errors.Error) {
+	names, err := this.datastore.NamespaceNames()
+	if err != nil {
+		return accounting.NewUnhealthyResult("datastore is not responding", err), nil
+	}
+	return accounting.NewHealthyResult(fmt.Sprintf("%d namespaces", len(names))), nil
+}
+
+// Indexes being built or deferred are a normal part of an index's life, so
+// only indexers that cannot be reached and indexes that are offline count
+func (this *Server) checkIndexers() (accounting.HealthCheckResult, errors.Error) {
+	var total, online int
+	var offline []string
+
+	namespaceIds, err := this.datastore.NamespaceIds()
+	if err != nil {
+		return nil, err
+	}
+	for _, namespaceId := range namespaceIds {
+		namespace, err := this.datastore.NamespaceById(namespaceId)
+		if err != nil {
+			return nil, err
+		}
+		keyspaceIds, err := namespace.KeyspaceIds()
+		if err != nil {
+			return nil, err
+		}
+		for _, keyspaceId := range keyspaceIds {
+			keyspace, err := namespace.KeyspaceById(keyspaceId)
+			if err != nil {
+				return nil, err
+			}
+			indexers, err := keyspace.Indexers()
+			if err != nil {
+				return accounting.NewUnhealthyResult("indexers of "+keyspace.Name()+" are not responding", err), nil
+			}
+			for _, indexer := range indexers {
+				indexes, err := indexer.Indexes()
+				if err != nil {
+					return accounting.NewUnhealthyResult(fmt.Sprintf("%s indexer of %s is not responding",
+						indexer.Name(), keyspace.Name()), err), nil
+				}
+				for _, index := range indexes {
+					total++
+					state, _, err := index.State()
+					switch {
+					case err != nil:
+						return accounting.NewUnhealthyResult(fmt.Sprintf("%s indexer of %s is not responding",
+							indexer.Name(), keyspace.Name()), err), nil
+					case state == datastore.ONLINE:
+						online++
+					case state == datastore.OFFLINE:
+						offline = append(offline, keyspace.Name()+"."+index.Name())
+					}
+				}
+			}
+		}
+	}
+
+	message := fmt.Sprintf("%d of %d indexes online", online, total)
+	if len(offline) > 0 {
+		return accounting.NewUnhealthyResult(message+", offline: "+strings.Join(offline, ", "), nil), nil
+	}
+	return accounting.NewHealthyResult(message), nil
+}
+
+// Requests are turned away once a queue is full
+func (this *Server) checkQueues() (accounting.HealthCheckResult, errors.Error) {
+	queued, capacity := len(this.channel), cap(this.channel)
+	plusQueued, plusCapacity := len(this.plusChannel), cap(this.plusChannel)
+	message := fmt.Sprintf("%d of %d requests queued, %d of %d plus requests queued",
+		queued, capacity, plusQueued, plusCapacity)
+	if queued >= capacity || plusQueued >= plusCapacity {
+		return accounting.NewUnhealthyResult(message, nil), nil
+	}
+	return accounting.NewHealthyResult(message), nil
+}
+
+// What counts is the memory held from the operating system, rather
+// than what is in use, as that is what limits are enforced against
+func checkMemory(limit uint64) (accounting.HealthCheckResult, errors.Error) {
+	var mem runtime.MemStats
+
+	runtime.ReadMemStats(&mem)
+	used := mem.Sys - mem.HeapReleased
+	if limit == 0 {
+		return accounting.NewHealthyResult(fmt.Sprintf("%d bytes used, no limit", used)), nil
+	}
+	message := fmt.Sprintf("%d of %d bytes used", used, limit)
+	if used > limit {
+		return accounting.NewUnhealthyResult(message, nil), nil
+	}
+	return accounting.NewHealthyResult(message), nil
+}
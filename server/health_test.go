@@ -0,0 +1,208 @@
+//  Copyright (c) 2018 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/couchbase/query/accounting"
+	"github.com/couchbase/query/datastore"
+	"github.com/couchbase/query/datastore/mock"
+	"github.com/couchbase/query/errors"
+)
+
+// a datastore whose namespaces, or else whose indexers, do not respond
+type failingDatastore struct {
+	datastore.Datastore
+	namespaces bool
+}
+
+func (this *failingDatastore) NamespaceNames() ([]string, errors.Error) {
+	if this.namespaces {
+		return nil, errors.NewOtherDatastoreError(nil, "not responding")
+	}
+	return this.Datastore.NamespaceNames()
+}
+
+func (this *failingDatastore) NamespaceById(id string) (datastore.Namespace, errors.Error) {
+	namespace, err := this.Datastore.NamespaceById(id)
+	if err != nil {
+		return nil, err
+	}
+	return &failingNamespace{namespace}, nil
+}
+
+type failingNamespace struct {
+	datastore.Namespace
+}
+
+func (this *failingNamespace) KeyspaceById(id string) (datastore.Keyspace, errors.Error) {
+	keyspace, err := this.Namespace.KeyspaceById(id)
+	if err != nil {
+		return nil, err
+	}
+	return &failingKeyspace{keyspace}, nil
+}
+
+type failingKeyspace struct {
+	datastore.Keyspace
+}
+
+func (this *failingKeyspace) Indexers() ([]datastore.Indexer, errors.Error) {
+	return nil, errors.NewOtherDatastoreError(nil, "not responding")
+}
+
+// an accounting store with just a health check registry, as the
+// gometrics one imports this package
+type healthTestStore struct {
+	accounting.AccountingStore
+	registry healthTestRegistry
+}
+
+func (this *healthTestStore) HealthCheckRegistry() accounting.HealthCheckRegistry {
+	return this.registry
+}
+
+type healthTestRegistry map[string]accounting.HealthCheck
+
+func (this healthTestRegistry) Register(name string, hc accounting.HealthCheck) errors.Error {
+	if _, ok := this[name]; ok {
+		return errors.NewAdminHealthCheckExists(name)
+	}
+	this[name] = hc
+	return nil
+}
+
+func (this healthTestRegistry) Unregister(name string) errors.Error {
+	delete(this, name)
+	return nil
+}
+
+func (this healthTestRegistry) RunHealthChecks() (map[string]accounting.HealthCheckResult, errors.Error) {
+	rv := make(map[string]accounting.HealthCheckResult, len(this))
+	for name := range this {
+		res, err := this.RunHealthCheck(name)
+		if err != nil {
+			return nil, err
+		}
+		rv[name] = res
+	}
+	return rv, nil
+}
+
+func (this healthTestRegistry) RunHealthCheck(name string) (accounting.HealthCheckResult, errors.Error) {
+	hc, ok := this[name]
+	if !ok {
+		return nil, errors.NewAdminHealthCheckNotFound(name)
+	}
+	return hc.Check()
+}
+
+func newHealthTestServer(t *testing.T) *Server {
+	store, err := mock.NewDatastore("mock:namespaces=2,keyspaces=3")
+	if err != nil {
+		t.Fatalf("Cannot create datastore: %v", err)
+	}
+	return &Server{
+		datastore:   store,
+		acctstore:   &healthTestStore{registry: healthTestRegistry{}},
+		channel:     make(RequestChannel, 1),
+		plusChannel: make(RequestChannel, 1),
+	}
+}
+
+func TestHealthChecks(t *testing.T) {
+	srv := newHealthTestServer(t)
+
+	res, err := srv.checkDatastore()
+	if err != nil || !res.IsHealthy() || res.Message() != "2 namespaces" {
+		t.Errorf("Expected a healthy datastore, got %v, %v", res, err)
+	}
+	res, err = srv.checkIndexers()
+	if err != nil || !res.IsHealthy() || res.Message() != "6 of 6 indexes online" {
+		t.Errorf("Expected healthy indexers, got %v, %v", res, err)
+	}
+	res, err = srv.checkQueues()
+	if err != nil || !res.IsHealthy() {
+		t.Errorf("Expected healthy queues, got %v, %v", res, err)
+	}
+	res, err = checkMemory(0)
+	if err != nil || !res.IsHealthy() {
+		t.Errorf("Expected healthy memory without a limit, got %v, %v", res, err)
+	}
+
+	store := srv.datastore
+	srv.datastore = &failingDatastore{Datastore: store, namespaces: true}
+	res, err = srv.checkDatastore()
+	if err != nil || res.IsHealthy() || res.Error() == nil {
+		t.Errorf("Expected an unhealthy datastore, got %v, %v", res, err)
+	}
+	srv.datastore = &failingDatastore{Datastore: store}
+	res, err = srv.checkIndexers()
+	if err != nil || res.IsHealthy() || res.Message() != "indexers of b0 are not responding" {
+		t.Errorf("Expected unhealthy indexers, got %v, %v", res, err)
+	}
+
+	srv.plusChannel <- nil
+	res, err = srv.checkQueues()
+	if err != nil || res.IsHealthy() {
+		t.Errorf("Expected a full queue to be unhealthy, got %v, %v", res, err)
+	}
+	res, err = checkMemory(1)
+	if err != nil || res.IsHealthy() {
+		t.Errorf("Expected memory over the limit to be unhealthy, got %v, %v", res, err)
+	}
+}
+
+func TestRegisterHealthChecks(t *testing.T) {
+	srv := newHealthTestServer(t)
+
+	err := srv.RegisterHealthChecks(1)
+	if err != nil {
+		t.Fatalf("Cannot register health checks: %v", err)
+	}
+	results, err := srv.acctstore.HealthCheckRegistry().RunHealthChecks()
+	if err != nil || len(results) != 4 {
+		t.Fatalf("Expected 4 health checks, got %v, %v", results, err)
+	}
+	for _, name := range []string{HEALTH_DATASTORE, HEALTH_INDEXERS, HEALTH_QUEUES} {
+		if !results[name].IsHealthy() {
+			t.Errorf("Expected %v to be healthy, got %v", name, results[name].Message())
+		}
+	}
+	if results[HEALTH_MEMORY].IsHealthy() {
+		t.Errorf("Expected memory over the limit to be unhealthy")
+	}
+
+	err = srv.RegisterHealthChecks(1)
+	if err == nil {
+		t.Errorf("Expected health checks to be registered only once")
+	}
+}
+
+func TestCachedCheck(t *testing.T) {
+	calls := 0
+	check := newCachedCheck(func() (accounting.HealthCheckResult, errors.Error) {
+		calls++
+		return accounting.NewHealthyResult(""), nil
+	}, 50*time.Millisecond)
+
+	check()
+	check()
+	if calls != 1 {
+		t.Errorf("Expected the result to be reused, got %v calls", calls)
+	}
+	time.Sleep(100 * time.Millisecond)
+	check()
+	if calls != 2 {
+		t.Errorf("Expected the check to run again once expired, got %v calls", calls)
+	}
+}
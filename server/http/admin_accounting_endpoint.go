@@ -13,13 +13,16 @@ import (
 	"encoding/json"
 	"io/ioutil"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/couchbase/query/accounting"
 	"github.com/couchbase/query/audit"
 	"github.com/couchbase/query/auth"
 	"github.com/couchbase/query/datastore"
+	"github.com/couchbase/query/distributed"
 	"github.com/couchbase/query/errors"
+	"github.com/couchbase/query/execution"
 	"github.com/couchbase/query/logging"
 	"github.com/couchbase/query/prepareds"
 	"github.com/couchbase/query/server"
@@ -62,12 +65,18 @@ func (this *HttpEndpoint) registerAccountingHandlers() {
 	preparedsHandler := func(w http.ResponseWriter, req *http.Request) {
 		this.wrapAPI(w, req, doPrepareds)
 	}
+	baselineHandler := func(w http.ResponseWriter, req *http.Request) {
+		this.wrapAPI(w, req, doBaseline)
+	}
 	requestsHandler := func(w http.ResponseWriter, req *http.Request) {
 		this.wrapAPI(w, req, doActiveRequests)
 	}
 	requestHandler := func(w http.ResponseWriter, req *http.Request) {
 		this.wrapAPI(w, req, doActiveRequest)
 	}
+	progressHandler := func(w http.ResponseWriter, req *http.Request) {
+		this.wrapAPI(w, req, doRequestProgress)
+	}
 	completedsHandler := func(w http.ResponseWriter, req *http.Request) {
 		this.wrapAPI(w, req, doCompletedRequests)
 	}
@@ -87,18 +96,20 @@ func (this *HttpEndpoint) registerAccountingHandlers() {
 		handler handlerFunc
 		methods []string
 	}{
-		accountingPrefix:                      {handler: statsHandler, methods: []string{"GET"}},
-		accountingPrefix + "/{stat}":          {handler: statHandler, methods: []string{"GET", "DELETE"}},
-		vitalsPrefix:                          {handler: vitalsHandler, methods: []string{"GET"}},
-		preparedsPrefix:                       {handler: preparedsHandler, methods: []string{"GET"}},
-		preparedsPrefix + "/{name}":           {handler: preparedHandler, methods: []string{"GET", "POST", "DELETE", "PUT"}},
-		requestsPrefix:                        {handler: requestsHandler, methods: []string{"GET"}},
-		requestsPrefix + "/{request}":         {handler: requestHandler, methods: []string{"GET", "POST", "DELETE"}},
-		completedsPrefix:                      {handler: completedsHandler, methods: []string{"GET"}},
-		completedsPrefix + "/{request}":       {handler: completedHandler, methods: []string{"GET", "POST", "DELETE"}},
-		indexesPrefix + "/prepareds":          {handler: preparedIndexHandler, methods: []string{"GET"}},
-		indexesPrefix + "/active_requests":    {handler: requestIndexHandler, methods: []string{"GET"}},
-		indexesPrefix + "/completed_requests": {handler: completedIndexHandler, methods: []string{"GET"}},
+		accountingPrefix:                       {handler: statsHandler, methods: []string{"GET"}},
+		accountingPrefix + "/{stat}":           {handler: statHandler, methods: []string{"GET", "DELETE"}},
+		vitalsPrefix:                           {handler: vitalsHandler, methods: []string{"GET"}},
+		preparedsPrefix:                        {handler: preparedsHandler, methods: []string{"GET"}},
+		preparedsPrefix + "/{name}":            {handler: preparedHandler, methods: []string{"GET", "POST", "DELETE", "PUT"}},
+		preparedsPrefix + "/{name}/baseline":   {handler: baselineHandler, methods: []string{"GET", "POST", "DELETE", "PUT"}},
+		requestsPrefix:                         {handler: requestsHandler, methods: []string{"GET", "DELETE"}},
+		requestsPrefix + "/{request}":          {handler: requestHandler, methods: []string{"GET", "POST", "DELETE"}},
+		requestsPrefix + "/{request}/progress": {handler: progressHandler, methods: []string{"GET"}},
+		completedsPrefix:                       {handler: completedsHandler, methods: []string{"GET"}},
+		completedsPrefix + "/{request}":        {handler: completedHandler, methods: []string{"GET", "POST", "DELETE"}},
+		indexesPrefix + "/prepareds":           {handler: preparedIndexHandler, methods: []string{"GET"}},
+		indexesPrefix + "/active_requests":     {handler: requestIndexHandler, methods: []string{"GET"}},
+		indexesPrefix + "/completed_requests":  {handler: completedIndexHandler, methods: []string{"GET"}},
 	}
 
 	for route, h := range routeMap {
@@ -307,6 +318,12 @@ func doPrepared(endpoint *HttpEndpoint, w http.ResponseWriter, req *http.Request
 				"encoded_plan":    entry.Prepared.EncodedPlan(),
 				"indexApiVersion": entry.Prepared.IndexApiVersion(),
 				"featureControls": entry.Prepared.FeatureControls(),
+				"restored":        entry.Restored,
+				"planGeneration":  entry.Generation,
+			}
+			if entry.ReprepareCause != "" {
+				itemMap["lastReprepare"] = entry.LastReprepare.String()
+				itemMap["lastReprepareReason"] = entry.ReprepareCause
 			}
 			if req.Method == "POST" {
 				itemMap["plan"] = entry.Prepared.Operator
@@ -329,6 +346,91 @@ func doPrepared(endpoint *HttpEndpoint, w http.ResponseWriter, req *http.Request
 	}
 }
 
+// POST pins the current plan of the statement, PUT pins the encoded plan in the body
+func doBaseline(endpoint *HttpEndpoint, w http.ResponseWriter, req *http.Request, af *audit.ApiAuditFields) (interface{}, errors.Error) {
+	vars := mux.Vars(req)
+	name := vars["name"]
+
+	af.EventTypeId = audit.API_ADMIN_PREPAREDS
+	af.Name = name
+
+	switch req.Method {
+	case "DELETE":
+		err := verifyCredentialsFromRequest("prepareds", req, af)
+		if err != nil {
+			return nil, err
+		}
+		err = prepareds.DeleteBaseline(name)
+		if err != nil {
+			return nil, err
+		}
+		return true, nil
+	case "POST":
+		err := verifyCredentialsFromRequest("prepareds", req, af)
+		if err != nil {
+			return nil, err
+		}
+		err = prepareds.CaptureBaseline(name)
+		if err != nil {
+			return nil, err
+		}
+		return baselineMap(name)
+	case "PUT":
+		body, err1 := ioutil.ReadAll(req.Body)
+		defer req.Body.Close()
+
+		// http.BasicAuth eats the body, so verify credentials after getting the body.
+		err := verifyCredentialsFromRequest("prepareds", req, af)
+		if err != nil {
+			return nil, err
+		}
+
+		if err1 != nil {
+			return nil, errors.NewAdminBodyError(err1)
+		}
+		err = prepareds.SetBaseline(name, string(body))
+		if err != nil {
+			return nil, err
+		}
+		return baselineMap(name)
+	case "GET":
+		err := verifyCredentialsFromRequest("prepareds", req, af)
+		if err != nil {
+			return nil, err
+		}
+		return baselineMap(name)
+	default:
+		return nil, errors.NewServiceErrorHttpMethod(req.Method)
+	}
+}
+
+func baselineMap(name string) (map[string]interface{}, errors.Error) {
+	var itemMap map[string]interface{}
+
+	prepareds.BaselineDo(name, func(baseline *prepareds.Baseline) {
+		itemMap = map[string]interface{}{
+			"name":            name,
+			"statement":       baseline.Text,
+			"encoded_plan":    baseline.EncodedPlan,
+			"indexApiVersion": baseline.IndexApiVersion,
+			"created":         baseline.Created.String(),
+			"uses":            baseline.Uses,
+			"divergences":     baseline.Divergences,
+			"invalidations":   baseline.Invalidations,
+		}
+		if baseline.Divergences > 0 {
+			itemMap["lastDivergence"] = baseline.LastDivergence.String()
+		}
+		if baseline.Invalidations > 0 {
+			itemMap["lastInvalidation"] = baseline.LastInvalidation.String()
+		}
+	})
+	if itemMap == nil {
+		return nil, errors.NewNoSuchBaselineError(name)
+	}
+	return itemMap, nil
+}
+
 func doPrepareds(endpoint *HttpEndpoint, w http.ResponseWriter, req *http.Request, af *audit.ApiAuditFields) (interface{}, errors.Error) {
 	af.EventTypeId = audit.API_ADMIN_PREPAREDS
 	switch req.Method {
@@ -353,6 +455,8 @@ func doPrepareds(endpoint *HttpEndpoint, w http.ResponseWriter, req *http.Reques
 			data[i]["encoded_plan"] = d.Prepared.EncodedPlan()
 			data[i]["statement"] = d.Prepared.Text()
 			data[i]["uses"] = d.Uses
+			data[i]["restored"] = d.Restored
+			data[i]["planGeneration"] = d.Generation
 			if d.Uses > 0 {
 				data[i]["lastUse"] = d.LastUse.String()
 			}
@@ -394,7 +498,7 @@ func doActiveRequest(endpoint *HttpEndpoint, w http.ResponseWriter, req *http.Re
 		if err != nil {
 			return nil, err
 		}
-		if endpoint.actives.Delete(requestId, true) {
+		if !endpoint.actives.Delete(requestId, true) {
 			return nil, errors.NewServiceErrorHttpReq(requestId)
 		}
 
@@ -404,6 +508,42 @@ func doActiveRequest(endpoint *HttpEndpoint, w http.ResponseWriter, req *http.Re
 	}
 }
 
+func doRequestProgress(endpoint *HttpEndpoint, w http.ResponseWriter, req *http.Request, af *audit.ApiAuditFields) (interface{}, errors.Error) {
+	vars := mux.Vars(req)
+	requestId := vars["request"]
+
+	af.EventTypeId = audit.API_ADMIN_ACTIVE_REQUESTS
+	af.Request = requestId
+
+	err := verifyCredentialsFromRequest("actives", req, af)
+	if err != nil {
+		return nil, err
+	}
+
+	var progress map[string]interface{}
+	_ = endpoint.actives.Get(requestId, func(request server.Request) {
+		timings := request.GetTimings()
+		if timings != nil {
+			progress, _ = execution.Progress(timings)
+		}
+		if progress == nil {
+			progress = map[string]interface{}{}
+		}
+		progress["requestId"] = requestId
+		progress["elapsedTime"] = time.Since(request.RequestTime()).String()
+		progress["executionTime"] = time.Since(request.ServiceTime()).String()
+		progress["state"] = request.State()
+		p := request.Output().FmtPhaseCounts()
+		if p != nil {
+			progress["phaseCounts"] = p
+		}
+	})
+	if progress == nil {
+		return nil, errors.NewServiceErrorHttpReq(requestId)
+	}
+	return progress, nil
+}
+
 func activeRequestWorkHorse(endpoint *HttpEndpoint, requestId string, profiling bool) map[string]interface{} {
 	reqMap := map[string]interface{}{}
 	_ = endpoint.actives.Get(requestId, func(request server.Request) {
@@ -486,11 +626,22 @@ func activeRequestWorkHorse(endpoint *HttpEndpoint, requestId string, profiling
 
 func doActiveRequests(endpoint *HttpEndpoint, w http.ResponseWriter, req *http.Request, af *audit.ApiAuditFields) (interface{}, errors.Error) {
 	af.EventTypeId = audit.API_ADMIN_PREPAREDS
+	if req.Method == "DELETE" {
+		af.EventTypeId = audit.API_ADMIN_ACTIVE_REQUESTS
+	}
 	err := verifyCredentialsFromRequest("actives", req, af)
 	if err != nil {
 		return nil, err
 	}
 
+	if req.Method == "DELETE" {
+		filter, err := newRequestsFilter(req)
+		if err != nil {
+			return nil, err
+		}
+		return cancelRequests(endpoint, filter), nil
+	}
+
 	numRequests, err := endpoint.actives.Count()
 	if err != nil {
 		return nil, err
@@ -622,6 +773,9 @@ func completedRequestWorkHorse(requestId string, profiling bool) map[string]inte
 			if request.Timings != nil {
 				reqMap["timings"] = request.Timings
 			}
+			if request.Plan != nil {
+				reqMap["explain"] = request.Plan.Operator
+			}
 		}
 		if request.Users != "" {
 			reqMap["users"] = request.Users
@@ -636,6 +790,125 @@ func completedRequestWorkHorse(requestId string, profiling bool) map[string]inte
 	return reqMap
 }
 
+// selects the active requests to cancel in bulk: all the conditions
+// given have to be satisfied
+type requestsFilter struct {
+	clientContextID string
+	user            string
+	statement       string
+}
+
+func newRequestsFilter(req *http.Request) (*requestsFilter, errors.Error) {
+	filter := &requestsFilter{
+		clientContextID: req.FormValue("clientContextID"),
+		user:            req.FormValue("user"),
+		statement:       req.FormValue("statement"),
+	}
+
+	// we don't want a mistyped parameter to kill every request
+	if filter.clientContextID == "" && filter.user == "" && filter.statement == "" {
+		return nil, errors.NewServiceErrorMissingValue("clientContextID, user or statement")
+	}
+	return filter, nil
+}
+
+func (this *requestsFilter) matches(clientContextID, users, statement, preparedText string) bool {
+	if this.clientContextID != "" && this.clientContextID != clientContextID {
+		return false
+	}
+	if this.user != "" {
+		found := false
+		for _, user := range strings.Split(users, ",") {
+			if user == this.user {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if this.statement != "" && !strings.Contains(statement, this.statement) &&
+		!strings.Contains(preparedText, this.statement) {
+		return false
+	}
+	return true
+}
+
+func (this *requestsFilter) matchesRequest(request server.Request) bool {
+	var preparedText string
+
+	if request.Prepared() != nil {
+		preparedText = request.Prepared().Text()
+	}
+	return this.matches(request.ClientID().String(),
+		datastore.CredsString(request.Credentials(), request.OriginalHttpRequest()),
+		request.Statement(), preparedText)
+}
+
+func (this *requestsFilter) matchesDoc(doc map[string]interface{}) bool {
+	clientContextID, _ := doc["clientContextID"].(string)
+	users, _ := doc["users"].(string)
+	statement, _ := doc["statement"].(string)
+	preparedText, _ := doc["preparedText"].(string)
+	return this.matches(clientContextID, users, statement, preparedText)
+}
+
+// cancels the matching requests on all query nodes, and
+// returns the keys of those that were cancelled
+func cancelRequests(endpoint *HttpEndpoint, filter *requestsFilter) []string {
+	var ids []string
+
+	cancelled := []string{}
+
+	// now that the node name can change in flight, use a consistent one throughout
+	whoAmI := distributed.RemoteAccess().WhoAmI()
+	endpoint.actives.ForEach(func(id string, request server.Request) bool {
+		if filter.matchesRequest(request) {
+			ids = append(ids, id)
+		}
+		return true
+	}, nil)
+	for _, id := range ids {
+		if endpoint.actives.Delete(id, true) {
+			cancelled = append(cancelled, distributed.RemoteAccess().MakeKey(whoAmI, id))
+		}
+	}
+
+	// remote requests are only known through their documents
+	var keys []string
+	distributed.RemoteAccess().GetRemoteKeys([]string{}, "active_requests", func(id string) bool {
+		keys = append(keys, id)
+		return true
+	}, func(warn errors.Error) {
+		logging.Warnp("Cannot scan remote active requests", logging.Pair{"error", warn})
+	})
+	for _, key := range keys {
+		node, localKey := distributed.RemoteAccess().SplitKey(key)
+		matched := false
+		distributed.RemoteAccess().GetRemoteDoc(node, localKey, "active_requests", "POST",
+			func(doc map[string]interface{}) {
+				matched = filter.matchesDoc(doc)
+			}, nil, distributed.NO_CREDS, "")
+		if !matched {
+			continue
+		}
+
+		// the remote node answers true, which does not make a document
+		failed := false
+		distributed.RemoteAccess().GetRemoteDoc(node, localKey, "active_requests", "DELETE", nil,
+			func(warn errors.Error) {
+				failed = true
+				logging.Warnp("Cannot cancel remote request", logging.Pair{"request", key},
+					logging.Pair{"error", warn})
+			}, distributed.NO_CREDS, "")
+		if !failed {
+			cancelled = append(cancelled, key)
+		}
+	}
+	return cancelled
+}
+
 func doCompletedRequests(endpoint *HttpEndpoint, w http.ResponseWriter, req *http.Request, af *audit.ApiAuditFields) (interface{}, errors.Error) {
 	af.EventTypeId = audit.API_ADMIN_COMPLETED_REQUESTS
 	err := verifyCredentialsFromRequest("completed_requests", req, af)
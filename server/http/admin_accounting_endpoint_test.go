@@ -0,0 +1,73 @@
+//  Copyright (c) 2018 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package http
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRequestsFilter(t *testing.T) {
+	for _, query := range []string{"", "?clientContextID=", "?users=u1", "?user=&statement="} {
+		_, err := newRequestsFilter(httptest.NewRequest("DELETE", adminPrefix+"/active_requests"+query, nil))
+		if err == nil {
+			t.Errorf("Expected filter %q to be refused", query)
+		}
+	}
+
+	var tests = []struct {
+		query        string
+		clientID     string
+		users        string
+		statement    string
+		preparedText string
+		expected     bool
+	}{
+		{"clientContextID=c1", "c1", "", "SELECT 1", "", true},
+		{"clientContextID=c1", "c2", "", "SELECT 1", "", false},
+		{"user=u2", "", "u1,u2,u3", "SELECT 1", "", true},
+		{"user=u2", "", "u2", "SELECT 1", "", true},
+		{"user=u2", "", "u1,u22", "SELECT 1", "", false},
+		{"user=u2", "", "", "SELECT 1", "", false},
+		{"statement=FROM+b1", "", "", "SELECT * FROM b1", "", true},
+		{"statement=FROM+b1", "", "", "EXECUTE p1", "SELECT * FROM b1", true},
+		{"statement=FROM+b1", "", "", "EXECUTE p1", "SELECT * FROM b2", false},
+		{"user=u1&statement=FROM+b1", "", "u1", "SELECT * FROM b1", "", true},
+		{"user=u1&statement=FROM+b1", "", "u2", "SELECT * FROM b1", "", false},
+		{"user=u1&statement=FROM+b1", "", "u1", "SELECT * FROM b2", "", false},
+		{"clientContextID=c1&user=u1", "c1", "u1", "SELECT 1", "", true},
+		{"clientContextID=c1&user=u1", "c2", "u1", "SELECT 1", "", false},
+	}
+
+	for _, test := range tests {
+		filter, err := newRequestsFilter(httptest.NewRequest("DELETE", adminPrefix+"/active_requests?"+test.query, nil))
+		if err != nil {
+			t.Errorf("Cannot create filter %v: %v", test.query, err)
+			continue
+		}
+		if filter.matches(test.clientID, test.users, test.statement, test.preparedText) != test.expected {
+			t.Errorf("Expected filter %v on %v, %v, %v, %v to match: %v", test.query, test.clientID,
+				test.users, test.statement, test.preparedText, test.expected)
+		}
+
+		// remote requests are matched by their documents
+		doc := map[string]interface{}{
+			"clientContextID": test.clientID,
+			"users":           test.users,
+			"statement":       test.statement,
+		}
+		if test.preparedText != "" {
+			doc["preparedText"] = test.preparedText
+		}
+		if filter.matchesDoc(doc) != test.expected {
+			t.Errorf("Expected filter %v on %v to match: %v", test.query, doc, test.expected)
+		}
+	}
+}
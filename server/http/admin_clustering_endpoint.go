@@ -37,6 +37,12 @@ func (this *HttpEndpoint) registerClusterHandlers() {
 	pingHandler := func(w http.ResponseWriter, req *http.Request) {
 		this.wrapAPI(w, req, doPing)
 	}
+	liveHandler := func(w http.ResponseWriter, req *http.Request) {
+		this.wrapAPI(w, req, doLive)
+	}
+	readyHandler := func(w http.ResponseWriter, req *http.Request) {
+		this.wrapAPI(w, req, doReady)
+	}
 	configHandler := func(w http.ResponseWriter, req *http.Request) {
 		this.wrapAPI(w, req, doConfig)
 	}
@@ -63,6 +69,8 @@ func (this *HttpEndpoint) registerClusterHandlers() {
 		methods []string
 	}{
 		adminPrefix + "/ping":                      {handler: pingHandler, methods: []string{"GET"}},
+		adminPrefix + "/health/live":               {handler: liveHandler, methods: []string{"GET"}},
+		adminPrefix + "/health/ready":              {handler: readyHandler, methods: []string{"GET"}},
 		adminPrefix + "/config":                    {handler: configHandler, methods: []string{"GET"}},
 		adminPrefix + "/ssl_cert":                  {handler: sslCertHandler, methods: []string{"POST"}},
 		adminPrefix + "/settings":                  {handler: settingsHandler, methods: []string{"GET", "POST"}},
@@ -146,6 +154,63 @@ func doPing(endpoint *HttpEndpoint, w http.ResponseWriter, req *http.Request, af
 	return &pingStatus, nil
 }
 
+// Liveness only says that the process can answer, like ping
+func doLive(endpoint *HttpEndpoint, w http.ResponseWriter, req *http.Request, af *audit.ApiAuditFields) (interface{}, errors.Error) {
+	af.EventTypeId = audit.API_ADMIN_PING
+	return map[string]interface{}{"status": "ok"}, nil
+}
+
+// Message and error are only given to authorized callers,
+// as they name the namespaces, keyspaces and indexes
+type healthCheckResult struct {
+	Healthy bool         `json:"healthy"`
+	Message string       `json:"message,omitempty"`
+	Error   errors.Error `json:"error,omitempty"`
+}
+
+type readiness struct {
+	Status string                       `json:"status"`
+	Checks map[string]healthCheckResult `json:"checks"`
+}
+
+// orchestrators only look at the status code
+func (this *readiness) httpStatus() int {
+	if this.Status != "ok" {
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusOK
+}
+
+// Readiness runs all the registered health checks.
+// It needs no credentials, so that orchestrators can probe it, but only
+// callers with credentials get to see the details of the checks
+func doReady(endpoint *HttpEndpoint, w http.ResponseWriter, req *http.Request, af *audit.ApiAuditFields) (interface{}, errors.Error) {
+	af.EventTypeId = audit.API_ADMIN_PING
+	details := verifyCredentialsFromRequest("health", req, af) == nil
+
+	rv := &readiness{Status: "ok", Checks: map[string]healthCheckResult{}}
+	acctStore := endpoint.server.AccountingStore()
+	if acctStore == nil {
+		return rv, nil
+	}
+	results, err := acctStore.HealthCheckRegistry().RunHealthChecks()
+	if err != nil {
+		return nil, err
+	}
+	for name, res := range results {
+		if !res.IsHealthy() {
+			rv.Status = "unavailable"
+		}
+		check := healthCheckResult{Healthy: res.IsHealthy()}
+		if details {
+			check.Message = res.Message()
+			check.Error = res.Error()
+		}
+		rv.Checks[name] = check
+	}
+	return rv, nil
+}
+
 var localConfig struct {
 	sync.Mutex
 	name     string
@@ -362,7 +427,18 @@ func doSettings(endpoint *HttpEndpoint, w http.ResponseWriter, req *http.Request
 	srvr := endpoint.server
 	switch req.Method {
 	case "GET":
-		return fillSettings(settings, srvr), nil
+		settings = fillSettings(settings, srvr)
+
+		// report where each value comes from
+		if req.FormValue("sources") == "true" {
+			for setting, value := range settings {
+				settings[setting] = map[string]interface{}{
+					"value":  value,
+					"source": paramSettings.Source(setting),
+				}
+			}
+		}
+		return settings, nil
 	case "POST":
 		decoder, e := getJsonDecoder(req.Body)
 		if e != nil {
@@ -377,6 +453,7 @@ func doSettings(endpoint *HttpEndpoint, w http.ResponseWriter, req *http.Request
 		if errP := server.ProcessSettings(settings, srvr); errP != nil {
 			return nil, errP
 		}
+		server.PersistSettings(settings)
 
 		return fillSettings(settings, srvr), nil
 	default:
@@ -400,6 +477,8 @@ func fillSettings(settings map[string]interface{}, srvr *server.Server) map[stri
 	threshold, _ := server.RequestsGetQualifier("threshold")
 	settings[paramSettings.CMPTHRESHOLD] = threshold
 	settings[paramSettings.CMPLIMIT] = server.RequestsLimit()
+	settings[paramSettings.CMPQUALIFIERS] = server.RequestsQualifiersSettings()
+	settings[paramSettings.CMPSLOW] = server.RequestsSlowThreshold()
 	settings[paramSettings.PRPLIMIT] = prepareds.PreparedsLimit()
 	settings[paramSettings.PRETTY] = srvr.Pretty()
 	settings[paramSettings.MAXINDEXAPI] = srvr.MaxIndexAPI()
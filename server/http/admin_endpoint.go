@@ -63,14 +63,23 @@ func (this *HttpEndpoint) wrapAPI(w http.ResponseWriter, req *http.Request, f ap
 		audit.SubmitApiRequest(&auditFields)
 		return
 	}
-	w.WriteHeader(http.StatusOK)
+	status := http.StatusOK
+	if s, ok := obj.(httpStatus); ok {
+		status = s.httpStatus()
+	}
+	w.WriteHeader(status)
 	w.Header().Set("Content-Type", "application/json")
 	w.Write(buf)
 
-	auditFields.HttpResultCode = http.StatusOK
+	auditFields.HttpResultCode = status
 	audit.SubmitApiRequest(&auditFields)
 }
 
+// Responses that are not errors, but still need a status other than 200
+type httpStatus interface {
+	httpStatus() int
+}
+
 // Returns the HTTP error code, e.g. 500.
 func writeError(w http.ResponseWriter, err errors.Error) int {
 	w.Header().Set("Content-Type", "application/json")
@@ -96,6 +105,8 @@ func mapErrorToHttpStatus(err errors.Error) int {
 		return http.StatusUnauthorized
 	case errors.ADMIN_CREDS_ERROR:
 		return http.StatusBadRequest
+	case errors.NO_SUCH_BASELINE:
+		return http.StatusNotFound
 	default:
 		return http.StatusInternalServerError
 	}
@@ -0,0 +1,107 @@
+//  Copyright (c) 2018 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/couchbase/query/accounting/gometrics"
+	"github.com/couchbase/query/auth"
+	"github.com/couchbase/query/datastore"
+	"github.com/couchbase/query/errors"
+	"github.com/couchbase/query/server"
+)
+
+// a datastore that only authorizes callers with credentials
+type credentialsDatastore struct {
+	datastore.Datastore
+}
+
+func (this *credentialsDatastore) Authorize(privs *auth.Privileges, creds auth.Credentials,
+	req *http.Request) (auth.AuthenticatedUsers, errors.Error) {
+	if len(creds) == 0 {
+		return nil, errors.NewDatastoreInsufficientCredentials("no credentials")
+	}
+	return this.Datastore.Authorize(privs, creds, req)
+}
+
+func doReadyRequest(t *testing.T, endpoint *HttpEndpoint, user string) (int, map[string]map[string]interface{}) {
+	req := httptest.NewRequest("GET", adminPrefix+"/health/ready", nil)
+	if user != "" {
+		req.SetBasicAuth(user, "password")
+	}
+	w := httptest.NewRecorder()
+	endpoint.wrapAPI(w, req, doReady)
+
+	var rv struct {
+		Status string                            `json:"status"`
+		Checks map[string]map[string]interface{} `json:"checks"`
+	}
+	err := json.Unmarshal(w.Body.Bytes(), &rv)
+	if err != nil {
+		t.Fatalf("Unexpected readiness response %s: %v", w.Body.Bytes(), err)
+	}
+	if (w.Code == http.StatusOK) != (rv.Status == "ok") {
+		t.Errorf("Status %v does not match status code %v", rv.Status, w.Code)
+	}
+	return w.Code, rv.Checks
+}
+
+func TestReadiness(t *testing.T) {
+	store := test_server.query_server.Datastore()
+	srv, err := server.NewServer(store, nil, nil, gometrics.NewAccountingStore(), "default",
+		false, make(server.RequestChannel, 10), make(server.RequestChannel, 10), 4, 4, 0, 0,
+		false, false, false, true, server.ProfOff, false)
+	if err != nil {
+		t.Fatalf("Cannot create server: %v", err)
+	}
+	endpoint := &HttpEndpoint{server: srv}
+
+	code, checks := doReadyRequest(t, endpoint, "")
+	if code != http.StatusOK || len(checks) != 0 {
+		t.Errorf("Expected a server without health checks to be ready, got %v, %v", code, checks)
+	}
+
+	// no process fits in one byte
+	err = srv.RegisterHealthChecks(1)
+	if err != nil {
+		t.Fatalf("Cannot register health checks: %v", err)
+	}
+
+	code, checks = doReadyRequest(t, endpoint, "admin")
+	if code != http.StatusServiceUnavailable || len(checks) != 4 {
+		t.Fatalf("Expected 4 checks and status 503, got %v, %v", code, checks)
+	}
+	for _, name := range []string{server.HEALTH_DATASTORE, server.HEALTH_INDEXERS, server.HEALTH_QUEUES} {
+		if checks[name]["healthy"] != true || checks[name]["message"] == nil {
+			t.Errorf("Expected %v to be healthy, with details, got %v", name, checks[name])
+		}
+	}
+	if checks[server.HEALTH_MEMORY]["healthy"] != false || checks[server.HEALTH_MEMORY]["message"] == nil {
+		t.Errorf("Expected memory to be unhealthy, with details, got %v", checks[server.HEALTH_MEMORY])
+	}
+
+	// callers without credentials only learn whether each check passed
+	datastore.SetDatastore(&credentialsDatastore{store})
+	defer datastore.SetDatastore(store)
+
+	code, checks = doReadyRequest(t, endpoint, "")
+	if code != http.StatusServiceUnavailable || len(checks) != 4 {
+		t.Fatalf("Expected 4 checks and status 503, got %v, %v", code, checks)
+	}
+	for name, check := range checks {
+		if len(check) != 1 || check["healthy"] != (name != server.HEALTH_MEMORY) {
+			t.Errorf("Expected %v to only say whether it is healthy, got %v", name, check)
+		}
+	}
+}
@@ -289,14 +289,12 @@ func (this *activeHttpRequests) Get(id string, f func(server.Request)) errors.Er
 }
 
 func (this *activeHttpRequests) Delete(id string, stop bool) bool {
-	this.cache.Delete(id, func(e interface{}) {
+	return this.cache.Delete(id, func(e interface{}) {
 		if stop {
 			req := e.(*httpRequest)
 			req.Stop(server.STOPPED)
 		}
 	})
-
-	return true
 }
 
 func (this *activeHttpRequests) Count() (int, errors.Error) {
@@ -88,6 +88,8 @@ type Request interface {
 	UserAgent() string
 	SetTimings(o execution.Operator)
 	GetTimings() execution.Operator
+	SetPlan(p *plan.Prepared)
+	Plan() *plan.Prepared
 	OriginalHttpRequest() *http.Request
 	IsAdHoc() bool
 	IndexApiVersion() int
@@ -199,12 +201,14 @@ type BaseRequest struct {
 	state           State
 	results         value.ValueChannel
 	errors          errors.ErrorChannel
+	errorCodes      []int
 	warnings        errors.ErrorChannel
 	closeNotify     chan bool          // implement http.CloseNotifier
 	stopResult      chan bool          // stop consuming results
 	stopExecute     chan bool          // stop executing request
 	stopOperator    execution.Operator // notified when request execution stops
 	timings         execution.Operator
+	plan            *plan.Prepared
 	controls        value.Tristate
 	profile         Profile
 	indexApiVersion int    // Index API version
@@ -484,6 +488,23 @@ func (this *BaseRequest) Fatal(err errors.Error) {
 }
 
 func (this *BaseRequest) Error(err errors.Error) {
+
+	// errors may be dropped from the channel, but we still want to
+	// know what went wrong when it comes to logging the request
+	// only distinct codes are kept, so that the list stays small
+	code := int(err.Code())
+	this.Lock()
+	found := false
+	for _, c := range this.errorCodes {
+		if c == code {
+			found = true
+			break
+		}
+	}
+	if !found {
+		this.errorCodes = append(this.errorCodes, code)
+	}
+	this.Unlock()
 	select {
 	case this.errors <- err:
 	default:
@@ -588,6 +609,15 @@ func (this *BaseRequest) GetTimings() execution.Operator {
 	return this.timings
 }
 
+// the plan being executed, whether the statement is prepared or not
+func (this *BaseRequest) SetPlan(p *plan.Prepared) {
+	this.plan = p
+}
+
+func (this *BaseRequest) Plan() *plan.Prepared {
+	return this.plan
+}
+
 func (this *BaseRequest) SetControls(c value.Tristate) {
 	this.controls = c
 }
@@ -632,6 +662,12 @@ func (this *BaseRequest) Errors() errors.ErrorChannel {
 	return this.errors
 }
 
+func (this *BaseRequest) ErrorCodes() []int {
+	this.RLock()
+	defer this.RUnlock()
+	return this.errorCodes
+}
+
 func (this *BaseRequest) Warnings() errors.ErrorChannel {
 	return this.warnings
 }
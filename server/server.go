@@ -99,6 +99,7 @@ type Server struct {
 	srvprofile  Profile
 	srvcontrols bool
 	whitelist   map[string]interface{}
+	copyAccess  map[string]interface{}
 }
 
 // Default Keep Alive Length
@@ -186,6 +187,15 @@ func (this *Server) GetWhitelist() map[string]interface{} {
 	return this.whitelist
 }
 
+// The whitelist of the directories COPY statements may use
+func (this *Server) SetCopyWhitelist(val map[string]interface{}) {
+	this.copyAccess = val
+}
+
+func (this *Server) GetCopyWhitelist() map[string]interface{} {
+	return this.copyAccess
+}
+
 func (this *Server) ConfigurationStore() clustering.ConfigurationStore {
 	return this.configstore
 }
@@ -542,6 +552,7 @@ func (this *Server) serviceRequest(request Request) {
 		prepared, request.IndexApiVersion(), request.FeatureControls())
 
 	context.SetWhitelist(this.whitelist)
+	context.SetCopyWhitelist(this.copyAccess)
 
 	build := time.Now()
 	operator, er := execution.Build(prepared, context)
@@ -556,6 +567,7 @@ func (this *Server) serviceRequest(request Request) {
 
 	operator.SetRoot()
 	request.SetTimings(operator)
+	request.SetPlan(prepared)
 	request.Output().AddPhaseTime(execution.INSTANTIATE, time.Since(build))
 
 	if request.State() == FATAL {
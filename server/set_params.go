@@ -24,6 +24,10 @@ import (
 
 type Setter func(*Server, interface{})
 
+func init() {
+	paramSettings.CheckCompletedQualifiers = RequestsCheckQualifiers
+}
+
 var _SETTERS = map[string]Setter{
 	paramSettings.CPUPROFILE: func(s *Server, o interface{}) {
 		value, _ := o.(string)
@@ -81,6 +85,17 @@ var _SETTERS = map[string]Setter{
 		value, _ := o.(float64)
 		RequestsSetLimit(int(value))
 	},
+	paramSettings.CMPQUALIFIERS: func(s *Server, o interface{}) {
+		value, _ := o.(map[string]interface{})
+		err := RequestsProcessQualifiers(value)
+		if err != nil {
+			logging.Errorf("Cannot change completed requests qualifiers: %v", err)
+		}
+	},
+	paramSettings.CMPSLOW: func(s *Server, o interface{}) {
+		value, _ := o.(float64)
+		RequestsSetSlowThreshold(int(value))
+	},
 	paramSettings.PRPLIMIT: func(s *Server, o interface{}) {
 		value, _ := o.(float64)
 		prepareds.PreparedsSetLimit(int(value))
@@ -121,13 +136,48 @@ func ProcessSettings(settings map[string]interface{}, srvr *Server) errors.Error
 		}
 	}
 	for setting, value := range settings {
-		set_it := _SETTERS[setting]
-		set_it(srvr, value)
-		logging.Infof("Query Configuration changed for %v. New value is %v", setting, value)
+
+		// qualifiers go last, so that they override the threshold setting
+		if setting == paramSettings.CMPQUALIFIERS {
+			continue
+		}
+		setSetting(srvr, setting, value)
+	}
+	if value, ok := settings[paramSettings.CMPQUALIFIERS]; ok {
+		setSetting(srvr, paramSettings.CMPQUALIFIERS, value)
 	}
 	return nil
 }
 
+func setSetting(srvr *Server, setting string, value interface{}) {
+	set_it := _SETTERS[setting]
+	set_it(srvr, value)
+	logging.Infof("Query Configuration changed for %v. New value is %v", setting, value)
+}
+
+// settings changed at runtime are saved, so that they survive restarts
+func PersistSettings(settings map[string]interface{}) {
+	saved := make(map[string]interface{}, len(settings))
+	for setting, value := range settings {
+		saved[setting] = value
+	}
+
+	// qualifiers are changed incrementally: save the outcome, replacing
+	// whatever qualifiers are in place when the settings are loaded.
+	// The threshold is one of them, and a stale list would override it
+	_, changed := saved[paramSettings.CMPQUALIFIERS]
+	_, thresholdChanged := saved[paramSettings.CMPTHRESHOLD]
+	if changed || thresholdChanged {
+		qualifiers := RequestsQualifiersSettings()
+		qualifiers["replace"] = true
+		saved[paramSettings.CMPQUALIFIERS] = qualifiers
+	}
+	err := paramSettings.SaveSettings(saved)
+	if err != nil {
+		logging.Errorp("Cannot save settings", logging.Pair{"error", err})
+	}
+}
+
 func SetParamValuesForAll(cfg queryMetakv.Config, srvr *Server) {
 	// Convert value.Value - type OBJECT to map[string]interface{}
 	// Range through the config changes and put together 2 lists.
@@ -150,6 +200,10 @@ func SetParamValuesForAll(cfg queryMetakv.Config, srvr *Server) {
 				// Set the whitelist value to pass to context
 				srvr.SetWhitelist(val.(map[string]interface{}))
 				logging.Infof("New Value for curl whitelist <ud>%v</ud>", val)
+			} else if ok && paramName == "copy_whitelist" {
+				// Set the COPY whitelist value to pass to context
+				srvr.SetCopyWhitelist(val.(map[string]interface{}))
+				logging.Infof("New Value for copy whitelist <ud>%v</ud>", val)
 			} else {
 				querySettings[key] = val
 			}
@@ -175,6 +229,8 @@ func SetParamValuesForAll(cfg queryMetakv.Config, srvr *Server) {
 
 	if len(querySettings) > 0 {
 		// Set the query values
-		ProcessSettings(querySettings, srvr)
+		if ProcessSettings(querySettings, srvr) == nil {
+			PersistSettings(querySettings)
+		}
 	}
 }
@@ -10,6 +10,8 @@
 package settings
 
 import (
+	"regexp"
+
 	"github.com/couchbase/query/errors"
 	"github.com/couchbase/query/logging"
 )
@@ -29,6 +31,8 @@ const (
 	TIMEOUTSETTING  = "timeout"
 	CMPTHRESHOLD    = "completed-threshold"
 	CMPLIMIT        = "completed-limit"
+	CMPQUALIFIERS   = "completed"
+	CMPSLOW         = "completed-slow-threshold"
 	PRPLIMIT        = "prepared-limit"
 	PRETTY          = "pretty"
 	MAXINDEXAPI     = "max-index-api"
@@ -54,6 +58,8 @@ var CHECKERS = map[string]Checker{
 	TIMEOUTSETTING:  checkNumber,
 	CMPTHRESHOLD:    checkNumber,
 	CMPLIMIT:        checkNumber,
+	CMPQUALIFIERS:   checkCompleted,
+	CMPSLOW:         checkNumber,
 	PRPLIMIT:        checkPositiveInteger,
 	PRETTY:          checkBool,
 	MAXINDEXAPI:     checkNumber,
@@ -93,3 +99,84 @@ func checkLogLevel(val interface{}) (bool, errors.Error) {
 	_, ok := logging.ParseLevel(level)
 	return ok, nil
 }
+
+// completed requests qualifiers, by the types of condition they take
+var _COMPLETED_QUALIFIERS = map[string]Checker{
+	"threshold":   checkNumber,
+	"error":       checkNumber,
+	"user":        checkString,
+	"statement":   checkRegexp,
+	"resultCount": checkNumber,
+	"resultSize":  checkNumber,
+	"sample":      checkPercentage,
+}
+
+func checkCompleted(val interface{}) (bool, errors.Error) {
+	qualifiers, ok := val.(map[string]interface{})
+	if !ok {
+		return false, nil
+	}
+	for name, cond := range qualifiers {
+		if name == "combine" {
+			if cond != "and" && cond != "or" {
+				return false, errors.NewCompletedQualifierInvalidArgument(name, cond)
+			}
+			continue
+		}
+		if name == "replace" {
+			if _, ok := cond.(bool); !ok {
+				return false, errors.NewCompletedQualifierInvalidArgument(name, cond)
+			}
+			continue
+		}
+		removing := false
+		if len(name) > 0 && (name[0] == '+' || name[0] == '-') {
+			removing = name[0] == '-'
+			name = name[1:]
+		}
+		check_it, ok := _COMPLETED_QUALIFIERS[name]
+		if !ok {
+			return false, errors.NewCompletedQualifierUnknown(name)
+		}
+
+		// unique qualifiers are removed irrespective of the condition
+		if removing && (cond == nil || cond == true) {
+			continue
+		}
+		conds, ok := cond.([]interface{})
+		if !ok {
+			conds = []interface{}{cond}
+		}
+		for _, c := range conds {
+			ok, _ = check_it(c)
+			if !ok {
+				return false, errors.NewCompletedQualifierInvalidArgument(name, c)
+			}
+		}
+	}
+
+	// the changes must also apply to the current qualifiers
+	if CheckCompletedQualifiers != nil {
+		if err := CheckCompletedQualifiers(qualifiers); err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// Set by the server, which owns the completed requests qualifiers
+var CheckCompletedQualifiers func(map[string]interface{}) errors.Error
+
+func checkRegexp(val interface{}) (bool, errors.Error) {
+	pattern, ok := val.(string)
+	if !ok {
+		return false, nil
+	}
+	_, err := regexp.Compile(pattern)
+	return err == nil, nil
+}
+
+func checkPercentage(val interface{}) (bool, errors.Error) {
+	v, ok := val.(float64)
+	return ok && v >= 0 && v <= 100, nil
+}
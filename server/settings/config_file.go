@@ -0,0 +1,192 @@
+//  Copyright (c) 2018 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package settings
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/couchbase/query/errors"
+)
+
+// where a setting's current value comes from
+const (
+	SOURCE_DEFAULT = "default"
+	SOURCE_FILE    = "file"
+	SOURCE_FLAG    = "flag"
+	SOURCE_RUNTIME = "runtime"
+)
+
+// the configuration file holds command line flags, in their command line format,
+// and settings that have no flag, in their /admin/settings format
+type configFile struct {
+	sync.Mutex
+	path    string
+	values  map[string]interface{}
+	sources map[string]string
+}
+
+var config = &configFile{
+	values:  map[string]interface{}{},
+	sources: map[string]string{},
+}
+
+// settings whose /admin/settings format differs from that of their flag
+var _TO_FLAG = map[string]func(interface{}) interface{}{
+	TIMEOUTSETTING: func(val interface{}) interface{} {
+		v, _ := val.(float64)
+		return time.Duration(v).String()
+	},
+}
+
+// Load the configuration file and apply it to those flags that have not been
+// set on the command line: flags take precedence over the file, which in turn
+// takes precedence over defaults.
+// The settings that are not flags are returned, to be processed once the
+// server is running.
+// A missing file is not an error: it will be created when settings change.
+// Only JSON is supported, and the file must have a .json extension.
+func LoadConfigFile(path string, flags *flag.FlagSet) (map[string]interface{}, errors.Error) {
+	config.Lock()
+	defer config.Unlock()
+
+	if ext := filepath.Ext(path); !strings.EqualFold(ext, ".json") {
+		return nil, errors.NewAdminConfigFileError(fmt.Errorf("unsupported format %q: configuration files must be JSON, with a .json extension", ext), path)
+	}
+	config.path = path
+	flags.Visit(func(f *flag.Flag) {
+		config.sources[f.Name] = SOURCE_FLAG
+	})
+
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, errors.NewAdminConfigFileError(err, path)
+	}
+
+	var values map[string]interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	err = decoder.Decode(&values)
+	if err != nil {
+		return nil, errors.NewAdminConfigFileError(err, path)
+	}
+
+	settings := map[string]interface{}{}
+	for name, val := range values {
+		if flags.Lookup(name) != nil {
+			if config.sources[name] == SOURCE_FLAG {
+				continue
+			}
+			err = flags.Set(name, flagValue(val))
+			if err != nil {
+				return nil, errors.NewAdminSettingTypeError(name, val)
+			}
+		} else if _, ok := CHECKERS[name]; ok {
+
+			// settings are checked as numbers, not as json.Number
+			var setting interface{}
+			b, _ := json.Marshal(val)
+			json.Unmarshal(b, &setting)
+			settings[name] = setting
+		} else {
+			return nil, errors.NewAdminUnknownSettingError(name)
+		}
+		config.sources[name] = SOURCE_FILE
+	}
+	config.values = values
+	return settings, nil
+}
+
+func flagValue(val interface{}) string {
+	switch val := val.(type) {
+	case string:
+		return val
+	case json.Number:
+		return val.String()
+	case bool:
+		return fmt.Sprint(val)
+	}
+	b, _ := json.Marshal(val)
+	return string(b)
+}
+
+// Record settings changed at runtime, and write them back to the
+// configuration file, if there is one
+func SaveSettings(settings map[string]interface{}) errors.Error {
+	config.Lock()
+	defer config.Unlock()
+
+	for name, val := range settings {
+		config.sources[name] = SOURCE_RUNTIME
+		toFlag, ok := _TO_FLAG[name]
+		if ok {
+			val = toFlag(val)
+		}
+		config.values[name] = val
+	}
+	if config.path == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(config.values, "", "    ")
+	if err != nil {
+		return errors.NewAdminConfigFileError(err, config.path)
+	}
+	err = writeFileAtomic(config.path, data)
+	if err != nil {
+		return errors.NewAdminConfigFileError(err, config.path)
+	}
+	return nil
+}
+
+// the new contents are synced to a temporary file, which then replaces
+// the old, so that a crash leaves either one or the other
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(data)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if cErr := tmp.Close(); err == nil {
+		err = cErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+	return err
+}
+
+// where the value of a setting comes from
+func Source(name string) string {
+	config.Lock()
+	defer config.Unlock()
+
+	source, ok := config.sources[name]
+	if !ok {
+		return SOURCE_DEFAULT
+	}
+	return source
+}
@@ -0,0 +1,172 @@
+//  Copyright (c) 2018 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package settings
+
+import (
+	"encoding/json"
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func resetConfig() {
+	config.path = ""
+	config.values = map[string]interface{}{}
+	config.sources = map[string]string{}
+}
+
+func writeConfig(t *testing.T, dir string, name string, values map[string]interface{}) string {
+	data, err := json.Marshal(values)
+	if err != nil {
+		t.Fatalf("Cannot marshal configuration: %v", err)
+	}
+	path := filepath.Join(dir, name)
+	err = ioutil.WriteFile(path, data, 0600)
+	if err != nil {
+		t.Fatalf("Cannot write configuration: %v", err)
+	}
+	return path
+}
+
+func TestLoadConfigFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "settings")
+	if err != nil {
+		t.Fatalf("Cannot create directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	resetConfig()
+
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	servicers := flags.Int("servicers", 4, "")
+	timeout := flags.Duration("timeout", 0, "")
+	pretty := flags.Bool("pretty", true, "")
+	err = flags.Parse([]string{"-servicers=8"})
+	if err != nil {
+		t.Fatalf("Cannot parse flags: %v", err)
+	}
+
+	path := writeConfig(t, dir, "config.json", map[string]interface{}{
+		"servicers":       16,
+		"timeout":         "5s",
+		"completed-limit": 1000,
+	})
+	settings, cErr := LoadConfigFile(path, flags)
+	if cErr != nil {
+		t.Fatalf("Cannot load configuration: %v", cErr)
+	}
+
+	// flag > file > default
+	if *servicers != 8 || Source("servicers") != SOURCE_FLAG {
+		t.Errorf("Expected servicers 8 from the command line, got %v from %v", *servicers, Source("servicers"))
+	}
+	if timeout.String() != "5s" || Source("timeout") != SOURCE_FILE {
+		t.Errorf("Expected timeout 5s from the file, got %v from %v", *timeout, Source("timeout"))
+	}
+	if !*pretty || Source("pretty") != SOURCE_DEFAULT {
+		t.Errorf("Expected default pretty, got %v from %v", *pretty, Source("pretty"))
+	}
+
+	// settings without a flag are returned, as numbers
+	if len(settings) != 1 || settings["completed-limit"] != float64(1000) ||
+		Source("completed-limit") != SOURCE_FILE {
+		t.Errorf("Expected completed-limit 1000 from the file, got %v", settings)
+	}
+}
+
+func TestLoadConfigFileErrors(t *testing.T) {
+	dir, err := ioutil.TempDir("", "settings")
+	if err != nil {
+		t.Fatalf("Cannot create directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	resetConfig()
+	settings, cErr := LoadConfigFile(filepath.Join(dir, "missing.json"), flag.NewFlagSet("test", flag.ContinueOnError))
+	if cErr != nil || settings != nil {
+		t.Errorf("Expected a missing file to be ignored, got %v, %v", settings, cErr)
+	}
+
+	resetConfig()
+	path := writeConfig(t, dir, "config.yaml", map[string]interface{}{})
+	_, cErr = LoadConfigFile(path, flag.NewFlagSet("test", flag.ContinueOnError))
+	if cErr == nil || cErr.Code() != 2240 {
+		t.Errorf("Expected a .yaml file to be rejected, got %v", cErr)
+	}
+
+	resetConfig()
+	path = writeConfig(t, dir, "unknown.json", map[string]interface{}{"no-such-setting": 1})
+	_, cErr = LoadConfigFile(path, flag.NewFlagSet("test", flag.ContinueOnError))
+	if cErr == nil {
+		t.Errorf("Expected an unknown setting to be rejected")
+	}
+}
+
+func TestSaveSettings(t *testing.T) {
+	dir, err := ioutil.TempDir("", "settings")
+	if err != nil {
+		t.Fatalf("Cannot create directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	resetConfig()
+
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	flags.Int("servicers", 4, "")
+	flags.Duration("timeout", 0, "")
+	path := writeConfig(t, dir, "config.json", map[string]interface{}{"servicers": 16})
+	_, cErr := LoadConfigFile(path, flags)
+	if cErr != nil {
+		t.Fatalf("Cannot load configuration: %v", cErr)
+	}
+
+	cErr = SaveSettings(map[string]interface{}{
+		"timeout":         float64(2000000000),
+		"completed-limit": float64(500),
+	})
+	if cErr != nil {
+		t.Fatalf("Cannot save settings: %v", cErr)
+	}
+	if Source("timeout") != SOURCE_RUNTIME || Source("servicers") != SOURCE_FILE {
+		t.Errorf("Expected runtime timeout and file servicers, got %v and %v", Source("timeout"), Source("servicers"))
+	}
+
+	// the file keeps what it had, and timeout is saved in its flag format
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Cannot read configuration: %v", err)
+	}
+	var values map[string]interface{}
+	err = json.Unmarshal(data, &values)
+	if err != nil {
+		t.Fatalf("Cannot unmarshal configuration: %v", err)
+	}
+	if len(values) != 3 || values["servicers"] != float64(16) || values["timeout"] != "2s" ||
+		values["completed-limit"] != float64(500) {
+		t.Errorf("Unexpected saved configuration %s", data)
+	}
+
+	// and loads back
+	resetConfig()
+	flags = flag.NewFlagSet("test", flag.ContinueOnError)
+	flags.Int("servicers", 4, "")
+	timeout := flags.Duration("timeout", 0, "")
+	settings, cErr := LoadConfigFile(path, flags)
+	if cErr != nil || timeout.String() != "2s" || settings["completed-limit"] != float64(500) {
+		t.Errorf("Expected saved settings to load, got %v, %v, %v", *timeout, settings, cErr)
+	}
+
+	// without a file, settings are only recorded
+	resetConfig()
+	cErr = SaveSettings(map[string]interface{}{"pretty": false})
+	if cErr != nil || Source("pretty") != SOURCE_RUNTIME {
+		t.Errorf("Expected pretty to be recorded as a runtime setting, got %v, %v", Source("pretty"), cErr)
+	}
+}
@@ -35,6 +35,7 @@ var INDEXERPARAM = map[string]string{
 
 var GLOBALPARAM = map[string]string{
 	"query.settings.curl_whitelist": "curl_whitelist",
+	"query.settings.copy_whitelist": "copy_whitelist",
 }
 
 type Config value.Value
@@ -14,6 +14,7 @@ import (
 	http_base "net/http"
 	"os"
 	"runtime"
+	"sync"
 	"time"
 
 	"github.com/couchbase/query/accounting"
@@ -203,7 +204,7 @@ func Run(mockServer *MockServer, p bool, q string) ([]interface{}, []errors.Erro
 func Start(site, pool string) *MockServer {
 
 	mockServer := &MockServer{}
-	ds, err := resolver.NewDatastore("dir:./json")
+	ds, err := testDatastore()
 	if err != nil {
 		logging.Errorp(err.Error())
 		os.Exit(1)
@@ -260,3 +261,94 @@ func Start(site, pool string) *MockServer {
 	mockServer.dstore = ds
 	return mockServer
 }
+
+var loadOnce sync.Once
+
+// The suite runs against the json directory, unless N1QL_TEST_DATASTORE
+// names another datastore, such as sqlite:PATH, in which case the
+// documents of the json directory are first copied into it.
+func testDatastore() (datastore.Datastore, errors.Error) {
+	dir, err := resolver.NewDatastore("dir:./json")
+	uri := os.Getenv("N1QL_TEST_DATASTORE")
+	if err != nil || uri == "" {
+		return dir, err
+	}
+
+	ds, err := resolver.NewDatastore(uri)
+	if err != nil {
+		return nil, err
+	}
+
+	loadOnce.Do(func() {
+		err = loadDatastore(dir, ds)
+	})
+	return ds, err
+}
+
+func loadDatastore(from, to datastore.Datastore) errors.Error {
+	fromNamespace, err := from.NamespaceByName("default")
+	if err != nil {
+		return err
+	}
+
+	toNamespace, err := to.NamespaceByName("default")
+	if err != nil {
+		return err
+	}
+
+	names, err := fromNamespace.KeyspaceNames()
+	if err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		fromKeyspace, err := fromNamespace.KeyspaceByName(name)
+		if err != nil {
+			return err
+		}
+
+		toKeyspace, err := toNamespace.KeyspaceByName(name)
+		if err != nil {
+			return err
+		}
+
+		indexer, err := fromKeyspace.Indexer(datastore.DEFAULT)
+		if err != nil {
+			return err
+		}
+
+		primary, err := indexer.PrimaryIndexes()
+		if err != nil {
+			return err
+		}
+
+		conn := datastore.NewIndexConnection(datastore.NULL_CONTEXT)
+		go primary[0].ScanEntries("", 0, datastore.UNBOUNDED, nil, conn)
+
+		keys := make([]string, 0, 64)
+		for entry := range conn.EntryChannel() {
+			keys = append(keys, entry.PrimaryKey)
+		}
+
+		docs, errs := fromKeyspace.Fetch(keys, datastore.NULL_QUERY_CONTEXT, nil)
+		if len(errs) > 0 {
+			return errs[0]
+		}
+
+		if len(docs) == 0 {
+			continue
+		}
+
+		pairs := make([]value.Pair, len(docs))
+		for i, doc := range docs {
+			pairs[i] = value.Pair{Name: doc.Name, Value: doc.Value}
+		}
+
+		_, err = toKeyspace.Upsert(pairs)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -26,6 +26,7 @@ type Bag struct {
 	booleans map[bool]*BagEntry
 	floats   map[float64]*BagEntry
 	ints     map[int64]*BagEntry
+	decimals map[string]*BagEntry
 	strings  map[string]*BagEntry
 	arrays   map[string]*BagEntry
 	objects  map[string]*BagEntry
@@ -44,6 +45,7 @@ func NewBag(objectCap int) *Bag {
 		booleans: make(map[bool]*BagEntry, 2),
 		floats:   make(map[float64]*BagEntry, mapCap),
 		ints:     make(map[int64]*BagEntry, mapCap),
+		decimals: make(map[string]*BagEntry, _MAP_CAP),
 		strings:  make(map[string]*BagEntry, mapCap),
 		arrays:   make(map[string]*BagEntry, _MAP_CAP),
 		objects:  make(map[string]*BagEntry, objectCap),
@@ -126,6 +128,33 @@ func (this *Bag) Put(key, item Value) {
 			}
 
 			entry.Count++
+		case *decimalValue:
+			switch i, f, d, kind := num.setKey(); kind {
+			case _KEY_INT:
+				entry := this.ints[i]
+				if entry == nil {
+					entry = &BagEntry{Value: item}
+					this.ints[i] = entry
+				}
+
+				entry.Count++
+			case _KEY_FLOAT:
+				entry := this.floats[f]
+				if entry == nil {
+					entry = &BagEntry{Value: item}
+					this.floats[f] = entry
+				}
+
+				entry.Count++
+			default:
+				entry := this.decimals[d]
+				if entry == nil {
+					entry = &BagEntry{Value: item}
+					this.decimals[d] = entry
+				}
+
+				entry.Count++
+			}
 		default:
 			panic(fmt.Sprintf("Unsupported value type %T.", key))
 		}
@@ -194,6 +223,15 @@ func (this *Bag) Entry(key Value) *BagEntry {
 			}
 		case intValue:
 			return this.ints[int64(num)]
+		case *decimalValue:
+			switch i, f, d, kind := num.setKey(); kind {
+			case _KEY_INT:
+				return this.ints[i]
+			case _KEY_FLOAT:
+				return this.floats[f]
+			default:
+				return this.decimals[d]
+			}
 		default:
 			panic(fmt.Sprintf("Unsupported value type %T.", key))
 		}
@@ -212,8 +250,8 @@ func (this *Bag) Entry(key Value) *BagEntry {
 }
 
 func (this *Bag) DistinctLen() int {
-	rv := len(this.booleans) + len(this.floats) + len(this.ints) + len(this.strings) +
-		len(this.arrays) + len(this.objects) + len(this.binaries)
+	rv := len(this.booleans) + len(this.floats) + len(this.ints) + len(this.decimals) +
+		len(this.strings) + len(this.arrays) + len(this.objects) + len(this.binaries)
 
 	if this.nills != nil {
 		rv++
@@ -257,6 +295,10 @@ func (this *Bag) Entries() []*BagEntry {
 		rv = append(rv, av)
 	}
 
+	for _, av := range this.decimals {
+		rv = append(rv, av)
+	}
+
 	for _, av := range this.strings {
 		rv = append(rv, av)
 	}
@@ -296,6 +338,11 @@ func (this *Bag) Clear() {
 		delete(this.ints, k)
 	}
 
+	for k, _ := range this.decimals {
+		this.decimals[k] = nil
+		delete(this.decimals, k)
+	}
+
 	for k, _ := range this.strings {
 		this.strings[k] = nil
 		delete(this.strings, k)
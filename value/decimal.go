@@ -0,0 +1,746 @@
+//  Copyright (c) 2018 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package value
+
+import (
+	"io"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/couchbase/query/util"
+)
+
+/*
+A decimal NUMBER holds the exact value unscaled * 10^-scale. Decimals
+are produced by TO_DECIMAL() and never by parsing documents, so that
+ordinary numbers keep their float64 and int64 behavior. Arithmetic
+with a decimal operand is exact, and a decimal is encoded in JSON
+with all its digits, including trailing zeros up to its scale.
+
+Decimals are immutable.
+*/
+type decimalValue struct {
+	unscaled *big.Int
+	scale    int
+}
+
+/*
+Rounding modes used when reducing the scale of a decimal.
+*/
+type RoundingMode int
+
+const (
+	ROUND_HALF_UP   RoundingMode = iota // Ties away from zero
+	ROUND_HALF_EVEN                     // Ties to the even neighbor
+	ROUND_HALF_DOWN                     // Ties towards zero
+	ROUND_UP                            // Away from zero
+	ROUND_DOWN                          // Towards zero
+	ROUND_CEILING                       // Towards positive infinity
+	ROUND_FLOOR                         // Towards negative infinity
+)
+
+var _ROUNDING_MODES = map[string]RoundingMode{
+	"half_up":   ROUND_HALF_UP,
+	"half_even": ROUND_HALF_EVEN,
+	"half_down": ROUND_HALF_DOWN,
+	"up":        ROUND_UP,
+	"down":      ROUND_DOWN,
+	"ceiling":   ROUND_CEILING,
+	"floor":     ROUND_FLOOR,
+}
+
+/*
+Return the rounding mode with the given name, such as "half_even".
+*/
+func NewRoundingMode(name string) (RoundingMode, bool) {
+	mode, ok := _ROUNDING_MODES[strings.ToLower(name)]
+	return mode, ok
+}
+
+/*
+Largest scale a decimal may have, and the largest exponent accepted
+when parsing one.
+*/
+const DECIMAL_MAX_SCALE = 100
+const _DECIMAL_MAX_EXPONENT = 1000
+
+/*
+Digits added to the scale of the operands when dividing without an
+explicit scale.
+*/
+const _DECIMAL_DIV_DIGITS = 6
+
+var _BIG_TEN = big.NewInt(10)
+var _BIG_MIN_INT64 = big.NewInt(math.MinInt64)
+var _BIG_MAX_INT64 = big.NewInt(math.MaxInt64)
+
+/*
+Convert a NUMBER or a numeric STRING to a decimal. If scale is not
+negative, the decimal is rounded or padded to that scale. Returns
+NULL_VALUE if val cannot be converted.
+*/
+func NewDecimalValue(val Value, scale int, mode RoundingMode) Value {
+	if scale > DECIMAL_MAX_SCALE {
+		return NULL_VALUE
+	}
+
+	var d *decimalValue
+	switch val.Type() {
+	case NUMBER:
+		d = decimalOf(AsNumberValue(val))
+	case STRING:
+		d = parseDecimal(strings.TrimSpace(val.Actual().(string)))
+	}
+
+	if d == nil {
+		return NULL_VALUE
+	}
+
+	if scale >= 0 {
+		d = d.rescale(scale, mode)
+	}
+
+	return d
+}
+
+/*
+Return true if val is a decimal.
+*/
+func IsDecimal(val Value) bool {
+	_, ok := val.unwrap().(*decimalValue)
+	return ok
+}
+
+/*
+Divide x by y, rounding the quotient to the given scale. If scale is
+negative, the quotient is computed to a few more digits than the
+operands, and trailing zeros beyond the operands' scale are dropped.
+Returns NULL_VALUE if y is 0 or either operand is not a finite NUMBER.
+*/
+func DecimalDiv(x, y Value, scale int, mode RoundingMode) Value {
+	if x.Type() != NUMBER || y.Type() != NUMBER || scale > DECIMAL_MAX_SCALE {
+		return NULL_VALUE
+	}
+
+	dx := decimalOf(AsNumberValue(x))
+	dy := decimalOf(AsNumberValue(y))
+	if dx == nil || dy == nil || dy.unscaled.Sign() == 0 {
+		return NULL_VALUE
+	}
+
+	min := -1
+	if scale < 0 {
+		min = dx.scale
+		if dy.scale > min {
+			min = dy.scale
+		}
+
+		scale = min + _DECIMAL_DIV_DIGITS
+		if scale > DECIMAL_MAX_SCALE {
+			scale = DECIMAL_MAX_SCALE
+		}
+	}
+
+	// x / y = (ux * 10^(scale - sx + sy) / uy) * 10^-scale
+	num := new(big.Int).Set(dx.unscaled)
+	den := new(big.Int).Set(dy.unscaled)
+	exp := scale - dx.scale + dy.scale
+	if exp >= 0 {
+		num.Mul(num, pow10(exp))
+	} else {
+		den.Mul(den, pow10(-exp))
+	}
+
+	rv := &decimalValue{roundQuo(num, den, mode), scale}
+	if min >= 0 {
+		rv = rv.trim(min)
+	}
+
+	return rv
+}
+
+/*
+Return the remainder of x / y, which has the sign of x. Returns
+NULL_VALUE if y is 0 or either operand is not a finite NUMBER.
+*/
+func DecimalMod(x, y Value) Value {
+	if x.Type() != NUMBER || y.Type() != NUMBER {
+		return NULL_VALUE
+	}
+
+	dx := decimalOf(AsNumberValue(x))
+	dy := decimalOf(AsNumberValue(y))
+	if dx == nil || dy == nil || dy.unscaled.Sign() == 0 {
+		return NULL_VALUE
+	}
+
+	ux, uy, scale := align(dx, dy)
+	return &decimalValue{ux.Rem(ux, uy), scale}
+}
+
+func (this *decimalValue) String() string {
+	digits := new(big.Int).Abs(this.unscaled).String()
+	if this.scale > 0 {
+		if len(digits) <= this.scale {
+			digits = strings.Repeat("0", this.scale-len(digits)+1) + digits
+		}
+
+		point := len(digits) - this.scale
+		digits = digits[:point] + "." + digits[point:]
+	}
+
+	if this.unscaled.Sign() < 0 {
+		digits = "-" + digits
+	}
+
+	return digits
+}
+
+func (this *decimalValue) MarshalJSON() ([]byte, error) {
+	return []byte(this.String()), nil
+}
+
+func (this *decimalValue) WriteJSON(w io.Writer, prefix, indent string) error {
+	_, err := w.Write([]byte(this.String()))
+	return err
+}
+
+/*
+Type NUMBER
+*/
+func (this *decimalValue) Type() Type {
+	return NUMBER
+}
+
+/*
+The nearest float64, so that Expressions that expect float64 can
+handle decimals.
+*/
+func (this *decimalValue) Actual() interface{} {
+	return this.float64()
+}
+
+func (this *decimalValue) ActualForIndex() interface{} {
+	return this.float64()
+}
+
+func (this *decimalValue) Equals(other Value) Value {
+	other = other.unwrap()
+	switch other := other.(type) {
+	case missingValue:
+		return other
+	case *nullValue:
+		return other
+	}
+
+	if this.EquivalentTo(other) {
+		return TRUE_VALUE
+	}
+
+	return FALSE_VALUE
+}
+
+func (this *decimalValue) EquivalentTo(other Value) bool {
+	other = other.unwrap()
+	return other.Type() == NUMBER && this.Collate(other) == 0
+}
+
+/*
+Floats are compared by their shortest decimal representation, so
+that TO_DECIMAL(0.1) = 0.1. NaN and infinities sort as for floats.
+*/
+func (this *decimalValue) Collate(other Value) int {
+	other = other.unwrap()
+	if other.Type() != NUMBER {
+		return int(NUMBER - other.Type())
+	}
+
+	o := decimalOf(AsNumberValue(other))
+	if o == nil {
+		f := other.Actual().(float64)
+		if math.IsInf(f, 1) {
+			return -1
+		}
+
+		return 1
+	}
+
+	t, u, _ := align(this, o)
+	return t.Cmp(u)
+}
+
+func (this *decimalValue) Compare(other Value) Value {
+	other = other.unwrap()
+	switch other := other.(type) {
+	case missingValue:
+		return other
+	case *nullValue:
+		return other
+	default:
+		return intValue(this.Collate(other))
+	}
+}
+
+/*
+Returns true if the receiver is not 0.
+*/
+func (this *decimalValue) Truth() bool {
+	return this.unscaled.Sign() != 0
+}
+
+/*
+Return receiver
+*/
+func (this *decimalValue) Copy() Value {
+	return this
+}
+
+/*
+Return receiver
+*/
+func (this *decimalValue) CopyForUpdate() Value {
+	return this
+}
+
+/*
+Calls missingField.
+*/
+func (this *decimalValue) Field(field string) (Value, bool) {
+	return missingField(field), false
+}
+
+/*
+Not valid for NUMBER.
+*/
+func (this *decimalValue) SetField(field string, val interface{}) error {
+	return Unsettable(field)
+}
+
+/*
+Not valid for NUMBER.
+*/
+func (this *decimalValue) UnsetField(field string) error {
+	return Unsettable(field)
+}
+
+/*
+Calls missingIndex.
+*/
+func (this *decimalValue) Index(index int) (Value, bool) {
+	return missingIndex(index), false
+}
+
+/*
+Not valid for NUMBER.
+*/
+func (this *decimalValue) SetIndex(index int, val interface{}) error {
+	return Unsettable(index)
+}
+
+/*
+Returns NULL_VALUE
+*/
+func (this *decimalValue) Slice(start, end int) (Value, bool) {
+	return NULL_VALUE, false
+}
+
+/*
+Returns NULL_VALUE
+*/
+func (this *decimalValue) SliceTail(start int) (Value, bool) {
+	return NULL_VALUE, false
+}
+
+/*
+Returns the input buffer as is.
+*/
+func (this *decimalValue) Descendants(buffer []interface{}) []interface{} {
+	return buffer
+}
+
+/*
+As number has no fields, return nil.
+*/
+func (this *decimalValue) Fields() map[string]interface{} {
+	return nil
+}
+
+func (this *decimalValue) FieldNames(buffer []string) []string {
+	return nil
+}
+
+/*
+Returns the input buffer as is.
+*/
+func (this *decimalValue) DescendantPairs(buffer []util.IPair) []util.IPair {
+	return buffer
+}
+
+/*
+The smallest float greater than the receiver. After that, NUMBER is
+succeeded by STRING.
+*/
+func (this *decimalValue) Successor() Value {
+	f := this.float64()
+	for {
+		if f >= math.MaxFloat64 {
+			return EMPTY_STRING_VALUE
+		}
+
+		f = math.Nextafter(f, math.MaxFloat64)
+		if this.Collate(floatValue(f)) < 0 {
+			return floatValue(f)
+		}
+	}
+}
+
+func (this *decimalValue) Recycle() {
+}
+
+func (this *decimalValue) Tokens(set *Set, options Value) *Set {
+	set.Add(this)
+	return set
+}
+
+func (this *decimalValue) ContainsToken(token, options Value) bool {
+	return this.EquivalentTo(token)
+}
+
+func (this *decimalValue) ContainsMatchingToken(matcher MatchFunc, options Value) bool {
+	return matcher(this.float64())
+}
+
+func (this *decimalValue) unwrap() Value {
+	return this
+}
+
+/*
+NumberValue methods. Operands that are NaN or infinite fall back to
+float arithmetic.
+*/
+
+func (this *decimalValue) Add(n NumberValue) NumberValue {
+	o := decimalOf(n)
+	if o == nil {
+		return floatValue(this.float64() + n.Actual().(float64))
+	}
+
+	t, u, scale := align(this, o)
+	return &decimalValue{t.Add(t, u), scale}
+}
+
+func (this *decimalValue) IDiv(n NumberValue) Value {
+	o := decimalOf(n)
+	if o == nil || o.unscaled.Sign() == 0 {
+		return NULL_VALUE
+	}
+
+	t, u, _ := align(this, o)
+	return newDecimalInt(t.Quo(t, u))
+}
+
+func (this *decimalValue) IMod(n NumberValue) Value {
+	o := decimalOf(n)
+	if o == nil {
+		return NULL_VALUE
+	}
+
+	t := this.rescale(0, ROUND_DOWN).unscaled
+	u := o.rescale(0, ROUND_DOWN).unscaled
+	if u.Sign() == 0 {
+		return NULL_VALUE
+	}
+
+	return newDecimalInt(new(big.Int).Rem(t, u))
+}
+
+func (this *decimalValue) Mult(n NumberValue) NumberValue {
+	o := decimalOf(n)
+	if o == nil {
+		return floatValue(this.float64() * n.Actual().(float64))
+	}
+
+	return (&decimalValue{new(big.Int).Mul(this.unscaled, o.unscaled), this.scale + o.scale}).limitScale()
+}
+
+func (this *decimalValue) Neg() NumberValue {
+	return &decimalValue{new(big.Int).Neg(this.unscaled), this.scale}
+}
+
+func (this *decimalValue) Sub(n NumberValue) NumberValue {
+	o := decimalOf(n)
+	if o == nil {
+		return floatValue(this.float64() - n.Actual().(float64))
+	}
+
+	t, u, scale := align(this, o)
+	return &decimalValue{t.Sub(t, u), scale}
+}
+
+/*
+Truncates towards zero.
+*/
+func (this *decimalValue) Int64() int64 {
+	i := this.rescale(0, ROUND_DOWN).unscaled
+	if i.IsInt64() {
+		return i.Int64()
+	}
+
+	return int64(this.float64())
+}
+
+func (this *decimalValue) float64() float64 {
+	f, _ := new(big.Rat).SetFrac(this.unscaled, pow10(this.scale)).Float64()
+	return f
+}
+
+/*
+Kinds of key of a decimal in a Set or Bag.
+*/
+const (
+	_KEY_INT = iota
+	_KEY_FLOAT
+	_KEY_DECIMAL
+)
+
+/*
+The key of the receiver in a Set or Bag. A decimal that equals an
+int64 or a float64 is keyed by it, so that it matches the ints and
+floats it is equal to. Any other decimal is keyed by its trimmed
+digits, so that decimals that only differ beyond the precision of
+a float64 stay distinct.
+*/
+func (this *decimalValue) setKey() (int64, float64, string, int) {
+	t := this.trim(0)
+	if t.scale == 0 && t.unscaled.IsInt64() {
+		return t.unscaled.Int64(), 0, "", _KEY_INT
+	}
+
+	f := this.float64()
+	if o := decimalOf(floatValue(f)); o != nil {
+		x, y, _ := align(t, o)
+		if x.Cmp(y) == 0 {
+			if IsInt(f) {
+				return int64(f), 0, "", _KEY_INT
+			}
+
+			return 0, f, "", _KEY_FLOAT
+		}
+	}
+
+	return 0, 0, t.String(), _KEY_DECIMAL
+}
+
+/*
+Return the decimal rounded or padded to scale.
+*/
+func (this *decimalValue) rescale(scale int, mode RoundingMode) *decimalValue {
+	switch {
+	case scale == this.scale:
+		return this
+	case scale > this.scale:
+		return &decimalValue{new(big.Int).Mul(this.unscaled, pow10(scale-this.scale)), scale}
+	default:
+		return &decimalValue{roundQuo(this.unscaled, pow10(this.scale-scale), mode), scale}
+	}
+}
+
+/*
+Return the decimal with a scale of at most DECIMAL_MAX_SCALE, dropping
+trailing zeros and then rounding if needed.
+*/
+func (this *decimalValue) limitScale() *decimalValue {
+	if this.scale <= DECIMAL_MAX_SCALE {
+		return this
+	}
+
+	rv := this.trim(0)
+	if rv.scale > DECIMAL_MAX_SCALE {
+		rv = rv.rescale(DECIMAL_MAX_SCALE, ROUND_HALF_UP)
+	}
+
+	return rv
+}
+
+/*
+Drop trailing zeros, keeping a scale of at least min.
+*/
+func (this *decimalValue) trim(min int) *decimalValue {
+	u := new(big.Int).Set(this.unscaled)
+	scale := this.scale
+	r := new(big.Int)
+	q := new(big.Int)
+
+	for scale > min && u.Sign() != 0 {
+		q.QuoRem(u, _BIG_TEN, r)
+		if r.Sign() != 0 {
+			break
+		}
+
+		u, q = q, u
+		scale--
+	}
+
+	if u.Sign() == 0 && scale > min {
+		scale = min
+	}
+
+	return &decimalValue{u, scale}
+}
+
+/*
+Convert a number to a decimal. Floats use their shortest decimal
+representation. Returns nil for NaN, infinities and floats too small
+for DECIMAL_MAX_SCALE.
+*/
+func decimalOf(n NumberValue) *decimalValue {
+	switch n := n.(type) {
+	case *decimalValue:
+		return n
+	case intValue:
+		return &decimalValue{big.NewInt(int64(n)), 0}
+	}
+
+	f := n.Actual().(float64)
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return nil
+	}
+
+	return parseDecimal(strconv.FormatFloat(f, 'g', -1, 64))
+}
+
+/*
+Parse an optionally signed decimal literal with an optional exponent,
+such as "-12.50" or "1e-3". Returns nil if s is not such a literal,
+or if it is not zero but rounds to zero at DECIMAL_MAX_SCALE.
+*/
+func parseDecimal(s string) *decimalValue {
+	exp := 0
+	if i := strings.IndexAny(s, "eE"); i >= 0 {
+		e, err := strconv.Atoi(s[i+1:])
+		if err != nil || e > _DECIMAL_MAX_EXPONENT || e < -_DECIMAL_MAX_EXPONENT {
+			return nil
+		}
+
+		exp = e
+		s = s[:i]
+	}
+
+	neg := false
+	if len(s) > 0 && (s[0] == '-' || s[0] == '+') {
+		neg = s[0] == '-'
+		s = s[1:]
+	}
+
+	whole, frac := s, ""
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		whole, frac = s[:i], s[i+1:]
+	}
+
+	digits := whole + frac
+	if digits == "" {
+		return nil
+	}
+
+	for i := 0; i < len(digits); i++ {
+		if digits[i] < '0' || digits[i] > '9' {
+			return nil
+		}
+	}
+
+	u, _ := new(big.Int).SetString(digits, 10)
+	if neg {
+		u.Neg(u)
+	}
+
+	scale := len(frac) - exp
+	if scale < 0 {
+		u.Mul(u, pow10(-scale))
+		scale = 0
+	}
+
+	rv := (&decimalValue{u, scale}).limitScale()
+	if rv.unscaled.Sign() == 0 && u.Sign() != 0 {
+		return nil
+	}
+
+	return rv
+}
+
+/*
+An integral result as an int64 if it fits, otherwise as a decimal.
+*/
+func newDecimalInt(i *big.Int) Value {
+	if i.Cmp(_BIG_MIN_INT64) >= 0 && i.Cmp(_BIG_MAX_INT64) <= 0 {
+		return intValue(i.Int64())
+	}
+
+	return &decimalValue{i, 0}
+}
+
+/*
+Return new unscaled values of x and y at their common scale.
+*/
+func align(x, y *decimalValue) (*big.Int, *big.Int, int) {
+	switch {
+	case x.scale < y.scale:
+		return new(big.Int).Mul(x.unscaled, pow10(y.scale-x.scale)), new(big.Int).Set(y.unscaled), y.scale
+	case x.scale > y.scale:
+		return new(big.Int).Set(x.unscaled), new(big.Int).Mul(y.unscaled, pow10(x.scale-y.scale)), x.scale
+	default:
+		return new(big.Int).Set(x.unscaled), new(big.Int).Set(y.unscaled), x.scale
+	}
+}
+
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(_BIG_TEN, big.NewInt(int64(n)), nil)
+}
+
+/*
+Return num / den rounded to an integer.
+*/
+func roundQuo(num, den *big.Int, mode RoundingMode) *big.Int {
+	neg := num.Sign()*den.Sign() < 0
+	q, r := new(big.Int).QuoRem(new(big.Int).Abs(num), new(big.Int).Abs(den), new(big.Int))
+
+	if r.Sign() != 0 {
+		half := r.Lsh(r, 1).Cmp(new(big.Int).Abs(den))
+		up := false
+
+		switch mode {
+		case ROUND_HALF_UP:
+			up = half >= 0
+		case ROUND_HALF_EVEN:
+			up = half > 0 || (half == 0 && q.Bit(0) == 1)
+		case ROUND_HALF_DOWN:
+			up = half > 0
+		case ROUND_UP:
+			up = true
+		case ROUND_DOWN:
+			up = false
+		case ROUND_CEILING:
+			up = !neg
+		case ROUND_FLOOR:
+			up = neg
+		}
+
+		if up {
+			q.Add(q, big.NewInt(1))
+		}
+	}
+
+	if neg {
+		q.Neg(q)
+	}
+
+	return q
+}
@@ -0,0 +1,199 @@
+//  Copyright (c) 2018 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package value
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDecimalConversion(t *testing.T) {
+	var tests = []struct {
+		input    interface{}
+		scale    int
+		mode     RoundingMode
+		expected string
+	}{
+		{0.1, -1, ROUND_HALF_UP, "0.1"},
+		{10, 2, ROUND_HALF_UP, "10.00"},
+		{-3, -1, ROUND_HALF_UP, "-3"},
+		{"12.345", 2, ROUND_HALF_UP, "12.35"},
+		{"12.345", 2, ROUND_HALF_DOWN, "12.34"},
+		{"12.345", 2, ROUND_HALF_EVEN, "12.34"},
+		{"12.355", 2, ROUND_HALF_EVEN, "12.36"},
+		{"-12.345", 2, ROUND_HALF_UP, "-12.35"},
+		{"-12.341", 2, ROUND_UP, "-12.35"},
+		{"-12.349", 2, ROUND_DOWN, "-12.34"},
+		{"-12.341", 2, ROUND_CEILING, "-12.34"},
+		{"-12.341", 2, ROUND_FLOOR, "-12.35"},
+		{" 1.5e3 ", -1, ROUND_HALF_UP, "1500"},
+		{"-.05", -1, ROUND_HALF_UP, "-0.05"},
+		{"123456789012345678901234567890.12", -1, ROUND_HALF_UP, "123456789012345678901234567890.12"},
+	}
+
+	for _, test := range tests {
+		rv := NewDecimalValue(NewValue(test.input), test.scale, test.mode)
+		if !IsDecimal(rv) || rv.String() != test.expected {
+			t.Errorf("Expected %v to convert to %s, got %v", test.input, test.expected, rv)
+		}
+	}
+
+	for _, input := range []interface{}{"abc", "1.2.3", "", "1e", true, []interface{}{1}} {
+		rv := NewDecimalValue(NewValue(input), -1, ROUND_HALF_UP)
+		if rv.Type() != NULL {
+			t.Errorf("Expected %v to convert to NULL, got %v", input, rv)
+		}
+	}
+}
+
+func TestDecimalArithmetic(t *testing.T) {
+	dec := func(s string) NumberValue {
+		return AsNumberValue(NewDecimalValue(NewValue(s), -1, ROUND_HALF_UP))
+	}
+
+	var tests = []struct {
+		actual   Value
+		expected string
+	}{
+		{dec("0.1").Add(dec("0.2")), "0.3"},
+		{dec("0.10").Add(intValue(1)), "1.10"},
+		{intValue(1).Add(dec("0.10")), "1.10"},
+		{floatValue(0.2).Add(dec("0.1")), "0.3"},
+		{intValue(1).Sub(dec("0.01")), "0.99"},
+		{floatValue(0.3).Sub(dec("0.1")), "0.2"},
+		{dec("1.10").Mult(dec("3")), "3.30"},
+		{floatValue(1.5).Mult(dec("1.1")), "1.65"},
+		{dec("19.99").Neg(), "-19.99"},
+		{dec("7.5").IDiv(intValue(2)), "3"},
+		{dec("7.5").IMod(intValue(2)), "1"},
+		{DecimalDiv(dec("10.00"), intValue(3), -1, ROUND_HALF_UP), "3.33333333"},
+		{DecimalDiv(dec("0.30"), intValue(2), -1, ROUND_HALF_UP), "0.15"},
+		{DecimalDiv(dec("1"), intValue(4), -1, ROUND_HALF_UP), "0.25"},
+		{DecimalDiv(dec("2"), intValue(3), 2, ROUND_DOWN), "0.66"},
+		{DecimalDiv(dec("-2"), intValue(3), 2, ROUND_HALF_UP), "-0.67"},
+		{DecimalMod(dec("-7.5"), dec("2")), "-1.5"},
+	}
+
+	for i, test := range tests {
+		if !IsDecimal(test.actual) && test.actual.Type() != NUMBER {
+			t.Errorf("Test %d: expected a number, got %v", i, test.actual)
+		} else if test.actual.String() != test.expected {
+			t.Errorf("Test %d: expected %s, got %v", i, test.expected, test.actual)
+		}
+	}
+
+	if rv := DecimalDiv(dec("1"), intValue(0), -1, ROUND_HALF_UP); rv.Type() != NULL {
+		t.Errorf("Expected division by 0 to be NULL, got %v", rv)
+	}
+}
+
+func TestDecimalScale(t *testing.T) {
+	dec := func(s string) NumberValue {
+		return AsNumberValue(NewDecimalValue(NewValue(s), -1, ROUND_HALF_UP))
+	}
+
+	// products are rounded to DECIMAL_MAX_SCALE
+	thirds := dec("0." + strings.Repeat("3", 60))
+	rv := thirds.Mult(thirds)
+	expected := "0." + strings.Repeat("1", 59) + "0" + strings.Repeat("8", 39) + "9"
+	if !IsDecimal(rv) || rv.String() != expected {
+		t.Errorf("Expected %s, got %v", expected, rv)
+	}
+
+	rv = dec("1e-60").Mult(dec("1.5e-40"))
+	if !IsDecimal(rv) || rv.String() != "0."+strings.Repeat("0", 99)+"2" {
+		t.Errorf("Expected a product rounded to %d digits, got %v", DECIMAL_MAX_SCALE, rv)
+	}
+
+	// floats too small for a decimal are not rounded to 0
+	if d := decimalOf(floatValue(1e-150)); d != nil {
+		t.Errorf("Expected no decimal for 1e-150, got %v", d)
+	}
+	if d := decimalOf(floatValue(1e-100)); d == nil || d.String() != "0."+strings.Repeat("0", 99)+"1" {
+		t.Errorf("Expected a decimal for 1e-100, got %v", d)
+	}
+
+	rv = dec("0").Add(floatValue(1e-150))
+	if IsDecimal(rv) || rv.Actual() != 1e-150 {
+		t.Errorf("Expected 0 + 1e-150 to be the float 1e-150, got %v", rv)
+	}
+
+	rv = dec("2").Mult(floatValue(-1e-150))
+	if IsDecimal(rv) || rv.Actual() != -2e-150 {
+		t.Errorf("Expected 2 * -1e-150 to be the float -2e-150, got %v", rv)
+	}
+
+	if rv := NewDecimalValue(NewValue(1e-150), -1, ROUND_HALF_UP); rv.Type() != NULL {
+		t.Errorf("Expected 1e-150 to convert to NULL, got %v", rv)
+	}
+}
+
+func TestDecimalCollation(t *testing.T) {
+	dec := func(s string) Value {
+		return NewDecimalValue(NewValue(s), -1, ROUND_HALF_UP)
+	}
+
+	if !dec("0.1").Equals(NewValue(0.1)).Truth() || !NewValue(0.1).Equals(dec("0.1")).Truth() {
+		t.Errorf("Expected decimal 0.1 to equal float 0.1")
+	}
+
+	if !dec("3.00").EquivalentTo(NewValue(3)) || !NewValue(3).EquivalentTo(dec("3.00")) {
+		t.Errorf("Expected decimal 3.00 to be equivalent to 3")
+	}
+
+	ordered := []Value{NewValue(-1), dec("-0.5"), dec("0"), NewValue(0.25), dec("0.30"), NewValue(1), dec("1.000000000000000000001"), NewValue("a")}
+	for i := 1; i < len(ordered); i++ {
+		if ordered[i-1].Collate(ordered[i]) >= 0 || ordered[i].Collate(ordered[i-1]) <= 0 {
+			t.Errorf("Expected %v to collate before %v", ordered[i-1], ordered[i])
+		}
+	}
+
+	succ := dec("0.1").Successor()
+	if dec("0.1").Collate(succ) >= 0 {
+		t.Errorf("Expected successor %v to collate after 0.1", succ)
+	}
+
+	set := NewSet(8, true)
+	set.Add(dec("3.00"))
+	set.Add(NewValue(3))
+	set.Add(dec("0.5"))
+	set.Add(NewValue(0.5))
+	set.Add(dec("0.25"))
+	if set.Len() != 3 || !set.Has(NewValue(0.25)) {
+		t.Errorf("Expected 3 distinct numbers, got %v", set.Actuals())
+	}
+
+	set.Add(dec("1.000000000000000000001"))
+	set.Add(dec("1.000000000000000000002"))
+	set.Add(dec("1.0000000000000000000020"))
+	if set.Len() != 5 || !set.Has(dec("1.00000000000000000000100")) || set.Has(NewValue(1)) {
+		t.Errorf("Expected decimals beyond float64 precision to be distinct, got %v", set.Actuals())
+	}
+
+	bag := NewBag(8)
+	bag.Add(dec("0.1"))
+	bag.Add(NewValue(0.1))
+	bag.Add(dec("0.10000000000000000001"))
+	if bag.DistinctLen() != 2 || bag.Entry(NewValue(0.1)).Count != 2 {
+		t.Errorf("Expected 2 distinct numbers in bag, got %d", bag.DistinctLen())
+	}
+}
+
+func TestDecimalJSON(t *testing.T) {
+	d := NewDecimalValue(NewValue("123456789012345678.90"), -1, ROUND_HALF_UP)
+	obj := NewValue(map[string]interface{}{"amount": d})
+
+	bytes, err := obj.MarshalJSON()
+	if err != nil {
+		t.Errorf("Error marshaling decimal: %v", err)
+	} else if string(bytes) != `{"amount":123456789012345678.90}` {
+		t.Errorf("Expected exact decimal in JSON, got %s", bytes)
+	}
+}
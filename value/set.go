@@ -26,6 +26,7 @@ type Set struct {
 	booleans  map[bool]Value
 	floats    map[float64]Value
 	ints      map[int64]Value
+	decimals  map[string]Value
 	strings   map[string]Value
 	arrays    map[string]Value
 	objects   map[string]Value
@@ -43,6 +44,7 @@ func NewSet(objectCap int, collect bool) *Set {
 		booleans:  make(map[bool]Value, 2),
 		floats:    make(map[float64]Value, mapCap),
 		ints:      make(map[int64]Value, mapCap),
+		decimals:  make(map[string]Value, _MAP_CAP),
 		strings:   make(map[string]Value, mapCap),
 		arrays:    make(map[string]Value, _MAP_CAP),
 		objects:   make(map[string]Value, objectCap),
@@ -92,6 +94,15 @@ func (this *Set) Put(key, item Value) {
 			}
 		case intValue:
 			this.ints[int64(num)] = mapItem
+		case *decimalValue:
+			switch i, f, d, kind := num.setKey(); kind {
+			case _KEY_INT:
+				this.ints[i] = mapItem
+			case _KEY_FLOAT:
+				this.floats[f] = mapItem
+			default:
+				this.decimals[d] = mapItem
+			}
 		default:
 			panic(fmt.Sprintf("Unsupported value type %T.", key))
 		}
@@ -134,6 +145,15 @@ func (this *Set) Remove(key Value) {
 			}
 		case intValue:
 			delete(this.ints, int64(num))
+		case *decimalValue:
+			switch i, f, d, kind := num.setKey(); kind {
+			case _KEY_INT:
+				delete(this.ints, i)
+			case _KEY_FLOAT:
+				delete(this.floats, f)
+			default:
+				delete(this.decimals, d)
+			}
 		default:
 			panic(fmt.Sprintf("Unsupported value type %T.", key))
 		}
@@ -176,6 +196,15 @@ func (this *Set) Has(key Value) bool {
 			}
 		case intValue:
 			_, ok = this.ints[int64(num)]
+		case *decimalValue:
+			switch i, f, d, kind := num.setKey(); kind {
+			case _KEY_INT:
+				_, ok = this.ints[i]
+			case _KEY_FLOAT:
+				_, ok = this.floats[f]
+			default:
+				_, ok = this.decimals[d]
+			}
 		default:
 			panic(fmt.Sprintf("Unsupported value type %T.", key))
 		}
@@ -196,8 +225,8 @@ func (this *Set) Has(key Value) bool {
 }
 
 func (this *Set) Len() int {
-	rv := len(this.booleans) + len(this.floats) + len(this.ints) + len(this.strings) +
-		len(this.arrays) + len(this.objects) + len(this.binaries)
+	rv := len(this.booleans) + len(this.floats) + len(this.ints) + len(this.decimals) +
+		len(this.strings) + len(this.arrays) + len(this.objects) + len(this.binaries)
 
 	if this.nills {
 		rv++
@@ -245,6 +274,10 @@ func (this *Set) Values() []Value {
 		rv = append(rv, av)
 	}
 
+	for _, av := range this.decimals {
+		rv = append(rv, av)
+	}
+
 	for _, av := range this.strings {
 		rv = append(rv, av)
 	}
@@ -287,6 +320,10 @@ func (this *Set) Actuals() []interface{} {
 		rv = append(rv, av.Actual())
 	}
 
+	for _, av := range this.decimals {
+		rv = append(rv, av.Actual())
+	}
+
 	for _, av := range this.strings {
 		rv = append(rv, av.Actual())
 	}
@@ -337,6 +374,10 @@ func (this *Set) Items() []interface{} {
 		rv = append(rv, av)
 	}
 
+	for _, av := range this.decimals {
+		rv = append(rv, av)
+	}
+
 	for _, av := range this.strings {
 		rv = append(rv, av)
 	}
@@ -376,6 +417,11 @@ func (this *Set) Clear() {
 		delete(this.ints, k)
 	}
 
+	for k, _ := range this.decimals {
+		this.decimals[k] = nil
+		delete(this.decimals, k)
+	}
+
 	for k, _ := range this.strings {
 		this.strings[k] = nil
 		delete(this.strings, k)
@@ -408,6 +454,7 @@ func (this *Set) Copy() *Set {
 	rv.booleans = make(map[bool]Value, len(this.booleans))
 	rv.floats = make(map[float64]Value, 2*(1+len(this.floats)))
 	rv.ints = make(map[int64]Value, 2*(1+len(this.ints)))
+	rv.decimals = make(map[string]Value, 2*(1+len(this.decimals)))
 	rv.strings = make(map[string]Value, 2*(1+len(this.strings)))
 	rv.arrays = make(map[string]Value, 2*(1+len(this.arrays)))
 	rv.objects = make(map[string]Value, 2*(1+len(this.objects)))
@@ -425,6 +472,10 @@ func (this *Set) Copy() *Set {
 		rv.ints[k] = v
 	}
 
+	for k, v := range this.decimals {
+		rv.decimals[k] = v
+	}
+
 	for k, v := range this.strings {
 		rv.strings[k] = v
 	}